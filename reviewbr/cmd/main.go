@@ -9,10 +9,11 @@ import (
 	"strings"
 
 	"github.com/BurntSushi/toml"
-	"github.com/open-and-sustainable/alembica/utils/logger"
 	"github.com/open-and-sustainable/prismaid"
 	"github.com/open-and-sustainable/prismaid/conversion"
 	terminal "github.com/open-and-sustainable/prismaid/init"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/progress"
 )
 
 // ZoteroConfig represents the configuration needed to download PDFs from Zotero.
@@ -26,11 +27,15 @@ type ZoteroConfig struct {
 // main is the entry point for the PrismAId CLI application.
 //
 // It processes command-line arguments to perform various operations:
-//   - Running a review project with a TOML configuration file
+//   - Running a review project with a TOML configuration file, or estimating its cost with -dry-run
+//   - Checking a review configuration for problems, reported with their TOML line numbers
+//   - Printing a configuration merged with the base files it extends
+//   - Validating review results against a gold standard coded by hand
 //   - Initializing a new project configuration file interactively
 //   - Downloading files from a list of URLs
 //   - Downloading PDFs from Zotero using credentials
 //   - Converting files in various formats (PDF, DOCX, HTML) to text
+//   - Serving an offline mock LLM provider with the mock-llm subcommand
 //
 // The function handles appropriate error logging and exits with
 // non-zero status codes when operations fail.
@@ -38,7 +43,17 @@ type ZoteroConfig struct {
 // If no valid options are provided, it displays an error message
 // and exits with status code 1.
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == mockLLMCommand {
+		handleMockLLM(os.Args[2:])
+		return
+	}
+
 	projectConfigPath := flag.String("project", "", "Path to the project configuration file")
+	checkConfigPath := flag.String("check-config", "", "Path to a review project configuration file to check without running the review")
+	printConfigPath := flag.String("print-config", "", "Path to a review or screening configuration file to print merged with the base files it extends")
+	dryRun := flag.Bool("dry-run", false, "Estimate tokens and cost of the project without calling any provider (use with -project)")
+	validatePath := flag.String("validate", "", "Path to a review results file to validate against a gold standard (use with -gold)")
+	goldPath := flag.String("gold", "", "Path to a CSV file of manuscripts coded by hand, with the same review keys as the results")
 	initFlag := flag.Bool("init", false, "Run interactively to initialize a new project configuration file")
 	downloadURLPath := flag.String("download-URL", "", "Path to a text file containing URLs to download")
 	downloadZoteroPath := flag.String("download-zotero", "", "Path to a TOML file containing Zotero credentials")
@@ -65,20 +80,20 @@ func main() {
 		if *singleFilePath != "" {
 			handleConversionFile(*singleFilePath, *tikaServer, *ocrOnly)
 		} else {
-			handleConversionIsolated(*convertPDFDir, "pdf", *tikaServer, *ocrOnly)
+			handleConversionIsolated(*convertPDFDir, "pdf", *tikaServer, *ocrOnly, terminalProgress())
 		}
 	}
 
 	// DOCX conversion
 	if *convertDOCXDir != "" {
 		logger.SetupLogging(logger.Stdout, "")
-		handleConversion(*convertDOCXDir, "docx", *tikaServer, false)
+		handleConversion(*convertDOCXDir, "docx", *tikaServer, false, terminalProgress())
 	}
 
 	// HTML conversion
 	if *convertHTMLDir != "" {
 		logger.SetupLogging(logger.Stdout, "")
-		handleConversion(*convertHTMLDir, "html", *tikaServer, false)
+		handleConversion(*convertHTMLDir, "html", *tikaServer, false, terminalProgress())
 	}
 
 	// Zotero PDF download
@@ -90,7 +105,7 @@ func main() {
 	// URL download
 	if *downloadURLPath != "" {
 		logger.SetupLogging(logger.Stdout, "")
-		prismaid.DownloadURLList(*downloadURLPath)
+		prismaid.DownloadURLListWithProgress(*downloadURLPath, terminalProgress())
 	}
 
 	// Screening process
@@ -101,7 +116,7 @@ func main() {
 			logger.Error("Error reading Screening configuration:", err)
 			os.Exit(1)
 		}
-		err = prismaid.Screening(string(data))
+		err = prismaid.ScreeningWithProgress(string(data), terminalProgress())
 		if err != nil {
 			logger.Error("Error running Screening logic:", err)
 			os.Exit(1)
@@ -116,11 +131,66 @@ func main() {
 			logger.Error("Error reading Review configuration:", err)
 			os.Exit(1)
 		}
-		err = prismaid.Review(string(data))
+		if *dryRun {
+			estimate, err := prismaid.EstimateReview(string(data))
+			if err != nil {
+				logger.Error("Error estimating Review cost:", err)
+				os.Exit(1)
+			}
+			estimate.Report(os.Stdout)
+		} else {
+			err = prismaid.ReviewWithProgress(string(data), terminalProgress())
+			if err != nil {
+				logger.Error("Error running Review logic:", err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	// Effective configuration, merged with its base files
+	if *printConfigPath != "" {
+		logger.SetupLogging(logger.Stdout, "")
+		data, err := os.ReadFile(*printConfigPath)
+		if err != nil {
+			logger.Error("Error reading configuration:", err)
+			os.Exit(1)
+		}
+		effective, err := prismaid.EffectiveConfig(string(data))
+		if err != nil {
+			logger.Error("Error resolving configuration:", err)
+			os.Exit(1)
+		}
+		os.Stdout.WriteString(effective)
+	}
+
+	// Review configuration check
+	if *checkConfigPath != "" {
+		logger.SetupLogging(logger.Stdout, "")
+		data, err := os.ReadFile(*checkConfigPath)
+		if err != nil {
+			logger.Error("Error reading Review configuration:", err)
+			os.Exit(1)
+		}
+		report := prismaid.CheckReviewConfig(string(data))
+		report.Write(os.Stdout)
+		if report.Errors() > 0 {
+			os.Exit(1)
+		}
+	}
+
+	// Validation against a gold standard
+	if *validatePath != "" {
+		logger.SetupLogging(logger.Stdout, "")
+		if *goldPath == "" {
+			logger.Error("Error: -validate must be used with -gold")
+			os.Exit(1)
+		}
+		validation, err := prismaid.ValidateReview(*validatePath, *goldPath)
 		if err != nil {
-			logger.Error("Error running Review logic:", err)
+			logger.Error("Error validating Review results:", err)
 			os.Exit(1)
 		}
+		validation.Markdown(os.Stdout)
 	}
 
 	// Initiate project configuration
@@ -128,17 +198,38 @@ func main() {
 		terminal.RunInteractiveConfigCreation()
 	}
 
+	if *dryRun && *projectConfigPath == "" {
+		logger.Error("Error: -dry-run must be used with -project")
+		os.Exit(1)
+	}
+
+	if *goldPath != "" && *validatePath == "" {
+		logger.Error("Error: -gold must be used with -validate")
+		os.Exit(1)
+	}
+
 	if *singleFilePath != "" && *convertPDFDir == "" {
 		logger.Error("Error: -single-file must be used with -convert-pdf")
 		os.Exit(1)
 	}
 
-	if *projectConfigPath == "" && !*initFlag && *downloadURLPath == "" && *downloadZoteroPath == "" && *convertPDFDir == "" && *convertDOCXDir == "" && *convertHTMLDir == "" && *screeningConfigPath == "" {
+	if *projectConfigPath == "" && !*initFlag && *downloadURLPath == "" && *downloadZoteroPath == "" && *convertPDFDir == "" && *convertDOCXDir == "" && *convertHTMLDir == "" && *screeningConfigPath == "" && *validatePath == "" && *checkConfigPath == "" && *printConfigPath == "" {
 		logger.Error("No valid options provided. Use -help for usage information.")
 		os.Exit(1)
 	}
 }
 
+// terminalProgress returns the progress display of long-running operations, a line rewritten in place
+// on the standard error, or nil when the standard error is not a terminal, so that redirected output
+// is not filled with progress lines.
+func terminalProgress() prismaid.ProgressFunc {
+	info, err := os.Stderr.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return nil
+	}
+	return progress.Display(os.Stderr)
+}
+
 // handleConversion processes files in the specified input directory
 // and converts them to text format based on the given source format.
 // If tikaServer is provided, uses Apache Tika as OCR fallback for failed conversions.
@@ -152,15 +243,18 @@ func main() {
 //   - inputDir: The directory containing files to be converted
 //   - format: The source format of the files (e.g., "pdf", "docx", "html")
 //   - tikaServer: Optional Tika server address (e.g., "localhost:9998"). Empty string disables OCR fallback.
+//   - ocrOnly: Whether PDF files are converted with Tika OCR only.
+//   - report: Optional progress display, nil to disable it.
 //
 // The function doesn't return anything as it handles errors internally
 // and terminates the program on failure.
-func handleConversion(inputDir, format, tikaServer string, ocrOnly bool) {
+func handleConversion(inputDir, format, tikaServer string, ocrOnly bool, report progress.Func) {
 	err := conversion.Convert(inputDir, format, conversion.ConvertOptions{
 		TikaServer: tikaServer,
 		PDF: conversion.PDFOptions{
 			OCROnly: ocrOnly && format == "pdf",
 		},
+		Progress: report,
 	})
 	if err != nil {
 		logger.Error("Error converting files in %s to %s: %v\n", inputDir, format, err)
@@ -184,7 +278,7 @@ func handleConversionFile(filePath, tikaServer string, ocrOnly bool) {
 	logger.Info("Successfully converted file %s to txt (source=pdf)\n", filePath)
 }
 
-func handleConversionIsolated(inputDir, format, tikaServer string, ocrOnly bool) {
+func handleConversionIsolated(inputDir, format, tikaServer string, ocrOnly bool, report progress.Func) {
 	reportPath := filepath.Join(inputDir, "conversion_report.csv")
 	reportFile, err := os.OpenFile(reportPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
@@ -219,17 +313,21 @@ func handleConversionIsolated(inputDir, format, tikaServer string, ocrOnly bool)
 		os.Exit(1)
 	}
 
+	var matching []os.DirEntry
 	for _, file := range files {
-		if file.IsDir() {
-			continue
+		if !file.IsDir() && matchesFormat(filepath.Ext(file.Name()), format) {
+			matching = append(matching, file)
 		}
+	}
+	tracker := progress.Start(report, progress.OperationConvert, len(matching))
+	defer tracker.Finish()
+
+	for _, file := range matching {
 		ext := filepath.Ext(file.Name())
-		if !matchesFormat(ext, format) {
-			continue
-		}
 		fullPath := filepath.Join(inputDir, file.Name())
 		txtPath := filepath.Join(inputDir, strings.TrimSuffix(file.Name(), ext)+".txt")
 		if info, err := os.Stat(txtPath); err == nil && info.Size() > 0 {
+			tracker.Skip(fullPath)
 			continue
 		}
 
@@ -274,6 +372,7 @@ func handleConversionIsolated(inputDir, format, tikaServer string, ocrOnly bool)
 			os.Exit(1)
 		}
 		writer.Flush()
+		tracker.Done(fullPath, status == "error", 0)
 	}
 	logger.Info("Conversion report written to %s\n", reportPath)
 }
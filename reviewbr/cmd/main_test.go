@@ -70,7 +70,7 @@ func TestHandleConversionIsolatedRetriesOnZeroSize(t *testing.T) {
 		return []byte("initial ok"), os.WriteFile(txtPath, []byte{}, 0644)
 	}
 
-	handleConversionIsolated(tempDir, "pdf", "localhost:9998", false)
+	handleConversionIsolated(tempDir, "pdf", "localhost:9998", false, nil)
 
 	txtPath := filepath.Join(tempDir, "sample.txt")
 	info, err := os.Stat(txtPath)
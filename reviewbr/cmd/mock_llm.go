@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/mockllm"
+)
+
+// mockLLMCommand is the subcommand serving an offline mock LLM provider.
+const mockLLMCommand = "mock-llm"
+
+// runMockLLM parses the options of the mock-llm subcommand and serves the mock provider until the
+// process is stopped. The options given on the command line override those of the script file.
+//
+// Parameters:
+//   - args: The command-line arguments following the subcommand name.
+//
+// Returns an error if the options are invalid, the script cannot be loaded, or the server stops.
+func runMockLLM(args []string) error {
+	flags := flag.NewFlagSet(mockLLMCommand, flag.ContinueOnError)
+	address := flags.String("addr", mockllm.DefaultAddress, "Address to listen on")
+	scriptPath := flags.String("script", "", "Path to a TOML script of answers keyed by prompt hash")
+	fixturesDir := flags.String("fixtures", "", "Directory of answers in files named <prompt hash>.json or .txt")
+	answer := flags.String("answer", "", "Answer to the prompts without fixture (default \""+mockllm.DefaultAnswer+"\")")
+	latency := flags.Duration("latency", 0, "Delay before every response, e.g. 200ms")
+	rateLimitEvery := flags.Int("rate-limit-every", 0, "Refuse every Nth request with HTTP 429")
+	malformedEvery := flags.Int("malformed-every", 0, "Answer every Nth request with malformed JSON")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	cfg := &mockllm.Config{}
+	if *scriptPath != "" {
+		var err error
+		if cfg, err = mockllm.LoadConfig(*scriptPath); err != nil {
+			return err
+		}
+	}
+	flags.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "fixtures":
+			cfg.FixturesDirectory = *fixturesDir
+		case "answer":
+			cfg.Answer = *answer
+		case "latency":
+			cfg.Latency = *latency
+		case "rate-limit-every":
+			cfg.RateLimitEvery = *rateLimitEvery
+		case "malformed-every":
+			cfg.MalformedEvery = *malformedEvery
+		}
+	})
+	if cfg.Latency < 0 || cfg.RateLimitEvery < 0 || cfg.MalformedEvery < 0 {
+		return fmt.Errorf("latency, rate-limit-every and malformed-every cannot be negative")
+	}
+
+	server, err := mockllm.NewServer(*cfg)
+	if err != nil {
+		return err
+	}
+	logger.Info("Mock LLM listening on http://%s/v1, use it with provider = \"SelfHosted\" and this base_url", *address)
+	httpServer := &http.Server{Addr: *address, Handler: server, ReadHeaderTimeout: 10 * time.Second}
+	return httpServer.ListenAndServe()
+}
+
+// handleMockLLM runs the mock-llm subcommand and exits with status 1 if it fails.
+func handleMockLLM(args []string) {
+	logger.SetupLogging(logger.Stdout, "")
+	if err := runMockLLM(args); err != nil {
+		logger.Error("Error running the mock LLM server: %v", err)
+		os.Exit(1)
+	}
+}
@@ -1,18 +1,20 @@
 package conversion
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/logger"
 
 	"github.com/open-and-sustainable/prismaid/conversion/doc"
 	"github.com/open-and-sustainable/prismaid/conversion/html"
 	"github.com/open-and-sustainable/prismaid/conversion/ocr"
 	"github.com/open-and-sustainable/prismaid/conversion/pdf"
+	"github.com/open-and-sustainable/prismaid/progress"
 )
 
 // Convert processes files from the specified input directory and converts them to text format.
@@ -56,26 +58,71 @@ type PDFOptions struct {
 type ConvertOptions struct {
 	TikaServer string
 	PDF        PDFOptions
+	Progress   progress.Func // optional, receives an event for every file converted
+}
+
+// ConvertedFile is the outcome of the conversion of a file.
+type ConvertedFile struct {
+	Path     string        // file converted
+	Format   string        // pdf, docx or html
+	Output   string        // text file written, empty if the conversion failed
+	UsedTika bool          // whether Tika OCR was used
+	Duration time.Duration // time spent on the file
+	Error    string        // reason of the failure, empty if the file was converted
+}
+
+// ConversionReport is the outcome of a conversion run with ConvertContext, one entry per file processed.
+type ConversionReport struct {
+	Files []ConvertedFile
+}
+
+// Failed returns the number of files that could not be converted.
+func (r *ConversionReport) Failed() int {
+	failed := 0
+	for _, file := range r.Files {
+		if file.Error != "" {
+			failed++
+		}
+	}
+	return failed
+}
+
+// conversionRun holds the state shared by the files of a conversion.
+type conversionRun struct {
+	ctx     context.Context
+	tracker *progress.Tracker
+	report  *ConversionReport
 }
 
 // Convert processes files from the specified input directory and converts them to text format.
 // If PDF.OCROnly is true, it skips standard PDF conversion and uses Tika OCR directly.
 // If PDF.SingleFile is set, only that PDF is processed for the pdf format.
 func Convert(inputDir, selectedFormats string, options ConvertOptions) error {
+	_, err := ConvertContext(context.Background(), inputDir, selectedFormats, options)
+	return err
+}
+
+// ConvertContext converts files as Convert does and reports the outcome of every file processed.
+// The conversion stops before the next file once the context is canceled, returning the report of
+// the files already processed with the error of the context.
+func ConvertContext(ctx context.Context, inputDir, selectedFormats string, options ConvertOptions) (*ConversionReport, error) {
 	useTika := resolveUseTika(options.TikaServer)
 	if options.PDF.OCROnly && !useTika {
-		return fmt.Errorf("ocr-only requested but Tika server not available at %s", options.TikaServer)
+		return nil, fmt.Errorf("ocr-only requested but Tika server not available at %s", options.TikaServer)
 	}
 
 	// Load files from the input directory
 	files, err := os.ReadDir(inputDir)
 	if err != nil {
 		logger.Error("Error: ", err)
-		return fmt.Errorf("error reading input directory: %v", err)
+		return nil, fmt.Errorf("error reading input directory: %v", err)
 	}
 
 	// formats
 	formats := strings.Split(selectedFormats, ",")
+	run := &conversionRun{ctx: ctx, report: &ConversionReport{}}
+	run.tracker = progress.Start(options.Progress, progress.OperationConvert, countFiles(files, formats, options.PDF))
+	defer run.tracker.Finish()
 
 	// parse files
 	for _, format := range formats { // FIXED: use value, not index
@@ -85,23 +132,48 @@ func Convert(inputDir, selectedFormats string, options ConvertOptions) error {
 		}
 		switch format {
 		case "pdf":
-			if err := convertPDF(inputDir, files, useTika, options.PDF, options.TikaServer); err != nil {
-				return err
+			if err := convertPDF(run, inputDir, files, useTika, options.PDF, options.TikaServer); err != nil {
+				return run.report, err
 			}
 		case "docx":
-			if err := convertDOCX(inputDir, files, useTika, options.TikaServer); err != nil {
-				return err
+			if err := convertDOCX(run, inputDir, files, useTika, options.TikaServer); err != nil {
+				return run.report, err
 			}
 		case "html":
-			if err := convertHTML(inputDir, files, useTika, options.TikaServer); err != nil {
-				return err
+			if err := convertHTML(run, inputDir, files, useTika, options.TikaServer); err != nil {
+				return run.report, err
 			}
 		default:
 			logger.Error("Unsupported document type: ", format)
-			return fmt.Errorf("unsupported document type: %s", format)
+			return run.report, fmt.Errorf("unsupported document type: %s", format)
 		}
 	}
-	return nil
+	return run.report, nil
+}
+
+// countFiles counts the files to convert in the selected formats, for the progress of the conversion.
+func countFiles(files []os.DirEntry, formats []string, options PDFOptions) int {
+	count := 0
+	for _, format := range formats {
+		format = strings.TrimSpace(format)
+		if format == "pdf" && options.SingleFile != "" {
+			count++
+			continue
+		}
+		for _, file := range files {
+			if file.IsDir() {
+				continue
+			}
+			ext := filepath.Ext(file.Name())
+			switch {
+			case format == "pdf" && strings.EqualFold(ext, ".pdf"),
+				format == "docx" && strings.EqualFold(ext, ".docx"),
+				format == "html" && (strings.EqualFold(ext, ".html") || strings.EqualFold(ext, ".htm")):
+				count++
+			}
+		}
+	}
+	return count
 }
 
 func resolveUseTika(tikaAddress string) bool {
@@ -116,13 +188,13 @@ func resolveUseTika(tikaAddress string) bool {
 	return false
 }
 
-func convertPDF(inputDir string, files []os.DirEntry, useTika bool, options PDFOptions, tikaAddress string) error {
+func convertPDF(run *conversionRun, inputDir string, files []os.DirEntry, useTika bool, options PDFOptions, tikaAddress string) error {
 	if options.SingleFile != "" {
 		ext := filepath.Ext(options.SingleFile)
 		if !strings.EqualFold(ext, ".pdf") {
 			return fmt.Errorf("file extension %s does not match format pdf", ext)
 		}
-		return convertSingle(options.SingleFile, "pdf", ext, useTika, tikaAddress, options.OCROnly)
+		return convertSingle(run, options.SingleFile, "pdf", ext, useTika, tikaAddress, options.OCROnly)
 	}
 	for _, file := range files {
 		if file.IsDir() {
@@ -133,14 +205,14 @@ func convertPDF(inputDir string, files []os.DirEntry, useTika bool, options PDFO
 			continue
 		}
 		fullPath := filepath.Join(inputDir, file.Name())
-		if err := convertSingle(fullPath, "pdf", ext, useTika, tikaAddress, options.OCROnly); err != nil {
+		if err := convertSingle(run, fullPath, "pdf", ext, useTika, tikaAddress, options.OCROnly); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func convertDOCX(inputDir string, files []os.DirEntry, useTika bool, tikaAddress string) error {
+func convertDOCX(run *conversionRun, inputDir string, files []os.DirEntry, useTika bool, tikaAddress string) error {
 	for _, file := range files {
 		if file.IsDir() {
 			continue
@@ -150,14 +222,14 @@ func convertDOCX(inputDir string, files []os.DirEntry, useTika bool, tikaAddress
 			continue
 		}
 		fullPath := filepath.Join(inputDir, file.Name())
-		if err := convertSingle(fullPath, "docx", ext, useTika, tikaAddress, false); err != nil {
+		if err := convertSingle(run, fullPath, "docx", ext, useTika, tikaAddress, false); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func convertHTML(inputDir string, files []os.DirEntry, useTika bool, tikaAddress string) error {
+func convertHTML(run *conversionRun, inputDir string, files []os.DirEntry, useTika bool, tikaAddress string) error {
 	for _, file := range files {
 		if file.IsDir() {
 			continue
@@ -167,14 +239,17 @@ func convertHTML(inputDir string, files []os.DirEntry, useTika bool, tikaAddress
 			continue
 		}
 		fullPath := filepath.Join(inputDir, file.Name())
-		if err := convertSingle(fullPath, "html", ext, useTika, tikaAddress, false); err != nil {
+		if err := convertSingle(run, fullPath, "html", ext, useTika, tikaAddress, false); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-func convertSingle(fullPath, format, ext string, useTika bool, tikaAddress string, ocrOnly bool) error {
+func convertSingle(run *conversionRun, fullPath, format, ext string, useTika bool, tikaAddress string, ocrOnly bool) error {
+	if err := run.ctx.Err(); err != nil {
+		return err
+	}
 	start := time.Now()
 	usedTika := false
 	logger.Info("Starting conversion: %s (format=%s)", fullPath, format)
@@ -202,15 +277,24 @@ func convertSingle(fullPath, format, ext string, useTika bool, tikaAddress strin
 		err = writeText(txtContent, txtPath)
 		if err != nil {
 			logger.Error("Error: ", err)
+			run.done(ConvertedFile{Path: fullPath, Format: format, UsedTika: usedTika, Duration: time.Since(start), Error: err.Error()})
 			return fmt.Errorf("error writing to file: %v", err)
 		}
 		logger.Info("Finished conversion: %s (format=%s, tika=%t, duration=%s)", fullPath, format, usedTika, time.Since(start))
+		run.done(ConvertedFile{Path: fullPath, Format: format, Output: txtPath, UsedTika: usedTika, Duration: time.Since(start)})
 	} else {
 		logger.Error("Failed to convert %s (tika=%t, duration=%s): %v", fullPath, usedTika, time.Since(start), err)
+		run.done(ConvertedFile{Path: fullPath, Format: format, UsedTika: usedTika, Duration: time.Since(start), Error: err.Error()})
 	}
 	return nil
 }
 
+// done records the outcome of a file in the report and the progress of the conversion.
+func (run *conversionRun) done(file ConvertedFile) {
+	run.report.Files = append(run.report.Files, file)
+	run.tracker.Done(file.Path, file.Error != "", 0)
+}
+
 // readText extracts text content from a file based on its format.
 //
 // It determines the appropriate reading function based on the specified format
@@ -1,11 +1,15 @@
 package conversion
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/open-and-sustainable/prismaid/progress"
 )
 
 // TestConvert tests the conversion logic without relying on real format conversions
@@ -154,6 +158,44 @@ func TestConvertErrors(t *testing.T) {
 	}
 }
 
+func TestConvertContextReportsFiles(t *testing.T) {
+	tempDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tempDir, "page.html"), []byte("<html><body>HTML test content</body></html>"), 0644); err != nil {
+		t.Fatalf("Failed to write test HTML file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "broken.pdf"), []byte("Not a PDF"), 0644); err != nil {
+		t.Fatalf("Failed to write test PDF file: %v", err)
+	}
+
+	var events []progress.Event
+	report, err := ConvertContext(context.Background(), tempDir, "html,pdf", ConvertOptions{
+		Progress: func(e progress.Event) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("ConvertContext failed: %v", err)
+	}
+	if len(report.Files) != 2 || report.Failed() != 1 {
+		t.Fatalf("Expected 2 files with 1 failure, got %+v", report.Files)
+	}
+	converted := report.Files[0]
+	if converted.Format != "html" || converted.Error != "" || converted.Output != filepath.Join(tempDir, "page.txt") {
+		t.Errorf("Unexpected report of the HTML file %+v", converted)
+	}
+	if report.Files[1].Format != "pdf" || report.Files[1].Output != "" || report.Files[1].Error == "" {
+		t.Errorf("Unexpected report of the broken PDF %+v", report.Files[1])
+	}
+	if len(events) != 4 || events[0].Total != 2 || !events[3].Finished || events[3].Failed != 1 {
+		t.Errorf("Unexpected progress events %+v", events)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	report, err = ConvertContext(ctx, tempDir, "html", ConvertOptions{})
+	if !errors.Is(err, context.Canceled) || len(report.Files) != 0 {
+		t.Errorf("Expected a canceled conversion without files, got %v and %+v", err, report)
+	}
+}
+
 func TestConvertPDFSingleFileExtensionMismatch(t *testing.T) {
 	tempDir, err := os.MkdirTemp("", "convert_single_test")
 	if err != nil {
@@ -8,7 +8,7 @@ import (
 	"os"
 	"time"
 
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/logger"
 )
 
 // ReadWithTika extracts text from a file using Apache Tika server with OCR support.
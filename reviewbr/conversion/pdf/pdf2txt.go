@@ -10,7 +10,7 @@ import (
 	model "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/model"
 	pdfTypes "github.com/pdfcpu/pdfcpu/pkg/pdfcpu/types"
 
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/logger"
 )
 
 // ReadPdf extracts text content from a PDF file using the ledongthuc/pdf library.
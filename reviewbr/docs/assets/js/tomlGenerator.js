@@ -26,10 +26,12 @@ function generateConfig() {
                 document.getElementById("results_file_name").value,
             output_format: document.getElementById("output_format").value,
             log_level: document.getElementById("log_level").value,
-            duplication: document.getElementById("duplication").value,
+            repetitions: document.getElementById("repetitions").value,
             cot_justification:
                 document.getElementById("cot_justification").value,
             summary: document.getElementById("summary").value,
+            schema_retries: document.getElementById("schema_retries").value,
+            prompt_language: document.getElementById("prompt_language").value,
         },
         llm_providers: collectProviderData(),
         prompt: {
@@ -98,7 +100,11 @@ function generateTOMLString(data) {
         if (value.includes("\\")) {
             value = value.replace(/\\/g, "/"); // Replace backslashes with forward slashes
         }
-        toml.push(`${key} = "${value}"`);
+        if (key === "repetitions" || key === "schema_retries") {
+            toml.push(`${key} = ${value}`); // integer options
+        } else {
+            toml.push(`${key} = "${value}"`);
+        }
     });
 
     toml.push("\n[project.llm]");
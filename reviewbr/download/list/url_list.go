@@ -19,7 +19,8 @@ import (
 	"sync"
 	"time"
 
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/progress"
 
 	"github.com/PuerkitoBio/goquery"
 	"golang.org/x/sync/semaphore"
@@ -67,6 +68,38 @@ type UnpaywallResponse struct {
 	} `json:"oa_locations"`
 }
 
+// DownloadOptions controls a download run with DownloadURLListContext.
+type DownloadOptions struct {
+	Progress progress.Func // optional, receives an event for every entry of the list
+}
+
+// DownloadedEntry is the outcome of an entry of a list, as written to its _download results file.
+type DownloadedEntry struct {
+	URL        string // URL of the entry, resolved from the DOI for CSV/TSV rows without URL
+	Row        string // row identifier of a CSV/TSV entry, empty for plain text lists
+	Title      string // title of a CSV/TSV entry
+	Downloaded bool   // whether the PDF was downloaded
+	File       string // path of the downloaded PDF, empty if not downloaded
+	Error      string // reason of the failure, empty if downloaded
+}
+
+// DownloadReport is the outcome of a download run with DownloadURLListContext.
+type DownloadReport struct {
+	ResultsFile string            // results file written next to the list
+	Entries     []DownloadedEntry // one entry per URL or CSV/TSV row, in list order
+}
+
+// Failed returns the number of entries whose PDF was not downloaded.
+func (r *DownloadReport) Failed() int {
+	failed := 0
+	for _, entry := range r.Entries {
+		if !entry.Downloaded {
+			failed++
+		}
+	}
+	return failed
+}
+
 // RetryConfig holds retry policy configuration
 type RetryConfig struct {
 	MaxRetries int
@@ -138,9 +171,10 @@ func (cd *ConcurrentDownloader) getHostSemaphore(host string) *semaphore.Weighte
 	return sem
 }
 
-// downloadConcurrently performs concurrent downloads with proper semaphore management
-func (cd *ConcurrentDownloader) downloadConcurrently(tasks []*DownloadTask) []DownloadResult {
-	ctx := context.Background()
+// downloadConcurrently performs concurrent downloads with proper semaphore management,
+// completing a unit of the tracker for every task. Once the context is canceled, the tasks
+// still waiting for a slot fail with the error of the context.
+func (cd *ConcurrentDownloader) downloadConcurrently(ctx context.Context, tasks []*DownloadTask, tracker *progress.Tracker) []DownloadResult {
 	results := make([]DownloadResult, len(tasks))
 	var wg sync.WaitGroup
 
@@ -148,6 +182,7 @@ func (cd *ConcurrentDownloader) downloadConcurrently(tasks []*DownloadTask) []Do
 		wg.Add(1)
 		go func(idx int, t *DownloadTask) {
 			defer wg.Done()
+			defer func() { tracker.Done(t.OriginalURL, !results[idx].Success, 0) }()
 
 			// Parse URL to get host for per-host limiting
 			parsedURL, err := url.Parse(t.PDFUrl)
@@ -243,6 +278,37 @@ type ColumnMapping struct {
 // Returns an error if the function fails to open or read the input file,
 // but continues processing even if individual URLs fail to download.
 func DownloadURLList(path string) error {
+	_, err := DownloadURLListContext(context.Background(), path, DownloadOptions{})
+	return err
+}
+
+// DownloadURLListWithProgress downloads the papers of a list as DownloadURLList does, reporting
+// its progress to a function. Every URL, or every row of a CSV/TSV file, is a unit of work,
+// completed once its PDF is downloaded or found missing.
+//
+// Parameters:
+//   - path: The path to a file containing URLs or paper metadata
+//   - report: The function receiving the progress events, nil to disable reporting
+//
+// Returns an error if the function fails to open or read the input file.
+func DownloadURLListWithProgress(path string, report progress.Func) error {
+	_, err := DownloadURLListContext(context.Background(), path, DownloadOptions{Progress: report})
+	return err
+}
+
+// DownloadURLListContext downloads the papers of a list as DownloadURLList does and reports the
+// outcome of every entry. Once the context is canceled, no further PDF link is looked up and no
+// further download is started; the downloads in progress are completed and recorded in the report
+// and in the results file, which are returned with the error of the context.
+//
+// Parameters:
+//   - ctx: The context of the downloads, whose cancellation stops them
+//   - path: The path to a file containing URLs or paper metadata
+//   - options: The options of the run, such as the progress hook
+//
+// Returns the outcome of every entry, and an error if the function fails to open or read the
+// input file or the context is canceled.
+func DownloadURLListContext(ctx context.Context, path string, options DownloadOptions) (*DownloadReport, error) {
 	// Extract the directory from the input file path
 	dirPath := filepath.Dir(path)
 
@@ -256,10 +322,10 @@ func DownloadURLList(path string) error {
 		if ext == ".tsv" {
 			delimiter = '\t'
 		}
-		return processCSVFile(path, dirPath, delimiter)
+		return processCSVFile(ctx, path, dirPath, delimiter, options)
 	default:
 		// Handle plain text files (original behavior)
-		return processTextFile(path, dirPath)
+		return processTextFile(ctx, path, dirPath, options)
 	}
 }
 
@@ -294,11 +360,11 @@ func findUniqueFilename(dirPath, filename string) string {
 }
 
 // processTextFile handles the original plain text URL list format
-func processTextFile(path, dirPath string) error {
+func processTextFile(ctx context.Context, path, dirPath string, options DownloadOptions) (*DownloadReport, error) {
 	// Open the file at the given path
 	file, err := os.Open(path)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer file.Close()
 
@@ -317,7 +383,7 @@ func processTextFile(path, dirPath string) error {
 	}
 
 	if err := scanner.Err(); err != nil {
-		return err
+		return nil, err
 	}
 
 	// Create concurrent downloader with reasonable limits
@@ -325,14 +391,23 @@ func processTextFile(path, dirPath string) error {
 
 	// Track failed URLs
 	var failedURLs []string
+	reasons := make(map[string]string)
 	var tasks []*DownloadTask
+	tracker := progress.Start(options.Progress, progress.OperationDownload, len(urls))
+	defer tracker.Finish()
+	tracker.SetStage("finding PDFs")
 
 	// Prepare download tasks
 	for _, url := range urls {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		pdfURL, filename, err := extractPDF(url)
 		if err != nil {
 			logger.Error("Error processing URL", url, ":", err)
 			failedURLs = append(failedURLs, url)
+			reasons[url] = fmt.Sprintf("Error: %v", err)
+			tracker.Done(url, true, 0)
 			continue
 		}
 
@@ -352,13 +427,16 @@ func processTextFile(path, dirPath string) error {
 		} else {
 			logger.Info("No PDF found for", url)
 			failedURLs = append(failedURLs, url)
+			reasons[url] = "No PDF found"
+			tracker.Done(url, true, 0)
 		}
 	}
 
 	// Perform concurrent downloads
 	if len(tasks) > 0 {
 		logger.Info(fmt.Sprintf("Starting concurrent download of %d PDFs (max 25 global, 4 per host)", len(tasks)))
-		results := downloader.downloadConcurrently(tasks)
+		tracker.SetStage("downloading")
+		results := downloader.downloadConcurrently(ctx, tasks, tracker)
 
 		// Process results
 		for _, result := range results {
@@ -367,6 +445,7 @@ func processTextFile(path, dirPath string) error {
 			} else {
 				logger.Error("Download failed for", result.Task.OriginalURL, ":", result.Error)
 				failedURLs = append(failedURLs, result.Task.OriginalURL)
+				reasons[result.Task.OriginalURL] = fmt.Sprintf("Download failed: %v", result.Error)
 			}
 		}
 	}
@@ -379,15 +458,29 @@ func processTextFile(path, dirPath string) error {
 		logger.Info(fmt.Sprintf("Download file saved to: %s", downloadPath))
 	}
 
-	return nil
+	// Report every URL as in the results file
+	report := &DownloadReport{ResultsFile: downloadPath}
+	downloaded := make(map[string]*DownloadTask)
+	for _, task := range tasks {
+		downloaded[task.OriginalURL] = task
+	}
+	for _, url := range urls {
+		entry := DownloadedEntry{URL: url, Error: reasons[url]}
+		if task, ok := downloaded[url]; ok && entry.Error == "" {
+			entry.Downloaded = true
+			entry.File = task.FullPath
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	return report, ctx.Err()
 }
 
 // processCSVFile handles CSV/TSV files with metadata
-func processCSVFile(path, dirPath string, delimiter rune) error {
+func processCSVFile(ctx context.Context, path, dirPath string, delimiter rune, options DownloadOptions) (*DownloadReport, error) {
 	// Parse the CSV/TSV file
 	papers, headers, err := parseCSVFile(path, delimiter)
 	if err != nil {
-		return fmt.Errorf("failed to parse CSV/TSV file: %w", err)
+		return nil, fmt.Errorf("failed to parse CSV/TSV file: %w", err)
 	}
 
 	logger.Info(fmt.Sprintf("Found %d entries to process", len(papers)))
@@ -399,8 +492,14 @@ func processCSVFile(path, dirPath string, delimiter rune) error {
 	successCount := 0
 	failCount := 0
 	var tasks []*DownloadTask
+	tracker := progress.Start(options.Progress, progress.OperationDownload, len(papers))
+	defer tracker.Finish()
+	tracker.SetStage("finding PDFs")
 
 	for i, paper := range papers {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
 		// Log progress every 10 papers during preparation
 		if (i+1)%10 == 0 {
 			logger.Info(fmt.Sprintf("Preparing paper %d of %d...", i+1, len(papers)))
@@ -411,6 +510,7 @@ func processCSVFile(path, dirPath string, delimiter rune) error {
 			logger.Info(fmt.Sprintf("Warning: Row %s: No URL available (Title: %s)", paper.ID, paper.Title))
 			paper.ErrorMsg = "No URL available"
 			failCount++
+			tracker.Done("row "+paper.ID, true, 0)
 			continue
 		}
 
@@ -420,6 +520,7 @@ func processCSVFile(path, dirPath string, delimiter rune) error {
 			logger.Error(fmt.Sprintf("Row %s: Error processing URL %s: %v", paper.ID, paper.URL, err))
 			paper.ErrorMsg = fmt.Sprintf("Error: %v", err)
 			failCount++
+			tracker.Done(paper.URL, true, 0)
 			continue
 		}
 
@@ -427,6 +528,7 @@ func processCSVFile(path, dirPath string, delimiter rune) error {
 			logger.Info(fmt.Sprintf("Warning: Row %s: No PDF found at %s", paper.ID, paper.URL))
 			paper.ErrorMsg = "No PDF found"
 			failCount++
+			tracker.Done(paper.URL, true, 0)
 			continue
 		}
 
@@ -455,7 +557,8 @@ func processCSVFile(path, dirPath string, delimiter rune) error {
 	// Perform concurrent downloads
 	if len(tasks) > 0 {
 		logger.Info(fmt.Sprintf("Starting concurrent download of %d PDFs (max 25 global, 4 per host)", len(tasks)))
-		results := downloader.downloadConcurrently(tasks)
+		tracker.SetStage("downloading")
+		results := downloader.downloadConcurrently(ctx, tasks, tracker)
 
 		// Process results
 		for _, result := range results {
@@ -484,7 +587,16 @@ func processCSVFile(path, dirPath string, delimiter rune) error {
 	logger.Info(fmt.Sprintf("Download complete: %d successful, %d failed out of %d total",
 		successCount, failCount, len(papers)))
 
-	return nil
+	// Report every row as in the enhanced CSV/TSV
+	report := &DownloadReport{ResultsFile: enhancedPath}
+	for _, paper := range papers {
+		entry := DownloadedEntry{URL: paper.URL, Row: paper.ID, Title: paper.Title, Downloaded: paper.Downloaded, Error: paper.ErrorMsg}
+		if paper.Downloaded {
+			entry.File = filepath.Join(dirPath, paper.Filename)
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+	return report, ctx.Err()
 }
 
 // parseCSVFile reads a CSV/TSV file and extracts paper metadata with intelligent column detection
@@ -1,6 +1,7 @@
 package list
 
 import (
+	"context"
 	"encoding/csv"
 	"fmt"
 	"net/http"
@@ -597,10 +598,16 @@ func TestProcessTextFileWithFailedLogging(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	err := processTextFile(txtPath, tempDir)
+	report, err := processTextFile(context.Background(), txtPath, tempDir, DownloadOptions{})
 	if err != nil {
 		t.Errorf("processTextFile failed: %v", err)
 	}
+	if report == nil || len(report.Entries) != 2 || report.ResultsFile != filepath.Join(tempDir, "test_download.csv") {
+		t.Fatalf("Expected a report of both URLs, got %+v", report)
+	}
+	if failed := report.Entries[1]; failed.URL != "https://invalid-url-will-fail.com/paper.pdf" || failed.Downloaded || failed.Error == "" {
+		t.Errorf("Expected the invalid URL to be reported as failed, got %+v", failed)
+	}
 
 	// Check if download file was created
 	downloadPath := filepath.Join(tempDir, "test_download.csv")
@@ -1138,7 +1145,7 @@ func TestConcurrentDownloader(t *testing.T) {
 	}
 
 	start := time.Now()
-	results := downloader.downloadConcurrently(tasks)
+	results := downloader.downloadConcurrently(context.Background(), tasks, nil)
 	duration := time.Since(start)
 
 	// Verify all downloads succeeded
@@ -41,7 +41,7 @@ type ModelItem struct {
 // The function collects multiple types of configuration data:
 // 1. Project metadata (name, author, version)
 // 2. File system settings (input/output directories and formats)
-// 3. Processing options (logging level, duplication, summaries)
+// 3. Processing options (logging level, repetitions, summaries)
 // 4. LLM model configurations (providers, API keys, model selections)
 // 5. Prompt components (persona, task descriptions, expected results)
 // 6. Review criteria (items to review, definitions, examples)
@@ -100,6 +100,8 @@ func RunInteractiveConfigCreation() {
 			[]choose.Choice{
 				{Text: "csv", Note: "Comma-separated values format for easier readability."},
 				{Text: "json", Note: "JavaScript Object Notation format for structured data."},
+				{Text: "jsonl", Note: "JSON Lines format, one object per line, for streaming tools such as jq."},
+				{Text: "xlsx", Note: "Excel workbook with sheets for answers, justifications and summaries."},
 			},
 			choose.WithHelp(true))
 	checkErr(err)
@@ -115,12 +117,14 @@ func RunInteractiveConfigCreation() {
 			choose.WithHelp(true))
 	checkErr(err)
 
-	// Duplication option with help
-	duplication, err := prompt.New().Ask("Enable duplication (for debugging)?").
+	// Repetitions option with help
+	repetitions, err := prompt.New().Ask("How many times should each manuscript be reviewed?").
 		AdvancedChoose(
 			[]choose.Choice{
-				{Text: "no", Note: "Do not duplicate reviews."},
-				{Text: "yes", Note: "Duplicate the manuscripts to review, and the cost, useful for consistency checks."},
+				{Text: "1", Note: "Review every manuscript once."},
+				{Text: "2", Note: "Repeat the review, and the cost, and report the stability of the answers."},
+				{Text: "3", Note: "Review three times, and report the stability of the answers."},
+				{Text: "5", Note: "Review five times, and report the stability of the answers."},
 			},
 			choose.WithHelp(true))
 	checkErr(err)
@@ -266,7 +270,7 @@ func RunInteractiveConfigCreation() {
 	config := generateTomlConfig(
 		projectName, author, version,
 		inputDir, resultsFileName, outputFormat, logLevel,
-		duplication, cotJustification, summary, models,
+		repetitions, cotJustification, summary, models,
 		persona, task, expected_result,
 		failsafe, definitions, example, review,
 	)
@@ -700,9 +704,9 @@ func collectExamples(reviewItems []ReviewItem) string {
 //   - version: Version number
 //   - inputDir: Directory containing input files
 //   - resultsFileName: Name of the file to store results
-//   - outputFormat: Format for output data (e.g., "csv", "json")
+//   - outputFormat: Format for output data ("csv", "json", "jsonl" or "xlsx")
 //   - logLevel: Logging verbosity level
-//   - duplication: Whether to enable duplication for debugging
+//   - repetitions: Number of times each manuscript is reviewed, to measure the stability of the answers
 //   - cotJustification: Whether to enable chain-of-thought justification
 //   - summary: Whether to enable document summarization
 //   - models: Pre-formatted TOML string for LLM model configurations
@@ -717,7 +721,7 @@ func collectExamples(reviewItems []ReviewItem) string {
 // Returns:
 //   - A formatted TOML configuration string with all whitespace trimmed
 func generateTomlConfig(projectName, author, version, inputDir, resultsFileName, outputFormat,
-	logLevel, duplication, cotJustification, summary, models,
+	logLevel, repetitions, cotJustification, summary, models,
 	persona, task, expected_result, failsafe, definitions, example, review string) string {
 	config := fmt.Sprintf(`
 [project]
@@ -730,7 +734,7 @@ input_directory = "%s"
 results_file_name = "%s"
 output_format = "%s"
 log_level = "%s"
-duplication = "%s"
+repetitions = %s
 cot_justification = "%s"
 summary = "%s"
 
@@ -747,7 +751,7 @@ example = "%s"
 [review]
 %s
 `, projectName, author, version, inputDir, resultsFileName, outputFormat,
-		logLevel, duplication, cotJustification, summary, models,
+		logLevel, repetitions, cotJustification, summary, models,
 		persona, task, expected_result, failsafe, definitions, example, review)
 	return strings.TrimSpace(config)
 }
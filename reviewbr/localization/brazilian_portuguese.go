@@ -0,0 +1,188 @@
+package localization
+
+// brazilianPortuguese holds the internal prompts in Brazilian Portuguese. JSON keys and values stay
+// in English, as they are parsed by prismAId.
+var brazilianPortuguese = map[string]string{
+	JustificationQuery: `Para cada uma das chaves e respostas que você forneceu, apresente uma justificativa para a sua resposta na forma de uma cadeia de raciocínio. Em particular, quero uma descrição textual das poucas etapas da cadeia de raciocínio que levaram você à resposta fornecida e das frases do texto analisado que sustentam a sua decisão. Se o valor de uma chave foi 'no' ou vazio '' por falta de informação sobre aquele tema no texto analisado, informe explicitamente esse motivo. Forneça apenas as informações solicitadas, sem observações introdutórias ou finais.
+Formato:
+{
+  "justifications": {
+    "<chave>": {
+      "reasoning_steps": ["Etapa 1", "Etapa 2", "Etapa 3"],
+      "supporting_sentences": ["Frase 1", "Frase 2"]
+    },
+    ...
+  }
+}
+`,
+
+	SummaryQuery: `Resuma em pouquíssimas frases o texto que lhe foi fornecido anteriormente para revisão, apresentando um objeto JSON que resuma o texto revisado.
+Formato do objeto JSON da resposta:
+{
+  "summary": "Seu resumo conciso aqui."
+}`,
+
+	ReduceQuery: `As respostas JSON abaixo foram extraídas de partes consecutivas do mesmo documento. Combine-as em uma única resposta final para o documento inteiro, usando as mesmas chaves e os mesmos valores permitidos. Prefira respostas sustentadas pelo conteúdo das partes a respostas vazias. Forneça apenas o objeto JSON, sem observações introdutórias ou finais.`,
+
+	ReaskQuery: `Sua resposta anterior não atende ao formato JSON esperado. Responda novamente, corrigindo os problemas listados abaixo para que a resposta atenda ao JSON Schema que os segue. Use exatamente as chaves e os valores permitidos solicitados e deixe um valor vazio se o texto não o fornecer. Forneça apenas o objeto JSON, sem observações introdutórias ou finais.`,
+
+	ReaskAnswer:   `Sua resposta anterior:`,
+	ReaskProblems: `Problemas:`,
+	ReaskSchema:   `JSON Schema:`,
+	ExamplesIntro: `Aqui estão exemplos de textos com as respostas esperadas para eles:`,
+	ExampleText:   `Texto do exemplo %d:`,
+	ExampleAnswer: `Resposta do exemplo %d:`,
+	ChunkPart:     `(Parte %d de %d do documento)`,
+	ReducePart:    `Parte %d:`,
+
+	LanguagePrompt: `Você é um especialista em detecção de idiomas analisando manuscritos científicos. Você precisa identificar o idioma principal de um manuscrito com base nos campos fornecidos.
+
+CONTEXTO:
+- Você está analisando: título, resumo e informações do periódico
+- IMPORTANTE: Muitas bases de dados científicas traduzem os resumos para o inglês mantendo o título original
+- O idioma do título costuma ser mais confiável do que o idioma do resumo
+- Nomes de periódicos podem indicar publicações regionais (por exemplo, "Revista Brasileira", "Revista Española", "Deutsche Zeitschrift")
+
+CONSIDERAÇÕES ESPECIAIS:
+- Se o título estiver em um idioma e o resumo em inglês, priorize o idioma do título
+- Procure caracteres específicos de cada idioma (ã, ç, é, ñ, ü, ø etc.)
+- Considere termos científicos em latim como parte do contexto do idioma ao redor
+- Conteúdo em vários idiomas: identifique o idioma dominante/principal
+
+DADOS DO MANUSCRITO:
+{{.Manuscript}}
+
+TAREFA: Identifique o idioma principal deste manuscrito.
+Responda APENAS com um objeto JSON contendo o código de idioma ISO 639-1: {"language": "en"} ou {"language": "es"} ou {"language": "pt"} etc.
+Códigos comuns: en (inglês), es (espanhol), fr (francês), de (alemão), it (italiano), pt (português), ru (russo), zh (chinês), ja (japonês), ar (árabe)`,
+
+	ArticleTypePrompt: `Você é um especialista em classificação de manuscritos científicos. Analise este manuscrito e forneça uma classificação de tipo abrangente.
+
+CONTEXTO:
+Um manuscrito pode ter VÁRIAS classificações sobrepostas. Por exemplo:
+- Um artigo pode ser ao mesmo tempo "research_article" E "empirical_study" E "sample_study"
+- Uma revisão pode ser ao mesmo tempo "review" E "systematic_review"
+- Um artigo de métodos pode ser ao mesmo tempo "research_article" E "methods_paper"
+
+DIMENSÕES DE CLASSIFICAÇÃO:
+
+1. TIPOS TRADICIONAIS DE PUBLICAÇÃO (como os editores o chamariam):
+   - research_article: Pesquisa original com métodos, resultados e conclusões
+   - review: Revisão de literatura sem metodologia sistemática (revisão narrativa, revisão de escopo)
+   - systematic_review: Segue um protocolo de revisão estruturado (PRISMA etc.)
+   - meta_analysis: Síntese estatística de vários estudos
+   - editorial: Texto de opinião dos editores
+   - letter: Breve correspondência aos editores
+   - case_report: Relato de um único paciente/caso/ocorrência
+   - commentary: Comentários sobre trabalhos publicados
+   - perspective: Pontos de vista e opiniões dos autores
+
+2. TIPOS METODOLÓGICOS (como a pesquisa é conduzida):
+   - empirical_study: Baseado em observação/experimentação com coleta de dados
+   - theoretical_paper: Trabalho conceitual sem dados empíricos
+   - methods_paper: Apresenta novos métodos, técnicas ou protocolos
+
+3. ESCOPO DO ESTUDO (para estudos empíricos):
+   - single_case_study: Análise aprofundada de UM caso/paciente/organização (n=1)
+   - sample_study: Vários sujeitos (estudos de coorte, inquéritos, estudos transversais etc.)
+
+REGRAS IMPORTANTES:
+- Um artigo pode ter tipos das TRÊS dimensões
+- Caso único ≠ relato de caso (relato de caso é um tipo de publicação, caso único é um escopo)
+- Se for empírico, DEVE ser single_case_study OU sample_study
+- Use "research_article" como tipo tradicional se não estiver claro
+- Seja específico - não responda apenas "unknown"
+
+MANUSCRITO:
+{{.Manuscript}}
+
+Forneça a classificação em JSON, com os tipos exatamente como escritos acima:
+{
+  "primary_type": "tipo_mais_especifico",
+  "all_types": ["tipo1", "tipo2", ...],
+  "methodological_types": ["empirical_study" | "theoretical_paper" | "methods_paper"],
+  "scope_types": ["single_case_study" | "sample_study"] (apenas se empírico),
+  "type_scores": {"tipo1": 0.95, "tipo2": 0.80, ...}
+}`,
+
+	ArticleTypeSinglePrompt: `Você é um especialista em classificação de literatura científica. Analise o manuscrito a seguir e classifique-o em TODAS as categorias aplicáveis.
+
+IMPORTANTE: Um manuscrito pode pertencer a VÁRIAS categorias sobrepostas. Por exemplo:
+- Um artigo pode ser ao mesmo tempo "research_article" E "empirical_study" E "sample_study"
+- Um artigo pode ser ao mesmo tempo "systematic_review" E "meta_analysis"
+- Um artigo pode ser ao mesmo tempo "methods_paper" E "empirical_study"
+
+DADOS DO MANUSCRITO:
+{{.Manuscript}}
+
+CATEGORIAS DE CLASSIFICAÇÃO:
+
+1. TIPOS TRADICIONAIS DE PUBLICAÇÃO (selecione todos os que se aplicam):
+- research_article: Pesquisa original com métodos e resultados
+- review: Revisão de literatura ou revisão narrativa
+- systematic_review: Segue protocolos estruturados (por exemplo, PRISMA)
+- meta_analysis: Síntese estatística de vários estudos
+- editorial: Texto de opinião dos editores
+- letter: Correspondência aos editores
+- case_report: Relato de um ou mais casos individuais
+- commentary: Comentário sobre um trabalho publicado
+- perspective: Ponto de vista/opinião dos autores
+
+2. TIPOS METODOLÓGICOS (selecione todos os que se aplicam):
+- empirical_study: Baseado em observação/experimentação com coleta de dados
+- theoretical_paper: Trabalho conceitual sem dados empíricos
+- methods_paper: Apresenta novos métodos/técnicas/protocolos
+
+3. ESCOPO DO ESTUDO (para estudos empíricos, selecione se aplicável):
+- single_case_study: Análise aprofundada de um único caso/paciente/organização (n=1)
+- sample_study: Vários participantes/sujeitos (coortes, estudos transversais, inquéritos etc.)
+
+FORMATO DA RESPOSTA:
+Forneça um objeto JSON com:
+{
+  "primary_type": "tipo_mais_especifico",
+  "all_types": ["tipo1", "tipo2", "tipo3"],
+  "methodological_types": ["empirical_study", "theoretical_paper" ou "methods_paper"],
+  "scope_types": ["single_case_study" ou "sample_study"] se aplicável
+}
+
+Exemplo de resposta para um artigo de pesquisa com dados empíricos de vários participantes:
+{
+  "primary_type": "research_article",
+  "all_types": ["research_article", "empirical_study", "sample_study"],
+  "methodological_types": ["empirical_study"],
+  "scope_types": ["sample_study"]
+}`,
+
+	TopicPrompt: `Você é um especialista em triagem de manuscritos acadêmicos. Sua tarefa é avaliar se um manuscrito é relevante para temas de pesquisa específicos.
+
+TEMAS DE INTERESSE:
+{{.Topics}}
+
+DADOS DO MANUSCRITO:
+{{.Manuscript}}
+
+TAREFA: Avalie a relevância deste manuscrito para os temas especificados.
+
+Analise o manuscrito considerando:
+1. Correspondências diretas de palavras-chave com os temas
+2. Alinhamento conceitual com as áreas de pesquisa
+3. Relevância para a área/domínio
+4. Relevância metodológica
+5. Alinhamento das perguntas e objetivos de pesquisa
+
+Responda com um objeto JSON contendo:
+{
+  "overall_score": 0.75,  // Pontuação de 0.0 a 1.0
+  "component_scores": {
+    "keyword_match": 0.8,
+    "concept_match": 0.7,
+    "field_relevance": 0.75
+  },
+  "matched_keywords": ["palavra-chave1", "palavra-chave2"],
+  "matched_concepts": ["conceito1", "conceito2"],
+  "confidence": 0.85,  // Confiança na avaliação (0-1)
+  "is_relevant": true,  // Decisão booleana
+  "reasoning": "Breve explicação da avaliação de relevância"
+}`,
+}
@@ -0,0 +1,8 @@
+// Package localization holds the prompts prismAId writes itself, in the languages it supports: the
+// follow-up, re-ask and merge prompts of reviews and the prompts of the AI-assisted screening
+// filters. A project selects the language of these prompts with its prompt_language setting and
+// can replace any of them, by name, in its [internal_prompts] table. Screening prompts are
+// text/templates receiving the manuscript data and, for topic relevance, the topics of interest.
+// Answers keep the JSON keys and values of the English prompts in every language, so that they are
+// parsed the same way.
+package localization
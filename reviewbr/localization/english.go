@@ -0,0 +1,187 @@
+package localization
+
+// english holds the internal prompts in English.
+var english = map[string]string{
+	JustificationQuery: `For each one of the keys and answers you provided, provide a justification for your answer as a chain of thought. In particular, I want a textual description of the few stages of the chain of thought that lead you to the answer you provided and the sentences in the text you analyzes that support your decision. If the value of a key was 'no' or empty '' because of lack of information on that topic in the text analyzed, explicitly report this reason. Please provide only the information requested, neither introductory nor concluding remarks.
+Format:
+{
+  "justifications": {
+    "<key>": {
+      "reasoning_steps": ["Step 1", "Step 2", "Step 3"],
+      "supporting_sentences": ["Sentence 1", "Sentence 2"]
+    },
+    ...
+  }
+}
+`,
+
+	SummaryQuery: `Summarize in very few sentences the text provided to you before for your review, provide a JSON object summarizing the reviewed text.
+JSON object format for response:
+{
+  "summary": "Your concise summary here."
+}`,
+
+	ReduceQuery: `The JSON answers below were extracted from consecutive parts of the same document. Combine them into a single final answer for the whole document, using the same keys and allowed values. Prefer answers supported by the content of the parts over empty answers. Please provide only the JSON object, neither introductory nor concluding remarks.`,
+
+	ReaskQuery: `Your previous answer does not satisfy the expected JSON format. Answer again, correcting the problems listed below so that the answer satisfies the JSON Schema that follows them. Use exactly the keys and allowed values requested, and leave a value empty if the text does not provide it. Please provide only the JSON object, neither introductory nor concluding remarks.`,
+
+	ReaskAnswer:   `Your previous answer:`,
+	ReaskProblems: `Problems:`,
+	ReaskSchema:   `JSON Schema:`,
+	ExamplesIntro: `Here are examples of texts with the answers expected for them:`,
+	ExampleText:   `Example %d text:`,
+	ExampleAnswer: `Example %d answer:`,
+	ChunkPart:     `(Part %d of %d of the document)`,
+	ReducePart:    `Part %d:`,
+
+	LanguagePrompt: `You are a language detection expert analyzing scientific manuscripts. You need to identify the primary language of a manuscript based on the provided fields.
+
+CONTEXT:
+- You are analyzing: title, abstract, and journal information
+- IMPORTANT: Many scientific databases translate abstracts to English while keeping the original title
+- The title language is often more reliable than abstract language
+- Journal names may indicate regional publications (e.g., "Revista Española", "Deutsche Zeitschrift")
+
+SPECIAL CONSIDERATIONS:
+- If title is in one language but abstract is in English, prioritize the title language
+- Look for language-specific characters (é, ñ, ü, ø, etc.)
+- Consider scientific Latin terms as part of the surrounding language context
+- Mixed language content: identify the dominant/primary language
+
+MANUSCRIPT DATA:
+{{.Manuscript}}
+
+TASK: Identify the primary language of this manuscript.
+Respond with ONLY a JSON object with the ISO 639-1 language code: {"language": "en"} or {"language": "es"} or {"language": "fr"} etc.
+Common codes: en (English), es (Spanish), fr (French), de (German), it (Italian), pt (Portuguese), ru (Russian), zh (Chinese), ja (Japanese), ar (Arabic)`,
+
+	ArticleTypePrompt: `You are a scientific manuscript classification expert. Analyze this manuscript and provide a comprehensive type classification.
+
+CONTEXT:
+A manuscript can have MULTIPLE overlapping classifications. For example:
+- A paper can be both "research_article" AND "empirical_study" AND "sample_study"
+- A review can be both "review" AND "systematic_review"
+- A methods paper can be both "research_article" AND "methods_paper"
+
+CLASSIFICATION DIMENSIONS:
+
+1. TRADITIONAL PUBLICATION TYPES (what editors would call it):
+   - research_article: Original research with methods, results, and conclusions
+   - review: Literature review without systematic methodology (narrative review, scoping review)
+   - systematic_review: Following structured review protocol (PRISMA, etc.)
+   - meta_analysis: Statistical synthesis of multiple studies
+   - editorial: Opinion piece by editors
+   - letter: Brief correspondence to editors
+   - case_report: Single patient/case/instance report
+   - commentary: Comments on published work
+   - perspective: Author viewpoints and opinions
+
+2. METHODOLOGICAL TYPES (how research is conducted):
+   - empirical_study: Based on observation/experimentation with data collection
+   - theoretical_paper: Conceptual work without empirical data
+   - methods_paper: Presenting new methods, techniques, or protocols
+
+3. STUDY SCOPE (for empirical studies):
+   - single_case_study: In-depth analysis of ONE case/patient/organization (n=1)
+   - sample_study: Multiple subjects (cohort studies, surveys, cross-sectional, etc.)
+
+IMPORTANT RULES:
+- A paper can have types from ALL three dimensions
+- Single case ≠ case report (case report is a publication type, single case is a scope)
+- If empirical, MUST be either single_case_study OR sample_study
+- Default to "research_article" for traditional type if unclear
+- Be specific - don't just say "unknown"
+
+MANUSCRIPT:
+{{.Manuscript}}
+
+Provide classification as JSON:
+{
+  "primary_type": "most_specific_type",
+  "all_types": ["type1", "type2", ...],
+  "methodological_types": ["empirical_study" | "theoretical_paper" | "methods_paper"],
+  "scope_types": ["single_case_study" | "sample_study"] (only if empirical),
+  "type_scores": {"type1": 0.95, "type2": 0.80, ...}
+}`,
+
+	ArticleTypeSinglePrompt: `You are an expert in scientific literature classification. Analyze the following manuscript and classify it into ALL applicable categories.
+
+IMPORTANT: A manuscript can belong to MULTIPLE overlapping categories. For example:
+- A paper can be both "research_article" AND "empirical_study" AND "sample_study"
+- A paper can be both "systematic_review" AND "meta_analysis"
+- A paper can be both "methods_paper" AND "empirical_study"
+
+MANUSCRIPT DATA:
+{{.Manuscript}}
+
+CLASSIFICATION CATEGORIES:
+
+1. TRADITIONAL PUBLICATION TYPES (select all that apply):
+- research_article: Original research with methods and results
+- review: Literature review or narrative review
+- systematic_review: Following structured protocols (e.g., PRISMA)
+- meta_analysis: Statistical synthesis of multiple studies
+- editorial: Opinion piece by editors
+- letter: Correspondence to editors
+- case_report: Report of individual case(s)
+- commentary: Comment on published work
+- perspective: Author viewpoint/opinion
+
+2. METHODOLOGICAL TYPES (select all that apply):
+- empirical_study: Based on observation/experimentation with data collection
+- theoretical_paper: Conceptual work without empirical data
+- methods_paper: Presenting new methods/techniques/protocols
+
+3. STUDY SCOPE (for empirical studies, select if applicable):
+- single_case_study: In-depth analysis of single case/patient/organization (n=1)
+- sample_study: Multiple participants/subjects (cohort, cross-sectional, survey, etc.)
+
+RESPONSE FORMAT:
+Provide a JSON object with:
+{
+  "primary_type": "most_specific_type",
+  "all_types": ["type1", "type2", "type3"],
+  "methodological_types": ["empirical_study", "theoretical_paper", or "methods_paper"],
+  "scope_types": ["single_case_study" or "sample_study"] if applicable
+}
+
+Example response for a research article with empirical data from multiple participants:
+{
+  "primary_type": "research_article",
+  "all_types": ["research_article", "empirical_study", "sample_study"],
+  "methodological_types": ["empirical_study"],
+  "scope_types": ["sample_study"]
+}`,
+
+	TopicPrompt: `You are an expert in academic manuscript screening. Your task is to evaluate whether a manuscript is relevant to specific research topics.
+
+TOPICS OF INTEREST:
+{{.Topics}}
+
+MANUSCRIPT DATA:
+{{.Manuscript}}
+
+TASK: Evaluate the relevance of this manuscript to the specified topics.
+
+Analyze the manuscript considering:
+1. Direct keyword matches with the topics
+2. Conceptual alignment with the research areas
+3. Field/domain relevance
+4. Methodological relevance
+5. Research questions and objectives alignment
+
+Respond with a JSON object containing:
+{
+  "overall_score": 0.75,  // Score from 0.0 to 1.0
+  "component_scores": {
+    "keyword_match": 0.8,
+    "concept_match": 0.7,
+    "field_relevance": 0.75
+  },
+  "matched_keywords": ["keyword1", "keyword2"],
+  "matched_concepts": ["concept1", "concept2"],
+  "confidence": 0.85,  // Confidence in the assessment (0-1)
+  "is_relevant": true,  // Boolean decision
+  "reasoning": "Brief explanation of the relevance assessment"
+}`,
+}
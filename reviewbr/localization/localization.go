@@ -0,0 +1,227 @@
+package localization
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/open-and-sustainable/prismaid/logger"
+)
+
+// Supported prompt languages.
+const (
+	English             = "en"
+	BrazilianPortuguese = "pt-BR"
+	Spanish             = "es"
+)
+
+// Names of the internal prompts, as used in the [internal_prompts] table.
+const (
+	JustificationQuery      = "justification_query" // review follow-up asking the reasoning behind every answer
+	SummaryQuery            = "summary_query"       // review follow-up asking a summary of the document
+	ReduceQuery             = "reduce_query"        // review prompt combining the answers given on the chunks of a document
+	ReaskQuery              = "reask_query"         // review prompt asking again an answer failing its JSON Schema
+	ReaskAnswer             = "reask_answer"        // label of the previous answer in the re-ask prompt
+	ReaskProblems           = "reask_problems"      // label of the problems of the answer in the re-ask prompt
+	ReaskSchema             = "reask_schema"        // label of the JSON Schema in the re-ask prompt
+	ExamplesIntro           = "examples_intro"      // introduction of the few-shot examples of a review prompt
+	ExampleText             = "example_text"        // label of the text of a few-shot example, with its number
+	ExampleAnswer           = "example_answer"      // label of the answer of a few-shot example, with its number
+	ChunkPart               = "chunk_part"          // heading of a chunk of a document, with its number and the number of chunks
+	ReducePart              = "reduce_part"         // label of the answer given on a chunk in the reduce prompt, with its number
+	LanguagePrompt          = "language"            // screening prompt detecting the language of a manuscript
+	ArticleTypePrompt       = "article_type"        // screening prompt classifying the type of the manuscripts of a batch
+	ArticleTypeSinglePrompt = "article_type_single" // screening prompt classifying the type of a single manuscript, outside batches
+	TopicPrompt             = "topic_relevance"     // screening prompt scoring the relevance of a manuscript to the topics
+)
+
+// ReviewPrompts lists the internal prompts of reviews.
+var ReviewPrompts = []string{
+	JustificationQuery, SummaryQuery, ReduceQuery, ReaskQuery, ReaskAnswer, ReaskProblems, ReaskSchema,
+	ExamplesIntro, ExampleText, ExampleAnswer, ChunkPart, ReducePart,
+}
+
+// numbered gives the number of %d verbs of the internal prompts receiving numbers, which their
+// overrides must keep.
+var numbered = map[string]int{ExampleText: 1, ExampleAnswer: 1, ChunkPart: 2, ReducePart: 1}
+
+// ScreeningPrompts lists the internal prompts of screenings, all of them templates receiving Data.
+var ScreeningPrompts = []string{LanguagePrompt, ArticleTypePrompt, ArticleTypeSinglePrompt, TopicPrompt}
+
+// builtin maps every supported language to its internal prompts.
+var builtin = map[string]map[string]string{
+	English:             english,
+	BrazilianPortuguese: brazilianPortuguese,
+	Spanish:             spanish,
+}
+
+// Data is passed to the screening prompt templates.
+type Data struct {
+	Manuscript string // fields of the manuscript, one per line
+	Topics     string // topics of interest, one per line starting with "- "
+}
+
+// Prompts gives the internal prompts of a project, in its language and with its overrides. A nil
+// Prompts gives the English ones.
+type Prompts struct {
+	language  string
+	overrides map[string]string
+}
+
+// Normalize returns the canonical code of a prompt language, matched case-insensitively and with
+// "_" accepted in place of "-"; an empty language stands for English.
+//
+// Arguments:
+// - language: The language as configured.
+//
+// Returns:
+// - The canonical code, one of the supported language constants.
+// - An error if the language is not supported.
+func Normalize(language string) (string, error) {
+	language = strings.ReplaceAll(strings.TrimSpace(language), "_", "-")
+	if language == "" {
+		return English, nil
+	}
+	for code := range builtin {
+		if strings.EqualFold(code, language) {
+			return code, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported prompt_language '%s', use one of %s", language, strings.Join(Languages(), ", "))
+}
+
+// Languages returns the codes of the supported prompt languages, sorted.
+func Languages() []string {
+	codes := make([]string, 0, len(builtin))
+	for code := range builtin {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
+
+// Validate checks the prompt language and the overrides of a configuration.
+//
+// Arguments:
+// - language: The prompt_language setting.
+// - overrides: The [internal_prompts] table, mapping prompt names to their replacements.
+// - names: The internal prompts of the tool, ReviewPrompts or ScreeningPrompts.
+//
+// Returns:
+// - An error if the language is not supported, an override names an unknown prompt or is empty,
+// the override of a numbered label does not keep its %d verbs, or the override of a screening prompt
+// is not a valid template of Data.
+func Validate(language string, overrides map[string]string, names []string) error {
+	if _, err := Normalize(language); err != nil {
+		return err
+	}
+	for name, text := range overrides {
+		if !slices.Contains(names, name) {
+			return fmt.Errorf("unknown internal prompt '%s', use one of %s", name, strings.Join(names, ", "))
+		}
+		if strings.TrimSpace(text) == "" {
+			return fmt.Errorf("internal prompt '%s' is empty", name)
+		}
+		if verbs, ok := numbered[name]; ok {
+			if err := checkNumbers(name, text, verbs); err != nil {
+				return err
+			}
+		}
+		if !slices.Contains(ScreeningPrompts, name) {
+			continue
+		}
+		tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+		if err != nil {
+			return fmt.Errorf("invalid template in internal prompt %s: %v", name, err)
+		}
+		if err := tmpl.Execute(&strings.Builder{}, Data{}); err != nil {
+			return fmt.Errorf("invalid template in internal prompt %s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// New returns the internal prompts in a language, replaced by the overrides where given. The
+// arguments are expected to have passed Validate; an unsupported language falls back to English.
+//
+// Arguments:
+// - language: The prompt_language setting.
+// - overrides: The [internal_prompts] table, mapping prompt names to their replacements.
+//
+// Returns:
+// - The prompts.
+func New(language string, overrides map[string]string) *Prompts {
+	code, err := Normalize(language)
+	if err != nil {
+		logger.Error("%v, using English prompts", err)
+		code = English
+	}
+	return &Prompts{language: code, overrides: overrides}
+}
+
+// Language returns the code of the language of the prompts.
+func (p *Prompts) Language() string {
+	if p == nil {
+		return English
+	}
+	return p.language
+}
+
+// Text returns an internal prompt: its override if given, otherwise its text in the language of the
+// prompts.
+func (p *Prompts) Text(name string) string {
+	if p == nil {
+		return english[name]
+	}
+	if text, ok := p.overrides[name]; ok {
+		return text
+	}
+	return builtin[p.language][name]
+}
+
+// Format returns a numbered label, such as ExampleText, with its %d verbs replaced by the numbers.
+func (p *Prompts) Format(name string, numbers ...int) string {
+	args := make([]any, len(numbers))
+	for i, number := range numbers {
+		args[i] = number
+	}
+	return fmt.Sprintf(p.Text(name), args...)
+}
+
+// Render executes a screening prompt with the data of a manuscript. An override that fails to
+// execute is reported and replaced by the built-in prompt.
+func (p *Prompts) Render(name string, data Data) string {
+	text, err := execute(name, p.Text(name), data)
+	if err != nil {
+		logger.Error("Error rendering internal prompt %s, using the built-in one: %v", name, err)
+		text, _ = execute(name, builtin[p.Language()][name], data)
+	}
+	return text
+}
+
+func execute(name string, text string, data Data) (string, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, data); err != nil {
+		return "", err
+	}
+	return prompt.String(), nil
+}
+
+// checkNumbers checks that the override of a numbered label has exactly the expected %d verbs and no
+// other formatting verb.
+func checkNumbers(name string, text string, verbs int) error {
+	numbers := make([]any, verbs)
+	for i := range numbers {
+		numbers[i] = i + 1
+	}
+	if strings.Count(text, "%d") != verbs || strings.Contains(fmt.Sprintf(text, numbers...), "%!") {
+		return fmt.Errorf("internal prompt '%s' must contain %d %%d placeholders for its numbers and no other %% verb, use %%%% for a percent sign", name, verbs)
+	}
+	return nil
+}
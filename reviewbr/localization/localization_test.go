@@ -0,0 +1,103 @@
+package localization
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEveryLanguageHasEveryPrompt(t *testing.T) {
+	names := append(append([]string{}, ReviewPrompts...), ScreeningPrompts...)
+	for _, language := range Languages() {
+		prompts := New(language, nil)
+		for _, name := range names {
+			if strings.TrimSpace(prompts.Text(name)) == "" {
+				t.Errorf("Missing %s prompt in %s", name, language)
+			}
+		}
+		for _, name := range ScreeningPrompts {
+			rendered := prompts.Render(name, Data{Manuscript: "TITLE: Manuscript", Topics: "- topic"})
+			if !strings.Contains(rendered, "TITLE: Manuscript") {
+				t.Errorf("Expected the manuscript in the %s prompt in %s, got %q", name, language, rendered)
+			}
+		}
+		if !strings.Contains(prompts.Render(TopicPrompt, Data{Topics: "- topic"}), "- topic") {
+			t.Errorf("Expected the topics in the topic relevance prompt in %s", language)
+		}
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	cases := map[string]string{"": English, "pt-br": BrazilianPortuguese, "pt_BR": BrazilianPortuguese, "ES": Spanish}
+	for language, expected := range cases {
+		code, err := Normalize(language)
+		if err != nil || code != expected {
+			t.Errorf("Normalize(%q) = %q, %v; expected %q", language, code, err, expected)
+		}
+	}
+	if _, err := Normalize("fr"); err == nil {
+		t.Errorf("Expected an error for an unsupported language")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := map[string]string{SummaryQuery: "Resuma.", TopicPrompt: "{{.Topics}}\n{{.Manuscript}}", ChunkPart: "[%d/%d, 100%%]"}
+	if err := Validate("pt-BR", valid, append(append([]string{}, ReviewPrompts...), ScreeningPrompts...)); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+
+	invalid := []struct {
+		language  string
+		overrides map[string]string
+		names     []string
+	}{
+		{"de", nil, ReviewPrompts},
+		{"en", map[string]string{TopicPrompt: "{{.Topics}}"}, ReviewPrompts},
+		{"en", map[string]string{SummaryQuery: " "}, ReviewPrompts},
+		{"en", map[string]string{LanguagePrompt: "{{.Manuscript"}, ScreeningPrompts},
+		{"en", map[string]string{LanguagePrompt: "{{.Abstract}}"}, ScreeningPrompts},
+		{"en", map[string]string{ExampleText: "Example text:"}, ReviewPrompts},
+		{"en", map[string]string{ChunkPart: "Part %d of %s"}, ReviewPrompts},
+	}
+	for _, c := range invalid {
+		if err := Validate(c.language, c.overrides, c.names); err == nil {
+			t.Errorf("Expected an error for language %q and overrides %v", c.language, c.overrides)
+		}
+	}
+}
+
+func TestPromptsOverridesAndDefaults(t *testing.T) {
+	var none *Prompts
+	if none.Text(SummaryQuery) != english[SummaryQuery] || none.Language() != English {
+		t.Errorf("Expected a nil Prompts to give the English prompts")
+	}
+
+	prompts := New("es", map[string]string{JustificationQuery: "Justifica.", LanguagePrompt: "Idioma de {{.Manuscript}}"})
+	if prompts.Text(JustificationQuery) != "Justifica." {
+		t.Errorf("Expected the override, got %q", prompts.Text(JustificationQuery))
+	}
+	if prompts.Text(SummaryQuery) != spanish[SummaryQuery] {
+		t.Errorf("Expected the Spanish summary query, got %q", prompts.Text(SummaryQuery))
+	}
+	if rendered := prompts.Render(LanguagePrompt, Data{Manuscript: "TITLE: Título"}); rendered != "Idioma de TITLE: Título" {
+		t.Errorf("Unexpected rendered override %q", rendered)
+	}
+}
+
+func TestSingleArticleTypePrompt(t *testing.T) {
+	single := New(English, nil).Render(ArticleTypeSinglePrompt, Data{Manuscript: "Title: T\nAbstract: A"})
+	if !strings.HasPrefix(single, "You are an expert in scientific literature classification.") || !strings.Contains(single, "MANUSCRIPT DATA:\nTitle: T\nAbstract: A\n\nCLASSIFICATION CATEGORIES:") {
+		t.Errorf("Expected the single-article classification prompt, got %q", single)
+	}
+	if english[ArticleTypeSinglePrompt] == english[ArticleTypePrompt] {
+		t.Errorf("Expected the single-article prompt to differ from the batch one")
+	}
+}
+
+func TestFormat(t *testing.T) {
+	if label := New("pt-BR", nil).Format(ChunkPart, 2, 3); label != "(Parte 2 de 3 do documento)" {
+		t.Errorf("Unexpected chunk heading %q", label)
+	}
+	if label := New("en", map[string]string{ExampleText: "Text %d"}).Format(ExampleText, 1); label != "Text 1" {
+		t.Errorf("Expected the overridden label, got %q", label)
+	}
+}
@@ -0,0 +1,188 @@
+package localization
+
+// spanish holds the internal prompts in Spanish. JSON keys and values stay in English, as they are
+// parsed by prismAId.
+var spanish = map[string]string{
+	JustificationQuery: `Para cada una de las claves y respuestas que proporcionaste, ofrece una justificación de tu respuesta en forma de cadena de razonamiento. En particular, quiero una descripción textual de las pocas etapas de la cadena de razonamiento que te llevaron a la respuesta proporcionada y de las oraciones del texto analizado que respaldan tu decisión. Si el valor de una clave fue 'no' o vacío '' por falta de información sobre ese tema en el texto analizado, indica explícitamente este motivo. Proporciona solo la información solicitada, sin comentarios introductorios ni finales.
+Formato:
+{
+  "justifications": {
+    "<clave>": {
+      "reasoning_steps": ["Paso 1", "Paso 2", "Paso 3"],
+      "supporting_sentences": ["Oración 1", "Oración 2"]
+    },
+    ...
+  }
+}
+`,
+
+	SummaryQuery: `Resume en muy pocas oraciones el texto que se te proporcionó antes para su revisión, presentando un objeto JSON que resuma el texto revisado.
+Formato del objeto JSON de la respuesta:
+{
+  "summary": "Tu resumen conciso aquí."
+}`,
+
+	ReduceQuery: `Las respuestas JSON siguientes se extrajeron de partes consecutivas del mismo documento. Combínalas en una única respuesta final para todo el documento, usando las mismas claves y los mismos valores permitidos. Prefiere las respuestas respaldadas por el contenido de las partes a las respuestas vacías. Proporciona solo el objeto JSON, sin comentarios introductorios ni finales.`,
+
+	ReaskQuery: `Tu respuesta anterior no cumple el formato JSON esperado. Responde de nuevo, corrigiendo los problemas enumerados a continuación para que la respuesta cumpla el JSON Schema que los sigue. Usa exactamente las claves y los valores permitidos solicitados, y deja un valor vacío si el texto no lo proporciona. Proporciona solo el objeto JSON, sin comentarios introductorios ni finales.`,
+
+	ReaskAnswer:   `Tu respuesta anterior:`,
+	ReaskProblems: `Problemas:`,
+	ReaskSchema:   `JSON Schema:`,
+	ExamplesIntro: `Estos son ejemplos de textos con las respuestas esperadas para ellos:`,
+	ExampleText:   `Texto del ejemplo %d:`,
+	ExampleAnswer: `Respuesta del ejemplo %d:`,
+	ChunkPart:     `(Parte %d de %d del documento)`,
+	ReducePart:    `Parte %d:`,
+
+	LanguagePrompt: `Eres un experto en detección de idiomas que analiza manuscritos científicos. Debes identificar el idioma principal de un manuscrito a partir de los campos proporcionados.
+
+CONTEXTO:
+- Estás analizando: título, resumen e información de la revista
+- IMPORTANTE: Muchas bases de datos científicas traducen los resúmenes al inglés manteniendo el título original
+- El idioma del título suele ser más fiable que el idioma del resumen
+- Los nombres de las revistas pueden indicar publicaciones regionales (por ejemplo, "Revista Española", "Deutsche Zeitschrift")
+
+CONSIDERACIONES ESPECIALES:
+- Si el título está en un idioma y el resumen en inglés, prioriza el idioma del título
+- Busca caracteres propios de cada idioma (é, ñ, ü, ø, etc.)
+- Considera los términos científicos en latín como parte del contexto del idioma que los rodea
+- Contenido en varios idiomas: identifica el idioma dominante/principal
+
+DATOS DEL MANUSCRITO:
+{{.Manuscript}}
+
+TAREA: Identifica el idioma principal de este manuscrito.
+Responde SOLO con un objeto JSON con el código de idioma ISO 639-1: {"language": "en"} o {"language": "es"} o {"language": "fr"}, etc.
+Códigos comunes: en (inglés), es (español), fr (francés), de (alemán), it (italiano), pt (portugués), ru (ruso), zh (chino), ja (japonés), ar (árabe)`,
+
+	ArticleTypePrompt: `Eres un experto en clasificación de manuscritos científicos. Analiza este manuscrito y proporciona una clasificación de tipo completa.
+
+CONTEXTO:
+Un manuscrito puede tener VARIAS clasificaciones superpuestas. Por ejemplo:
+- Un artículo puede ser a la vez "research_article" Y "empirical_study" Y "sample_study"
+- Una revisión puede ser a la vez "review" Y "systematic_review"
+- Un artículo de métodos puede ser a la vez "research_article" Y "methods_paper"
+
+DIMENSIONES DE CLASIFICACIÓN:
+
+1. TIPOS TRADICIONALES DE PUBLICACIÓN (cómo lo llamarían los editores):
+   - research_article: Investigación original con métodos, resultados y conclusiones
+   - review: Revisión de la literatura sin metodología sistemática (revisión narrativa, revisión de alcance)
+   - systematic_review: Sigue un protocolo de revisión estructurado (PRISMA, etc.)
+   - meta_analysis: Síntesis estadística de varios estudios
+   - editorial: Artículo de opinión de los editores
+   - letter: Breve correspondencia a los editores
+   - case_report: Informe de un único paciente/caso/instancia
+   - commentary: Comentarios sobre trabajos publicados
+   - perspective: Puntos de vista y opiniones de los autores
+
+2. TIPOS METODOLÓGICOS (cómo se realiza la investigación):
+   - empirical_study: Basado en observación/experimentación con recopilación de datos
+   - theoretical_paper: Trabajo conceptual sin datos empíricos
+   - methods_paper: Presenta nuevos métodos, técnicas o protocolos
+
+3. ALCANCE DEL ESTUDIO (para estudios empíricos):
+   - single_case_study: Análisis en profundidad de UN caso/paciente/organización (n=1)
+   - sample_study: Varios sujetos (estudios de cohortes, encuestas, estudios transversales, etc.)
+
+REGLAS IMPORTANTES:
+- Un artículo puede tener tipos de LAS TRES dimensiones
+- Caso único ≠ informe de caso (el informe de caso es un tipo de publicación, el caso único es un alcance)
+- Si es empírico, DEBE ser single_case_study O sample_study
+- Usa "research_article" como tipo tradicional si no está claro
+- Sé específico - no respondas solo "unknown"
+
+MANUSCRITO:
+{{.Manuscript}}
+
+Proporciona la clasificación en JSON, con los tipos exactamente como se escriben arriba:
+{
+  "primary_type": "tipo_mas_especifico",
+  "all_types": ["tipo1", "tipo2", ...],
+  "methodological_types": ["empirical_study" | "theoretical_paper" | "methods_paper"],
+  "scope_types": ["single_case_study" | "sample_study"] (solo si es empírico),
+  "type_scores": {"tipo1": 0.95, "tipo2": 0.80, ...}
+}`,
+
+	ArticleTypeSinglePrompt: `Eres un experto en clasificación de literatura científica. Analiza el siguiente manuscrito y clasifícalo en TODAS las categorías aplicables.
+
+IMPORTANTE: Un manuscrito puede pertenecer a VARIAS categorías superpuestas. Por ejemplo:
+- Un artículo puede ser a la vez "research_article" Y "empirical_study" Y "sample_study"
+- Un artículo puede ser a la vez "systematic_review" Y "meta_analysis"
+- Un artículo puede ser a la vez "methods_paper" Y "empirical_study"
+
+DATOS DEL MANUSCRITO:
+{{.Manuscript}}
+
+CATEGORÍAS DE CLASIFICACIÓN:
+
+1. TIPOS TRADICIONALES DE PUBLICACIÓN (selecciona todos los que correspondan):
+- research_article: Investigación original con métodos y resultados
+- review: Revisión de la literatura o revisión narrativa
+- systematic_review: Sigue protocolos estructurados (por ejemplo, PRISMA)
+- meta_analysis: Síntesis estadística de varios estudios
+- editorial: Artículo de opinión de los editores
+- letter: Correspondencia a los editores
+- case_report: Informe de uno o varios casos individuales
+- commentary: Comentario sobre un trabajo publicado
+- perspective: Punto de vista/opinión de los autores
+
+2. TIPOS METODOLÓGICOS (selecciona todos los que correspondan):
+- empirical_study: Basado en observación/experimentación con recopilación de datos
+- theoretical_paper: Trabajo conceptual sin datos empíricos
+- methods_paper: Presenta nuevos métodos/técnicas/protocolos
+
+3. ALCANCE DEL ESTUDIO (para estudios empíricos, selecciona si corresponde):
+- single_case_study: Análisis en profundidad de un único caso/paciente/organización (n=1)
+- sample_study: Varios participantes/sujetos (cohortes, estudios transversales, encuestas, etc.)
+
+FORMATO DE RESPUESTA:
+Proporciona un objeto JSON con:
+{
+  "primary_type": "tipo_mas_especifico",
+  "all_types": ["tipo1", "tipo2", "tipo3"],
+  "methodological_types": ["empirical_study", "theoretical_paper" o "methods_paper"],
+  "scope_types": ["single_case_study" o "sample_study"] si corresponde
+}
+
+Ejemplo de respuesta para un artículo de investigación con datos empíricos de varios participantes:
+{
+  "primary_type": "research_article",
+  "all_types": ["research_article", "empirical_study", "sample_study"],
+  "methodological_types": ["empirical_study"],
+  "scope_types": ["sample_study"]
+}`,
+
+	TopicPrompt: `Eres un experto en cribado de manuscritos académicos. Tu tarea es evaluar si un manuscrito es relevante para temas de investigación específicos.
+
+TEMAS DE INTERÉS:
+{{.Topics}}
+
+DATOS DEL MANUSCRITO:
+{{.Manuscript}}
+
+TAREA: Evalúa la relevancia de este manuscrito para los temas especificados.
+
+Analiza el manuscrito considerando:
+1. Coincidencias directas de palabras clave con los temas
+2. Alineación conceptual con las áreas de investigación
+3. Relevancia para el campo/dominio
+4. Relevancia metodológica
+5. Alineación de las preguntas y los objetivos de investigación
+
+Responde con un objeto JSON que contenga:
+{
+  "overall_score": 0.75,  // Puntuación de 0.0 a 1.0
+  "component_scores": {
+    "keyword_match": 0.8,
+    "concept_match": 0.7,
+    "field_relevance": 0.75
+  },
+  "matched_keywords": ["palabra_clave1", "palabra_clave2"],
+  "matched_concepts": ["concepto1", "concepto2"],
+  "confidence": 0.85,  // Confianza en la evaluación (0-1)
+  "is_relevant": true,  // Decisión booleana
+  "reasoning": "Breve explicación de la evaluación de relevancia"
+}`,
+}
@@ -0,0 +1,5 @@
+// Package logger is the logger of prismAId: it writes through the alembica logger, with the same
+// functions and modes, after removing the secrets registered with the secrets package, such as
+// resolved API keys, from every message. Provider errors echoing a key are therefore safe to log
+// as they are.
+package logger
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"fmt"
+
+	alembica "github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/secrets"
+)
+
+// Mode sets where log messages are written.
+type Mode = alembica.Mode
+
+// Logging modes, as in the alembica logger.
+const (
+	Silent = alembica.Silent
+	Stdout = alembica.Stdout
+	File   = alembica.File
+)
+
+// The alembica functions receiving the redacted messages, replaced in tests.
+var (
+	errorSink = alembica.Error
+	infoSink  = alembica.Info
+	writeSink = alembica.Write
+)
+
+// SetupLogging sets where log messages are written.
+//
+// Arguments:
+// - mode: Silent, Stdout or File.
+// - filename: The path of the project file, next to which the log file is written in File mode.
+func SetupLogging(mode Mode, filename string) {
+	alembica.SetupLogging(mode, filename)
+}
+
+// Error logs an error message, given as for the alembica logger, without registered secrets.
+func Error(args ...interface{}) {
+	errorSink(redact(args)...)
+}
+
+// Info logs an informative message, given as for the alembica logger, without registered secrets.
+func Info(args ...interface{}) {
+	infoSink(redact(args)...)
+}
+
+// Write writes a row of the log, without registered secrets.
+func Write(row []string) {
+	redacted := make([]string, len(row))
+	for i, cell := range row {
+		redacted[i] = secrets.Redact(cell)
+	}
+	writeSink(redacted)
+}
+
+// Flush writes the buffered rows of the log.
+func Flush() {
+	alembica.Flush()
+}
+
+// redact returns the arguments of a log call with the registered secrets removed. Strings and
+// errors are replaced by their redacted text; other values are kept, so that they are formatted
+// as given, unless their text holds a secret.
+func redact(args []interface{}) []interface{} {
+	redacted := make([]interface{}, len(args))
+	for i, arg := range args {
+		switch value := arg.(type) {
+		case string:
+			redacted[i] = secrets.Redact(value)
+		case error:
+			redacted[i] = secrets.RedactError(value)
+		default:
+			redacted[i] = arg
+			if text := fmt.Sprint(value); secrets.Redact(text) != text {
+				redacted[i] = secrets.Redact(text)
+			}
+		}
+	}
+	return redacted
+}
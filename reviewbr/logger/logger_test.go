@@ -0,0 +1,42 @@
+package logger
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/prismaid/secrets"
+)
+
+func TestErrorRedactsSecrets(t *testing.T) {
+	defer func(e, i func(...interface{}), w func([]string)) { errorSink, infoSink, writeSink = e, i, w }(errorSink, infoSink, writeSink)
+	var logged []string
+	errorSink = func(args ...interface{}) {
+		format, _ := args[0].(string)
+		logged = append(logged, fmt.Sprintf(format, args[1:]...))
+	}
+	infoSink = func(args ...interface{}) { logged = append(logged, fmt.Sprint(args...)) }
+	var written []string
+	writeSink = func(row []string) { written = append(written, row...) }
+
+	secret := "sk-logger-test-secret"
+	secrets.Register(secret)
+	providerError := errors.New("401 Unauthorized: invalid api key " + secret)
+	Error("Error calling the provider: %v", providerError)
+	Error("Request %d failed with key %s", 3, secret)
+	Info("Using key", secret)
+	Write([]string{"key", secret})
+
+	for _, message := range append(logged, written...) {
+		if strings.Contains(message, secret) {
+			t.Errorf("Expected the secret to be redacted, got %q", message)
+		}
+	}
+	if logged[0] != "Error calling the provider: 401 Unauthorized: invalid api key "+secrets.Redacted {
+		t.Errorf("Unexpected redacted error %q", logged[0])
+	}
+	if logged[1] != "Request 3 failed with key "+secrets.Redacted {
+		t.Errorf("Expected other arguments to keep their formatting, got %q", logged[1])
+	}
+}
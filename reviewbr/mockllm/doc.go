@@ -0,0 +1,7 @@
+// Package mockllm implements an offline stand-in for an LLM provider, to run review and screening
+// pipelines end to end without network access or API costs. It serves the chat completions endpoint
+// of the OpenAI API, so that projects reach it through the SelfHosted provider and its base_url.
+// Answers are looked up by the SHA-256 hash of the prompt, in a script of successive steps or in a
+// directory of fixture files, and the server can add latency, rate limit errors (HTTP 429) and
+// malformed JSON answers, to exercise the error paths of the pipelines deterministically.
+package mockllm
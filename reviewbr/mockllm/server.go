@@ -0,0 +1,343 @@
+package mockllm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/open-and-sustainable/prismaid/logger"
+)
+
+const (
+	// DefaultAddress is the address the mock server listens on unless another one is given.
+	DefaultAddress = "127.0.0.1:8000"
+	// DefaultAnswer is the answer to the prompts without fixture unless another one is configured.
+	DefaultAnswer = "{}"
+	// HashHeader is the response header holding the hash of the prompt, to write fixtures for it.
+	HashHeader = "X-Mock-Prompt-Hash"
+
+	completionsPath = "/chat/completions"
+	modelsPath      = "/models"
+	mockModel       = "mock"
+)
+
+// Step is one scripted outcome of a prompt: an answer, an HTTP error, or a malformed answer.
+type Step struct {
+	Content   string        `toml:"content"`   // answer of the model
+	Status    int           `toml:"status"`    // HTTP status returned instead of the answer, e.g. 429 or 500
+	Malformed bool          `toml:"malformed"` // the answer is cut in the middle, so that it is not valid JSON
+	Latency   time.Duration `toml:"latency"`   // delay before responding, replacing the latency of the server
+}
+
+// Fixture scripts the successive outcomes of a prompt. The steps are played in order, one per
+// request of the prompt, and the last one is repeated once the script is over.
+type Fixture struct {
+	PromptHash string `toml:"prompt_hash"` // SHA-256 hash of the prompt, see HashPrompt
+	Steps      []Step `toml:"step"`
+}
+
+// Config describes the behavior of the mock server. It is read from a TOML script file, whose
+// options can be overridden on the command line.
+type Config struct {
+	Answer            string        `toml:"answer"`             // answer to the prompts without fixture, DefaultAnswer if empty
+	Latency           time.Duration `toml:"latency"`            // delay before every response
+	RateLimitEvery    int           `toml:"rate_limit_every"`   // every Nth request is refused with HTTP 429, 0 to disable
+	MalformedEvery    int           `toml:"malformed_every"`    // every Nth request gets a malformed answer, 0 to disable
+	FixturesDirectory string        `toml:"fixtures_directory"` // directory of answers in files named <prompt hash>.json or .txt
+	Fixtures          []Fixture     `toml:"fixture"`
+}
+
+// LoadConfig reads the script of the mock server from a TOML file. A relative fixtures directory
+// is resolved from the directory of the script.
+//
+// Arguments:
+// - path: The script file.
+//
+// Returns:
+// - The configuration of the server.
+// - An error if the file cannot be read or parsed, or holds unknown keys.
+func LoadConfig(path string) (*Config, error) {
+	var cfg Config
+	metadata, err := toml.DecodeFile(path, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse mock script %s: %v", path, err)
+	}
+	if undecoded := metadata.Undecoded(); len(undecoded) > 0 {
+		return nil, fmt.Errorf("unknown key '%s' in mock script %s", undecoded[0], path)
+	}
+	if cfg.FixturesDirectory != "" && !filepath.IsAbs(cfg.FixturesDirectory) {
+		cfg.FixturesDirectory = filepath.Join(filepath.Dir(path), cfg.FixturesDirectory)
+	}
+	return &cfg, nil
+}
+
+// HashPrompt returns the key of a prompt in the fixtures: the hex-encoded SHA-256 hash of the
+// text of the last user message of the request.
+//
+// Arguments:
+// - prompt: The text of the prompt.
+//
+// Returns:
+// - The hex-encoded hash.
+func HashPrompt(prompt string) string {
+	sum := sha256.Sum256([]byte(prompt))
+	return hex.EncodeToString(sum[:])
+}
+
+// Server answers chat completion requests as an OpenAI-compatible provider would, from its script
+// and fixtures. It is safe for concurrent use.
+type Server struct {
+	config   Config
+	fixtures map[string][]Step // scripted steps, by prompt hash
+	mutex    sync.Mutex
+	requests int            // requests received, to apply rate_limit_every and malformed_every
+	played   map[string]int // steps played, by prompt hash
+	sleep    func(time.Duration)
+}
+
+// NewServer creates a mock server. The fixtures of the script take precedence over the files of
+// the fixtures directory.
+//
+// Arguments:
+// - cfg: The configuration of the server.
+//
+// Returns:
+// - The server, to be used as an http.Handler.
+// - An error if the fixtures directory cannot be read, a fixture has no step, or a prompt hash is repeated.
+func NewServer(cfg Config) (*Server, error) {
+	if cfg.Answer == "" {
+		cfg.Answer = DefaultAnswer
+	}
+	s := &Server{
+		config:   cfg,
+		fixtures: make(map[string][]Step),
+		played:   make(map[string]int),
+		sleep:    time.Sleep,
+	}
+
+	if cfg.FixturesDirectory != "" {
+		entries, err := os.ReadDir(cfg.FixturesDirectory)
+		if err != nil {
+			logger.Error("Error reading mock fixtures directory: %v", err)
+			return nil, err
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".json" && ext != ".txt") {
+				continue
+			}
+			content, err := os.ReadFile(filepath.Join(cfg.FixturesDirectory, entry.Name()))
+			if err != nil {
+				logger.Error("Error reading mock fixture: %v", err)
+				return nil, err
+			}
+			hash := strings.ToLower(strings.TrimSuffix(entry.Name(), ext))
+			s.fixtures[hash] = []Step{{Content: string(content)}}
+		}
+	}
+
+	scripted := make(map[string]bool)
+	for i, fixture := range cfg.Fixtures {
+		hash := strings.ToLower(strings.TrimSpace(fixture.PromptHash))
+		if hash == "" {
+			return nil, fmt.Errorf("fixture %d has no prompt_hash", i+1)
+		}
+		if len(fixture.Steps) == 0 {
+			return nil, fmt.Errorf("fixture %s has no step", hash)
+		}
+		if scripted[hash] {
+			return nil, fmt.Errorf("duplicate fixture for prompt %s", hash)
+		}
+		scripted[hash] = true
+		s.fixtures[hash] = fixture.Steps
+	}
+	return s, nil
+}
+
+// chatRequest is the part of an OpenAI chat completion request read by the server.
+type chatRequest struct {
+	Model    string `json:"model"`
+	Messages []struct {
+		Role    string          `json:"role"`
+		Content json.RawMessage `json:"content"`
+	} `json:"messages"`
+}
+
+// ServeHTTP answers the chat completions and models endpoints under any base path, such as /v1.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case strings.HasSuffix(r.URL.Path, completionsPath) && r.Method == http.MethodPost:
+		s.complete(w, r)
+	case strings.HasSuffix(r.URL.Path, modelsPath) && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, map[string]any{
+			"object": "list",
+			"data":   []any{map[string]any{"id": mockModel, "object": "model", "owned_by": "prismaid"}},
+		})
+	default:
+		writeError(w, http.StatusNotFound, "invalid_request_error", fmt.Sprintf("unknown endpoint %s %s", r.Method, r.URL.Path))
+	}
+}
+
+// complete answers a chat completion request with the next step of the fixture of its prompt, or
+// with the default answer, unless the request is the one refused or malformed by the server options.
+func (s *Server) complete(w http.ResponseWriter, r *http.Request) {
+	var request chatRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	prompt, ok := lastUserMessage(request)
+	if !ok {
+		writeError(w, http.StatusBadRequest, "invalid_request_error", "the request has no user message")
+		return
+	}
+	hash := HashPrompt(prompt)
+	w.Header().Set(HashHeader, hash)
+
+	step, number, source := s.next(hash)
+	latency := s.config.Latency
+	if step.Latency > 0 {
+		latency = step.Latency
+	}
+	if latency > 0 {
+		s.sleep(latency)
+	}
+
+	switch {
+	case step.Status == http.StatusTooManyRequests:
+		logger.Info("Mock LLM request %d: prompt %s refused with a rate limit error (%s)", number, hash, source)
+		w.Header().Set("Retry-After", "1")
+		writeError(w, http.StatusTooManyRequests, "rate_limit_exceeded", "Rate limit reached for mock requests, please retry")
+		return
+	case step.Status != 0 && step.Status != http.StatusOK:
+		logger.Info("Mock LLM request %d: prompt %s answered with HTTP %d (%s)", number, hash, step.Status, source)
+		writeError(w, step.Status, "server_error", fmt.Sprintf("mock error %d", step.Status))
+		return
+	}
+
+	content := step.Content
+	if step.Malformed {
+		content = malform(content)
+	}
+	logger.Info("Mock LLM request %d: prompt %s answered (%s)", number, hash, source)
+
+	model := request.Model
+	if model == "" {
+		model = mockModel
+	}
+	promptTokens, completionTokens := len(strings.Fields(prompt)), len(strings.Fields(content))
+	writeJSON(w, http.StatusOK, map[string]any{
+		"id":      "chatcmpl-mock-" + strconv.Itoa(number),
+		"object":  "chat.completion",
+		"created": time.Now().Unix(),
+		"model":   model,
+		"choices": []any{map[string]any{
+			"index":         0,
+			"message":       map[string]any{"role": "assistant", "content": content},
+			"finish_reason": "stop",
+		}},
+		"usage": map[string]any{
+			"prompt_tokens":     promptTokens,
+			"completion_tokens": completionTokens,
+			"total_tokens":      promptTokens + completionTokens,
+		},
+	})
+}
+
+// next returns the outcome of a request for a prompt. A request refused by rate_limit_every does
+// not play the next step of the fixture, so that scripts are replayed whole once the client retries.
+//
+// Arguments:
+// - hash: The hash of the prompt.
+//
+// Returns:
+// - The step to play.
+// - The number of the request, from 1.
+// - The origin of the step, for the log.
+func (s *Server) next(hash string) (Step, int, string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.requests++
+	number := s.requests
+	if s.config.RateLimitEvery > 0 && number%s.config.RateLimitEvery == 0 {
+		return Step{Status: http.StatusTooManyRequests}, number, "rate_limit_every"
+	}
+
+	step, source := Step{Content: s.config.Answer}, "default answer"
+	if steps, ok := s.fixtures[hash]; ok {
+		played := s.played[hash]
+		step = steps[min(played, len(steps)-1)]
+		s.played[hash] = played + 1
+		source = fmt.Sprintf("fixture step %d of %d", min(played, len(steps)-1)+1, len(steps))
+	}
+	if s.config.MalformedEvery > 0 && number%s.config.MalformedEvery == 0 && step.Status == 0 {
+		step.Malformed = true
+		source += ", malformed_every"
+	}
+	return step, number, source
+}
+
+// lastUserMessage returns the text of the last user message of a request. Contents given as a
+// list of parts are joined.
+func lastUserMessage(request chatRequest) (string, bool) {
+	for i := len(request.Messages) - 1; i >= 0; i-- {
+		message := request.Messages[i]
+		if message.Role != "user" {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal(message.Content, &text); err == nil {
+			return text, true
+		}
+		var parts []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		}
+		if err := json.Unmarshal(message.Content, &parts); err != nil {
+			return "", false
+		}
+		var texts []string
+		for _, part := range parts {
+			if part.Type == "text" {
+				texts = append(texts, part.Text)
+			}
+		}
+		return strings.Join(texts, "\n"), true
+	}
+	return "", false
+}
+
+// malform cuts an answer in the middle, so that a JSON answer is no longer valid.
+func malform(content string) string {
+	runes := []rune(content)
+	if len(runes) < 2 {
+		return "{"
+	}
+	return string(runes[:len(runes)/2])
+}
+
+// writeError writes an error in the format of the OpenAI API.
+func writeError(w http.ResponseWriter, status int, kind string, message string) {
+	writeJSON(w, status, map[string]any{
+		"error": map[string]any{"message": message, "type": kind, "code": kind},
+	})
+}
+
+// writeJSON writes a JSON response body with the given status.
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logger.Error("Error writing mock response: %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package mockllm
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// chat sends a chat completion request with a single user message and returns the status and the answer.
+func chat(t *testing.T, server *Server, prompt string) (int, string, http.Header) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{
+		"model":    "llama-3",
+		"messages": []any{map[string]any{"role": "system", "content": "persona"}, map[string]any{"role": "user", "content": prompt}},
+	})
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(string(body))))
+
+	var response struct {
+		Model   string `json:"model"`
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.Unmarshal(recorder.Body.Bytes(), &response); err != nil {
+		t.Fatalf("Response is not JSON: %v", err)
+	}
+	if recorder.Code != http.StatusOK {
+		return recorder.Code, "", recorder.Header()
+	}
+	if response.Model != "llama-3" || len(response.Choices) != 1 {
+		t.Fatalf("Unexpected completion %s", recorder.Body.String())
+	}
+	return recorder.Code, response.Choices[0].Message.Content, recorder.Header()
+}
+
+func TestServerPlaysScriptedSteps(t *testing.T) {
+	server, err := NewServer(Config{
+		Answer: `{"test": "no"}`,
+		Fixtures: []Fixture{{
+			PromptHash: strings.ToUpper(HashPrompt("review paper1")),
+			Steps: []Step{
+				{Status: http.StatusTooManyRequests},
+				{Content: `{"test": "yes"}`, Malformed: true},
+				{Content: `{"test": "yes"}`},
+			},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+
+	status, _, header := chat(t, server, "review paper1")
+	if status != http.StatusTooManyRequests || header.Get("Retry-After") == "" {
+		t.Errorf("Expected a rate limit error first, got %d", status)
+	}
+	if header.Get(HashHeader) != HashPrompt("review paper1") {
+		t.Errorf("Expected the prompt hash in the response headers, got %q", header.Get(HashHeader))
+	}
+	if _, answer, _ := chat(t, server, "review paper1"); json.Valid([]byte(answer)) {
+		t.Errorf("Expected a malformed answer second, got %q", answer)
+	}
+	for range 2 {
+		if _, answer, _ := chat(t, server, "review paper1"); answer != `{"test": "yes"}` {
+			t.Errorf("Expected the last step to be repeated, got %q", answer)
+		}
+	}
+	if _, answer, _ := chat(t, server, "review paper2"); answer != `{"test": "no"}` {
+		t.Errorf("Expected the default answer without fixture, got %q", answer)
+	}
+}
+
+func TestServerInjectsFailures(t *testing.T) {
+	server, err := NewServer(Config{RateLimitEvery: 3, MalformedEvery: 2, Latency: time.Second})
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	var slept time.Duration
+	server.sleep = func(d time.Duration) { slept += d }
+
+	var outcomes []string
+	for range 4 {
+		status, answer, _ := chat(t, server, "prompt")
+		switch {
+		case status == http.StatusTooManyRequests:
+			outcomes = append(outcomes, "429")
+		case json.Valid([]byte(answer)):
+			outcomes = append(outcomes, answer)
+		default:
+			outcomes = append(outcomes, "malformed")
+		}
+	}
+	if strings.Join(outcomes, " ") != "{} malformed 429 malformed" {
+		t.Errorf("Unexpected outcomes %v", outcomes)
+	}
+	if slept != 4*time.Second {
+		t.Errorf("Expected the latency to be applied to every request, got %v", slept)
+	}
+}
+
+func TestLoadConfigWithFixturesDirectory(t *testing.T) {
+	dir := t.TempDir()
+	fixtures := filepath.Join(dir, "fixtures")
+	if err := os.Mkdir(fixtures, 0755); err != nil {
+		t.Fatalf("Failed to create fixtures directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(fixtures, HashPrompt("from file")+".json"), []byte(`{"source": "file"}`), 0644); err != nil {
+		t.Fatalf("Failed to write fixture: %v", err)
+	}
+	script := filepath.Join(dir, "mock.toml")
+	content := `
+answer = '{"source": "default"}'
+latency = "10ms"
+fixtures_directory = "fixtures"
+
+[[fixture]]
+prompt_hash = "` + HashPrompt("from script") + `"
+[[fixture.step]]
+content = '{"source": "script"}'
+`
+	if err := os.WriteFile(script, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+
+	cfg, err := LoadConfig(script)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+	if cfg.Latency != 10*time.Millisecond || cfg.FixturesDirectory != fixtures {
+		t.Errorf("Unexpected configuration %+v", cfg)
+	}
+	server, err := NewServer(*cfg)
+	if err != nil {
+		t.Fatalf("NewServer failed: %v", err)
+	}
+	server.sleep = func(time.Duration) {}
+	for prompt, expected := range map[string]string{
+		"from file":   `{"source": "file"}`,
+		"from script": `{"source": "script"}`,
+		"other":       `{"source": "default"}`,
+	} {
+		if _, answer, _ := chat(t, server, prompt); answer != expected {
+			t.Errorf("Expected %s for %q, got %q", expected, prompt, answer)
+		}
+	}
+
+	if err := os.WriteFile(script, []byte("rate_limit = 3\n"), 0644); err != nil {
+		t.Fatalf("Failed to write script: %v", err)
+	}
+	if _, err := LoadConfig(script); err == nil {
+		t.Errorf("Expected an error for an unknown key")
+	}
+}
+
+func TestNewServerRejectsInvalidFixtures(t *testing.T) {
+	if _, err := NewServer(Config{Fixtures: []Fixture{{PromptHash: "abc"}}}); err == nil {
+		t.Errorf("Expected an error for a fixture without steps")
+	}
+	step := []Step{{Content: "{}"}}
+	if _, err := NewServer(Config{Fixtures: []Fixture{{PromptHash: "abc", Steps: step}, {PromptHash: "ABC", Steps: step}}}); err == nil {
+		t.Errorf("Expected an error for a repeated prompt hash")
+	}
+}
@@ -1,13 +1,21 @@
 package prismaid
 
 import (
+	"context"
 	"net/http"
 
 	"github.com/open-and-sustainable/prismaid/conversion"
 	"github.com/open-and-sustainable/prismaid/download/list"
 	"github.com/open-and-sustainable/prismaid/download/zotero"
+	"github.com/open-and-sustainable/prismaid/progress"
+	"github.com/open-and-sustainable/prismaid/review/cost"
+	"github.com/open-and-sustainable/prismaid/review/gold"
+	"github.com/open-and-sustainable/prismaid/review/lint"
 	"github.com/open-and-sustainable/prismaid/review/logic"
+	"github.com/open-and-sustainable/prismaid/review/results"
 	screening "github.com/open-and-sustainable/prismaid/screening/logic"
+	"github.com/open-and-sustainable/prismaid/secrets"
+	"github.com/open-and-sustainable/prismaid/tomlinclude"
 )
 
 // ConvertOptions exposes conversion options for the public API.
@@ -16,6 +24,54 @@ type ConvertOptions = conversion.ConvertOptions
 // PDFOptions exposes PDF-specific conversion options for the public API.
 type PDFOptions = conversion.PDFOptions
 
+// ProgressEvent exposes the progress of a review, screening, conversion or download for the public API.
+type ProgressEvent = progress.Event
+
+// ProgressFunc exposes the functions receiving progress events for the public API.
+type ProgressFunc = progress.Func
+
+// ReviewOptions exposes the options of ReviewContext for the public API.
+type ReviewOptions = logic.ReviewOptions
+
+// ReviewResult exposes the outcome of ReviewContext for the public API.
+type ReviewResult = logic.ReviewResult
+
+// ReviewAnswer exposes the answers of a document by a model for the public API.
+type ReviewAnswer = results.Answer
+
+// ScreenOptions exposes the options of ScreenContext for the public API.
+type ScreenOptions = screening.ScreenOptions
+
+// ScreeningResult exposes the outcome of ScreenContext for the public API.
+type ScreeningResult = screening.ScreeningResult
+
+// ScreeningRecord exposes a screened manuscript, with its tags and inclusion, for the public API.
+type ScreeningRecord = screening.ManuscriptRecord
+
+// ConversionReport exposes the outcome of ConvertContext for the public API.
+type ConversionReport = conversion.ConversionReport
+
+// ConvertedFile exposes the outcome of the conversion of a file for the public API.
+type ConvertedFile = conversion.ConvertedFile
+
+// DownloadOptions exposes the options of DownloadContext for the public API.
+type DownloadOptions = list.DownloadOptions
+
+// DownloadReport exposes the outcome of DownloadContext for the public API.
+type DownloadReport = list.DownloadReport
+
+// DownloadedEntry exposes the outcome of an entry of a URL list for the public API.
+type DownloadedEntry = list.DownloadedEntry
+
+// ReviewEstimate exposes the token and cost estimate of a review project for the public API.
+type ReviewEstimate = cost.Estimate
+
+// ReviewConfigCheck exposes the problems found in a review configuration for the public API.
+type ReviewConfigCheck = lint.Report
+
+// ReviewValidation exposes the comparison of review results with a gold standard for the public API.
+type ReviewValidation = gold.Comparison
+
 // Review processes a systematic literature review based on the provided TOML configuration.
 //
 // The tomlConfiguration parameter should contain a valid TOML string with all the
@@ -25,7 +81,95 @@ type PDFOptions = conversion.PDFOptions
 // Returns an error if the review process fails for any reason, such as invalid configuration,
 // inaccessible files, or API errors.
 func Review(tomlConfiguration string) error {
-	return logic.Review(tomlConfiguration)
+	_, err := ReviewContext(context.Background(), tomlConfiguration, ReviewOptions{})
+	return err
+}
+
+// ReviewWithProgress runs a review as Review does, reporting its progress to a function.
+//
+// Every document reviewed by a model in a repetition is a unit of work. The report function receives an
+// event when the review starts, after every unit, with the units done and failed, the tokens exchanged
+// with the models and an estimate of the remaining time, and when the review ends. Documents resumed
+// from a checkpoint count as done without affecting the estimate. The function is called from the
+// goroutine reviewing the document, so it should return quickly; a nil function disables reporting.
+//
+// Returns an error if the review process fails, as Review does.
+func ReviewWithProgress(tomlConfiguration string, report ProgressFunc) error {
+	_, err := ReviewContext(context.Background(), tomlConfiguration, ReviewOptions{Progress: report})
+	return err
+}
+
+// ReviewContext runs a review as Review does and returns its answers.
+//
+// The review stops before the next document once ctx is canceled, returning the error of the context;
+// the documents already reviewed are kept in the checkpoint store, so that running the project again
+// resumes the review. The options set the progress hook, as in ReviewWithProgress.
+//
+// Returns the results file, the documents selected for review and the validated answers of every document
+// and model, as written to the results file. When some extractions fail, the result is returned along with
+// the error, with the number of failures. Returns a nil result if the review cannot be run.
+func ReviewContext(ctx context.Context, tomlConfiguration string, options ReviewOptions) (*ReviewResult, error) {
+	return logic.ReviewContext(ctx, tomlConfiguration, options)
+}
+
+// EstimateReview estimates the tokens and the cost of a review project without calling any provider.
+//
+// The tomlConfiguration parameter is the same TOML string accepted by Review. Prompts are generated
+// as for a real run, including justification and summary follow-ups, and priced for every configured
+// model with a bundled price table that can be overridden in the [prices] section of the configuration.
+// Use the Report method of the returned estimate to print a summary.
+//
+// Returns the estimate for every model, including the documents exceeding their context window,
+// or an error if the configuration is invalid or the input documents cannot be read.
+func EstimateReview(tomlConfiguration string) (*ReviewEstimate, error) {
+	return logic.EstimateReview(tomlConfiguration)
+}
+
+// CheckReviewConfig checks a review configuration without running the review.
+//
+// The tomlConfiguration parameter is the same TOML string accepted by Review. Besides decoding it,
+// the check verifies that the input directory exists, that the results can be written, that the
+// providers are supported and the models known, that every model has an API key in the configuration
+// or in the environment, and that review keys are unique and have values. Unknown keys are reported
+// as warnings. Use the Write method of the returned report to print the problems.
+//
+// Returns the problems found, each with the TOML line of the offending key; the Errors method of the
+// report counts those that would make the review fail.
+func CheckReviewConfig(tomlConfiguration string) *ReviewConfigCheck {
+	return logic.CheckConfig(tomlConfiguration)
+}
+
+// ValidateReview compares the results of a review with a gold standard of manuscripts coded by hand.
+//
+// The resultsPath parameter is a results file written by Review, in CSV, JSON or JSON Lines format. The goldPath
+// parameter is a CSV file with a "File Name" column and a column for each review key to validate.
+// Answers are aligned by filename and compared for every model. The reports are written next to the
+// results file: <results>_accuracy.csv, <results>_confusion.csv, <results>_disagreements.csv and
+// <results>_accuracy.md.
+//
+// Returns the per-key accuracy, per-value precision and recall, confusion matrices and disagreements,
+// or an error if the files cannot be read, have no review key in common, or the reports cannot be written.
+func ValidateReview(resultsPath, goldPath string) (*ReviewValidation, error) {
+	return logic.ValidateResults(resultsPath, goldPath)
+}
+
+// EffectiveConfig returns the configuration a review or screening project runs with, once merged
+// with the base files named in its "extends" key.
+//
+// The tomlConfiguration parameter is a TOML string accepted by Review or Screening. Base files are
+// merged table by table, and the values of the project override those of its bases; relative paths
+// are resolved from the working directory. Defaults applied when the project runs are not shown.
+//
+// API keys written in the configuration are replaced with "[REDACTED]", so that the output can be
+// shared; "env:NAME" and "file:/path" references are shown as written.
+//
+// Returns the merged configuration as TOML, or an error if a base file cannot be read or parsed.
+func EffectiveConfig(tomlConfiguration string) (string, error) {
+	resolved, err := tomlinclude.Resolve(tomlConfiguration)
+	if err != nil {
+		return "", err
+	}
+	return secrets.RedactConfiguration(resolved), nil
 }
 
 // DownloadZoteroPDFs downloads PDF documents from a specified Zotero collection.
@@ -52,7 +196,33 @@ func DownloadZoteroPDFs(username, apiKey, collectionName, parentDir string) erro
 // Returns an error if the function fails to open or read the input file,
 // but continues processing even if individual URLs fail to download.
 func DownloadURLList(path string) error {
-	return list.DownloadURLList(path)
+	_, err := DownloadContext(context.Background(), path, DownloadOptions{})
+	return err
+}
+
+// DownloadURLListWithProgress downloads the files of a list as DownloadURLList does, reporting its
+// progress to a function.
+//
+// Every URL, or every row of a CSV/TSV list, is a unit of work, completed once its file is downloaded
+// or could not be found. Downloads run concurrently, so the report function may be called from several
+// goroutines, one at a time; a nil function disables reporting.
+//
+// Returns an error if the function fails to open or read the input file.
+func DownloadURLListWithProgress(path string, report ProgressFunc) error {
+	_, err := DownloadContext(context.Background(), path, DownloadOptions{Progress: report})
+	return err
+}
+
+// DownloadContext downloads the files of a list as DownloadURLList does and returns the outcome of every entry.
+//
+// Once ctx is canceled, no further PDF link is looked up and no further download is started. Downloads already
+// running are completed, and the report and results file cover them, returned with the error of the context.
+// The options set the progress hook, as in DownloadURLListWithProgress.
+//
+// Returns the results file and the outcome of every URL, or CSV/TSV row, in list order, or an error if the
+// input file cannot be read or ctx is canceled.
+func DownloadContext(ctx context.Context, path string, options DownloadOptions) (*DownloadReport, error) {
+	return list.DownloadURLListContext(ctx, path, options)
 }
 
 // Convert processes files in the specified directory and converts them to plain text format.
@@ -69,11 +239,24 @@ func DownloadURLList(path string) error {
 //
 // When options.TikaServer is provided and standard conversion methods fail, files are
 // automatically sent to the Tika server for OCR-based text extraction as a fallback.
+// When options.Progress is set, it receives a progress event for every file converted.
 //
 // Returns an error if the conversion process fails for any reason, such as inaccessible
 // files, unsupported formats, or file system permission issues.
 func Convert(inputDir, selectedFormats string, options conversion.ConvertOptions) error {
-	return conversion.Convert(inputDir, selectedFormats, options)
+	_, err := ConvertContext(context.Background(), inputDir, selectedFormats, options)
+	return err
+}
+
+// ConvertContext converts files as Convert does and returns the outcome of every file processed.
+//
+// The conversion stops before the next file once ctx is canceled, returning the report of the files
+// already processed with the error of the context.
+//
+// Returns the path, format, text file, use of Tika and failure reason of every file processed, or an
+// error if the conversion cannot proceed, as Convert does.
+func ConvertContext(ctx context.Context, inputDir, selectedFormats string, options ConvertOptions) (*ConversionReport, error) {
+	return conversion.ConvertContext(ctx, inputDir, selectedFormats, options)
 }
 
 // Screening processes a list of manuscripts to identify items for exclusion based on various criteria.
@@ -91,5 +274,29 @@ func Convert(inputDir, selectedFormats string, options conversion.ConvertOptions
 // Returns an error if the screening process fails for any reason, such as invalid configuration,
 // inaccessible files, or processing errors.
 func Screening(tomlConfiguration string) error {
-	return screening.Screen(tomlConfiguration)
+	_, err := ScreenContext(context.Background(), tomlConfiguration, ScreenOptions{})
+	return err
+}
+
+// ScreeningWithProgress screens a list of manuscripts as Screening does, reporting its progress to a function.
+//
+// Every enabled filter checks every manuscript, and an event is reported when a filter completes, with
+// the manuscripts checked so far and an estimate of the remaining time. A nil function disables reporting.
+//
+// Returns an error if the screening process fails, as Screening does.
+func ScreeningWithProgress(tomlConfiguration string, report ProgressFunc) error {
+	_, err := ScreenContext(context.Background(), tomlConfiguration, ScreenOptions{Progress: report})
+	return err
+}
+
+// ScreenContext screens a list of manuscripts as Screening does and returns the screened records.
+//
+// The screening stops before the next filter, or during the wait between AI-assisted filters, once ctx
+// is canceled, returning the error of the context without saving results. The options set the progress
+// hook, as in ScreeningWithProgress.
+//
+// Returns every record with its tags, inclusion and exclusion reason, and the counts of included and
+// excluded records, as saved to the output file, or an error if the screening fails.
+func ScreenContext(ctx context.Context, tomlConfiguration string, options ScreenOptions) (*ScreeningResult, error) {
+	return screening.ScreenContext(ctx, tomlConfiguration, options)
 }
@@ -1,6 +1,8 @@
 package prismaid
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -183,3 +185,56 @@ exclude_letters = false
 		t.Error("Output should contain exclusion_reason column")
 	}
 }
+
+func TestScreenContextReturnsRecords(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputFile := filepath.Join(tmpDir, "test_manuscripts.csv")
+	inputContent := `id,title,abstract
+1,"Climate Study","This research examines climate change effects using empirical data."
+2,"Climate Study","This research examines climate change effects using empirical data."
+3,"Soil Study","This research measures soil moisture in dry regions."`
+	if err := os.WriteFile(inputFile, []byte(inputContent), 0644); err != nil {
+		t.Fatalf("Failed to create input file: %v", err)
+	}
+	outputFile := filepath.Join(tmpDir, "screening_output")
+	screeningConfig := fmt.Sprintf(`
+[project]
+name = "Test Screening"
+input_file = "%s"
+output_file = "%s"
+text_column = "abstract"
+identifier_column = "id"
+output_format = "json"
+log_level = "low"
+
+[filters.deduplication]
+enabled = true
+compare_fields = ["title", "abstract"]
+`, inputFile, outputFile)
+
+	var events []ProgressEvent
+	result, err := ScreenContext(context.Background(), screeningConfig, ScreenOptions{
+		Progress: func(e ProgressEvent) { events = append(events, e) },
+	})
+	if err != nil {
+		t.Fatalf("ScreenContext failed: %v", err)
+	}
+	if result.TotalRecords != 3 || result.IncludedRecords != 2 || len(result.Records) != 3 || result.Records[1].Include {
+		t.Errorf("Expected the second record to be excluded as a duplicate, got %+v", result)
+	}
+	if len(events) == 0 || !events[len(events)-1].Finished || events[len(events)-1].Done != 3 {
+		t.Errorf("Unexpected progress events %+v", events)
+	}
+
+	if err := os.Remove(outputFile + ".json"); err != nil {
+		t.Fatalf("Expected the results to be saved: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ScreenContext(ctx, screeningConfig, ScreenOptions{}); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a canceled screening, got %v", err)
+	}
+	if _, err := os.Stat(outputFile + ".json"); !os.IsNotExist(err) {
+		t.Errorf("Expected no results to be saved by a canceled screening")
+	}
+}
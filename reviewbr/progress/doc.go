@@ -0,0 +1,6 @@
+// Package progress reports the advancement of long-running operations, such as reviews, screenings,
+// conversions and downloads. An operation counts its units of work, the documents sent to each model
+// or the files converted, with a Tracker, which sends an Event to a caller-provided function every
+// time a unit is completed, with the units done, failed and left, the tokens used and an estimate of
+// the remaining time. The package also renders events as a live progress line for terminals.
+package progress
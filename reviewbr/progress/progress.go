@@ -0,0 +1,235 @@
+package progress
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Operations reporting their progress.
+const (
+	OperationReview    = "review"
+	OperationScreening = "screening"
+	OperationConvert   = "convert"
+	OperationDownload  = "download"
+)
+
+// Event describes the progress of an operation after a unit of work is started or completed.
+type Event struct {
+	Operation string        `json:"operation"`          // one of the Operation constants
+	Stage     string        `json:"stage,omitempty"`    // current stage, e.g. the repetition of a review or the filter of a screening
+	Item      string        `json:"item,omitempty"`     // unit just completed: a document, a filter, a file or a URL
+	Done      int           `json:"done"`               // units completed, failed ones included
+	Total     int           `json:"total"`              // units of the operation
+	Failed    int           `json:"failed"`             // units that failed
+	Tokens    int           `json:"tokens"`             // estimated tokens sent to and received from models so far
+	Elapsed   time.Duration `json:"elapsed"`            // time since the operation started
+	ETA       time.Duration `json:"eta"`                // estimated remaining time, 0 until a unit is completed
+	Finished  bool          `json:"finished,omitempty"` // set on the last event of the operation
+}
+
+// Func receives the progress events of an operation. It is called synchronously from the goroutine
+// completing the unit, so it should return quickly.
+type Func func(Event)
+
+// Tracker counts the units of work of an operation and reports an Event for each of them. A nil
+// Tracker, created without a Func, ignores every call, so operations track their progress
+// unconditionally. It is safe for concurrent use.
+type Tracker struct {
+	report  Func
+	mutex   sync.Mutex
+	event   Event
+	start   time.Time
+	skipped int // units completed without work, such as resumed ones, left out of the ETA
+	now     func() time.Time
+}
+
+// Start begins tracking an operation and reports its first event, with no unit done.
+//
+// Arguments:
+// - report: The function receiving the events; nil to disable tracking.
+// - operation: The operation tracked, one of the Operation constants.
+// - total: The number of units of the operation.
+//
+// Returns:
+// - The tracker, nil if report is nil.
+func Start(report Func, operation string, total int) *Tracker {
+	if report == nil {
+		return nil
+	}
+	t := &Tracker{report: report, event: Event{Operation: operation, Total: total}, now: time.Now}
+	t.start = t.now()
+	report(t.event)
+	return t
+}
+
+// SetStage changes the stage reported with the next events.
+func (t *Tracker) SetStage(stage string) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.event.Stage = stage
+}
+
+// AddTotal adds units to the operation, when they are only known once it has started.
+func (t *Tracker) AddTotal(units int) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.event.Total += units
+}
+
+// Done completes a unit of work and reports the progress.
+//
+// Arguments:
+// - item: The unit completed.
+// - failed: Whether the unit failed.
+// - tokens: The tokens sent and received for the unit, 0 if no model was called.
+func (t *Tracker) Done(item string, failed bool, tokens int) {
+	if t == nil {
+		return
+	}
+	t.complete(item, failed, tokens, false)
+}
+
+// Advance completes several units of work at once, none of them failed, such as the records
+// processed by a filter in a single batch.
+//
+// Arguments:
+// - item: The step that completed the units.
+// - units: The number of units completed.
+func (t *Tracker) Advance(item string, units int) {
+	if t == nil || units <= 0 {
+		return
+	}
+	t.mutex.Lock()
+	t.event.Done += units - 1 // the last unit is completed and reported below
+	t.mutex.Unlock()
+	t.complete(item, false, 0, false)
+}
+
+// Skip completes a unit of work that needed none, such as a document resumed from a checkpoint.
+// Skipped units count as done but do not speed up the estimate of the remaining time.
+func (t *Tracker) Skip(item string) {
+	if t == nil {
+		return
+	}
+	t.complete(item, false, 0, true)
+}
+
+// Finish reports the last event of the operation, whether every unit was completed or not.
+func (t *Tracker) Finish() {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	t.event.Item = ""
+	t.event.Finished = true
+	t.event.Elapsed = t.now().Sub(t.start)
+	t.event.ETA = 0
+	event := t.event
+	t.mutex.Unlock()
+	t.report(event)
+}
+
+func (t *Tracker) complete(item string, failed bool, tokens int, skipped bool) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	t.event.Item = item
+	t.event.Done++
+	t.event.Total = max(t.event.Total, t.event.Done)
+	if failed {
+		t.event.Failed++
+	}
+	if skipped {
+		t.skipped++
+	}
+	t.event.Tokens += tokens
+	t.event.Elapsed = t.now().Sub(t.start)
+	t.event.ETA = 0
+	if worked := t.event.Done - t.skipped; worked > 0 {
+		t.event.ETA = t.event.Elapsed / time.Duration(worked) * time.Duration(t.event.Total-t.event.Done)
+	}
+	event := t.event
+	t.mutex.Unlock()
+	t.report(event)
+}
+
+// String formats the event as a single line, e.g.
+// "review (repetition 2): 12/40 (30%), 1 failed, 35.2k tokens, elapsed 3m10s, ETA 7m24s".
+func (e Event) String() string {
+	var line strings.Builder
+	line.WriteString(e.Operation)
+	if e.Stage != "" {
+		fmt.Fprintf(&line, " (%s)", e.Stage)
+	}
+	fmt.Fprintf(&line, ": %d/%d", e.Done, e.Total)
+	if e.Total > 0 {
+		fmt.Fprintf(&line, " (%d%%)", e.Done*100/e.Total)
+	}
+	if e.Failed > 0 {
+		fmt.Fprintf(&line, ", %d failed", e.Failed)
+	}
+	if e.Tokens > 0 {
+		fmt.Fprintf(&line, ", %s tokens", formatCount(e.Tokens))
+	}
+	fmt.Fprintf(&line, ", elapsed %s", e.Elapsed.Round(time.Second))
+	if e.Finished {
+		line.WriteString(", done")
+	} else if e.ETA > 0 {
+		fmt.Fprintf(&line, ", ETA %s", e.ETA.Round(time.Second))
+	}
+	return line.String()
+}
+
+// JSON encodes the event as a JSON object, with durations in seconds, for the language bindings.
+func (e Event) JSON() string {
+	type jsonEvent Event
+	data, _ := json.Marshal(struct {
+		jsonEvent
+		Elapsed float64 `json:"elapsed"`
+		ETA     float64 `json:"eta"`
+	}{jsonEvent(e), e.Elapsed.Seconds(), e.ETA.Seconds()})
+	return string(data)
+}
+
+// formatCount abbreviates large counts, e.g. 35200 as 35.2k.
+func formatCount(count int) string {
+	switch {
+	case count >= 1_000_000:
+		return fmt.Sprintf("%.1fM", float64(count)/1_000_000)
+	case count >= 1_000:
+		return fmt.Sprintf("%.1fk", float64(count)/1_000)
+	default:
+		return fmt.Sprint(count)
+	}
+}
+
+// Display returns a Func rendering the events as a progress line rewritten in place, for terminals.
+// The line of the last event of an operation is ended with a newline.
+//
+// Arguments:
+// - w: The terminal, usually os.Stderr.
+//
+// Returns:
+// - The function to pass as progress hook.
+func Display(w io.Writer) Func {
+	var mutex sync.Mutex
+	return func(e Event) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		fmt.Fprintf(w, "\r\033[K%s", e)
+		if e.Finished {
+			fmt.Fprintln(w)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package progress
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+// clock returns a time advancing by a second at every call, to make the ETA predictable.
+func clock() func() time.Time {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	return func() time.Time {
+		now = now.Add(time.Second)
+		return now
+	}
+}
+
+func TestTrackerCountsUnitsAndEstimatesRemainingTime(t *testing.T) {
+	var events []Event
+	tracker := Start(func(e Event) { events = append(events, e) }, OperationReview, 4)
+	tracker.now = clock()
+	tracker.start = tracker.now()
+
+	tracker.Skip("resumed.txt")
+	tracker.SetStage("repetition 2")
+	tracker.Done("paper1.txt", false, 1500)
+	tracker.Done("paper2.txt", true, 500)
+	tracker.Finish()
+
+	if len(events) != 5 {
+		t.Fatalf("Expected 5 events, got %d", len(events))
+	}
+	if events[0].Done != 0 || events[0].Total != 4 || events[0].Finished {
+		t.Errorf("Unexpected first event %+v", events[0])
+	}
+	if events[1].ETA != 0 {
+		t.Errorf("Expected no ETA after a skipped unit only, got %v", events[1].ETA)
+	}
+	last := events[3]
+	if last.Item != "paper2.txt" || last.Stage != "repetition 2" || last.Done != 3 || last.Failed != 1 || last.Tokens != 2000 {
+		t.Errorf("Unexpected event %+v", last)
+	}
+	// 3 seconds for the 2 units worked, 1 unit left
+	if last.Elapsed != 3*time.Second || last.ETA != 1500*time.Millisecond {
+		t.Errorf("Expected elapsed 3s and ETA 1.5s, got %v and %v", last.Elapsed, last.ETA)
+	}
+	if !events[4].Finished || events[4].ETA != 0 || events[4].Item != "" {
+		t.Errorf("Unexpected final event %+v", events[4])
+	}
+}
+
+func TestTrackerAdvance(t *testing.T) {
+	var last Event
+	tracker := Start(func(e Event) { last = e }, OperationScreening, 6)
+	tracker.Advance("deduplication", 3)
+	tracker.Advance("language", 0)
+	if last.Done != 3 || last.Item != "deduplication" {
+		t.Errorf("Unexpected event %+v", last)
+	}
+	tracker.Advance("language", 5)
+	if last.Done != 8 || last.Total != 8 {
+		t.Errorf("Expected the total to grow with the units done, got %+v", last)
+	}
+}
+
+func TestNilTrackerIgnoresCalls(t *testing.T) {
+	tracker := Start(nil, OperationConvert, 3)
+	if tracker != nil {
+		t.Fatalf("Expected a nil tracker without report function")
+	}
+	tracker.SetStage("stage")
+	tracker.AddTotal(2)
+	tracker.Done("file.pdf", false, 0)
+	tracker.Advance("file.pdf", 2)
+	tracker.Skip("file.pdf")
+	tracker.Finish()
+}
+
+func TestEventFormats(t *testing.T) {
+	event := Event{
+		Operation: OperationReview,
+		Stage:     "repetition 2",
+		Item:      "paper.txt",
+		Done:      12,
+		Total:     40,
+		Failed:    1,
+		Tokens:    35200,
+		Elapsed:   190 * time.Second,
+		ETA:       444 * time.Second,
+	}
+	expected := "review (repetition 2): 12/40 (30%), 1 failed, 35.2k tokens, elapsed 3m10s, ETA 7m24s"
+	if event.String() != expected {
+		t.Errorf("Expected %q, got %q", expected, event.String())
+	}
+
+	var decoded map[string]any
+	if err := json.Unmarshal([]byte(event.JSON()), &decoded); err != nil {
+		t.Fatalf("Event JSON is invalid: %v", err)
+	}
+	if decoded["elapsed"] != 190.0 || decoded["eta"] != 444.0 || decoded["done"] != 12.0 || decoded["item"] != "paper.txt" {
+		t.Errorf("Unexpected JSON %s", event.JSON())
+	}
+
+	var output bytes.Buffer
+	display := Display(&output)
+	display(event)
+	event.Finished = true
+	display(event)
+	if !strings.HasSuffix(output.String(), ", done\n") || strings.Count(output.String(), "\r\033[K") != 2 {
+		t.Errorf("Unexpected display %q", output.String())
+	}
+}
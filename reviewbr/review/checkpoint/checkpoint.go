@@ -0,0 +1,208 @@
+package checkpoint
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"hash"
+	"os"
+	"sync"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+)
+
+const (
+	// StatusCompleted marks an entry whose responses were received successfully.
+	StatusCompleted = "completed"
+	// StatusFailed marks an entry whose extraction failed and must be retried.
+	StatusFailed = "failed"
+
+	checkpointSuffix = "_checkpoint.jsonl"
+)
+
+// Entry is a single record of the checkpoint store, describing the outcome of the prompts sent
+// for one document to one model.
+type Entry struct {
+	File       string                 `json:"file"`
+	Provider   string                 `json:"provider"`
+	Model      string                 `json:"model"`
+	PromptHash string                 `json:"prompt_hash"`
+	Status     string                 `json:"status"`
+	Error      string                 `json:"error,omitempty"`
+	Timestamp  string                 `json:"timestamp"`
+	Responses  []definitions.Response `json:"responses,omitempty"`
+
+	Retries      map[int]int      `json:"retries,omitempty"`       // times each answer was asked again, by sequence number
+	SchemaErrors map[int][]string `json:"schema_errors,omitempty"` // problems left in each answer by the schema validation
+}
+
+// Store keeps the latest entry for every (file, provider, model, request hash) combination and
+// appends each new entry to the checkpoint file as soon as it is recorded.
+type Store struct {
+	path    string
+	entries map[string]Entry
+	mutex   sync.Mutex
+}
+
+// Path returns the location of the checkpoint file associated with a results file name.
+//
+// Arguments:
+// - resultsFileName: The results_file_name from the project configuration, without extension.
+//
+// Returns:
+// - The path of the checkpoint file, placed next to the results.
+func Path(resultsFileName string) string {
+	return resultsFileName + checkpointSuffix
+}
+
+// Open loads the checkpoint file at the given path, if it exists, and returns a Store ready to
+// record new entries. Malformed lines, such as a line truncated by a crash, are skipped.
+//
+// Arguments:
+// - path: The location of the checkpoint file.
+//
+// Returns:
+// - A pointer to the Store.
+// - An error if the existing file cannot be read.
+func Open(path string) (*Store, error) {
+	store := &Store{
+		path:    path,
+		entries: make(map[string]Entry),
+	}
+
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return store, nil
+	}
+	if err != nil {
+		logger.Error("Error opening checkpoint file: %v", err)
+		return nil, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			logger.Error("Skipping malformed checkpoint entry: %v", err)
+			continue
+		}
+		store.entries[entryKey(entry.File, entry.Provider, entry.Model, entry.PromptHash)] = entry
+	}
+	if err := scanner.Err(); err != nil {
+		logger.Error("Error reading checkpoint file: %v", err)
+		return nil, err
+	}
+
+	logger.Info("Loaded %d checkpoint entries from %s", len(store.entries), path)
+	return store, nil
+}
+
+// Lookup returns the completed entry recorded for the given combination, if any.
+// Failed entries are not returned, so that their work is retried.
+//
+// Arguments:
+// - file: The name of the reviewed document, without extension.
+// - provider: The configured LLM provider.
+// - model: The configured model name.
+// - promptHash: The hash of the prompts sent for the document and of the model parameters, as returned by HashRequest.
+//
+// Returns:
+// - The recorded entry and true if a completed entry exists, otherwise an empty entry and false.
+func (s *Store) Lookup(file, provider, model, promptHash string) (Entry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	entry, ok := s.entries[entryKey(file, provider, model, promptHash)]
+	if !ok || entry.Status != StatusCompleted {
+		return Entry{}, false
+	}
+	return entry, true
+}
+
+// Record stores the entry and appends it to the checkpoint file, replacing any previous
+// entry for the same combination.
+//
+// Arguments:
+// - entry: The entry to record.
+//
+// Returns:
+// - An error if the entry cannot be written to the checkpoint file.
+func (s *Store) Record(entry Entry) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	line, err := json.Marshal(entry)
+	if err != nil {
+		logger.Error("Error marshaling checkpoint entry: %v", err)
+		return err
+	}
+
+	file, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		logger.Error("Error opening checkpoint file: %v", err)
+		return err
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		logger.Error("Error writing checkpoint entry: %v", err)
+		return err
+	}
+
+	s.entries[entryKey(entry.File, entry.Provider, entry.Model, entry.PromptHash)] = entry
+	return nil
+}
+
+// HashPrompts computes a stable SHA-256 hash of the prompts sent for a document, including
+// their sequence numbers, so that any change in the prompt text or the document invalidates
+// previously recorded responses.
+//
+// Arguments:
+// - prompts: The prompts of a single sequence, in the order they are sent.
+//
+// Returns:
+// - The hex-encoded hash.
+func HashPrompts(prompts []definitions.Prompt) string {
+	hash := sha256.New()
+	writePrompts(hash, prompts)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// HashRequest computes the hash under which the responses of a model to the prompts of a document
+// are recorded. Besides the prompts, it covers the parameters of the model that change its answers,
+// i.e. the temperature and the endpoint, so that editing them also invalidates previously recorded
+// responses. The API key and the rate limits are left out.
+//
+// Arguments:
+// - model: The model the prompts are sent to.
+// - prompts: The prompts of a single sequence, in the order they are sent.
+//
+// Returns:
+// - The hex-encoded hash.
+func HashRequest(model definitions.Model, prompts []definitions.Prompt) string {
+	hash := sha256.New()
+	data, _ := json.Marshal([]any{model.Temperature, model.BaseURL, model.EndpointType, model.Region, model.ProjectID, model.Location, model.APIVersion})
+	hash.Write(data)
+	hash.Write([]byte{'\n'})
+	writePrompts(hash, prompts)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// writePrompts writes the sequence number and content of every prompt to a hash.
+func writePrompts(writer hash.Hash, prompts []definitions.Prompt) {
+	for _, prompt := range prompts {
+		data, _ := json.Marshal([]any{prompt.SequenceNumber, prompt.PromptContent})
+		writer.Write(data)
+		writer.Write([]byte{'\n'})
+	}
+}
+
+func entryKey(file, provider, model, promptHash string) string {
+	data, _ := json.Marshal([]string{file, provider, model, promptHash})
+	return string(data)
+}
@@ -0,0 +1,124 @@
+package checkpoint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+)
+
+func TestStoreRecordAndReopen(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "results"))
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned an error: %v", err)
+	}
+
+	completed := Entry{
+		File:       "paper1",
+		Provider:   "OpenAI",
+		Model:      "gpt-4o-mini",
+		PromptHash: "abc",
+		Status:     StatusCompleted,
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"key": "yes"}`}},
+		},
+	}
+	failed := Entry{
+		File:       "paper2",
+		Provider:   "OpenAI",
+		Model:      "gpt-4o-mini",
+		PromptHash: "def",
+		Status:     StatusFailed,
+		Error:      "rate limited",
+	}
+	for _, entry := range []Entry{completed, failed} {
+		if err := store.Record(entry); err != nil {
+			t.Fatalf("Record returned an error: %v", err)
+		}
+	}
+
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned an error on existing file: %v", err)
+	}
+
+	entry, ok := reopened.Lookup("paper1", "OpenAI", "gpt-4o-mini", "abc")
+	if !ok {
+		t.Fatalf("Expected completed entry to be found after reopening")
+	}
+	if len(entry.Responses) != 1 || entry.Responses[0].ModelResponses[0] != `{"key": "yes"}` {
+		t.Errorf("Unexpected responses restored: %+v", entry.Responses)
+	}
+
+	if _, ok := reopened.Lookup("paper1", "OpenAI", "gpt-4o-mini", "changed"); ok {
+		t.Errorf("Expected lookup with a different prompt hash to miss")
+	}
+	if _, ok := reopened.Lookup("paper2", "OpenAI", "gpt-4o-mini", "def"); ok {
+		t.Errorf("Expected failed entry not to be returned")
+	}
+}
+
+func TestOpenSkipsMalformedLines(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "results_checkpoint.jsonl")
+	content := `{"file":"paper1","provider":"OpenAI","model":"m","prompt_hash":"h","status":"completed"}
+{"file":"paper2","provid`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write checkpoint file: %v", err)
+	}
+
+	store, err := Open(path)
+	if err != nil {
+		t.Fatalf("Open returned an error: %v", err)
+	}
+	if _, ok := store.Lookup("paper1", "OpenAI", "m", "h"); !ok {
+		t.Errorf("Expected valid entry to be loaded despite truncated line")
+	}
+}
+
+func TestHashPrompts(t *testing.T) {
+	prompts := []definitions.Prompt{
+		{PromptContent: "main prompt", SequenceID: "1", SequenceNumber: 1},
+		{PromptContent: "justification", SequenceID: "1", SequenceNumber: 2},
+	}
+	moved := []definitions.Prompt{
+		{PromptContent: "main prompt", SequenceID: "7", SequenceNumber: 1},
+		{PromptContent: "justification", SequenceID: "7", SequenceNumber: 2},
+	}
+	if HashPrompts(prompts) != HashPrompts(moved) {
+		t.Errorf("Expected hash to ignore the sequence ID")
+	}
+
+	changed := []definitions.Prompt{
+		{PromptContent: "main prompt, edited", SequenceID: "1", SequenceNumber: 1},
+		{PromptContent: "justification", SequenceID: "1", SequenceNumber: 2},
+	}
+	if HashPrompts(prompts) == HashPrompts(changed) {
+		t.Errorf("Expected hash to change with the prompt content")
+	}
+}
+
+func TestHashRequest(t *testing.T) {
+	prompts := []definitions.Prompt{{PromptContent: "main prompt", SequenceID: "1", SequenceNumber: 1}}
+	model := definitions.Model{Provider: "OpenAI", Model: "gpt-4o-mini", APIKey: "key", Temperature: 0.2, TPMLimit: 1000}
+
+	other := model
+	other.APIKey, other.TPMLimit, other.RPMLimit = "other key", 2000, 10
+	if HashRequest(model, prompts) != HashRequest(other, prompts) {
+		t.Errorf("Expected hash to ignore the API key and the rate limits")
+	}
+
+	other = model
+	other.Temperature = 0.7
+	if HashRequest(model, prompts) == HashRequest(other, prompts) {
+		t.Errorf("Expected hash to change with the temperature")
+	}
+
+	other = model
+	other.BaseURL = "http://localhost:11434/v1"
+	if HashRequest(model, prompts) == HashRequest(other, prompts) {
+		t.Errorf("Expected hash to change with the endpoint")
+	}
+}
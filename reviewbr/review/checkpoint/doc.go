@@ -0,0 +1,5 @@
+// Package checkpoint provides a persistent store recording every completed model response of a review
+// run. Each document, model, and prompt combination is written to an append-only JSON Lines file placed
+// next to the configured results file, so that an interrupted review can be resumed without querying
+// the providers again for work that already succeeded.
+package checkpoint
@@ -1,9 +1,18 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
 
 	"github.com/BurntSushi/toml"
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/secrets"
+	"github.com/open-and-sustainable/prismaid/tomlinclude"
 )
 
 // EnvReader is an interface for accessing environment variables.
@@ -17,11 +26,50 @@ func (r RealEnvReader) GetEnv(key string) string {
 	return os.Getenv(key)
 }
 
+// dotEnvReader reads environment variables, falling back to the variables of a .env file.
+type dotEnvReader struct {
+	env       EnvReader
+	variables map[string]string
+}
+
+func (r dotEnvReader) GetEnv(key string) string {
+	if value := r.env.GetEnv(key); value != "" {
+		return value
+	}
+	return r.variables[key]
+}
+
+// WithDotEnv extends an EnvReader with the variables of a .env file. Variables set in the
+// environment take precedence over those of the file.
+//
+// Arguments:
+// - envReader: The reader of the environment variables.
+// - path: The location of the .env file; a missing file adds no variable.
+//
+// Returns:
+// - The extended EnvReader.
+// - An error if the file cannot be read or parsed.
+func WithDotEnv(envReader EnvReader, path string) (EnvReader, error) {
+	variables, err := secrets.ReadDotEnv(path)
+	if err != nil {
+		return nil, fmt.Errorf("error loading %s: %v", path, err)
+	}
+	if len(variables) == 0 {
+		return envReader, nil
+	}
+	return dotEnvReader{env: envReader, variables: variables}, nil
+}
+
 // Config defines the top-level configuration structure, matching the TOML file layout.
 type Config struct {
-	Project ProjectConfig         `toml:"project"`
-	Prompt  PromptConfig          `toml:"prompt"`
-	Review  map[string]ReviewItem `toml:"review"`
+	Project         ProjectConfig              `toml:"project"`
+	Prompt          PromptConfig               `toml:"prompt"`
+	Review          map[string]ReviewItem      `toml:"review"`
+	ReviewGroups    map[string]ReviewGroupItem `toml:"review_groups"`
+	FollowUpQueries map[string]FollowUpItem    `toml:"follow_ups"` // Named follow-up queries asked after the review items, see FollowUps
+	Prices          map[string]PriceItem       `toml:"prices"`
+
+	InternalPrompts map[string]string `toml:"internal_prompts"` // Replacements of the follow-up, merge and re-ask prompts, by name
 }
 
 // ProjectConfig holds details about the project, its metadata, and settings.
@@ -35,23 +83,120 @@ type ProjectConfig struct {
 
 // ProjectConfiguration defines various settings related to project input and output.
 type ProjectConfiguration struct {
-	InputDirectory   string `toml:"input_directory"`
-	ResultsFileName  string `toml:"results_file_name"`
-	OutputFormat     string `toml:"output_format"`
-	LogLevel         string `toml:"log_level"`
-	CotJustification string `toml:"cot_justification"`
-	Duplication      string `toml:"duplication"`
-	Summary          string `toml:"summary"`
+	InputDirectory    string `toml:"input_directory"`
+	ResultsFileName   string `toml:"results_file_name"`
+	OutputFormat      string `toml:"output_format"`
+	OutputLayout      string `toml:"output_layout"` // Shape of the tabular outputs, see the Layout constants
+	LogLevel          string `toml:"log_level"`
+	CotJustification  string `toml:"cot_justification"`
+	Duplication       string `toml:"duplication"` // Deprecated: "yes" stands for two repetitions
+	Repetitions       int    `toml:"repetitions"` // Times every document is sent to each model, 1 unless measuring stability
+	Summary           string `toml:"summary"`
+	SchemaRetries     int    `toml:"schema_retries"`  // Times an answer failing the JSON Schema of the review items is asked again, 0 to disable
+	PromptLanguage    string `toml:"prompt_language"` // Language of the follow-up, merge and re-ask prompts, see the localization package
+	Resume            string `toml:"resume"`
+	Incremental       string `toml:"incremental"`
+	Chunking          string `toml:"chunking"`
+	ChunkTokens       int    `toml:"chunk_tokens"`
+	ChunkOverlap      int    `toml:"chunk_overlap"`
+	MergeStrategy     string `toml:"merge_strategy"`
+	MetadataFile      string `toml:"metadata_file"`
+	ExamplesDirectory string `toml:"examples_directory"` // Directory of example manuscripts (.txt) with their expected answers (.json)
+	ExamplesTokens    int    `toml:"examples_tokens"`    // Token budget of the few-shot examples of a prompt
+	ExamplesSelection string `toml:"examples_selection"` // How examples are chosen, see the Examples constants
+	ExamplesCount     int    `toml:"examples_count"`     // Maximum number of examples per prompt, 0 for as many as fit
+
+	ScreeningResults          string `toml:"screening_results"`           // Screening output (.json or .csv) whose included records are reviewed instead of the input directory
+	ScreeningTextColumn       string `toml:"screening_text_column"`       // Column of the screening results holding the text of the manuscripts or their file paths
+	ScreeningIdentifierColumn string `toml:"screening_identifier_column"` // Column identifying the records, the screening ID if empty
+}
+
+// Layouts of the tabular (csv and xlsx) outputs.
+const (
+	LayoutWide = "wide" // one row per file and model, one column per review key
+	LayoutLong = "long" // one row per file, model and review key, with its justification and summary
+)
+
+// Strategies to merge the answers given on the chunks of a long document.
+const (
+	MergeFirstNonEmpty = "first_non_empty" // the first non-empty answer, in document order
+	MergeUnion         = "union"           // the distinct non-empty answers, separated by "; "
+	MergeLLMReduce     = "llm_reduce"      // the model combines the chunk answers in a final prompt
+)
+
+// DefaultChunkTokens is the default maximum number of manuscript tokens sent in a single chunk.
+const DefaultChunkTokens = 4000
+
+// Selections of the few-shot examples added to the prompt of a document.
+const (
+	ExamplesAll     = "all"     // the examples in filename order
+	ExamplesSimilar = "similar" // the examples most similar to the document first
+)
+
+// DefaultExamplesTokens is the default token budget of the few-shot examples of a prompt.
+const DefaultExamplesTokens = 2000
+
+// apiKeyVariables maps the supported providers to the environment variables read for their API keys.
+var apiKeyVariables = map[string]string{
+	"OpenAI":      "OPENAI_API_KEY",
+	"GoogleAI":    "GOOGLE_AI_API_KEY",
+	"Cohere":      "CO_API_KEY",
+	"Anthropic":   "ANTHROPIC_API_KEY",
+	"DeepSeek":    "DEEPSEEK_API_KEY",
+	"Perplexity":  "PERPLEXITY_API_KEY",
+	"AWS Bedrock": "AWS_ACCESS_KEY_ID",
+	"Azure AI":    "AZURE_OPENAI_API_KEY",
+	"Vertex AI":   "GOOGLE_APPLICATION_CREDENTIALS",
+	"SelfHosted":  "SELF_HOSTED_API_KEY",
+}
+
+// Providers returns the names of the supported LLM providers, sorted.
+func Providers() []string {
+	providers := make([]string, 0, len(apiKeyVariables))
+	for provider := range apiKeyVariables {
+		providers = append(providers, provider)
+	}
+	sort.Strings(providers)
+	return providers
+}
+
+// APIKeyVariable returns the environment variable read for the API key of a provider when the
+// api_key of a model is empty, and false if the provider is not supported.
+func APIKeyVariable(provider string) (string, bool) {
+	variable, ok := apiKeyVariables[provider]
+	return variable, ok
+}
+
+// ResolveAPIKey returns the API key of a model: the key given in api_key, the secret it refers to
+// with "env:NAME" or "file:/path", or, if api_key is empty, the environment variable of the
+// provider.
+//
+// Arguments:
+// - llm: The model configuration.
+// - envReader: The reader of the environment variables.
+//
+// Returns:
+// - The API key, empty if none is set.
+// - An error if the reference cannot be resolved.
+func ResolveAPIKey(llm LLMItem, envReader EnvReader) (string, error) {
+	if llm.ApiKey != "" {
+		return secrets.Resolve(llm.ApiKey, envReader.GetEnv)
+	}
+	if variable, ok := apiKeyVariables[llm.Provider]; ok {
+		return envReader.GetEnv(variable), nil
+	}
+	return "", nil
 }
 
 // LLMConfig holds the configuration settings specific to the AI model being used.
 type LLMItem struct {
 	Provider     string  `toml:"provider"`
-	ApiKey       string  `toml:"api_key"`
+	ApiKey       string  `toml:"api_key"` // The key, or a reference to it: "env:NAME" or "file:/path"
 	Model        string  `toml:"model"`
 	Temperature  float64 `toml:"temperature"`
 	TpmLimit     int64   `toml:"tpm_limit"`
 	RpmLimit     int64   `toml:"rpm_limit"`
+	Weight       float64 `toml:"weight,omitempty"`        // Vote weight in the ensemble consensus, positive, 1 if not set
 	BaseURL      string  `toml:"base_url,omitempty"`      // For self-hosted OpenAI-compatible endpoints
 	EndpointType string  `toml:"endpoint_type,omitempty"` // For cloud providers (AWS Bedrock, Azure, Vertex)
 	Region       string  `toml:"region,omitempty"`        // For AWS Bedrock
@@ -60,6 +205,15 @@ type LLMItem struct {
 	APIVersion   string  `toml:"api_version,omitempty"`   // For Azure AI
 }
 
+// PriceItem overrides or extends the bundled price table used to estimate the cost of a review.
+type PriceItem struct {
+	Provider      string  `toml:"provider"`
+	Model         string  `toml:"model"`
+	Input         float64 `toml:"input"`          // USD per million input tokens
+	Output        float64 `toml:"output"`         // USD per million output tokens
+	ContextWindow int     `toml:"context_window"` // Maximum input tokens of a request, 0 if unknown
+}
+
 // PromptConfig specifies the configurations related to task prompting.
 type PromptConfig struct {
 	Persona        string `toml:"persona"`
@@ -70,10 +224,218 @@ type PromptConfig struct {
 	Example        string `toml:"example"`
 }
 
+// validatePromptTemplates checks that every prompt field is a valid text/template.
+func validatePromptTemplates(prompt PromptConfig) error {
+	fields := []struct {
+		name string
+		text string
+	}{
+		{"persona", prompt.Persona},
+		{"task", prompt.Task},
+		{"expected_result", prompt.ExpectedResult},
+		{"failsafe", prompt.Failsafe},
+		{"definitions", prompt.Definitions},
+		{"example", prompt.Example},
+	}
+	for _, field := range fields {
+		if _, err := template.New(field.name).Parse(field.text); err != nil {
+			return fmt.Errorf("invalid template in prompt %s: %v", field.name, err)
+		}
+	}
+	return nil
+}
+
+// Types of review items, setting how answers are requested and validated.
+const (
+	ItemTypeEnum      = "enum"       // one of the allowed values
+	ItemTypeMultiEnum = "multi_enum" // one or more of the allowed values
+	ItemTypeInteger   = "integer"    // whole number, optionally within min and max
+	ItemTypeFloat     = "float"      // decimal number, optionally within min and max
+	ItemTypeDate      = "date"       // date as YYYY-MM-DD, YYYY-MM or YYYY
+	ItemTypeText      = "text"       // free text
+	ItemTypeBoolean   = "boolean"    // yes or no
+)
+
 // ReviewItem defines key-value pairs for review configurations.
 type ReviewItem struct {
 	Key    string   `toml:"key"`
 	Values []string `toml:"values"`
+	Type   string   `toml:"type,omitempty"`  // Item type, see ItemType constants; inferred from values if empty
+	Min    *float64 `toml:"min,omitempty"`   // Lower bound for integer and float items
+	Max    *float64 `toml:"max,omitempty"`   // Upper bound for integer and float items
+	Group  string   `toml:"group,omitempty"` // Name of the review group asking the item, empty for the main prompt
+}
+
+// ReviewGroupItem defines a named group of review items, asked in a separate prompt of the same sequence.
+type ReviewGroupItem struct {
+	Task string `toml:"task"` // Task text of the group prompt, replacing the task of the [prompt] section
+}
+
+// ReviewGroup is a set of review items asked together in one prompt.
+type ReviewGroup struct {
+	Name  string   // empty for the items without group
+	Task  string   // task text of the prompt
+	Items []string // TOML entry keys of the review items, sorted
+}
+
+// Groups returns the review groups in the order their prompts are sent: the items without group
+// first, with the task of the [prompt] section, then the named groups sorted by name.
+// Without named groups, a single group holds every review item.
+//
+// Returns:
+// - The review groups; at least one, with no items if no review item is configured.
+func (c *Config) Groups() []ReviewGroup {
+	items := make(map[string][]string)
+	for entry, item := range c.Review {
+		items[item.Group] = append(items[item.Group], entry)
+	}
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names) // the empty name of the items without group comes first
+
+	if len(names) == 0 {
+		return []ReviewGroup{{Task: c.Prompt.Task}}
+	}
+
+	groups := make([]ReviewGroup, 0, len(names))
+	for _, name := range names {
+		task := c.Prompt.Task
+		if name != "" {
+			task = c.ReviewGroups[name].Task
+		}
+		sort.Strings(items[name])
+		groups = append(groups, ReviewGroup{Name: name, Task: task, Items: items[name]})
+	}
+	return groups
+}
+
+// Names of the built-in follow-ups, enabled by cot_justification and summary.
+const (
+	FollowUpJustification = "justification"
+	FollowUpSummary       = "summary"
+)
+
+// reservedFollowUpNames cannot name a follow-up of the [follow_ups] table, as they are taken by the
+// built-in follow-ups, by the columns and keys of the results or by the xlsx sheets, whose names
+// Excel compares case-insensitively.
+var reservedFollowUpNames = []string{FollowUpJustification, FollowUpSummary, "provider", "model", "filename", "file_name", "metadata", "grounding", "retries", "answers", "justifications", "summaries"}
+
+// followUpName matches the names of the [follow_ups] table, which also name the result files,
+// columns and xlsx sheets of the follow-ups.
+var followUpName = regexp.MustCompile(`^[a-z][a-z0-9_]{0,30}$`)
+
+// FollowUpItem defines a named follow-up query, asked in the same conversation after the review items.
+type FollowUpItem struct {
+	Query string `toml:"query"` // Text of the query, a template of the document variables
+}
+
+// FollowUp is a follow-up query asked after the review items of a document.
+type FollowUp struct {
+	Name  string // FollowUpJustification, FollowUpSummary or a name of the [follow_ups] table
+	Query string // text of the query, not yet rendered for named follow-ups
+}
+
+// Builtin reports whether the follow-up is the justification or the summary, whose answers are
+// JSON objects, rather than a query of the [follow_ups] table, whose answers are free text.
+func (f FollowUp) Builtin() bool {
+	return f.Name == FollowUpJustification || f.Name == FollowUpSummary
+}
+
+// FollowUps returns the follow-up queries in the order they are asked after the review items: the
+// justification and the summary when enabled, then the [follow_ups] table sorted by name.
+//
+// Returns:
+// - The follow-ups; empty if none is enabled.
+func (c *Config) FollowUps() []FollowUp {
+	var followUps []FollowUp
+	prompts := c.LocalizedPrompts()
+	if c.Project.Configuration.CotJustification == "yes" {
+		followUps = append(followUps, FollowUp{Name: FollowUpJustification, Query: prompts.Text(localization.JustificationQuery)})
+	}
+	if c.Project.Configuration.Summary == "yes" {
+		followUps = append(followUps, FollowUp{Name: FollowUpSummary, Query: prompts.Text(localization.SummaryQuery)})
+	}
+	names := make([]string, 0, len(c.FollowUpQueries))
+	for name := range c.FollowUpQueries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		followUps = append(followUps, FollowUp{Name: name, Query: c.FollowUpQueries[name].Query})
+	}
+	return followUps
+}
+
+// ValidateFollowUp checks the name and the query of an entry of the [follow_ups] table.
+//
+// Arguments:
+// - name: The name of the follow-up, its key in the table.
+// - item: The follow-up query.
+//
+// Returns:
+// - An error if the name is reserved or not made of lowercase letters, digits and underscores, or
+// the query is empty or not a valid template.
+func ValidateFollowUp(name string, item FollowUpItem) error {
+	if slices.Contains(reservedFollowUpNames, name) {
+		return fmt.Errorf("follow-up name '%s' is reserved, use another name", name)
+	}
+	if !followUpName.MatchString(name) {
+		return fmt.Errorf("invalid follow-up name '%s': use up to 31 lowercase letters, digits and underscores, starting with a letter", name)
+	}
+	if strings.TrimSpace(item.Query) == "" {
+		return fmt.Errorf("follow-up '%s' has no query", name)
+	}
+	if _, err := template.New(name).Parse(item.Query); err != nil {
+		return fmt.Errorf("invalid template in query of follow-up %s: %v", name, err)
+	}
+	return nil
+}
+
+// LocalizedPrompts returns the follow-up, merge and re-ask prompts of the review, in its
+// prompt_language and with the overrides of the [internal_prompts] table.
+func (c *Config) LocalizedPrompts() *localization.Prompts {
+	return localization.New(c.Project.Configuration.PromptLanguage, c.InternalPrompts)
+}
+
+// ResolvedType returns the type of the review item. Items without an explicit type are
+// free text when no value other than the empty string is allowed, and enums otherwise.
+func (item ReviewItem) ResolvedType() string {
+	if item.Type != "" {
+		return item.Type
+	}
+	for _, value := range item.Values {
+		if value != "" {
+			return ItemTypeEnum
+		}
+	}
+	return ItemTypeText
+}
+
+// Validate checks that the type of a review item is supported and consistent with its allowed
+// values and range.
+func (item ReviewItem) Validate() error {
+	switch item.ResolvedType() {
+	case ItemTypeEnum, ItemTypeMultiEnum:
+		hasValue := false
+		for _, value := range item.Values {
+			if value != "" {
+				hasValue = true
+			}
+		}
+		if !hasValue {
+			return fmt.Errorf("review item '%s' of type %s requires a non-empty values list", item.Key, item.ResolvedType())
+		}
+	case ItemTypeInteger, ItemTypeFloat:
+		if item.Min != nil && item.Max != nil && *item.Min > *item.Max {
+			return fmt.Errorf("review item '%s' has min %v greater than max %v", item.Key, *item.Min, *item.Max)
+		}
+	case ItemTypeDate, ItemTypeText, ItemTypeBoolean:
+	default:
+		return fmt.Errorf("review item '%s' has unsupported type '%s'", item.Key, item.Type)
+	}
+	return nil
 }
 
 // LoadConfig parses the given TOML configuration string and populates a Config structure.
@@ -90,46 +452,51 @@ type ReviewItem struct {
 //   - An error if the TOML data cannot be decoded or any other processing error occurs.
 //
 // The function handles the following:
-//  1. Decoding the TOML configuration into the Config structure.
-//  2. Checking for missing API keys and attempting to retrieve them from environment variables
-//     based on the provider (OpenAI, GoogleAI, Cohere, Anthropic, DeepSeek).
+//  1. Merging the base files named in the "extends" key and decoding the TOML configuration
+//     into the Config structure.
+//  2. Resolving the "env:NAME" and "file:/path" references given as API keys, and retrieving
+//     missing API keys from the environment variables of their provider (OpenAI, GoogleAI, Cohere,
+//     Anthropic, DeepSeek), or from the .env file of the working directory. Resolved keys are
+//     registered for redaction with the secrets package.
 //  3. Setting default values for missing or invalid configuration fields, such as
-//     OutputFormat, LogLevel, CotJustification, Summary, and Duplication.
+//     OutputFormat, OutputLayout, LogLevel, CotJustification, Summary, Duplication, Repetitions,
+//     SchemaRetries, Resume, Incremental, the chunking options and the few-shot example options,
+//     rejecting an unsupported output format or layout, merge strategy or example selection, and
+//     incremental reviews with xlsx output.
 //  4. Ensuring that LLM configuration parameters like Temperature, TpmLimit, and RpmLimit are
-//     non-negative by applying minimum value constraints.
+//     non-negative by applying minimum value constraints, and rejecting a weight that is set but
+//     not positive.
+//  5. Checking that every review item has a supported type, consistent with its values and range.
+//  6. Checking that every prompt field is a valid text/template, and that the prompt_language and
+//     the [internal_prompts] overrides are supported; the language defaults to English.
+//  7. Checking that review items refer to defined review groups, each with a valid task template,
+//     and that the [follow_ups] table has valid names and query templates.
 func LoadConfig(tomlConfiguration string, envReader EnvReader) (*Config, error) {
 	var config Config
 
+	// Merge the base files the configuration extends
+	tomlConfiguration, err := tomlinclude.Resolve(tomlConfiguration)
+	if err != nil {
+		return nil, err
+	}
+
 	// Decode the TOML data
-	if _, err := toml.Decode(tomlConfiguration, &config); err != nil {
+	meta, err := toml.Decode(tomlConfiguration, &config)
+	if err != nil {
 		return nil, err
 	}
 
+	envReader, err = WithDotEnv(envReader, secrets.DotEnvFile)
+	if err != nil {
+		return nil, err
+	}
 	for key, llm := range config.Project.LLM {
-		if llm.ApiKey == "" { // If API key is empty, look for it in environment variables
-			switch llm.Provider {
-			case "OpenAI":
-				llm.ApiKey = envReader.GetEnv("OPENAI_API_KEY")
-			case "GoogleAI":
-				llm.ApiKey = envReader.GetEnv("GOOGLE_AI_API_KEY")
-			case "Cohere":
-				llm.ApiKey = envReader.GetEnv("CO_API_KEY")
-			case "Anthropic":
-				llm.ApiKey = envReader.GetEnv("ANTHROPIC_API_KEY")
-			case "DeepSeek":
-				llm.ApiKey = envReader.GetEnv("DEEPSEEK_API_KEY")
-			case "Perplexity":
-				llm.ApiKey = envReader.GetEnv("PERPLEXITY_API_KEY")
-			case "AWS Bedrock":
-				llm.ApiKey = envReader.GetEnv("AWS_ACCESS_KEY_ID")
-			case "Azure AI":
-				llm.ApiKey = envReader.GetEnv("AZURE_OPENAI_API_KEY")
-			case "Vertex AI":
-				llm.ApiKey = envReader.GetEnv("GOOGLE_APPLICATION_CREDENTIALS")
-			case "SelfHosted":
-				llm.ApiKey = envReader.GetEnv("SELF_HOSTED_API_KEY")
-			}
+		apiKey, err := ResolveAPIKey(llm, envReader)
+		if err != nil {
+			return nil, fmt.Errorf("api_key of [project.llm.%s]: %v", key, err)
 		}
+		llm.ApiKey = apiKey
+		secrets.Register(apiKey)
 
 		if llm.Temperature < 0 {
 			llm.Temperature = 0
@@ -140,12 +507,64 @@ func LoadConfig(tomlConfiguration string, envReader EnvReader) (*Config, error)
 		if llm.RpmLimit < 0 {
 			llm.RpmLimit = 0
 		}
+		if meta.IsDefined("project", "llm", key, "weight") && llm.Weight <= 0 {
+			return nil, fmt.Errorf("weight of [project.llm.%s] must be positive, got %v: remove the model to leave it out of the consensus", key, llm.Weight)
+		}
 		// Update the map directly with the modified llm
 		config.Project.LLM[key] = llm
 	}
 
-	if config.Project.Configuration.OutputFormat == "" {
+	for _, item := range config.Review {
+		if err := item.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validatePromptTemplates(config.Prompt); err != nil {
+		return nil, err
+	}
+
+	if err := localization.Validate(config.Project.Configuration.PromptLanguage, config.InternalPrompts, localization.ReviewPrompts); err != nil {
+		return nil, err
+	}
+	config.Project.Configuration.PromptLanguage, _ = localization.Normalize(config.Project.Configuration.PromptLanguage)
+
+	for _, item := range config.Review {
+		if _, defined := config.ReviewGroups[item.Group]; item.Group != "" && !defined {
+			return nil, fmt.Errorf("review item '%s' refers to undefined review group '%s'", item.Key, item.Group)
+		}
+	}
+	for name, group := range config.ReviewGroups {
+		if strings.TrimSpace(group.Task) == "" {
+			return nil, fmt.Errorf("review group '%s' has no task", name)
+		}
+		if _, err := template.New(name).Parse(group.Task); err != nil {
+			return nil, fmt.Errorf("invalid template in task of review group %s: %v", name, err)
+		}
+	}
+	for name, item := range config.FollowUpQueries {
+		if err := ValidateFollowUp(name, item); err != nil {
+			return nil, err
+		}
+	}
+
+	switch config.Project.Configuration.OutputFormat {
+	case "":
 		config.Project.Configuration.OutputFormat = "csv"
+	case "csv", "json", "jsonl", "xlsx":
+	default:
+		return nil, fmt.Errorf("unsupported output_format '%s'", config.Project.Configuration.OutputFormat)
+	}
+
+	switch config.Project.Configuration.OutputLayout {
+	case "":
+		config.Project.Configuration.OutputLayout = LayoutWide
+	case LayoutWide, LayoutLong:
+	default:
+		return nil, fmt.Errorf("unsupported output_layout '%s'", config.Project.Configuration.OutputLayout)
+	}
+	if config.Project.Configuration.OutputLayout == LayoutLong && config.Project.Configuration.OutputFormat != "csv" && config.Project.Configuration.OutputFormat != "xlsx" {
+		return nil, fmt.Errorf("output_layout '%s' requires csv or xlsx output", LayoutLong)
 	}
 
 	if config.Project.Configuration.LogLevel == "" {
@@ -164,5 +583,67 @@ func LoadConfig(tomlConfiguration string, envReader EnvReader) (*Config, error)
 		config.Project.Configuration.Duplication = "no"
 	}
 
+	if config.Project.Configuration.Repetitions == 0 && config.Project.Configuration.Duplication == "yes" {
+		config.Project.Configuration.Repetitions = 2
+	}
+	config.Project.Configuration.Repetitions = max(config.Project.Configuration.Repetitions, 1)
+	config.Project.Configuration.SchemaRetries = max(config.Project.Configuration.SchemaRetries, 0)
+
+	if config.Project.Configuration.Resume == "" {
+		config.Project.Configuration.Resume = "yes"
+	}
+
+	if config.Project.Configuration.Incremental == "" {
+		config.Project.Configuration.Incremental = "no"
+	}
+
+	if config.Project.Configuration.Incremental == "yes" && config.Project.Configuration.OutputFormat == "xlsx" {
+		return nil, fmt.Errorf("incremental reviews are not supported with xlsx output, use csv, json or jsonl")
+	}
+
+	if config.Project.Configuration.Chunking == "" {
+		config.Project.Configuration.Chunking = "no"
+	}
+
+	if config.Project.Configuration.ChunkTokens <= 0 {
+		config.Project.Configuration.ChunkTokens = DefaultChunkTokens
+	}
+
+	if config.Project.Configuration.ChunkOverlap < 0 {
+		config.Project.Configuration.ChunkOverlap = 0
+	}
+	if config.Project.Configuration.ChunkOverlap >= config.Project.Configuration.ChunkTokens {
+		return nil, fmt.Errorf("chunk_overlap (%d) must be smaller than chunk_tokens (%d)",
+			config.Project.Configuration.ChunkOverlap, config.Project.Configuration.ChunkTokens)
+	}
+
+	switch config.Project.Configuration.MergeStrategy {
+	case "":
+		config.Project.Configuration.MergeStrategy = MergeFirstNonEmpty
+	case MergeFirstNonEmpty, MergeUnion, MergeLLMReduce:
+	default:
+		return nil, fmt.Errorf("unsupported merge_strategy '%s'", config.Project.Configuration.MergeStrategy)
+	}
+
+	if config.Project.Configuration.ScreeningResults != "" && config.Project.Configuration.ScreeningTextColumn == "" {
+		return nil, fmt.Errorf("screening_text_column must be set to review screening results")
+	}
+
+	if config.Project.Configuration.ExamplesTokens <= 0 {
+		config.Project.Configuration.ExamplesTokens = DefaultExamplesTokens
+	}
+
+	if config.Project.Configuration.ExamplesCount < 0 {
+		config.Project.Configuration.ExamplesCount = 0
+	}
+
+	switch config.Project.Configuration.ExamplesSelection {
+	case "":
+		config.Project.Configuration.ExamplesSelection = ExamplesAll
+	case ExamplesAll, ExamplesSimilar:
+	default:
+		return nil, fmt.Errorf("unsupported examples_selection '%s'", config.Project.Configuration.ExamplesSelection)
+	}
+
 	return &config, nil
 }
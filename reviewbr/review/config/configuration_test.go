@@ -1,7 +1,10 @@
 package config
 
 import (
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -114,13 +117,23 @@ values = ["world", "continent", "river basin"]
 			Author:  "John Doe",
 			Version: "1.0",
 			Configuration: ProjectConfiguration{
-				InputDirectory:   "/path/to/txt/files",
-				ResultsFileName:  "/path/to/save/results",
-				OutputFormat:     "json",
-				LogLevel:         "low",
-				Duplication:      "no",
-				CotJustification: "no",
-				Summary:          "no",
+				InputDirectory:    "/path/to/txt/files",
+				ResultsFileName:   "/path/to/save/results",
+				OutputFormat:      "json",
+				OutputLayout:      LayoutWide,
+				LogLevel:          "low",
+				Duplication:       "no",
+				Repetitions:       1,
+				CotJustification:  "no",
+				Summary:           "no",
+				PromptLanguage:    "en",
+				Resume:            "yes",
+				Incremental:       "no",
+				Chunking:          "no",
+				ChunkTokens:       DefaultChunkTokens,
+				MergeStrategy:     MergeFirstNonEmpty,
+				ExamplesTokens:    DefaultExamplesTokens,
+				ExamplesSelection: ExamplesAll,
 			},
 			LLM: map[string]LLMItem{
 				"1": {
@@ -186,3 +199,381 @@ values = ["world", "continent", "river basin"]
 		t.Errorf("Loaded config does not match expected config.\nExpected: %+v\nGot: %+v", expectedConfig, config)
 	}
 }
+
+// TestLoadConfigTypedReviewItems tests decoding and validation of typed review items.
+func TestLoadConfigTypedReviewItems(t *testing.T) {
+	tomlContent := `
+[review]
+[review.1]
+key = "sample size"
+type = "integer"
+min = 1
+max = 5000
+[review.2]
+key = "regression models"
+values = ["yes", "no"]
+[review.3]
+key = "notes"
+values = [""]
+`
+	config, err := LoadConfig(tomlContent, &MockEnvReader{})
+	if err != nil {
+		t.Fatalf("LoadConfig returned an unexpected error: %v", err)
+	}
+
+	item := config.Review["1"]
+	if item.ResolvedType() != ItemTypeInteger || item.Min == nil || *item.Min != 1 || item.Max == nil || *item.Max != 5000 {
+		t.Errorf("Unexpected typed review item: %+v", item)
+	}
+	if config.Review["2"].ResolvedType() != ItemTypeEnum {
+		t.Errorf("Expected item with values to default to enum, got %s", config.Review["2"].ResolvedType())
+	}
+	if config.Review["3"].ResolvedType() != ItemTypeText {
+		t.Errorf("Expected item with empty values to default to text, got %s", config.Review["3"].ResolvedType())
+	}
+
+	invalid := []string{
+		"[review]\n[review.1]\nkey = \"k\"\ntype = \"percentage\"\n",
+		"[review]\n[review.1]\nkey = \"k\"\ntype = \"multi_enum\"\nvalues = [\"\"]\n",
+		"[review]\n[review.1]\nkey = \"k\"\ntype = \"float\"\nmin = 10\nmax = 1\n",
+	}
+	for _, content := range invalid {
+		if _, err := LoadConfig(content, &MockEnvReader{}); err == nil {
+			t.Errorf("Expected an error for invalid review item:\n%s", content)
+		}
+	}
+}
+
+func TestLoadConfigChunkingOptions(t *testing.T) {
+	tomlContent := `
+[project.configuration]
+chunking = "yes"
+chunk_tokens = 1000
+chunk_overlap = 100
+merge_strategy = "union"
+`
+	config, err := LoadConfig(tomlContent, &MockEnvReader{})
+	if err != nil {
+		t.Fatalf("LoadConfig returned an unexpected error: %v", err)
+	}
+	configuration := config.Project.Configuration
+	if configuration.Chunking != "yes" || configuration.ChunkTokens != 1000 || configuration.ChunkOverlap != 100 || configuration.MergeStrategy != MergeUnion {
+		t.Errorf("Unexpected chunking options: %+v", configuration)
+	}
+
+	invalid := []string{
+		"[project.configuration]\nmerge_strategy = \"average\"\n",
+		"[project.configuration]\nchunk_tokens = 100\nchunk_overlap = 100\n",
+	}
+	for _, content := range invalid {
+		if _, err := LoadConfig(content, &MockEnvReader{}); err == nil {
+			t.Errorf("Expected an error for invalid chunking options:\n%s", content)
+		}
+	}
+}
+
+func TestLoadConfigPromptTemplates(t *testing.T) {
+	valid := "[prompt]\ntask = \"This paper, published in {{.Year}} in {{.Journal}}.\"\n"
+	if _, err := LoadConfig(valid, &MockEnvReader{}); err != nil {
+		t.Errorf("LoadConfig returned an unexpected error for a valid template: %v", err)
+	}
+
+	invalid := "[prompt]\ntask = \"This paper, published in {{.Year}.\"\n"
+	if _, err := LoadConfig(invalid, &MockEnvReader{}); err == nil || !strings.Contains(err.Error(), "prompt task") {
+		t.Errorf("Expected an error naming the invalid prompt template, got %v", err)
+	}
+}
+
+func TestLoadConfigReviewGroups(t *testing.T) {
+	tomlContent := `
+[prompt]
+task = "Main task."
+
+[review_groups.outcomes]
+task = "Outcomes task."
+
+[review_groups.design]
+task = "Design task."
+
+[review]
+[review.3]
+key = "mortality"
+values = ["yes", "no"]
+group = "outcomes"
+[review.1]
+key = "country"
+values = [""]
+[review.2]
+key = "design"
+values = ["cohort", "trial"]
+group = "design"
+[review.4]
+key = "follow-up"
+values = [""]
+group = "outcomes"
+`
+	cfg, err := LoadConfig(tomlContent, &MockEnvReader{})
+	if err != nil {
+		t.Fatalf("LoadConfig returned an error: %v", err)
+	}
+
+	groups := cfg.Groups()
+	expected := []ReviewGroup{
+		{Task: "Main task.", Items: []string{"1"}},
+		{Name: "design", Task: "Design task.", Items: []string{"2"}},
+		{Name: "outcomes", Task: "Outcomes task.", Items: []string{"3", "4"}},
+	}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Errorf("Expected groups %+v, got %+v", expected, groups)
+	}
+
+	undefined := "[review]\n[review.1]\nkey = \"design\"\nvalues = [\"\"]\ngroup = \"missing\"\n"
+	if _, err := LoadConfig(undefined, &MockEnvReader{}); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Errorf("Expected an error for an undefined review group, got %v", err)
+	}
+
+	noTask := "[review_groups.design]\ntask = \"\"\n[review]\n[review.1]\nkey = \"design\"\nvalues = [\"\"]\ngroup = \"design\"\n"
+	if _, err := LoadConfig(noTask, &MockEnvReader{}); err == nil {
+		t.Errorf("Expected an error for a review group without task")
+	}
+}
+
+func TestLoadConfigOutputFormat(t *testing.T) {
+	for _, format := range []string{"csv", "json", "jsonl", "xlsx"} {
+		if _, err := LoadConfig("[project.configuration]\noutput_format = \""+format+"\"\n", &MockEnvReader{}); err != nil {
+			t.Errorf("LoadConfig returned an unexpected error for %s: %v", format, err)
+		}
+	}
+
+	invalid := []string{
+		"[project.configuration]\noutput_format = \"parquet\"\n",
+		"[project.configuration]\noutput_format = \"xlsx\"\nincremental = \"yes\"\n",
+	}
+	for _, content := range invalid {
+		if _, err := LoadConfig(content, &MockEnvReader{}); err == nil {
+			t.Errorf("Expected an error for invalid output options:\n%s", content)
+		}
+	}
+}
+
+func TestLoadConfigOutputLayout(t *testing.T) {
+	config, err := LoadConfig("[project.configuration]\noutput_format = \"xlsx\"\noutput_layout = \"long\"\n", &MockEnvReader{})
+	if err != nil {
+		t.Fatalf("LoadConfig returned an unexpected error: %v", err)
+	}
+	if config.Project.Configuration.OutputLayout != LayoutLong {
+		t.Errorf("Expected the long layout, got %s", config.Project.Configuration.OutputLayout)
+	}
+
+	invalid := []string{
+		"[project.configuration]\noutput_layout = \"tall\"\n",
+		"[project.configuration]\noutput_format = \"json\"\noutput_layout = \"long\"\n",
+	}
+	for _, content := range invalid {
+		if _, err := LoadConfig(content, &MockEnvReader{}); err == nil {
+			t.Errorf("Expected an error for invalid output options:\n%s", content)
+		}
+	}
+}
+
+func TestLoadConfigPromptLanguage(t *testing.T) {
+	config, err := LoadConfig("[project.configuration]\nprompt_language = \"pt_br\"\n\n[internal_prompts]\nsummary_query = \"Resuma o texto.\"\n", &MockEnvReader{})
+	if err != nil {
+		t.Fatalf("LoadConfig returned an unexpected error: %v", err)
+	}
+	if config.Project.Configuration.PromptLanguage != "pt-BR" {
+		t.Errorf("Expected the canonical pt-BR code, got %s", config.Project.Configuration.PromptLanguage)
+	}
+	prompts := config.LocalizedPrompts()
+	if prompts.Text("summary_query") != "Resuma o texto." || !strings.HasPrefix(prompts.Text("justification_query"), "Para cada uma") {
+		t.Errorf("Expected the override and the Portuguese justification query")
+	}
+
+	invalid := []string{
+		"[project.configuration]\nprompt_language = \"de\"\n",
+		"[internal_prompts]\nlanguage = \"Detect {{.Manuscript}}\"\n",
+	}
+	for _, content := range invalid {
+		if _, err := LoadConfig(content, &MockEnvReader{}); err == nil {
+			t.Errorf("Expected an error for invalid prompt options:\n%s", content)
+		}
+	}
+}
+
+func TestLoadConfigFollowUps(t *testing.T) {
+	tomlContent := `
+[project.configuration]
+cot_justification = "yes"
+
+[follow_ups.limitations]
+query = "List the limitations of {{.Filename}}."
+
+[follow_ups.funding_sources]
+query = "Extract the funding sources."
+`
+	cfg, err := LoadConfig(tomlContent, &MockEnvReader{})
+	if err != nil {
+		t.Fatalf("LoadConfig returned an error: %v", err)
+	}
+
+	var names []string
+	for _, followUp := range cfg.FollowUps() {
+		names = append(names, followUp.Name)
+	}
+	expected := []string{FollowUpJustification, "funding_sources", "limitations"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected follow-ups %v, got %v", expected, names)
+	}
+	if followUps := cfg.FollowUps(); !followUps[0].Builtin() || followUps[1].Builtin() {
+		t.Errorf("Expected only the justification to be built-in, got %+v", followUps)
+	}
+
+	invalid := []string{
+		"[follow_ups.summary]\nquery = \"Summarize.\"\n",
+		"[follow_ups.answers]\nquery = \"Repeat the answers.\"\n",
+		"[follow_ups.summaries]\nquery = \"Summarize.\"\n",
+		"[follow_ups.Limitations]\nquery = \"List the limitations.\"\n",
+		"[follow_ups.limitations]\nquery = \" \"\n",
+		"[follow_ups.limitations]\nquery = \"List {{.Filename\"\n",
+	}
+	for _, content := range invalid {
+		if _, err := LoadConfig(content, &MockEnvReader{}); err == nil {
+			t.Errorf("Expected an error for invalid follow-ups:\n%s", content)
+		}
+	}
+}
+
+func TestLoadConfigExtends(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "base.toml")
+	baseContent := `
+[project.llm.1]
+provider = "OpenAI"
+model = "gpt-4o-mini"
+temperature = 0.01
+
+[prompt]
+persona = "You are an experienced scientist."
+`
+	if err := os.WriteFile(base, []byte(baseContent), 0644); err != nil {
+		t.Fatalf("Failed to write base configuration: %v", err)
+	}
+
+	config, err := LoadConfig(`extends = "`+base+`"
+[project.llm.1]
+model = "gpt-4o"
+
+[prompt]
+task = "Map the concepts."
+`, &MockEnvReader{values: map[string]string{"OPENAI_API_KEY": "env12345"}})
+	if err != nil {
+		t.Fatalf("LoadConfig returned an unexpected error: %v", err)
+	}
+
+	llm := config.Project.LLM["1"]
+	if llm.Provider != "OpenAI" || llm.Model != "gpt-4o" || llm.Temperature != 0.01 || llm.ApiKey != "env12345" {
+		t.Errorf("Expected the inherited model with the overridden name, got %+v", llm)
+	}
+	if config.Prompt.Persona != "You are an experienced scientist." || config.Prompt.Task != "Map the concepts." {
+		t.Errorf("Expected the inherited persona and the own task, got %+v", config.Prompt)
+	}
+}
+
+func TestLoadConfigSecretReferences(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "anthropic.key")
+	if err := os.WriteFile(keyFile, []byte("file24680\n"), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+
+	config, err := LoadConfig(`[project.llm.1]
+provider = "OpenAI"
+api_key = "env:TEAM_OPENAI_KEY"
+model = "gpt-4o-mini"
+
+[project.llm.2]
+provider = "Anthropic"
+api_key = "file:`+keyFile+`"
+model = "claude-3-haiku"
+`, &MockEnvReader{values: map[string]string{"TEAM_OPENAI_KEY": "env13579"}})
+	if err != nil {
+		t.Fatalf("LoadConfig returned an unexpected error: %v", err)
+	}
+	if config.Project.LLM["1"].ApiKey != "env13579" || config.Project.LLM["2"].ApiKey != "file24680" {
+		t.Errorf("Expected the referenced keys, got %+v", config.Project.LLM)
+	}
+
+	_, err = LoadConfig(`[project.llm.1]
+provider = "OpenAI"
+api_key = "env:MISSING_KEY"
+`, &MockEnvReader{})
+	if err == nil || !strings.Contains(err.Error(), "MISSING_KEY") {
+		t.Errorf("Expected an error naming the missing variable, got %v", err)
+	}
+}
+
+func TestWithDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("OPENAI_API_KEY=dotenv12345\nCO_API_KEY=dotenv67890\n"), 0600); err != nil {
+		t.Fatalf("Failed to write .env file: %v", err)
+	}
+
+	envReader, err := WithDotEnv(&MockEnvReader{values: map[string]string{"OPENAI_API_KEY": "env12345"}}, path)
+	if err != nil {
+		t.Fatalf("WithDotEnv returned an unexpected error: %v", err)
+	}
+	if envReader.GetEnv("OPENAI_API_KEY") != "env12345" {
+		t.Errorf("Expected the environment to take precedence over the .env file")
+	}
+	if envReader.GetEnv("CO_API_KEY") != "dotenv67890" {
+		t.Errorf("Expected the variable of the .env file, got %q", envReader.GetEnv("CO_API_KEY"))
+	}
+}
+
+func TestLoadConfigRepetitions(t *testing.T) {
+	for content, expected := range map[string]int{
+		"":                                       1,
+		"repetitions = 3":                        3,
+		"repetitions = -1":                       1,
+		`duplication = "yes"`:                    2,
+		"duplication = \"yes\"\nrepetitions = 4": 4,
+	} {
+		config, err := LoadConfig("[project.configuration]\n"+content+"\n", &MockEnvReader{})
+		if err != nil {
+			t.Fatalf("LoadConfig returned an unexpected error for %q: %v", content, err)
+		}
+		if config.Project.Configuration.Repetitions != expected {
+			t.Errorf("Expected %d repetitions for %q, got %d", expected, content, config.Project.Configuration.Repetitions)
+		}
+	}
+}
+
+func TestLoadConfigModelWeight(t *testing.T) {
+	for content, valid := range map[string]bool{
+		"":               true,
+		"weight = 2":     true,
+		"weight = 0.5":   true,
+		"weight = 0":     false,
+		"weight = -1":    false,
+		"weight = -0.25": false,
+	} {
+		_, err := LoadConfig("[project.llm.1]\nprovider = \"OpenAI\"\nmodel = \"gpt-4o-mini\"\n"+content+"\n", &MockEnvReader{})
+		if valid && err != nil {
+			t.Errorf("LoadConfig returned an unexpected error for %q: %v", content, err)
+		}
+		if !valid && err == nil {
+			t.Errorf("Expected an error for %q", content)
+		}
+	}
+}
+
+func TestLoadConfigScreeningResults(t *testing.T) {
+	if _, err := LoadConfig("[project.configuration]\nscreening_results = \"screening.json\"\n", &MockEnvReader{}); err == nil {
+		t.Error("Expected an error for screening results without text column")
+	}
+	config, err := LoadConfig("[project.configuration]\nscreening_results = \"screening.json\"\nscreening_text_column = \"abstract\"\n", &MockEnvReader{})
+	if err != nil {
+		t.Fatalf("LoadConfig returned an unexpected error: %v", err)
+	}
+	if config.Project.Configuration.ScreeningTextColumn != "abstract" || config.Project.Configuration.ScreeningIdentifierColumn != "" {
+		t.Errorf("Unexpected screening options: %+v", config.Project.Configuration)
+	}
+}
@@ -0,0 +1,355 @@
+package cost
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/BurntSushi/toml"
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/prompt"
+)
+
+// Rough sizes of the answers to the follow-up queries, used as output token estimates.
+const (
+	justificationTokensPerKey = 120
+	summaryTokens             = 100
+	followUpAnswerTokens      = 150 // answer to a query of the [follow_ups] table
+	textAnswerTokens          = 30
+)
+
+//go:embed prices.toml
+var bundledPrices string
+
+// Price is the price of a model, in USD per million tokens, with the size of its context window.
+type Price struct {
+	Provider      string  `toml:"provider"`
+	Model         string  `toml:"model"`
+	Input         float64 `toml:"input"`
+	Output        float64 `toml:"output"`
+	ContextWindow int     `toml:"context_window"`
+	Default       bool    `toml:"default"` // used for models left blank for automatic selection
+}
+
+// Document is a document whose largest request exceeds the context window of a model.
+type Document struct {
+	File   string
+	Tokens int
+}
+
+// ModelEstimate holds the estimated usage and cost of a review for one of the configured models.
+type ModelEstimate struct {
+	Provider      string
+	Model         string // as configured, empty for automatic selection
+	PricedAs      string // model of the price table entry applied, empty if the model has no price
+	Requests      int
+	InputTokens   int
+	OutputTokens  int
+	Cost          float64
+	ContextWindow int
+	Oversized     []Document
+}
+
+// Estimate holds the estimated usage and cost of a review for all the configured models.
+type Estimate struct {
+	Documents int
+	Models    []ModelEstimate
+	Total     float64
+}
+
+// usage holds the token counts of the requests of a document.
+type usage struct {
+	requests   int
+	input      int
+	output     int
+	maxRequest int
+}
+
+// BundledPrices returns the price table distributed with prismAId.
+//
+// Returns:
+// - The prices of the supported models.
+// - An error if the bundled table cannot be parsed.
+func BundledPrices() ([]Price, error) {
+	var table struct {
+		Price []Price `toml:"price"`
+	}
+	if _, err := toml.Decode(bundledPrices, &table); err != nil {
+		return nil, fmt.Errorf("error parsing bundled prices: %v", err)
+	}
+	return table.Price, nil
+}
+
+// Review estimates the tokens and the cost of a review project, without calling any provider.
+// Prompts are generated with prompt.PrepareInput, so chunking and follow-up queries are accounted
+// for as in a real run. Follow-up queries are sent with the conversation history, so their input
+// includes the previous prompts and answers, and every document is counted once per repetition.
+// Answers are estimated from the review items, since their actual length is only known after the run.
+//
+// Arguments:
+// - cfg: A pointer to the application's configuration.
+//
+// Returns:
+// - The estimate for every configured model.
+// - An error if the prompts cannot be generated or the price table cannot be loaded.
+func Review(cfg *config.Config) (*Estimate, error) {
+	jsonInput, filenames, err := prompt.PrepareInput(cfg)
+	if err != nil {
+		return nil, err
+	}
+	var input definitions.Input
+	if err := json.Unmarshal([]byte(jsonInput), &input); err != nil {
+		return nil, fmt.Errorf("error parsing review input: %v", err)
+	}
+	prices, err := loadPrices(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	documents := documentUsage(cfg, input.Prompts, len(filenames))
+	repetitions := max(cfg.Project.Configuration.Repetitions, 1)
+
+	estimate := &Estimate{Documents: len(filenames)}
+	for _, model := range input.Models {
+		modelEstimate := ModelEstimate{Provider: model.Provider, Model: model.Model}
+		price, priced := lookupPrice(prices, model.Provider, model.Model)
+		if priced {
+			modelEstimate.PricedAs = price.Model
+			modelEstimate.ContextWindow = price.ContextWindow
+		}
+		for i, document := range documents {
+			modelEstimate.Requests += document.requests * repetitions
+			modelEstimate.InputTokens += document.input * repetitions
+			modelEstimate.OutputTokens += document.output * repetitions
+			if modelEstimate.ContextWindow > 0 && document.maxRequest > modelEstimate.ContextWindow {
+				modelEstimate.Oversized = append(modelEstimate.Oversized, Document{File: filenames[i], Tokens: document.maxRequest})
+			}
+		}
+		if priced {
+			modelEstimate.Cost = (float64(modelEstimate.InputTokens)*price.Input + float64(modelEstimate.OutputTokens)*price.Output) / 1e6
+			estimate.Total += modelEstimate.Cost
+		}
+		estimate.Models = append(estimate.Models, modelEstimate)
+	}
+	return estimate, nil
+}
+
+// documentUsage measures the requests of every document, including the chunks of long documents
+// and the final request of the llm_reduce merge strategy.
+func documentUsage(cfg *config.Config, prompts []definitions.Prompt, documents int) []usage {
+	sequences := make(map[string][]definitions.Prompt)
+	var order []string
+	for _, p := range prompts {
+		if _, seen := sequences[p.SequenceID]; !seen {
+			order = append(order, p.SequenceID)
+		}
+		sequences[p.SequenceID] = append(sequences[p.SequenceID], p)
+	}
+
+	groups := cfg.Groups()
+	answers := make([]string, len(groups))
+	answerTokens := make([]int, len(groups))
+	for i, group := range groups {
+		answers[i] = sampleAnswer(cfg, group.Items)
+		answerTokens[i] = prompt.CountTokens(answers[i])
+	}
+	usages := make([]usage, documents)
+	chunks := make([]int, documents)
+	for _, sequenceID := range order {
+		document, _, _ := strings.Cut(sequenceID, ".")
+		index, err := strconv.Atoi(document)
+		if err != nil || index < 1 || index > documents {
+			continue
+		}
+		sequence := sequences[sequenceID]
+		sort.SliceStable(sequence, func(i, j int) bool { return sequence[i].SequenceNumber < sequence[j].SequenceNumber })
+
+		history := 0
+		for _, p := range sequence {
+			input := history + prompt.CountTokens(p.PromptContent)
+			output := followUpTokens(cfg, p.SequenceNumber, answerTokens)
+			usages[index-1].add(input, output)
+			history = input + output
+		}
+		chunks[index-1]++
+	}
+
+	if cfg.Project.Configuration.MergeStrategy == config.MergeLLMReduce {
+		for i, count := range chunks {
+			if count <= 1 {
+				continue
+			}
+			for g, group := range groups {
+				chunkAnswers := make([]string, count)
+				for k := range chunkAnswers {
+					chunkAnswers[k] = answers[g]
+				}
+				usages[i].add(prompt.CountTokens(prompt.BuildReducePrompt(cfg, group, chunkAnswers)), answerTokens[g])
+			}
+		}
+	}
+	return usages
+}
+
+// add records a request with the given input and output tokens.
+func (u *usage) add(input int, output int) {
+	u.requests++
+	u.input += input
+	u.output += output
+	if input > u.maxRequest {
+		u.maxRequest = input
+	}
+}
+
+// followUpTokens estimates the output tokens of the prompt with the given sequence number:
+// the answers of the review groups, then the follow-ups of cfg.FollowUps in their order.
+func followUpTokens(cfg *config.Config, sequenceNumber int, answerTokens []int) int {
+	if sequenceNumber <= len(answerTokens) {
+		return answerTokens[sequenceNumber-1]
+	}
+	if followUps, index := cfg.FollowUps(), sequenceNumber-len(answerTokens)-1; index < len(followUps) {
+		switch followUps[index].Name {
+		case config.FollowUpJustification:
+			return justificationTokensPerKey * len(cfg.Review)
+		case config.FollowUpSummary:
+			return summaryTokens
+		}
+	}
+	return followUpAnswerTokens
+}
+
+// sampleAnswer builds an answer of the expected format using the longest allowed value of the given
+// review items, identified by their TOML entry keys.
+func sampleAnswer(cfg *config.Config, keys []string) string {
+	answer := make(map[string]string, len(keys))
+	for _, key := range keys {
+		item := cfg.Review[key]
+		var value string
+		switch item.ResolvedType() {
+		case config.ItemTypeEnum:
+			for _, allowed := range item.Values {
+				if len(allowed) > len(value) {
+					value = allowed
+				}
+			}
+		case config.ItemTypeMultiEnum:
+			value = strings.Join(item.Values, "; ")
+		case config.ItemTypeInteger, config.ItemTypeFloat:
+			value = "1000"
+		case config.ItemTypeDate:
+			value = "2000-01-01"
+		case config.ItemTypeBoolean:
+			value = "yes"
+		default:
+			value = strings.TrimSpace(strings.Repeat("word ", textAnswerTokens))
+		}
+		answer[item.Key] = value
+	}
+	data, _ := json.Marshal(answer)
+	return string(data)
+}
+
+// loadPrices returns the bundled price table updated with the prices of the project configuration.
+func loadPrices(cfg *config.Config) ([]Price, error) {
+	prices, err := BundledPrices()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(cfg.Prices))
+	for key := range cfg.Prices {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		item := cfg.Prices[key]
+		override := Price{
+			Provider:      item.Provider,
+			Model:         item.Model,
+			Input:         item.Input,
+			Output:        item.Output,
+			ContextWindow: item.ContextWindow,
+		}
+		replaced := false
+		for i, price := range prices {
+			if price.Provider == item.Provider && price.Model == item.Model {
+				override.Default = price.Default
+				prices[i] = override
+				replaced = true
+			}
+		}
+		if !replaced {
+			prices = append(prices, override)
+		}
+	}
+	return prices, nil
+}
+
+// lookupPrice finds the price of a model. A blank model uses the price set for it in the project
+// configuration, if any, and the default model of the provider otherwise.
+func lookupPrice(prices []Price, provider string, model string) (Price, bool) {
+	for _, price := range prices {
+		if price.Provider == provider && price.Model == model {
+			return price, true
+		}
+	}
+	if model == "" {
+		for _, price := range prices {
+			if price.Provider == provider && price.Default {
+				return price, true
+			}
+		}
+	}
+	return Price{}, false
+}
+
+// Report writes a human-readable summary of the estimate: a table with the usage and cost of every
+// model, followed by the documents exceeding the context window of each model.
+//
+// Arguments:
+// - w: The writer receiving the report.
+//
+// Returns:
+// - An error if writing fails.
+func (e *Estimate) Report(w io.Writer) error {
+	fmt.Fprintf(w, "Dry run for %d documents, no provider was called. Output tokens are estimated.\n\n", e.Documents)
+
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(table, "Provider\tModel\tRequests\tInput tokens\tOutput tokens\tCost (USD)")
+	for _, model := range e.Models {
+		name := model.Model
+		if name == "" {
+			name = "(automatic)"
+			if model.PricedAs != "" {
+				name = fmt.Sprintf("(automatic, priced as %s)", model.PricedAs)
+			}
+		}
+		cost := "unknown price"
+		if model.PricedAs != "" {
+			cost = fmt.Sprintf("%.4f", model.Cost)
+		}
+		fmt.Fprintf(table, "%s\t%s\t%d\t%d\t%d\t%s\n", model.Provider, name, model.Requests, model.InputTokens, model.OutputTokens, cost)
+	}
+	fmt.Fprintf(table, "Total\t\t\t\t\t%.4f\n", e.Total)
+	if err := table.Flush(); err != nil {
+		return err
+	}
+
+	for _, model := range e.Models {
+		if len(model.Oversized) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\nDocuments exceeding the context window of %s %s (%d tokens):\n", model.Provider, model.PricedAs, model.ContextWindow)
+		for _, document := range model.Oversized {
+			fmt.Fprintf(w, "  - %s: %d tokens\n", document.File, document.Tokens)
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
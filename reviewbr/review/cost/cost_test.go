@@ -0,0 +1,136 @@
+package cost
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestBundledPrices(t *testing.T) {
+	prices, err := BundledPrices()
+	if err != nil {
+		t.Fatalf("BundledPrices returned an error: %v", err)
+	}
+
+	defaults := make(map[string]int)
+	seen := make(map[string]bool)
+	for _, price := range prices {
+		key := price.Provider + "/" + price.Model
+		if seen[key] {
+			t.Errorf("Duplicate price for %s", key)
+		}
+		seen[key] = true
+		if price.Input <= 0 || price.Output <= 0 || price.ContextWindow <= 0 {
+			t.Errorf("Incomplete price for %s: %+v", key, price)
+		}
+		if price.Default {
+			defaults[price.Provider]++
+		}
+	}
+	for _, provider := range []string{"OpenAI", "GoogleAI", "Cohere", "Anthropic", "DeepSeek", "Perplexity"} {
+		if defaults[provider] != 1 {
+			t.Errorf("Expected one default model for %s, got %d", provider, defaults[provider])
+		}
+	}
+}
+
+func TestReview(t *testing.T) {
+	inputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "long.txt"), []byte(strings.Repeat("word ", 5000)), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "short.txt"), []byte("A short manuscript."), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{InputDirectory: inputDir, CotJustification: "yes"},
+			LLM: map[string]config.LLMItem{
+				"1": {Provider: "OpenAI", Model: ""},
+				"2": {Provider: "SelfHosted", Model: "llama-3-8b"},
+				"3": {Provider: "SelfHosted", Model: "unknown"},
+			},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "design", Values: []string{"cohort", "case-control"}},
+		},
+		Prices: map[string]config.PriceItem{
+			"1": {Provider: "SelfHosted", Model: "llama-3-8b", Input: 1, Output: 2, ContextWindow: 1000},
+		},
+	}
+
+	estimate, err := Review(cfg)
+	if err != nil {
+		t.Fatalf("Review returned an error: %v", err)
+	}
+	if estimate.Documents != 2 || len(estimate.Models) != 3 {
+		t.Fatalf("Unexpected estimate: %+v", estimate)
+	}
+
+	automatic := estimate.Models[0]
+	if automatic.PricedAs != "gpt-4o-mini" || automatic.Requests != 4 || automatic.Oversized != nil {
+		t.Errorf("Unexpected estimate for the automatic OpenAI model: %+v", automatic)
+	}
+	expectedCost := (float64(automatic.InputTokens)*0.15 + float64(automatic.OutputTokens)*0.60) / 1e6
+	if math.Abs(automatic.Cost-expectedCost) > 1e-12 {
+		t.Errorf("Expected cost %f, got %f", expectedCost, automatic.Cost)
+	}
+
+	overridden := estimate.Models[1]
+	if overridden.PricedAs != "llama-3-8b" || len(overridden.Oversized) != 1 || overridden.Oversized[0].File != "long" {
+		t.Errorf("Expected the long document to exceed the overridden context window: %+v", overridden)
+	}
+	if overridden.InputTokens != automatic.InputTokens || overridden.OutputTokens != automatic.OutputTokens {
+		t.Errorf("Expected the same token counts for every model")
+	}
+
+	unknown := estimate.Models[2]
+	if unknown.PricedAs != "" || unknown.Cost != 0 {
+		t.Errorf("Expected no price for an unknown model: %+v", unknown)
+	}
+	if math.Abs(estimate.Total-automatic.Cost-overridden.Cost) > 1e-12 {
+		t.Errorf("Expected the total to sum the priced models, got %f", estimate.Total)
+	}
+
+	var report bytes.Buffer
+	if err := estimate.Report(&report); err != nil {
+		t.Fatalf("Report returned an error: %v", err)
+	}
+	for _, expected := range []string{"(automatic, priced as gpt-4o-mini)", "unknown price", "context window of SelfHosted llama-3-8b", "  - long: "} {
+		if !strings.Contains(report.String(), expected) {
+			t.Errorf("Expected %q in report:\n%s", expected, report.String())
+		}
+	}
+
+	cfg.Project.Configuration.Repetitions = 3
+	repeated, err := Review(cfg)
+	if err != nil {
+		t.Fatalf("Review returned an error: %v", err)
+	}
+	if model := repeated.Models[0]; model.Requests != 3*automatic.Requests || model.InputTokens != 3*automatic.InputTokens || model.OutputTokens != 3*automatic.OutputTokens {
+		t.Errorf("Expected every document to be counted once per repetition, got %+v", model)
+	}
+}
+
+func TestFollowUpTokens(t *testing.T) {
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{CotJustification: "yes", Summary: "yes"},
+		},
+		Review:          map[string]config.ReviewItem{"1": {Key: "design"}, "2": {Key: "country"}},
+		FollowUpQueries: map[string]config.FollowUpItem{"limitations": {Query: "List the limitations."}},
+	}
+
+	expected := []int{40, 2 * justificationTokensPerKey, summaryTokens, followUpAnswerTokens}
+	for i, tokens := range expected {
+		if estimate := followUpTokens(cfg, i+1, []int{40}); estimate != tokens {
+			t.Errorf("Expected %d output tokens for sequence number %d, got %d", tokens, i+1, estimate)
+		}
+	}
+}
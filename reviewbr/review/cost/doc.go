@@ -0,0 +1,5 @@
+// Package cost estimates the tokens and the cost of a review project without calling any provider.
+// Prompts are generated as for a real run and measured with a tokenizer, follow-up queries are
+// counted with the conversation history they are sent with, and the totals are priced with a
+// bundled price table that project configurations can override.
+package cost
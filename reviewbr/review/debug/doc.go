@@ -1,5 +0,0 @@
-// Package debug provides utilities to aid in debugging and logging within the application. It includes
-// functions for duplicating input files for testing and for configuring logging outputs based on different
-// logging levels. This package allows for streamlined management of debug files and flexible logging
-// configurations that support different output destinations like standard output or log files.
-package debug
@@ -0,0 +1,5 @@
+// Package gold validates the results of a review against a gold standard: a CSV file of manuscripts
+// coded by hand with the same review keys. Answers are aligned by filename and compared for every
+// model, reporting per-key accuracy, per-value precision and recall, confusion matrices and the list
+// of disagreements, as CSV files and a Markdown report.
+package gold
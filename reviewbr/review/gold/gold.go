@@ -0,0 +1,453 @@
+package gold
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/open-and-sustainable/prismaid/logger"
+)
+
+// fileNameColumn is the column holding the manuscript filename in results and gold standard files.
+const fileNameColumn = "File Name"
+
+// emptyLabel stands for empty answers in the reports.
+const emptyLabel = "(empty)"
+
+// documentExtensions are stripped from the filenames of the gold standard, so that manuscripts can be
+// coded under the name of the original document.
+var documentExtensions = []string{".txt", ".pdf", ".docx", ".html", ".htm"}
+
+// Comparison holds the outcome of the comparison of review results with a gold standard.
+type Comparison struct {
+	ResultsPath   string
+	GoldPath      string
+	Keys          []string          // review keys found in both files, in gold standard order
+	Documents     int               // documents of the gold standard
+	Models        []ModelComparison // in order of appearance in the results
+	Disagreements []Disagreement
+}
+
+// ModelComparison holds the metrics of the answers of one model.
+type ModelComparison struct {
+	Provider string
+	Model    string
+	Missing  []string // gold standard documents without results for the model
+	Keys     []KeyMetrics
+}
+
+// KeyMetrics holds the agreement of a model with the gold standard on a review key.
+type KeyMetrics struct {
+	Key       string
+	Compared  int
+	Correct   int
+	Accuracy  float64
+	Values    []ValueMetrics
+	Labels    []string                  // values found in the gold standard or the answers, sorted
+	Confusion map[string]map[string]int // counts by gold value, then by answered value
+}
+
+// ValueMetrics holds the precision and recall of a model on one value of a review key.
+type ValueMetrics struct {
+	Value     string
+	Precision float64
+	Recall    float64
+	F1        float64
+	Support   int // occurrences in the gold standard
+	Predicted int // occurrences in the answers
+}
+
+// Disagreement is an answer differing from the gold standard.
+type Disagreement struct {
+	Provider string
+	Model    string
+	File     string
+	Key      string
+	Gold     string
+	Answer   string
+}
+
+// row is a set of answers of a model for a document.
+type row struct {
+	provider string
+	model    string
+	file     string
+	values   map[string]string
+}
+
+// Compare aligns the results of a review with a gold standard by filename and compares the answers
+// of every model on the review keys present in both files. Values are compared ignoring case,
+// surrounding spaces and the order of multiple values separated by semicolons.
+//
+// Arguments:
+// - resultsPath: The results file of the review, in CSV, JSON or JSON Lines format.
+// - goldPath: The CSV file of the manuscripts coded by hand, with a "File Name" column and a column per review key.
+//
+// Returns:
+// - A pointer to the comparison.
+// - An error if the files cannot be read or have no review key in common.
+func Compare(resultsPath string, goldPath string) (*Comparison, error) {
+	goldKeys, goldRows, err := readCSV(goldPath)
+	if err != nil {
+		return nil, err
+	}
+	_, answers, err := readResults(resultsPath)
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &Comparison{ResultsPath: resultsPath, GoldPath: goldPath}
+	available := make(map[string]bool)
+	for _, answer := range answers {
+		for key := range answer.values {
+			available[key] = true
+		}
+	}
+	for _, key := range goldKeys {
+		if available[key] {
+			comparison.Keys = append(comparison.Keys, key)
+		}
+	}
+	if len(comparison.Keys) == 0 {
+		return nil, fmt.Errorf("no review key in common between %s and %s", resultsPath, goldPath)
+	}
+
+	gold := make(map[string]map[string]string)
+	var documents []string
+	for _, goldRow := range goldRows {
+		file := documentName(goldRow.file)
+		if _, seen := gold[file]; seen {
+			logger.Error("Duplicate gold standard entry for %s, using the first one", file)
+			continue
+		}
+		gold[file] = goldRow.values
+		documents = append(documents, file)
+	}
+	comparison.Documents = len(documents)
+
+	// group answers by model, keeping the first answer of each model for each document
+	var models []*ModelComparison
+	byModel := make(map[string]map[string]map[string]string)
+	for _, answer := range answers {
+		modelKey := answer.provider + "/" + answer.model
+		if byModel[modelKey] == nil {
+			byModel[modelKey] = make(map[string]map[string]string)
+			models = append(models, &ModelComparison{Provider: answer.provider, Model: answer.model})
+		}
+		if _, seen := byModel[modelKey][answer.file]; !seen {
+			byModel[modelKey][answer.file] = answer.values
+		}
+	}
+
+	for _, model := range models {
+		modelAnswers := byModel[model.Provider+"/"+model.Model]
+		for _, file := range documents {
+			if _, ok := modelAnswers[file]; !ok {
+				model.Missing = append(model.Missing, file)
+			}
+		}
+		for _, key := range comparison.Keys {
+			metrics := KeyMetrics{Key: key, Confusion: make(map[string]map[string]int)}
+			for _, file := range documents {
+				values, ok := modelAnswers[file]
+				if !ok {
+					continue
+				}
+				expected, answered := normalize(gold[file][key]), normalize(values[key])
+				metrics.Compared++
+				if metrics.Confusion[expected] == nil {
+					metrics.Confusion[expected] = make(map[string]int)
+				}
+				metrics.Confusion[expected][answered]++
+				if expected == answered {
+					metrics.Correct++
+				} else {
+					comparison.Disagreements = append(comparison.Disagreements, Disagreement{
+						Provider: model.Provider,
+						Model:    model.Model,
+						File:     file,
+						Key:      key,
+						Gold:     gold[file][key],
+						Answer:   values[key],
+					})
+				}
+			}
+			metrics.computeValues()
+			model.Keys = append(model.Keys, metrics)
+		}
+		comparison.Models = append(comparison.Models, *model)
+	}
+	return comparison, nil
+}
+
+// computeValues derives the accuracy and the per-value metrics from the confusion matrix.
+func (m *KeyMetrics) computeValues() {
+	if m.Compared > 0 {
+		m.Accuracy = float64(m.Correct) / float64(m.Compared)
+	}
+
+	support := make(map[string]int)
+	predicted := make(map[string]int)
+	for expected, answers := range m.Confusion {
+		for answered, count := range answers {
+			support[expected] += count
+			predicted[answered] += count
+		}
+	}
+	labels := make(map[string]bool)
+	for label := range support {
+		labels[label] = true
+	}
+	for label := range predicted {
+		labels[label] = true
+	}
+	for label := range labels {
+		m.Labels = append(m.Labels, label)
+	}
+	sort.Strings(m.Labels)
+
+	for _, label := range m.Labels {
+		value := ValueMetrics{Value: label, Support: support[label], Predicted: predicted[label]}
+		correct := m.Confusion[label][label]
+		if value.Predicted > 0 {
+			value.Precision = float64(correct) / float64(value.Predicted)
+		}
+		if value.Support > 0 {
+			value.Recall = float64(correct) / float64(value.Support)
+		}
+		if value.Precision+value.Recall > 0 {
+			value.F1 = 2 * value.Precision * value.Recall / (value.Precision + value.Recall)
+		}
+		m.Values = append(m.Values, value)
+	}
+}
+
+// normalize brings a value to the form used for comparison: lowercase, trimmed, with multiple
+// values separated by semicolons sorted alphabetically. Empty values are reported as emptyLabel.
+func normalize(value string) string {
+	var parts []string
+	for _, part := range strings.Split(value, ";") {
+		if part = strings.ToLower(strings.Join(strings.Fields(part), " ")); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return emptyLabel
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "; ")
+}
+
+// documentName strips the document extensions from a filename of the gold standard.
+func documentName(filename string) string {
+	filename = strings.TrimSpace(filename)
+	extension := strings.ToLower(filepath.Ext(filename))
+	for _, documentExtension := range documentExtensions {
+		if extension == documentExtension {
+			return strings.TrimSuffix(filename, filepath.Ext(filename))
+		}
+	}
+	return filename
+}
+
+// readResults reads the answers of a results file in CSV, JSON or JSON Lines format.
+func readResults(path string) ([]string, []row, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json", ".jsonl":
+		return readJSON(path)
+	default:
+		return readCSV(path)
+	}
+}
+
+// readCSV reads a CSV file with a "File Name" column and, for results, "Provider" and "Model" columns.
+// The other columns are review keys, unless the file has "Key" and "Value" columns, as results in the
+// long layout, whose rows are gathered by file and model.
+func readCSV(path string) ([]string, []row, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		logger.Error("Error opening %s: %v", path, err)
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		logger.Error("Error reading %s: %v", path, err)
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("%s is empty", path)
+	}
+
+	header := records[0]
+	for i := range header {
+		header[i] = strings.TrimSpace(strings.TrimPrefix(header[i], "\ufeff"))
+	}
+	fileColumn := -1
+	var keys []string
+	for i, column := range header {
+		switch column {
+		case fileNameColumn:
+			fileColumn = i
+		case "Provider", "Model":
+		default:
+			keys = append(keys, column)
+		}
+	}
+	if fileColumn < 0 {
+		return nil, nil, fmt.Errorf("%s has no %q column", path, fileNameColumn)
+	}
+	if slices.Contains(header, "Key") && slices.Contains(header, "Value") {
+		keys, rows := longRows(header, records[1:])
+		return keys, rows, nil
+	}
+
+	var rows []row
+	for _, record := range records[1:] {
+		r := row{values: make(map[string]string)}
+		for i, column := range header {
+			if i >= len(record) {
+				break
+			}
+			switch column {
+			case fileNameColumn:
+				r.file = record[i]
+			case "Provider":
+				r.provider = record[i]
+			case "Model":
+				r.model = record[i]
+			default:
+				r.values[column] = record[i]
+			}
+		}
+		if strings.TrimSpace(r.file) != "" {
+			rows = append(rows, r)
+		}
+	}
+	return keys, rows, nil
+}
+
+// longRows gathers the records of a results file in the long layout, with one record per file, model
+// and key, into one row per file and model. Keys are returned in order of first appearance.
+func longRows(header []string, records [][]string) ([]string, []row) {
+	columns := make(map[string]int, len(header))
+	for i, column := range header {
+		columns[column] = i
+	}
+	field := func(record []string, column string) string {
+		if i, ok := columns[column]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var keys []string
+	var rows []row
+	seenKeys := make(map[string]bool)
+	index := make(map[[3]string]int)
+	for _, record := range records {
+		r := row{provider: field(record, "Provider"), model: field(record, "Model"), file: field(record, fileNameColumn)}
+		if strings.TrimSpace(r.file) == "" {
+			continue
+		}
+		key := field(record, "Key")
+		if !seenKeys[key] {
+			seenKeys[key] = true
+			keys = append(keys, key)
+		}
+		id := [3]string{r.file, r.provider, r.model}
+		i, ok := index[id]
+		if !ok {
+			r.values = make(map[string]string)
+			rows = append(rows, r)
+			i = len(rows) - 1
+			index[id] = i
+		}
+		rows[i].values[key] = field(record, "Value")
+	}
+	return keys, rows
+}
+
+// readJSON reads the answers of a results file in JSON or JSON Lines format. Objects of follow-up
+// queries (justifications and summaries) are skipped.
+func readJSON(path string) ([]string, []row, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		logger.Error("Error reading %s: %v", path, err)
+		return nil, nil, err
+	}
+	var objects []map[string]any
+	if strings.ToLower(filepath.Ext(path)) == ".jsonl" {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		for decoder.More() {
+			var object map[string]any
+			if err := decoder.Decode(&object); err != nil {
+				logger.Error("Error parsing %s: %v", path, err)
+				return nil, nil, err
+			}
+			objects = append(objects, object)
+		}
+	} else if err := json.Unmarshal(data, &objects); err != nil {
+		logger.Error("Error parsing %s: %v", path, err)
+		return nil, nil, err
+	}
+
+	seen := make(map[string]bool)
+	var keys []string
+	var rows []row
+	for _, object := range objects {
+		if _, ok := object["justifications"]; ok {
+			continue
+		}
+		if _, ok := object["summary"]; ok {
+			continue
+		}
+		r := row{values: make(map[string]string)}
+		for key, value := range object {
+			switch key {
+			case "filename":
+				r.file = fmt.Sprint(value)
+			case "provider":
+				r.provider = fmt.Sprint(value)
+			case "model":
+				r.model = fmt.Sprint(value)
+			default:
+				r.values[key] = formatValue(value)
+				if !seen[key] {
+					seen[key] = true
+					keys = append(keys, key)
+				}
+			}
+		}
+		rows = append(rows, r)
+	}
+	sort.Strings(keys)
+	return keys, rows, nil
+}
+
+// formatValue converts a JSON answer to text, joining lists with semicolons.
+func formatValue(value any) string {
+	switch typed := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return typed
+	case []any:
+		parts := make([]string, len(typed))
+		for i, element := range typed {
+			parts[i] = formatValue(element)
+		}
+		return strings.Join(parts, "; ")
+	default:
+		return fmt.Sprint(typed)
+	}
+}
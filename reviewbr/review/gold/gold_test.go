@@ -0,0 +1,182 @@
+package gold
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const goldCSV = `File Name,design,countries,notes
+paper1.pdf,cohort,Italy; Spain,read twice
+paper2.pdf,trial,France,
+paper3.pdf,cohort,,
+`
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", name, err)
+	}
+	return path
+}
+
+func TestCompare(t *testing.T) {
+	dir := t.TempDir()
+	goldPath := writeFile(t, dir, "gold.csv", goldCSV)
+	resultsPath := writeFile(t, dir, "results.csv", `Provider,Model,File Name,countries,design
+OpenAI,gpt-4o-mini,paper1,spain;  italy,Cohort
+OpenAI,gpt-4o-mini,paper2,France,cohort
+OpenAI,gpt-4o-mini,paper3,,cohort
+Anthropic,claude-3-5-haiku,paper1,Italy,cohort
+OpenAI,gpt-4o-mini,paper9,Peru,trial
+`)
+
+	comparison, err := Compare(resultsPath, goldPath)
+	if err != nil {
+		t.Fatalf("Compare returned an error: %v", err)
+	}
+	if strings.Join(comparison.Keys, ",") != "design,countries" || comparison.Documents != 3 {
+		t.Fatalf("Unexpected keys or documents: %v, %d", comparison.Keys, comparison.Documents)
+	}
+	if len(comparison.Models) != 2 {
+		t.Fatalf("Expected 2 models, got %d", len(comparison.Models))
+	}
+
+	openai := comparison.Models[0]
+	design := openai.Keys[0]
+	if design.Compared != 3 || design.Correct != 2 || math.Abs(design.Accuracy-2.0/3) > 1e-9 {
+		t.Errorf("Unexpected design metrics: %+v", design)
+	}
+	if design.Confusion["trial"]["cohort"] != 1 {
+		t.Errorf("Expected the trial coded as cohort in the confusion matrix, got %v", design.Confusion)
+	}
+	for _, value := range design.Values {
+		switch value.Value {
+		case "cohort":
+			if math.Abs(value.Precision-2.0/3) > 1e-9 || value.Recall != 1 || value.Support != 2 || value.Predicted != 3 {
+				t.Errorf("Unexpected metrics for cohort: %+v", value)
+			}
+		case "trial":
+			if value.Precision != 0 || value.Recall != 0 || value.Support != 1 {
+				t.Errorf("Unexpected metrics for trial: %+v", value)
+			}
+		default:
+			t.Errorf("Unexpected value %q", value.Value)
+		}
+	}
+	if countries := openai.Keys[1]; countries.Correct != 3 {
+		t.Errorf("Expected multiple values and empty answers to match regardless of order and case: %+v", countries)
+	}
+
+	anthropic := comparison.Models[1]
+	if strings.Join(anthropic.Missing, ",") != "paper2,paper3" || anthropic.Keys[0].Compared != 1 {
+		t.Errorf("Expected missing documents to be left out of the metrics: %+v", anthropic)
+	}
+
+	if len(comparison.Disagreements) != 2 {
+		t.Fatalf("Expected 2 disagreements, got %+v", comparison.Disagreements)
+	}
+	expected := Disagreement{Provider: "OpenAI", Model: "gpt-4o-mini", File: "paper2", Key: "design", Gold: "trial", Answer: "cohort"}
+	if comparison.Disagreements[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, comparison.Disagreements[0])
+	}
+}
+
+func TestCompareJSONResults(t *testing.T) {
+	dir := t.TempDir()
+	goldPath := writeFile(t, dir, "gold.csv", goldCSV)
+	resultsPath := writeFile(t, dir, "results.json", `[
+{"provider": "OpenAI", "model": "gpt-4o-mini", "filename": "paper1", "design": "cohort", "countries": ["Spain", "Italy"]},
+{"provider": "OpenAI", "model": "gpt-4o-mini", "filename": "paper1", "justifications": {"design": {}}},
+{"provider": "OpenAI", "model": "gpt-4o-mini", "filename": "paper2", "design": "trial", "countries": "France"}
+]`)
+
+	comparison, err := Compare(resultsPath, goldPath)
+	if err != nil {
+		t.Fatalf("Compare returned an error: %v", err)
+	}
+	if len(comparison.Models) != 1 || len(comparison.Disagreements) != 0 {
+		t.Fatalf("Unexpected comparison: %+v", comparison)
+	}
+	if keys := comparison.Models[0].Keys; keys[0].Correct != 2 || keys[1].Correct != 2 {
+		t.Errorf("Expected every answer to match the gold standard: %+v", keys)
+	}
+}
+
+func TestCompareLongResults(t *testing.T) {
+	dir := t.TempDir()
+	goldPath := writeFile(t, dir, "gold.csv", goldCSV)
+	resultsPath := writeFile(t, dir, "results.csv", `File Name,Provider,Model,Key,Value,Summary
+paper1,OpenAI,gpt-4o-mini,design,cohort,A cohort.
+paper1,OpenAI,gpt-4o-mini,countries,Spain; Italy,A cohort.
+paper2,OpenAI,gpt-4o-mini,design,cohort,A trial.
+paper2,OpenAI,gpt-4o-mini,countries,France,A trial.
+`)
+
+	comparison, err := Compare(resultsPath, goldPath)
+	if err != nil {
+		t.Fatalf("Compare returned an error: %v", err)
+	}
+	if len(comparison.Models) != 1 || len(comparison.Disagreements) != 1 {
+		t.Fatalf("Expected one model with one disagreement: %+v", comparison)
+	}
+	if keys := comparison.Models[0].Keys; keys[0].Correct != 1 || keys[1].Correct != 2 {
+		t.Errorf("Unexpected key accuracy: %+v", keys)
+	}
+}
+
+func TestCompareWithoutCommonKeys(t *testing.T) {
+	dir := t.TempDir()
+	goldPath := writeFile(t, dir, "gold.csv", goldCSV)
+	resultsPath := writeFile(t, dir, "results.csv", "Provider,Model,File Name,scale\nOpenAI,gpt-4o-mini,paper1,world\n")
+
+	if _, err := Compare(resultsPath, goldPath); err == nil {
+		t.Errorf("Expected an error without review keys in common")
+	}
+}
+
+func TestSave(t *testing.T) {
+	dir := t.TempDir()
+	goldPath := writeFile(t, dir, "gold.csv", goldCSV)
+	resultsPath := writeFile(t, dir, "results.csv", "Provider,Model,File Name,design\nOpenAI,gpt-4o-mini,paper1,trial\n")
+
+	comparison, err := Compare(resultsPath, goldPath)
+	if err != nil {
+		t.Fatalf("Compare returned an error: %v", err)
+	}
+	paths, err := comparison.Save(ReportBase(resultsPath))
+	if err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	if len(paths) != 4 {
+		t.Fatalf("Expected 4 report files, got %v", paths)
+	}
+
+	expected := map[string]string{
+		"results_accuracy.csv":      "OpenAI,gpt-4o-mini,design,,1,0,0.000,,,,,\n",
+		"results_confusion.csv":     "OpenAI,gpt-4o-mini,design,cohort,trial,1\n",
+		"results_disagreements.csv": "OpenAI,gpt-4o-mini,paper1,design,cohort,trial\n",
+		"results_accuracy.md":       "| | cohort | trial |\n|---|---|---|\n| cohort | 0 | 1 |\n| trial | 0 | 0 |\n",
+	}
+	for name, fragment := range expected {
+		content, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatalf("Expected report %s: %v", name, err)
+		}
+		if !strings.Contains(string(content), fragment) {
+			t.Errorf("Expected %q in %s:\n%s", fragment, name, content)
+		}
+	}
+
+	var report bytes.Buffer
+	if err := comparison.Markdown(&report); err != nil {
+		t.Fatalf("Markdown returned an error: %v", err)
+	}
+	if !strings.Contains(report.String(), "No results for: paper2, paper3.") {
+		t.Errorf("Expected the documents without results in the report:\n%s", report.String())
+	}
+}
@@ -0,0 +1,197 @@
+package gold
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/open-and-sustainable/prismaid/logger"
+)
+
+// Suffixes of the report files, appended to the results file name without extension.
+const (
+	accuracySuffix      = "_accuracy.csv"
+	confusionSuffix     = "_confusion.csv"
+	disagreementsSuffix = "_disagreements.csv"
+	markdownSuffix      = "_accuracy.md"
+)
+
+// ReportBase returns the base name of the reports of a results file: its path without extension.
+//
+// Arguments:
+// - resultsPath: The results file of the review.
+//
+// Returns:
+// - The path to which the report suffixes are appended.
+func ReportBase(resultsPath string) string {
+	return strings.TrimSuffix(resultsPath, filepath.Ext(resultsPath))
+}
+
+// Save writes the comparison as CSV files and a Markdown report:
+// <base>_accuracy.csv with the per-key accuracy and the per-value precision and recall of every model,
+// <base>_confusion.csv with the confusion matrices in long format,
+// <base>_disagreements.csv with the answers differing from the gold standard,
+// and <base>_accuracy.md with all of the above.
+//
+// Arguments:
+// - base: The base name of the reports, usually ReportBase of the results file.
+//
+// Returns:
+// - The paths of the written files.
+// - An error if any file cannot be written.
+func (c *Comparison) Save(base string) ([]string, error) {
+	writers := []struct {
+		suffix string
+		write  func(io.Writer) error
+	}{
+		{accuracySuffix, c.writeAccuracy},
+		{confusionSuffix, c.writeConfusion},
+		{disagreementsSuffix, c.writeDisagreements},
+		{markdownSuffix, c.Markdown},
+	}
+
+	var paths []string
+	for _, writer := range writers {
+		path := base + writer.suffix
+		file, err := os.Create(path)
+		if err != nil {
+			logger.Error("Error creating %s: %v", path, err)
+			return paths, err
+		}
+		err = writer.write(file)
+		if closeErr := file.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			logger.Error("Error writing %s: %v", path, err)
+			return paths, err
+		}
+		paths = append(paths, path)
+	}
+	return paths, nil
+}
+
+// writeAccuracy writes one row per model and key with its accuracy, followed by one row per value
+// with its precision, recall and F1 score.
+func (c *Comparison) writeAccuracy(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"Provider", "Model", "Key", "Value", "Compared", "Correct", "Accuracy", "Precision", "Recall", "F1", "Support", "Predicted"})
+	for _, model := range c.Models {
+		for _, key := range model.Keys {
+			writer.Write([]string{model.Provider, model.Model, key.Key, "", strconv.Itoa(key.Compared), strconv.Itoa(key.Correct), formatScore(key.Accuracy), "", "", "", "", ""})
+			for _, value := range key.Values {
+				writer.Write([]string{
+					model.Provider, model.Model, key.Key, value.Value, "", "", "",
+					formatScore(value.Precision), formatScore(value.Recall), formatScore(value.F1),
+					strconv.Itoa(value.Support), strconv.Itoa(value.Predicted),
+				})
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeConfusion writes the non-empty cells of the confusion matrices.
+func (c *Comparison) writeConfusion(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"Provider", "Model", "Key", "Gold", "Answer", "Count"})
+	for _, model := range c.Models {
+		for _, key := range model.Keys {
+			for _, expected := range key.Labels {
+				for _, answered := range key.Labels {
+					if count := key.Confusion[expected][answered]; count > 0 {
+						writer.Write([]string{model.Provider, model.Model, key.Key, expected, answered, strconv.Itoa(count)})
+					}
+				}
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// writeDisagreements writes the answers differing from the gold standard, as found in the files.
+func (c *Comparison) writeDisagreements(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"Provider", "Model", "File Name", "Key", "Gold", "Answer"})
+	for _, disagreement := range c.Disagreements {
+		writer.Write([]string{disagreement.Provider, disagreement.Model, disagreement.File, disagreement.Key, disagreement.Gold, disagreement.Answer})
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// Markdown writes a human-readable report of the comparison: per-key accuracy, per-value metrics and
+// confusion matrices for every model, followed by the list of disagreements.
+//
+// Arguments:
+// - w: The writer receiving the report.
+//
+// Returns:
+// - An error if writing fails.
+func (c *Comparison) Markdown(w io.Writer) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Validation against the gold standard\n\n")
+	fmt.Fprintf(&b, "Results `%s` compared with %d manuscripts coded in `%s`.\n", c.ResultsPath, c.Documents, c.GoldPath)
+
+	for _, model := range c.Models {
+		fmt.Fprintf(&b, "\n## %s %s\n\n", model.Provider, model.Model)
+		if len(model.Missing) > 0 {
+			fmt.Fprintf(&b, "No results for: %s.\n\n", strings.Join(model.Missing, ", "))
+		}
+		b.WriteString("| Key | Compared | Correct | Accuracy |\n|---|---|---|---|\n")
+		for _, key := range model.Keys {
+			fmt.Fprintf(&b, "| %s | %d | %d | %s |\n", cell(key.Key), key.Compared, key.Correct, formatScore(key.Accuracy))
+		}
+
+		for _, key := range model.Keys {
+			fmt.Fprintf(&b, "\n### %s\n\n", key.Key)
+			b.WriteString("| Value | Precision | Recall | F1 | Support |\n|---|---|---|---|---|\n")
+			for _, value := range key.Values {
+				fmt.Fprintf(&b, "| %s | %s | %s | %s | %d |\n", cell(value.Value), formatScore(value.Precision), formatScore(value.Recall), formatScore(value.F1), value.Support)
+			}
+
+			b.WriteString("\nConfusion matrix (rows: gold standard, columns: answers):\n\n| |")
+			for _, label := range key.Labels {
+				fmt.Fprintf(&b, " %s |", cell(label))
+			}
+			b.WriteString("\n|---|" + strings.Repeat("---|", len(key.Labels)) + "\n")
+			for _, expected := range key.Labels {
+				fmt.Fprintf(&b, "| %s |", cell(expected))
+				for _, answered := range key.Labels {
+					fmt.Fprintf(&b, " %d |", key.Confusion[expected][answered])
+				}
+				b.WriteString("\n")
+			}
+		}
+	}
+
+	b.WriteString("\n## Disagreements\n\n")
+	if len(c.Disagreements) == 0 {
+		b.WriteString("None.\n")
+	} else {
+		b.WriteString("| Provider | Model | File Name | Key | Gold | Answer |\n|---|---|---|---|---|---|\n")
+		for _, d := range c.Disagreements {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s |\n", cell(d.Provider), cell(d.Model), cell(d.File), cell(d.Key), cell(d.Gold), cell(d.Answer))
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// formatScore formats a score between 0 and 1 with three decimals.
+func formatScore(score float64) string {
+	return strconv.FormatFloat(score, 'f', 3, 64)
+}
+
+// cell escapes a value for a Markdown table cell.
+func cell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.Join(strings.Fields(value), " ")
+}
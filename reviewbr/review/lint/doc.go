@@ -0,0 +1,5 @@
+// Package lint checks a review configuration before a run. It reports, with the TOML line of each
+// offending key, the problems that would otherwise only surface during the review: typos in option
+// names and values, unsupported providers and unknown models, missing API keys, missing input
+// directories, results that cannot be written, and duplicate or incomplete review items.
+package lint
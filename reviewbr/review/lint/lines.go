@@ -0,0 +1,91 @@
+package lint
+
+import (
+	"strings"
+)
+
+// keyLines maps the dotted TOML keys and table names of a configuration to the line where they are
+// first defined, starting at 1.
+type keyLines map[string]int
+
+// indexLines scans a TOML configuration for table headers and key/value pairs and records their line
+// numbers. Lines inside multi-line strings are skipped. The scan is lenient: it only serves to locate
+// problems, the configuration itself is parsed by the TOML decoder.
+//
+// Arguments:
+// - tomlConfiguration: The TOML configuration text.
+//
+// Returns:
+// - The line of every key and table found.
+func indexLines(tomlConfiguration string) keyLines {
+	lines := make(keyLines)
+	table := ""
+	multiline := ""
+	for i, line := range strings.Split(tomlConfiguration, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if multiline != "" {
+			if strings.Count(trimmed, multiline)%2 == 1 {
+				multiline = ""
+			}
+			continue
+		}
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			end := strings.LastIndex(trimmed, "]")
+			if end < 0 {
+				continue
+			}
+			table = normalizeKey(strings.Trim(trimmed[:end+1], "[]"))
+			lines.add(table, i+1)
+			continue
+		}
+
+		equals := strings.Index(trimmed, "=")
+		if equals <= 0 {
+			continue
+		}
+		key := normalizeKey(trimmed[:equals])
+		if table != "" {
+			key = table + "." + key
+		}
+		lines.add(key, i+1)
+
+		value := trimmed[equals+1:]
+		for _, delimiter := range []string{`"""`, `'''`} {
+			if strings.Count(value, delimiter)%2 == 1 {
+				multiline = delimiter
+			}
+		}
+	}
+	return lines
+}
+
+// add records the line of a key, keeping the first definition.
+func (l keyLines) add(key string, line int) {
+	if _, ok := l[key]; !ok {
+		l[key] = line
+	}
+}
+
+// find returns the line of a key given by its parts. When the key is not defined, the line of its
+// closest enclosing table is returned, and 0 if none is found.
+func (l keyLines) find(key ...string) int {
+	for n := len(key); n > 0; n-- {
+		if line, ok := l[strings.Join(key[:n], ".")]; ok {
+			return line
+		}
+	}
+	return 0
+}
+
+// normalizeKey removes the spaces and quotes around the parts of a dotted TOML key.
+func normalizeKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, part := range parts {
+		parts[i] = strings.Trim(strings.TrimSpace(part), `"'`)
+	}
+	return strings.Join(parts, ".")
+}
@@ -0,0 +1,524 @@
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/BurntSushi/toml"
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/cost"
+	"github.com/open-and-sustainable/prismaid/review/prompt"
+	"github.com/open-and-sustainable/prismaid/review/records"
+	"github.com/open-and-sustainable/prismaid/secrets"
+	"github.com/open-and-sustainable/prismaid/tomlinclude"
+)
+
+// Severities of the problems found in a configuration.
+const (
+	SeverityError   = "error"   // the review would fail or run with wrong settings
+	SeverityWarning = "warning" // the review can run, but the setting is likely a mistake
+)
+
+// Problem is an issue found in a review configuration.
+type Problem struct {
+	Line     int    // TOML line of the offending key or of its table, 0 if it cannot be located
+	Key      string // dotted TOML key, such as "project.llm.1.provider"
+	Severity string // SeverityError or SeverityWarning
+	Message  string
+}
+
+// Report holds the problems found in a review configuration, sorted by line.
+type Report struct {
+	Problems []Problem
+}
+
+// checker collects the problems of a configuration, locating them through the line index.
+type checker struct {
+	lines    keyLines
+	problems []Problem
+}
+
+// Allowed values of the options of [project.configuration]; empty values select the default.
+var allowedOptions = []struct {
+	key    string
+	value  func(c config.ProjectConfiguration) string
+	values []string
+}{
+	{"output_format", func(c config.ProjectConfiguration) string { return c.OutputFormat }, []string{"csv", "json", "jsonl", "xlsx"}},
+	{"output_layout", func(c config.ProjectConfiguration) string { return c.OutputLayout }, []string{config.LayoutWide, config.LayoutLong}},
+	{"log_level", func(c config.ProjectConfiguration) string { return c.LogLevel }, []string{"low", "medium", "high"}},
+	{"cot_justification", func(c config.ProjectConfiguration) string { return c.CotJustification }, []string{"yes", "no"}},
+	{"duplication", func(c config.ProjectConfiguration) string { return c.Duplication }, []string{"yes", "no"}},
+	{"summary", func(c config.ProjectConfiguration) string { return c.Summary }, []string{"yes", "no"}},
+	{"resume", func(c config.ProjectConfiguration) string { return c.Resume }, []string{"yes", "no"}},
+	{"incremental", func(c config.ProjectConfiguration) string { return c.Incremental }, []string{"yes", "no"}},
+	{"chunking", func(c config.ProjectConfiguration) string { return c.Chunking }, []string{"yes", "no"}},
+	{"merge_strategy", func(c config.ProjectConfiguration) string { return c.MergeStrategy }, []string{config.MergeFirstNonEmpty, config.MergeUnion, config.MergeLLMReduce}},
+	{"examples_selection", func(c config.ProjectConfiguration) string { return c.ExamplesSelection }, []string{config.ExamplesAll, config.ExamplesSimilar}},
+}
+
+// Check validates a review configuration and reports every problem found, with the TOML line of the
+// offending key. Beyond the checks of config.LoadConfig, it verifies that the input directory exists
+// and holds manuscripts, or that the screening results reviewed in its place can be read, that the
+// results can be written, that the providers are supported and the models known, that every model
+// has an API key, and that review keys are unique and have values.
+// API keys written in the configuration, instead of referred to, are reported as warnings.
+// Unknown keys, often typos of option names, are reported as warnings. Configurations extending base
+// files are checked once merged; problems in keys inherited from a base file have the line of their
+// table in the configuration, if any, or no line.
+//
+// Arguments:
+// - tomlConfiguration: The TOML configuration of the review project.
+// - envReader: The reader of the environment variables holding API keys, completed by the .env file
+// of the working directory.
+//
+// Returns:
+// - The report of the problems found, empty if the configuration is valid.
+func Check(tomlConfiguration string, envReader config.EnvReader) *Report {
+	c := &checker{lines: indexLines(tomlConfiguration)}
+
+	envReader, err := config.WithDotEnv(envReader, secrets.DotEnvFile)
+	if err != nil {
+		c.problems = append(c.problems, Problem{Severity: SeverityError, Message: err.Error()})
+		return c.report()
+	}
+
+	var cfg config.Config
+	var undecoded []toml.Key
+	resolved, err := tomlinclude.Resolve(tomlConfiguration)
+	if err == nil {
+		var metadata toml.MetaData
+		metadata, err = toml.Decode(resolved, &cfg)
+		undecoded = metadata.Undecoded()
+	}
+	if err != nil {
+		line := 0
+		var parseError toml.ParseError
+		// positions in a merged configuration do not match the lines of the file
+		if errors.As(err, &parseError) && (resolved == "" || resolved == tomlConfiguration) {
+			line = parseError.Position.Line
+		}
+		c.problems = append(c.problems, Problem{Line: line, Severity: SeverityError, Message: err.Error()})
+		return c.report()
+	}
+
+	c.checkUndecoded(undecoded)
+	c.checkProject(cfg.Project.Configuration)
+	c.checkModels(&cfg, envReader)
+	c.checkPrompt(cfg.Prompt)
+	c.checkInternalPrompts(cfg.InternalPrompts)
+	c.checkReview(&cfg)
+	c.checkFollowUps(cfg.FollowUpQueries)
+
+	// the remaining rules of LoadConfig, such as option combinations, are reported without a line
+	if !c.hasErrors() {
+		if _, err := config.LoadConfig(tomlConfiguration, envReader); err != nil {
+			c.problems = append(c.problems, Problem{Severity: SeverityError, Message: secrets.RedactError(err)})
+		}
+	}
+	return c.report()
+}
+
+// add records a problem on a key given by its parts.
+func (c *checker) add(severity string, message string, key ...string) {
+	c.problems = append(c.problems, Problem{
+		Line:     c.lines.find(key...),
+		Key:      strings.Join(key, "."),
+		Severity: severity,
+		Message:  message,
+	})
+}
+
+// hasErrors reports whether an error was found.
+func (c *checker) hasErrors() bool {
+	for _, problem := range c.problems {
+		if problem.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
+
+// report returns the problems found, sorted by line.
+func (c *checker) report() *Report {
+	sort.SliceStable(c.problems, func(i, j int) bool { return c.problems[i].Line < c.problems[j].Line })
+	return &Report{Problems: c.problems}
+}
+
+// checkUndecoded reports the keys that do not match any configuration field. Only the outermost
+// unknown key is reported, not the keys of an unknown table.
+func (c *checker) checkUndecoded(keys []toml.Key) {
+	unknown := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		unknown[strings.Join(key, ".")] = true
+	}
+	for _, key := range keys {
+		if len(key) > 1 && unknown[strings.Join(key[:len(key)-1], ".")] {
+			continue
+		}
+		c.add(SeverityWarning, "unknown key, it is ignored", key...)
+	}
+}
+
+// checkProject checks the options of [project.configuration] and the files and directories they refer to.
+func (c *checker) checkProject(configuration config.ProjectConfiguration) {
+	section := []string{"project", "configuration"}
+	key := func(name string) []string { return append(slices.Clone(section), name) }
+
+	for _, option := range allowedOptions {
+		if value := option.value(configuration); value != "" && !slices.Contains(option.values, value) {
+			c.add(SeverityError, fmt.Sprintf("unsupported value '%s', use one of: %s", value, strings.Join(option.values, ", ")), key(option.key)...)
+		}
+	}
+
+	if configuration.ScreeningResults != "" {
+		c.checkScreeningResults(configuration, key)
+	} else if configuration.InputDirectory == "" {
+		c.add(SeverityError, "input_directory is not set", section...)
+	} else if info, err := os.Stat(configuration.InputDirectory); err != nil || !info.IsDir() {
+		c.add(SeverityError, fmt.Sprintf("input directory '%s' does not exist", configuration.InputDirectory), key("input_directory")...)
+	} else if matches, _ := filepath.Glob(filepath.Join(configuration.InputDirectory, "*.txt")); len(matches) == 0 {
+		c.add(SeverityWarning, fmt.Sprintf("input directory '%s' has no .txt manuscripts", configuration.InputDirectory), key("input_directory")...)
+	}
+
+	if configuration.ResultsFileName == "" {
+		c.add(SeverityError, "results_file_name is not set", section...)
+	} else if err := checkWritable(filepath.Dir(configuration.ResultsFileName)); err != nil {
+		c.add(SeverityError, fmt.Sprintf("results cannot be written: %v", err), key("results_file_name")...)
+	}
+
+	if configuration.Duplication == "yes" {
+		c.add(SeverityWarning, "duplication is deprecated, use repetitions = 2 to measure the stability of the answers", key("duplication")...)
+	}
+	if configuration.Repetitions < 0 {
+		c.add(SeverityWarning, "negative repetitions, every document is sent once", key("repetitions")...)
+	}
+	if configuration.SchemaRetries < 0 {
+		c.add(SeverityWarning, "negative schema_retries, answers are not validated", key("schema_retries")...)
+	}
+	if _, err := localization.Normalize(configuration.PromptLanguage); err != nil {
+		c.add(SeverityError, err.Error(), key("prompt_language")...)
+	}
+	if configuration.Chunking == "yes" {
+		if err := loadTokenEncoding(); err != nil {
+			c.add(SeverityError, fmt.Sprintf("the token encoding used to split documents cannot be loaded: %v; without network access, set TIKTOKEN_CACHE_DIR to a directory holding the cl100k_base encoding file", err), key("chunking")...)
+		}
+	}
+
+	if configuration.MetadataFile != "" {
+		if _, err := os.Stat(configuration.MetadataFile); err != nil {
+			c.add(SeverityError, fmt.Sprintf("metadata file '%s' does not exist", configuration.MetadataFile), key("metadata_file")...)
+		}
+	}
+	if configuration.ExamplesDirectory != "" {
+		if info, err := os.Stat(configuration.ExamplesDirectory); err != nil || !info.IsDir() {
+			c.add(SeverityError, fmt.Sprintf("examples directory '%s' does not exist", configuration.ExamplesDirectory), key("examples_directory")...)
+		}
+	}
+}
+
+// loadTokenEncoding loads the token encoding used for chunking. It can be replaced in tests.
+var loadTokenEncoding = prompt.LoadTokenEncoding
+
+// checkScreeningResults checks that the screening results reviewed in place of the input directory
+// can be read and include at least one record.
+func (c *checker) checkScreeningResults(configuration config.ProjectConfiguration, key func(string) []string) {
+	if configuration.ScreeningTextColumn == "" {
+		c.add(SeverityError, "screening_text_column is not set, it names the column holding the manuscript texts", key("screening_results")...)
+		return
+	}
+	screening, err := records.Load(configuration.ScreeningResults, configuration.ScreeningTextColumn, configuration.ScreeningIdentifierColumn)
+	if err != nil {
+		c.add(SeverityError, fmt.Sprintf("screening results cannot be read: %v", err), key("screening_results")...)
+	} else if len(screening.Records) == 0 {
+		c.add(SeverityWarning, fmt.Sprintf("screening results '%s' have no included records", configuration.ScreeningResults), key("screening_results")...)
+	}
+}
+
+// checkWritable verifies that files can be created in a directory.
+func checkWritable(directory string) error {
+	info, err := os.Stat(directory)
+	if err != nil {
+		return fmt.Errorf("directory '%s' does not exist", directory)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("'%s' is not a directory", directory)
+	}
+	probe, err := os.CreateTemp(directory, ".prismaid-check-*")
+	if err != nil {
+		return fmt.Errorf("directory '%s' is not writable", directory)
+	}
+	probe.Close()
+	return os.Remove(probe.Name())
+}
+
+// checkModels checks the providers, models and API keys of the [project.llm] entries. Models are
+// compared with the bundled price table and the [prices] section; providers without known models,
+// such as self-hosted and cloud endpoints, are not checked.
+func (c *checker) checkModels(cfg *config.Config, envReader config.EnvReader) {
+	if len(cfg.Project.LLM) == 0 {
+		c.add(SeverityError, "no model is configured in [project.llm]", "project", "llm")
+		return
+	}
+
+	known := make(map[string][]string)
+	if prices, err := cost.BundledPrices(); err == nil {
+		for _, price := range prices {
+			known[price.Provider] = append(known[price.Provider], price.Model)
+		}
+	}
+	for _, price := range cfg.Prices {
+		known[price.Provider] = append(known[price.Provider], price.Model)
+	}
+
+	for _, entry := range sortedKeys(cfg.Project.LLM) {
+		llm := cfg.Project.LLM[entry]
+		key := func(name string) []string { return []string{"project", "llm", entry, name} }
+
+		variable, supported := config.APIKeyVariable(llm.Provider)
+		if !supported {
+			message := fmt.Sprintf("unsupported provider '%s'", llm.Provider)
+			if suggestion := closest(llm.Provider, config.Providers()); suggestion != "" {
+				message += fmt.Sprintf(", did you mean '%s'?", suggestion)
+			} else {
+				message += ", use one of: " + strings.Join(config.Providers(), ", ")
+			}
+			c.add(SeverityError, message, key("provider")...)
+			continue
+		}
+
+		if models := known[llm.Provider]; llm.Model != "" && len(models) > 0 && !slices.Contains(models, llm.Model) {
+			message := fmt.Sprintf("model '%s' is not a known %s model", llm.Model, llm.Provider)
+			if suggestion := closest(llm.Model, models); suggestion != "" {
+				message += fmt.Sprintf(", did you mean '%s'?", suggestion)
+			}
+			c.add(SeverityWarning, message, key("model")...)
+		}
+
+		apiKey, err := config.ResolveAPIKey(llm, envReader)
+		switch {
+		case err != nil:
+			c.add(SeverityError, err.Error(), key("api_key")...)
+		case apiKey == "":
+			severity := SeverityError
+			if llm.Provider == "SelfHosted" {
+				severity = SeverityWarning
+			}
+			c.add(severity, fmt.Sprintf("no API key: set api_key or the %s environment variable", variable), key("api_key")...)
+		case llm.ApiKey != "" && !secrets.IsReference(llm.ApiKey):
+			c.add(SeverityWarning, fmt.Sprintf("the API key is written in the configuration, use \"%s%s\" or \"%sPATH\" to keep it out of the file", secrets.EnvPrefix, variable, secrets.FilePrefix), key("api_key")...)
+		}
+		if llm.Provider == "SelfHosted" && llm.BaseURL == "" {
+			c.add(SeverityError, "base_url is required for self-hosted models", key("base_url")...)
+		}
+
+		if llm.Temperature < 0 {
+			c.add(SeverityWarning, "negative temperature, 0 is used", key("temperature")...)
+		}
+		if llm.TpmLimit < 0 {
+			c.add(SeverityWarning, "negative tpm_limit, the limit is disabled", key("tpm_limit")...)
+		}
+		if llm.RpmLimit < 0 {
+			c.add(SeverityWarning, "negative rpm_limit, the limit is disabled", key("rpm_limit")...)
+		}
+	}
+}
+
+// checkPrompt checks that the prompt has a task and that every prompt field is a valid template.
+func (c *checker) checkPrompt(prompt config.PromptConfig) {
+	if strings.TrimSpace(prompt.Task) == "" {
+		c.add(SeverityWarning, "the prompt has no task", "prompt", "task")
+	}
+	fields := []struct {
+		name string
+		text string
+	}{
+		{"persona", prompt.Persona},
+		{"task", prompt.Task},
+		{"expected_result", prompt.ExpectedResult},
+		{"failsafe", prompt.Failsafe},
+		{"definitions", prompt.Definitions},
+		{"example", prompt.Example},
+	}
+	for _, field := range fields {
+		if _, err := template.New(field.name).Parse(field.text); err != nil {
+			c.add(SeverityError, fmt.Sprintf("invalid template: %v", err), "prompt", field.name)
+		}
+	}
+}
+
+// checkInternalPrompts checks the replacements of the internal prompts.
+func (c *checker) checkInternalPrompts(prompts map[string]string) {
+	for _, name := range sortedKeys(prompts) {
+		if err := localization.Validate("", map[string]string{name: prompts[name]}, localization.ReviewPrompts); err != nil {
+			c.add(SeverityError, err.Error(), "internal_prompts", name)
+		}
+	}
+}
+
+// checkReview checks the review items and the review groups.
+func (c *checker) checkReview(cfg *config.Config) {
+	if len(cfg.Review) == 0 {
+		c.add(SeverityError, "no review item is configured in [review]", "review")
+	}
+
+	entries := sortedKeys(cfg.Review)
+	sort.SliceStable(entries, func(i, j int) bool {
+		return c.lines.find("review", entries[i]) < c.lines.find("review", entries[j])
+	})
+	defined := make(map[string]string)
+	used := make(map[string]bool)
+	for _, entry := range entries {
+		item := cfg.Review[entry]
+		key := func(name string) []string { return []string{"review", entry, name} }
+
+		if strings.TrimSpace(item.Key) == "" {
+			c.add(SeverityError, "review item has no key", key("key")...)
+		} else if previous, ok := defined[item.Key]; ok {
+			c.add(SeverityError, fmt.Sprintf("duplicate review key '%s', already defined in [review.%s]", item.Key, previous), key("key")...)
+		} else {
+			defined[item.Key] = entry
+		}
+
+		if item.Type == "" && len(item.Values) == 0 {
+			c.add(SeverityError, `values list is empty, use values = [""] for free text answers or set a type`, key("values")...)
+		} else if err := item.Validate(); err != nil {
+			field := "values"
+			if item.Type != "" {
+				field = "type"
+			}
+			c.add(SeverityError, err.Error(), key(field)...)
+		}
+
+		if item.Group != "" {
+			used[item.Group] = true
+			if _, ok := cfg.ReviewGroups[item.Group]; !ok {
+				c.add(SeverityError, fmt.Sprintf("undefined review group '%s'", item.Group), key("group")...)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(cfg.ReviewGroups) {
+		group := cfg.ReviewGroups[name]
+		if strings.TrimSpace(group.Task) == "" {
+			c.add(SeverityError, "review group has no task", "review_groups", name, "task")
+		} else if _, err := template.New(name).Parse(group.Task); err != nil {
+			c.add(SeverityError, fmt.Sprintf("invalid template: %v", err), "review_groups", name, "task")
+		}
+		if !used[name] {
+			c.add(SeverityWarning, "no review item belongs to this group", "review_groups", name)
+		}
+	}
+}
+
+// checkFollowUps checks the names and the queries of the [follow_ups] table.
+func (c *checker) checkFollowUps(followUps map[string]config.FollowUpItem) {
+	for _, name := range sortedKeys(followUps) {
+		if err := config.ValidateFollowUp(name, followUps[name]); err != nil {
+			c.add(SeverityError, err.Error(), "follow_ups", name)
+		}
+	}
+}
+
+// sortedKeys returns the keys of a map, sorted.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// closest returns the candidate most similar to a value, if it differs only by case or by at most
+// two edits, and an empty string otherwise.
+func closest(value string, candidates []string) string {
+	best, bestDistance := "", 3
+	for _, candidate := range candidates {
+		if strings.EqualFold(value, candidate) {
+			return candidate
+		}
+		if distance := editDistance(strings.ToLower(value), strings.ToLower(candidate)); distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between two strings.
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current := make([]int, len(rb)+1)
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+	return previous[len(rb)]
+}
+
+// Errors returns the number of problems of error severity.
+func (r *Report) Errors() int {
+	count := 0
+	for _, problem := range r.Problems {
+		if problem.Severity == SeverityError {
+			count++
+		}
+	}
+	return count
+}
+
+// Write writes the problems of the report, one per line with its TOML line number, followed by the
+// number of errors and warnings.
+//
+// Arguments:
+// - w: The writer receiving the report.
+//
+// Returns:
+// - An error if writing fails.
+func (r *Report) Write(w io.Writer) error {
+	for _, problem := range r.Problems {
+		location := ""
+		if problem.Line > 0 {
+			location = fmt.Sprintf("line %d: ", problem.Line)
+		}
+		if problem.Key != "" {
+			location += problem.Key + ": "
+		}
+		fmt.Fprintf(w, "%s%s: %s\n", location, problem.Severity, problem.Message)
+	}
+
+	if len(r.Problems) == 0 {
+		_, err := fmt.Fprintln(w, "Configuration is valid.")
+		return err
+	}
+	count := r.Errors()
+	_, err := fmt.Fprintf(w, "%s, %s\n", plural(count, "error"), plural(len(r.Problems)-count, "warning"))
+	return err
+}
+
+// plural formats a count with a noun, adding an "s" unless the count is one.
+func plural(count int, noun string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, noun)
+	}
+	return fmt.Sprintf("%d %ss", count, noun)
+}
@@ -0,0 +1,362 @@
+package lint
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type mockEnvReader map[string]string
+
+func (m mockEnvReader) GetEnv(key string) string {
+	return m[key]
+}
+
+func TestCheckReportsProblemsWithLines(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "paper1.txt"), []byte("text"), 0644); err != nil {
+		t.Fatalf("Failed to write manuscript: %v", err)
+	}
+
+	tomlContent := `[project.configuration]
+input_directory = "` + dir + `"
+results_file_name = "` + filepath.Join(dir, "missing", "results") + `"
+output_format = "xls"
+sumary = "yes"
+
+[project.llm.1]
+provider = "OpenAi"
+model = "gpt-4o-mini"
+
+[project.llm.2]
+provider = "Anthropic"
+model = "claude-3-haiku"
+
+[prompt]
+task = """Map the concepts
+of the paper."""
+
+[review.1]
+key = "design"
+values = ["cohort", "trial"]
+
+[review.2]
+key = "design"
+values = []
+`
+	report := Check(tomlContent, mockEnvReader{})
+
+	expected := []struct {
+		line     int
+		key      string
+		severity string
+	}{
+		{3, "project.configuration.results_file_name", SeverityError},
+		{4, "project.configuration.output_format", SeverityError},
+		{5, "project.configuration.sumary", SeverityWarning},
+		{8, "project.llm.1.provider", SeverityError},
+		{11, "project.llm.2.api_key", SeverityError},
+		{24, "review.2.key", SeverityError},
+		{25, "review.2.values", SeverityError},
+	}
+	if len(report.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %+v", len(expected), report.Problems)
+	}
+	for i, problem := range report.Problems {
+		if problem.Line != expected[i].line || problem.Key != expected[i].key || problem.Severity != expected[i].severity {
+			t.Errorf("Expected %+v, got %+v", expected[i], problem)
+		}
+	}
+	if !strings.Contains(report.Problems[3].Message, "did you mean 'OpenAI'") {
+		t.Errorf("Expected a suggestion for the provider, got %s", report.Problems[3].Message)
+	}
+	if report.Errors() != 6 {
+		t.Errorf("Expected 6 errors, got %d", report.Errors())
+	}
+
+	var output bytes.Buffer
+	if err := report.Write(&output); err != nil {
+		t.Fatalf("Write returned an error: %v", err)
+	}
+	if !strings.Contains(output.String(), "line 4: project.configuration.output_format: error: unsupported value 'xls'") {
+		t.Errorf("Unexpected report:\n%s", output.String())
+	}
+}
+
+func TestCheckValidConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "paper1.txt"), []byte("text"), 0644); err != nil {
+		t.Fatalf("Failed to write manuscript: %v", err)
+	}
+
+	tomlContent := `[project.configuration]
+input_directory = "` + dir + `"
+results_file_name = "` + filepath.Join(dir, "results") + `"
+
+[project.llm.1]
+provider = "OpenAI"
+model = ""
+
+[prompt]
+task = "Map the concepts of the paper."
+
+[review.1]
+key = "design"
+values = ["cohort", "trial"]
+`
+	report := Check(tomlContent, mockEnvReader{"OPENAI_API_KEY": "key"})
+	if len(report.Problems) != 0 {
+		t.Errorf("Expected no problems, got %+v", report.Problems)
+	}
+}
+
+func TestCheckPromptLanguage(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "paper1.txt"), []byte("text"), 0644); err != nil {
+		t.Fatalf("Failed to write manuscript: %v", err)
+	}
+
+	tomlContent := `[project.configuration]
+input_directory = "` + dir + `"
+results_file_name = "` + filepath.Join(dir, "results") + `"
+prompt_language = "pt"
+
+[project.llm.1]
+provider = "OpenAI"
+model = ""
+
+[prompt]
+task = "Map the concepts of the paper."
+
+[review.1]
+key = "design"
+values = ["cohort", "trial"]
+
+[internal_prompts]
+summary_query = "Resuma o texto."
+sumary_query = "Resuma."
+`
+	report := Check(tomlContent, mockEnvReader{"OPENAI_API_KEY": "key"})
+	expected := []struct {
+		line int
+		key  string
+	}{
+		{4, "project.configuration.prompt_language"},
+		{19, "internal_prompts.sumary_query"},
+	}
+	if len(report.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %+v", len(expected), report.Problems)
+	}
+	for i, problem := range report.Problems {
+		if problem.Line != expected[i].line || problem.Key != expected[i].key || problem.Severity != SeverityError {
+			t.Errorf("Expected an error on %+v, got %+v", expected[i], problem)
+		}
+	}
+}
+
+func TestCheckTokenEncoding(t *testing.T) {
+	originalLoad := loadTokenEncoding
+	defer func() { loadTokenEncoding = originalLoad }()
+	loadTokenEncoding = func() error { return errors.New("no network") }
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "paper1.txt"), []byte("text"), 0644); err != nil {
+		t.Fatalf("Failed to write manuscript: %v", err)
+	}
+
+	tomlContent := `[project.configuration]
+input_directory = "` + dir + `"
+results_file_name = "` + filepath.Join(dir, "results") + `"
+chunking = "yes"
+
+[project.llm.1]
+provider = "OpenAI"
+model = ""
+
+[prompt]
+task = "Map the concepts of the paper."
+
+[review.1]
+key = "design"
+values = ["cohort", "trial"]
+`
+	report := Check(tomlContent, mockEnvReader{"OPENAI_API_KEY": "key"})
+	if len(report.Problems) != 1 {
+		t.Fatalf("Expected one problem, got %+v", report.Problems)
+	}
+	problem := report.Problems[0]
+	if problem.Line != 4 || problem.Severity != SeverityError || !strings.Contains(problem.Message, "TIKTOKEN_CACHE_DIR") {
+		t.Errorf("Expected an error on the chunking line, got %+v", problem)
+	}
+
+	report = Check(strings.Replace(tomlContent, `chunking = "yes"`, `chunking = "no"`, 1), mockEnvReader{"OPENAI_API_KEY": "key"})
+	if len(report.Problems) != 0 {
+		t.Errorf("Expected the encoding to be checked only with chunking, got %+v", report.Problems)
+	}
+}
+
+func TestCheckFollowUps(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "paper1.txt"), []byte("text"), 0644); err != nil {
+		t.Fatalf("Failed to write manuscript: %v", err)
+	}
+
+	tomlContent := `[project.configuration]
+input_directory = "` + dir + `"
+results_file_name = "` + filepath.Join(dir, "results") + `"
+
+[project.llm.1]
+provider = "OpenAI"
+model = ""
+
+[prompt]
+task = "Map the concepts of the paper."
+
+[review.1]
+key = "design"
+values = ["cohort", "trial"]
+
+[follow_ups.limitations]
+query = "List the limitations of {{.Filename}}."
+
+[follow_ups.summary]
+query = "Summarize the paper."
+
+[follow_ups.funding]
+query = ""
+`
+	report := Check(tomlContent, mockEnvReader{"OPENAI_API_KEY": "key"})
+	expected := []struct {
+		line int
+		key  string
+	}{
+		{19, "follow_ups.summary"},
+		{22, "follow_ups.funding"},
+	}
+	if len(report.Problems) != len(expected) {
+		t.Fatalf("Expected %d problems, got %+v", len(expected), report.Problems)
+	}
+	for i, problem := range report.Problems {
+		if problem.Line != expected[i].line || problem.Key != expected[i].key || problem.Severity != SeverityError {
+			t.Errorf("Expected an error on %+v, got %+v", expected[i], problem)
+		}
+	}
+}
+
+func TestCheckParseError(t *testing.T) {
+	report := Check("[project.configuration]\ninput_directory = \"dir\"\noutput_format = csv\n", mockEnvReader{})
+	if len(report.Problems) != 1 || report.Problems[0].Line != 3 {
+		t.Errorf("Expected a parse error on line 3, got %+v", report.Problems)
+	}
+}
+
+func TestCheckExtendedConfiguration(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "paper1.txt"), []byte("text"), 0644); err != nil {
+		t.Fatalf("Failed to write manuscript: %v", err)
+	}
+	base := filepath.Join(dir, "base.toml")
+	if err := os.WriteFile(base, []byte("[project.llm.1]\nprovider = \"OpenAI\"\nmodel = \"\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write base configuration: %v", err)
+	}
+
+	tomlContent := `extends = "` + base + `"
+
+[project.configuration]
+input_directory = "` + dir + `"
+results_file_name = "` + filepath.Join(dir, "results") + `"
+
+[prompt]
+task = "Map the concepts of the paper."
+
+[review.1]
+key = "design"
+values = ["cohort", "trial"]
+`
+	report := Check(tomlContent, mockEnvReader{})
+	if len(report.Problems) != 1 || report.Problems[0].Key != "project.llm.1.api_key" || report.Problems[0].Line != 0 {
+		t.Errorf("Expected only the missing API key of the inherited model, without line, got %+v", report.Problems)
+	}
+}
+
+func TestCheckAPIKeyReferences(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "paper1.txt"), []byte("text"), 0644); err != nil {
+		t.Fatalf("Failed to write manuscript: %v", err)
+	}
+
+	tomlContent := `[project.configuration]
+input_directory = "` + dir + `"
+results_file_name = "` + filepath.Join(dir, "results") + `"
+
+[project.llm.1]
+provider = "OpenAI"
+model = ""
+api_key = "sk-written-in-file"
+
+[project.llm.2]
+provider = "Anthropic"
+model = ""
+api_key = "env:TEAM_ANTHROPIC_KEY"
+
+[project.llm.3]
+provider = "Cohere"
+model = ""
+api_key = "env:CO_API_KEY"
+
+[prompt]
+task = "Map the concepts of the paper."
+
+[review.1]
+key = "design"
+values = ["cohort", "trial"]
+`
+	report := Check(tomlContent, mockEnvReader{"CO_API_KEY": "co-key"})
+	if len(report.Problems) != 2 {
+		t.Fatalf("Expected 2 problems, got %+v", report.Problems)
+	}
+	written, missing := report.Problems[0], report.Problems[1]
+	if written.Line != 8 || written.Severity != SeverityWarning || strings.Contains(written.Message, "sk-written-in-file") {
+		t.Errorf("Expected a warning on the key written in the configuration, got %+v", written)
+	}
+	if missing.Line != 13 || missing.Severity != SeverityError || !strings.Contains(missing.Message, "TEAM_ANTHROPIC_KEY") {
+		t.Errorf("Expected an error on the unresolved reference, got %+v", missing)
+	}
+}
+
+func TestCheckScreeningResults(t *testing.T) {
+	dir := t.TempDir()
+	screeningResults := filepath.Join(dir, "screening.csv")
+	if err := os.WriteFile(screeningResults, []byte("doi,abstract,include\n10.1/a,Text,false\n"), 0644); err != nil {
+		t.Fatalf("Failed to write screening results: %v", err)
+	}
+
+	tomlContent := `[project.configuration]
+screening_results = "` + screeningResults + `"
+screening_text_column = "summary"
+results_file_name = "` + filepath.Join(dir, "results") + `"
+
+[project.llm.1]
+provider = "OpenAI"
+model = ""
+
+[prompt]
+task = "Map the concepts of the paper."
+
+[review.1]
+key = "design"
+values = ["cohort", "trial"]
+`
+	report := Check(tomlContent, mockEnvReader{"OPENAI_API_KEY": "key"})
+	if len(report.Problems) != 1 || report.Problems[0].Line != 2 || !strings.Contains(report.Problems[0].Message, "text column 'summary' not found") {
+		t.Fatalf("Expected only the missing text column, without input directory, got %+v", report.Problems)
+	}
+
+	report = Check(strings.Replace(tomlContent, `"summary"`, `"abstract"`, 1), mockEnvReader{"OPENAI_API_KEY": "key"})
+	if len(report.Problems) != 1 || report.Problems[0].Severity != SeverityWarning || !strings.Contains(report.Problems[0].Message, "no included records") {
+		t.Errorf("Expected a warning on the results without included records, got %+v", report.Problems)
+	}
+}
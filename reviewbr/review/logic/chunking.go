@@ -0,0 +1,241 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/prompt"
+)
+
+// chunkMerger reduces the responses given on the chunks of a long document into the responses
+// of the whole document.
+type chunkMerger struct {
+	strategy     string
+	groups       [][]string // review keys of each review group, in sequence order
+	followUps    int        // number of follow-up prompts, sent after the review groups
+	reducePrompt func(group int, answers []string) string
+}
+
+// newChunkMerger creates the merger for the configured merge strategy and review groups.
+func newChunkMerger(cfg *config.Config) *chunkMerger {
+	groups := cfg.Groups()
+	merger := &chunkMerger{
+		strategy:  cfg.Project.Configuration.MergeStrategy,
+		followUps: len(cfg.FollowUps()),
+		reducePrompt: func(group int, answers []string) string {
+			return prompt.BuildReducePrompt(cfg, groups[group], answers)
+		},
+	}
+	for _, group := range groups {
+		keys := make([]string, 0, len(group.Items))
+		for _, item := range group.Items {
+			keys = append(keys, cfg.Review[item].Key)
+		}
+		sort.Strings(keys)
+		merger.groups = append(merger.groups, keys)
+	}
+	return merger
+}
+
+// merge combines the responses of the chunks of a document. The answers of each review group are
+// merged with the configured strategy, while the JSON follow-up answers (justifications and summaries)
+// are merged by concatenating their lists and texts, and the text answers to the [follow_ups] queries
+// are joined. A follow-up not answered on any chunk is given an empty answer.
+//
+// Arguments:
+// - metadata: The metadata of the full review input, used by the llm_reduce strategy.
+// - model: The model that answered on the chunks.
+// - sequenceID: The SequenceID of the document.
+// - chunks: The responses of each chunk, in document order.
+//
+// Returns:
+// - The responses of the document, one per sequence number.
+// - An error if the llm_reduce call fails or does not return a JSON answer.
+func (m *chunkMerger) merge(metadata definitions.InputMetadata, model definitions.Model, sequenceID string, chunks [][]definitions.Response) ([]definitions.Response, error) {
+	answers := make(map[int][]string)
+	var provider, modelName string
+	for _, responses := range chunks {
+		for _, response := range responses {
+			if len(response.ModelResponses) == 0 {
+				continue
+			}
+			answers[response.SequenceNumber] = append(answers[response.SequenceNumber], response.ModelResponses[0])
+			provider, modelName = response.Provider, response.Model
+		}
+	}
+
+	var merged []definitions.Response
+	for group, keys := range m.groups {
+		sequenceNumber := group + 1
+		var main string
+		switch m.strategy {
+		case config.MergeLLMReduce:
+			responses, err := extractDocument(metadata, model, []definitions.Prompt{{
+				PromptContent:  m.reducePrompt(group, answers[sequenceNumber]),
+				SequenceID:     sequenceID,
+				SequenceNumber: 1,
+			}})
+			if err != nil {
+				return nil, fmt.Errorf("error reducing chunk answers: %v", err)
+			}
+			for _, response := range responses {
+				if response.SequenceNumber == 1 && len(response.ModelResponses) > 0 {
+					main = response.ModelResponses[0]
+				}
+			}
+			var reduced map[string]any
+			if err := json.Unmarshal([]byte(trimJSONFence(main)), &reduced); err != nil {
+				return nil, fmt.Errorf("error reducing chunk answers: no JSON answer from %s %s: %v", model.Provider, model.Model, err)
+			}
+		default:
+			answer, err := json.Marshal(mergeAnswers(m.strategy, keys, answers[sequenceNumber]))
+			if err != nil {
+				return nil, err
+			}
+			main = string(answer)
+		}
+		merged = append(merged, definitions.Response{
+			SequenceID:     sequenceID,
+			SequenceNumber: sequenceNumber,
+			Provider:       provider,
+			Model:          modelName,
+			ModelResponses: []string{main},
+		})
+	}
+	for sequenceNumber := len(m.groups) + 1; sequenceNumber <= len(m.groups)+m.followUps; sequenceNumber++ {
+		answer := ""
+		if len(answers[sequenceNumber]) > 0 {
+			answer = mergeFollowUps(answers[sequenceNumber])
+		}
+		merged = append(merged, definitions.Response{
+			SequenceID:     sequenceID,
+			SequenceNumber: sequenceNumber,
+			Provider:       provider,
+			Model:          modelName,
+			ModelResponses: []string{answer},
+		})
+	}
+	return merged, nil
+}
+
+// mergeAnswers combines the JSON answers given on the chunks of a document, key by key.
+//
+// Arguments:
+// - strategy: The merge strategy, first_non_empty or union.
+// - keys: The review keys to merge.
+// - answers: The JSON answers of the chunks, in document order; unparsable answers are skipped.
+//
+// Returns:
+// - The merged answer, with an empty string for keys not answered on any chunk.
+func mergeAnswers(strategy string, keys []string, answers []string) map[string]any {
+	var parsed []map[string]any
+	for _, answer := range answers {
+		var data map[string]any
+		if err := json.Unmarshal([]byte(trimJSONFence(answer)), &data); err != nil {
+			logger.Error("Error parsing chunk answer: %v", err)
+			continue
+		}
+		parsed = append(parsed, data)
+	}
+
+	merged := make(map[string]any, len(keys))
+	for _, key := range keys {
+		var values []string
+		seen := make(map[string]bool)
+		for _, data := range parsed {
+			for _, value := range answerValues(data[key]) {
+				if !seen[strings.ToLower(value)] {
+					seen[strings.ToLower(value)] = true
+					values = append(values, value)
+				}
+			}
+		}
+
+		switch {
+		case len(values) == 0:
+			merged[key] = ""
+		case strategy == config.MergeUnion:
+			merged[key] = strings.Join(values, "; ")
+		default:
+			merged[key] = values[0]
+		}
+	}
+	return merged
+}
+
+// answerValues returns the non-empty values of an answer, flattening lists.
+func answerValues(value any) []string {
+	switch typed := value.(type) {
+	case nil:
+		return nil
+	case []any:
+		var values []string
+		for _, element := range typed {
+			values = append(values, answerValues(element)...)
+		}
+		return values
+	default:
+		text := strings.TrimSpace(fmt.Sprintf("%v", typed))
+		if text == "" {
+			return nil
+		}
+		return []string{text}
+	}
+}
+
+// mergeFollowUps combines the JSON answers to a follow-up query given on the chunks of a document.
+// Lists are concatenated, texts are joined, and objects are merged recursively. If an answer is not
+// a JSON object, the answers are joined as plain text.
+func mergeFollowUps(answers []string) string {
+	merged := make(map[string]any)
+	for _, answer := range answers {
+		var data map[string]any
+		if err := json.Unmarshal([]byte(trimJSONFence(answer)), &data); err != nil {
+			return strings.Join(answers, "\n\n")
+		}
+		mergeObjects(merged, data)
+	}
+	result, err := json.Marshal(merged)
+	if err != nil {
+		return strings.Join(answers, "\n\n")
+	}
+	return string(result)
+}
+
+// mergeObjects merges the fields of src into dst.
+func mergeObjects(dst map[string]any, src map[string]any) {
+	for key, value := range src {
+		existing, ok := dst[key]
+		if !ok {
+			dst[key] = value
+			continue
+		}
+		switch typed := value.(type) {
+		case map[string]any:
+			if object, ok := existing.(map[string]any); ok {
+				mergeObjects(object, typed)
+			}
+		case []any:
+			if list, ok := existing.([]any); ok {
+				dst[key] = append(list, typed...)
+			}
+		case string:
+			if text, ok := existing.(string); ok && typed != "" {
+				dst[key] = strings.TrimSpace(text + " " + typed)
+			}
+		}
+	}
+}
+
+// trimJSONFence removes the markdown code fence some models put around JSON answers.
+func trimJSONFence(answer string) string {
+	answer = strings.TrimSpace(answer)
+	answer = strings.TrimPrefix(answer, "```json")
+	answer = strings.TrimSuffix(answer, "```")
+	return strings.TrimSpace(answer)
+}
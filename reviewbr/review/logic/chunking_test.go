@@ -0,0 +1,202 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestMergeAnswers(t *testing.T) {
+	answers := []string{
+		`{"design": "", "countries": ["Italy"]}`,
+		"```json\n{\"design\": \"cohort\", \"countries\": \"Spain\"}\n```",
+		`{"design": "trial", "countries": ["italy", "France"]}`,
+	}
+	keys := []string{"countries", "design", "missing"}
+
+	tests := []struct {
+		strategy string
+		expected map[string]any
+	}{
+		{config.MergeFirstNonEmpty, map[string]any{"countries": "Italy", "design": "cohort", "missing": ""}},
+		{config.MergeUnion, map[string]any{"countries": "Italy; Spain; France", "design": "cohort; trial", "missing": ""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			merged := mergeAnswers(tt.strategy, keys, answers)
+			for key, value := range tt.expected {
+				if merged[key] != value {
+					t.Errorf("Key %s: expected %q, got %q", key, value, merged[key])
+				}
+			}
+		})
+	}
+}
+
+func TestMergeFollowUps(t *testing.T) {
+	merged := mergeFollowUps([]string{
+		`{"justifications": {"design": {"reasoning_steps": ["a"], "supporting_sentences": ["s1"]}}}`,
+		`{"justifications": {"design": {"reasoning_steps": ["b"], "supporting_sentences": ["s2"]}, "countries": {"reasoning_steps": ["c"]}}}`,
+	})
+	var data struct {
+		Justifications map[string]struct {
+			ReasoningSteps      []string `json:"reasoning_steps"`
+			SupportingSentences []string `json:"supporting_sentences"`
+		} `json:"justifications"`
+	}
+	if err := json.Unmarshal([]byte(merged), &data); err != nil {
+		t.Fatalf("Expected merged JSON, got %q: %v", merged, err)
+	}
+	design := data.Justifications["design"]
+	if strings.Join(design.ReasoningSteps, ",") != "a,b" || strings.Join(design.SupportingSentences, ",") != "s1,s2" {
+		t.Errorf("Unexpected merged justification: %+v", design)
+	}
+	if len(data.Justifications["countries"].ReasoningSteps) != 1 {
+		t.Errorf("Expected the justification of the second chunk to be kept, got %+v", data.Justifications)
+	}
+
+	summary := mergeFollowUps([]string{`{"summary": "First part."}`, `{"summary": "Second part."}`})
+	if summary != `{"summary":"First part. Second part."}` {
+		t.Errorf("Unexpected merged summary: %s", summary)
+	}
+
+	if text := mergeFollowUps([]string{"plain", "text"}); text != "plain\n\ntext" {
+		t.Errorf("Expected plain answers to be joined, got %q", text)
+	}
+}
+
+func TestMergeFollowUpsOfChunks(t *testing.T) {
+	merger := &chunkMerger{strategy: config.MergeFirstNonEmpty, groups: [][]string{{"test"}}, followUps: 2}
+	response := func(sequenceNumber int, answer string) definitions.Response {
+		return definitions.Response{SequenceNumber: sequenceNumber, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{answer}}
+	}
+	chunks := [][]definitions.Response{
+		{response(1, `{"test": ""}`), response(2, `{"summary": "First part."}`)},
+		{response(1, `{"test": "yes"}`), response(4, "unexpected")},
+	}
+
+	merged, err := merger.merge(definitions.InputMetadata{}, definitions.Model{}, "1", chunks)
+	if err != nil {
+		t.Fatalf("merge returned an error: %v", err)
+	}
+	if len(merged) != 3 {
+		t.Fatalf("Expected one response per review group and follow-up, got %+v", merged)
+	}
+	if merged[1].ModelResponses[0] != `{"summary":"First part."}` {
+		t.Errorf("Unexpected merged summary: %s", merged[1].ModelResponses[0])
+	}
+	if merged[2].SequenceNumber != 3 || merged[2].ModelResponses[0] != "" {
+		t.Errorf("Expected an empty answer for the unanswered follow-up, got %+v", merged[2])
+	}
+}
+
+func TestMergeRejectsInvalidReduce(t *testing.T) {
+	originalExtract := extract
+	defer func() { extract = originalExtract }()
+
+	merger := &chunkMerger{
+		strategy:     config.MergeLLMReduce,
+		groups:       [][]string{{"test"}},
+		reducePrompt: func(group int, answers []string) string { return "reduce" },
+	}
+	chunks := [][]definitions.Response{{{SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"test": "yes"}`}}}}
+
+	for name, answers := range map[string][]string{"empty": nil, "not json": {"The answer is yes."}} {
+		t.Run(name, func(t *testing.T) {
+			extract = func(input string) (string, error) {
+				data, err := json.Marshal(definitions.Output{Responses: []definitions.Response{{
+					SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: answers,
+				}}})
+				return string(data), err
+			}
+			if _, err := merger.merge(definitions.InputMetadata{}, definitions.Model{Provider: "OpenAI", Model: "gpt-4o-mini"}, "1", chunks); err == nil {
+				t.Errorf("Expected an error for a reduce without a JSON answer")
+			}
+		})
+	}
+}
+
+func TestRunExtractionMergesChunks(t *testing.T) {
+	originalExtract := extract
+	defer func() { extract = originalExtract }()
+
+	calls := 0
+	var prompts []string
+	extract = func(input string) (string, error) {
+		calls++
+		var parsed definitions.Input
+		if err := json.Unmarshal([]byte(input), &parsed); err != nil {
+			return "", err
+		}
+		prompts = append(prompts, parsed.Prompts[0].PromptContent)
+		answer := `{"test": ""}`
+		switch {
+		case strings.HasPrefix(parsed.Prompts[0].PromptContent, "reduce"):
+			answer = `{"test": "reduced"}`
+		case strings.Contains(parsed.Prompts[0].PromptContent, "second"):
+			answer = `{"test": "yes"}`
+		}
+		data, err := json.Marshal(definitions.Output{Responses: []definitions.Response{{
+			SequenceID:     parsed.Prompts[0].SequenceID,
+			SequenceNumber: 1,
+			Provider:       parsed.Models[0].Provider,
+			Model:          parsed.Models[0].Model,
+			ModelResponses: []string{answer},
+		}}})
+		return string(data), err
+	}
+
+	input := definitions.Input{
+		Models: []definitions.Model{{Provider: "OpenAI", Model: "gpt-4o-mini"}},
+		Prompts: []definitions.Prompt{
+			{PromptContent: "first chunk", SequenceID: "1.1", SequenceNumber: 1},
+			{PromptContent: "second chunk", SequenceID: "1.2", SequenceNumber: 1},
+			{PromptContent: "whole document", SequenceID: "2", SequenceNumber: 1},
+		},
+	}
+
+	tests := []struct {
+		strategy string
+		calls    int
+		expected string
+	}{
+		{config.MergeFirstNonEmpty, 3, `{"test":"yes"}`},
+		{config.MergeLLMReduce, 4, `{"test": "reduced"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			calls, prompts = 0, nil
+			merger := &chunkMerger{
+				strategy:     tt.strategy,
+				groups:       [][]string{{"test"}},
+				reducePrompt: func(group int, answers []string) string { return "reduce " + strings.Join(answers, " ") },
+			}
+
+			reviewResults, _, failed, err := runExtraction(context.Background(), input, []string{"long", "short"}, nil, merger, nil, nil, 1)
+			if err != nil || len(failed) != 0 {
+				t.Fatalf("runExtraction failed: %v, %v", err, failed)
+			}
+			if calls != tt.calls {
+				t.Errorf("Expected %d provider calls, got %d: %q", tt.calls, calls, prompts)
+			}
+
+			var output definitions.Output
+			if err := json.Unmarshal([]byte(reviewResults), &output); err != nil {
+				t.Fatalf("Failed to parse results: %v", err)
+			}
+			if len(output.Responses) != 2 {
+				t.Fatalf("Expected one response per document, got %+v", output.Responses)
+			}
+			merged := output.Responses[0]
+			if merged.SequenceID != "1" || merged.ModelResponses[0] != tt.expected {
+				t.Errorf("Expected merged response %s for sequence 1, got %s for sequence %s", tt.expected, merged.ModelResponses[0], merged.SequenceID)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package logic
+
+import (
+	"encoding/json"
+
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
+	"github.com/open-and-sustainable/prismaid/review/records"
+	"github.com/open-and-sustainable/prismaid/review/results"
+)
+
+// selectChangedDocuments compares the content hashes of the input documents with the run manifest
+// and the existing results file, and selects the documents that need to be reviewed: those that are
+// new, whose content changed, or that have no row in the existing results.
+//
+// Arguments:
+// - config: A pointer to the application's configuration.
+// - runManifest: The manifest written by the previous run.
+// - hashes: The content hashes of the current input documents, by filename.
+// - configHash: The hash of the current prompt and model configuration.
+//
+// Returns:
+//   - The set of filenames to review, or nil if every document must be reviewed because the prompt
+//     or model configuration changed since the previous run.
+//   - The files of the previous run to merge the new rows into, or nil if there are none.
+//   - An error if the existing results cannot be read.
+func selectChangedDocuments(config *config.Config, runManifest *manifest.Manifest, hashes map[string]string, configHash string) (map[string]bool, *results.Previous, error) {
+	if runManifest.ConfigHash != configHash {
+		logger.Info("Prompt or model configuration changed since the previous run, reviewing all documents.")
+		return nil, nil, nil
+	}
+
+	previous, err := results.LoadPrevious(config)
+	if err != nil {
+		return nil, nil, err
+	}
+	previousFilenames, err := results.PreviousFilenames(config, previous)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	selected := make(map[string]bool)
+	for filename, hash := range hashes {
+		document, ok := runManifest.Documents[filename]
+		if !ok || document.ContentHash != hash || !previousFilenames[filename] {
+			selected[filename] = true
+		}
+	}
+
+	logger.Info("Incremental review: %d of %d documents are new or modified", len(selected), len(hashes))
+	return selected, previous, nil
+}
+
+// hasRemovedDocuments tells whether the previous results have rows of documents that are no longer
+// among the inputs, and must be removed from the results even if no document is reviewed.
+//
+// Arguments:
+// - config: A pointer to the application's configuration.
+// - previous: The files of the previous run.
+// - hashes: The content hashes of the current input documents, by filename.
+//
+// Returns:
+// - Whether a document of the previous results is no longer among the inputs.
+// - An error if the existing results cannot be read.
+func hasRemovedDocuments(config *config.Config, previous *results.Previous, hashes map[string]string) (bool, error) {
+	previousFilenames, err := results.PreviousFilenames(config, previous)
+	if err != nil {
+		return false, err
+	}
+	for filename := range previousFilenames {
+		if _, ok := hashes[filename]; !ok {
+			logger.Info("Removing %s, no longer among the inputs, from the results", filename)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// inputHashes computes the content hashes of the documents to review: the .txt files of the input
+// directory or, when screening results are configured, the included records, whose hash covers their
+// text and metadata so that records whose metadata changed are reviewed again.
+//
+// Arguments:
+// - config: A pointer to the application's configuration.
+//
+// Returns:
+// - A map from filename or record identity to hex-encoded content hash.
+// - An error if the documents cannot be read.
+func inputHashes(config *config.Config) (map[string]string, error) {
+	screening, err := records.FromConfig(config)
+	if err != nil {
+		logger.Error("Error reading screening results: %v", err)
+		return nil, err
+	}
+	if screening == nil {
+		return manifest.HashInputs(config.Project.Configuration.InputDirectory)
+	}
+
+	hashes := make(map[string]string, len(screening.Records))
+	for _, record := range screening.Records {
+		metadata, _ := json.Marshal(record.Metadata)
+		hashes[record.ID] = manifest.HashContent(append([]byte(record.Text), metadata...))
+	}
+	return hashes, nil
+}
@@ -0,0 +1,120 @@
+package logic
+
+import (
+	"slices"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/prompt"
+	"github.com/open-and-sustainable/prismaid/review/schema"
+)
+
+// answerChecker validates the answers to the review prompts against the JSON Schema of their review
+// group and asks the model again, with the problems found, when an answer fails the validation.
+type answerChecker struct {
+	validators []*schema.Validator // validator of each review group, in sequence order
+	retries    int                 // maximum number of times an answer is asked again
+	prompts    *localization.Prompts
+}
+
+// newAnswerChecker creates the checker of the answers from the schema_retries option and the review
+// groups of the configuration.
+//
+// Arguments:
+// - cfg: The application configuration.
+//
+// Returns:
+// - The checker; nil when schema_retries is 0, so that answers are not validated.
+// - An error if the JSON Schema of a review group cannot be compiled.
+func newAnswerChecker(cfg *config.Config) (*answerChecker, error) {
+	if cfg.Project.Configuration.SchemaRetries <= 0 {
+		return nil, nil
+	}
+	checker := &answerChecker{retries: cfg.Project.Configuration.SchemaRetries, prompts: cfg.LocalizedPrompts()}
+	for _, group := range cfg.Groups() {
+		validator, err := schema.NewValidator(cfg, group)
+		if err != nil {
+			logger.Error("Error compiling the JSON Schema of review group %q: %v", group.Name, err)
+			return nil, err
+		}
+		checker.validators = append(checker.validators, validator)
+	}
+	return checker, nil
+}
+
+// check validates the answers of a model to the review prompts of a document. An invalid answer is
+// asked again, up to the configured number of times, in a new sequence made of the main prompt of
+// the document, which carries its text, and of the re-ask prompt; the latest answer replaces the
+// invalid one. Follow-up answers, such as justifications and summaries, are not validated. A nil
+// checker returns the responses unchanged.
+//
+// Arguments:
+// - metadata: The metadata of the full review input.
+// - model: The model that answered.
+// - prompts: The prompts sent for the document, sharing one SequenceID.
+// - responses: The responses of the model.
+//
+// Returns:
+// - The responses, with the answers given again in place of the invalid ones.
+// - The number of times the answer was asked again, per sequence number.
+// - The problems left in the final answer, per sequence number.
+func (c *answerChecker) check(metadata definitions.InputMetadata, model definitions.Model, prompts []definitions.Prompt, responses []definitions.Response) ([]definitions.Response, map[int]int, map[int][]string) {
+	if c == nil {
+		return responses, nil, nil
+	}
+
+	originals := make(map[int]definitions.Prompt, len(prompts))
+	for _, p := range prompts {
+		originals[p.SequenceNumber] = p
+	}
+
+	retries := make(map[int]int)
+	problems := make(map[int][]string)
+	checked := slices.Clone(responses)
+	for i, response := range checked {
+		number := response.SequenceNumber
+		original, ok := originals[number]
+		if number < 1 || number > len(c.validators) || !ok || len(response.ModelResponses) == 0 {
+			continue
+		}
+		validator := c.validators[number-1]
+		answer := response.ModelResponses[0]
+		found := validator.Validate(answer)
+		for len(found) > 0 && retries[number] < c.retries {
+			retries[number]++
+			logger.Info("Answer %s.%d of %s %s does not satisfy its JSON Schema, asking again (%d of %d): %v",
+				response.SequenceID, number, model.Provider, model.Model, retries[number], c.retries, found)
+
+			var sequence []definitions.Prompt
+			if number > 1 {
+				sequence = append(sequence, originals[1]) // the main prompt carries the document
+			}
+			sequence = append(sequence, definitions.Prompt{
+				PromptContent:  prompt.BuildReaskPrompt(c.prompts, original.PromptContent, answer, found, validator.Schema()),
+				SequenceID:     original.SequenceID,
+				SequenceNumber: len(sequence) + 1,
+			})
+			reasked, err := extractDocument(metadata, model, sequence)
+			if err != nil {
+				logger.Error("Error asking again for answer %s.%d of %s %s: %v", response.SequenceID, number, model.Provider, model.Model, err)
+				break
+			}
+			for _, r := range reasked {
+				if r.SequenceNumber == len(sequence) && len(r.ModelResponses) > 0 {
+					answer = r.ModelResponses[0]
+				}
+			}
+			found = validator.Validate(answer)
+		}
+		if retries[number] > 0 {
+			checked[i].ModelResponses = append([]string{answer}, response.ModelResponses[1:]...)
+		}
+		if len(found) > 0 {
+			problems[number] = found
+			logger.Error("Answer %s.%d of %s %s does not satisfy its JSON Schema: %v", response.SequenceID, number, model.Provider, model.Model, found)
+		}
+	}
+	return checked, retries, problems
+}
@@ -0,0 +1,102 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
+)
+
+func TestReviewReasksAnswersFailingTheSchema(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	if err := os.Mkdir(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input directory: %v", err)
+	}
+	for _, name := range []string{"paper1.txt", "paper2.txt"} {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte("Content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to write input file: %v", err)
+		}
+	}
+	mockConfig := strings.Replace(fmt.Sprintf(mockConfigDataTemplate, inputDir, tmpDir),
+		`summary = "no"`, "summary = \"no\"\nschema_retries = 2\nresume = \"no\"", 1) + `
+[review]
+[review.1]
+key = "test"
+values = ["yes", "no"]
+`
+
+	originalExtract := extract
+	defer func() { extract = originalExtract }()
+	calls := 0
+	var reasks []string
+	extract = func(input string) (string, error) {
+		calls++
+		var parsed definitions.Input
+		if err := json.Unmarshal([]byte(input), &parsed); err != nil {
+			return "", err
+		}
+		p := parsed.Prompts[0]
+		// paper1 is answered with a wrong key, then correctly; paper2 always with an invalid value
+		answer := `{"tset": "yes"}`
+		switch {
+		case strings.Contains(p.PromptContent, "Content of paper2"):
+			answer = `{"test": "maybe"}`
+		case strings.Contains(p.PromptContent, "Your previous answer"):
+			answer = `{"test": "yes"}`
+		}
+		if strings.Contains(p.PromptContent, "Your previous answer") {
+			reasks = append(reasks, p.PromptContent)
+		}
+		output := definitions.Output{Responses: []definitions.Response{{
+			SequenceID:     p.SequenceID,
+			SequenceNumber: p.SequenceNumber,
+			Provider:       parsed.Models[0].Provider,
+			Model:          parsed.Models[0].Model,
+			ModelResponses: []string{answer},
+		}}}
+		result, err := json.Marshal(output)
+		return string(result), err
+	}
+
+	if err := Review(mockConfig); err != nil {
+		t.Fatalf("Review failed: %v", err)
+	}
+	if calls != 5 {
+		t.Fatalf("Expected 2 prompts, 1 re-ask for paper1 and 2 for paper2, got %d calls", calls)
+	}
+	if !strings.Contains(reasks[0], "Additional property tset is not allowed") || !strings.Contains(reasks[0], `"additionalProperties": false`) {
+		t.Errorf("Expected the re-ask prompt to list the problems and the schema, got %q", reasks[0])
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test_results.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	if !strings.Contains(string(content), "Provider,Model,File Name,Retries,test\n") || !strings.Contains(string(content), "OpenAI,gpt-4o-mini,paper1,1,yes\n") {
+		t.Errorf("Expected the corrected answer of paper1 and its retries in the results, got %q", string(content))
+	}
+	if !strings.Contains(string(content), "OpenAI,gpt-4o-mini,paper2,2,\n") {
+		t.Errorf("Expected the retries of paper2 in the results, got %q", string(content))
+	}
+
+	runManifest, err := manifest.Load(manifest.Path(filepath.Join(tmpDir, "test_results")))
+	if err != nil {
+		t.Fatalf("Failed to load run manifest: %v", err)
+	}
+	retries := make(map[string]manifest.Response)
+	for _, response := range runManifest.Responses {
+		retries[response.File] = response
+	}
+	if retries["paper1"].Retries != 1 || len(retries["paper1"].SchemaErrors) != 0 {
+		t.Errorf("Expected paper1 to be asked again once and fixed, got %+v", retries["paper1"])
+	}
+	if retries["paper2"].Retries != 2 || len(retries["paper2"].SchemaErrors) != 1 {
+		t.Errorf("Expected paper2 to be asked again twice and left invalid, got %+v", retries["paper2"])
+	}
+}
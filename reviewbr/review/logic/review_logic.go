@@ -1,17 +1,31 @@
 package logic
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/open-and-sustainable/alembica/definitions"
 	"github.com/open-and-sustainable/alembica/extraction"
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/progress"
+	"github.com/open-and-sustainable/prismaid/review/checkpoint"
 	"github.com/open-and-sustainable/prismaid/review/config"
-	"github.com/open-and-sustainable/prismaid/review/debug"
+	"github.com/open-and-sustainable/prismaid/review/cost"
+	"github.com/open-and-sustainable/prismaid/review/gold"
+	"github.com/open-and-sustainable/prismaid/review/lint"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
 	"github.com/open-and-sustainable/prismaid/review/prompt"
 	"github.com/open-and-sustainable/prismaid/review/results"
+	"github.com/open-and-sustainable/prismaid/secrets"
 )
 
 const (
@@ -27,14 +41,30 @@ const (
 
 var exitFunc = os.Exit
 
+// extract runs the prompts through alembica; it is a variable so tests can replace the provider calls.
+var extract = extraction.Extract
+
 func exit(code int) {
 	exitFunc(code)
 }
 
-// Global variable to store the timestamps of requests
-var requestTimestamps []time.Time
+// Global variable to store the timestamps of requests, per provider and model
+var requestTimestamps = make(map[string][]time.Time)
 var mutex sync.Mutex
 
+// ReviewOptions controls a review run with ReviewContext.
+type ReviewOptions struct {
+	Progress progress.Func // optional, receives an event for every document reviewed by a model
+}
+
+// ReviewResult is the outcome of a review run with ReviewContext, also written to the results files.
+type ReviewResult struct {
+	ResultsFile string           // results file, with the extension of the output format
+	Documents   []string         // documents selected for review, without extension
+	Answers     []results.Answer // answers of the first repetition, per document and model
+	Failed      int              // extractions that failed, over models and repetitions
+}
+
 // Review is the main function responsible for orchestrating the systematic review process.
 // It takes a TOML string as input, which defines the configuration for the review, and executes
 // the steps to carry out the review process, including configuration loading, prompt generation,
@@ -61,24 +91,39 @@ var mutex sync.Mutex
 //   - Logging can be written to a file, stdout, or be silent, depending on the log level. Logs are saved
 //     in the directory specified by the ResultsFileName.
 //
-// 3. **Debugging Features Setup**:
-//   - If the Duplication feature is enabled (`Duplication == "yes"`), it duplicates the input files for debugging purposes,
-//     allowing the system to run the extraction twice on the same data for testing and comparison purposes.
+// 3. **Repetitions**:
+//   - With `repetitions` above 1, every document is sent that many times to each model, in memory, and the
+//     stability of the answers across repetitions is reported next to the results. The input directory is
+//     never modified. The deprecated `duplication = "yes"` stands for two repetitions.
 //
 // 4. **Prompt Generation**:
-//   - Prompts are generated using the PrepareInput function, based on the parameters defined in the TOML configuration.
+//   - Prompts are generated using the BuildSelectedInput function, based on the parameters defined in the TOML configuration.
+//   - With `incremental = "yes"`, only documents that are new, modified according to their content hash in the
+//     run manifest, or missing from the existing results file are selected.
+//   - With `screening_results` set, the records included by a screening run are reviewed in place of the
+//     .txt files of the input directory, identified by their record ID or identifier column.
 //   - The function logs the number of files found for review.
 //
 // 5. **Run Extraction**:
-//   - The function calls extraction.Extract with the prepared JSON string to perform the actual review process.
+//   - The function calls extraction.Extract once per document and model, so that every completed response
+//     can be recorded in the checkpoint store placed next to the results file (`resume = "yes"`, default).
+//   - Documents already recorded as completed for the same model and prompt are not sent again, so a rerun
+//     of an interrupted project only retries what is missing or failed.
+//   - With `schema_retries` above 0, every answer is validated against the JSON Schema of its review group
+//     and asked again to the same model when it fails, recording the retries in the run manifest.
 //   - The extraction results are logged.
+//   - The further repetitions, if any, are run the same way once every document has been reviewed; each of
+//     them is recorded in the checkpoint store on its own.
 //
 // 6. **Save Results**:
-//   - Results are saved using the Save function, with review keys sorted alphabetically.
+//   - Results are saved using the Save function, with review keys sorted alphabetically. They hold the answers
+//     of the first repetition, while the answers of all repetitions are compared by SaveStability.
+//   - In incremental mode, the new rows are merged into the existing CSV or JSON output.
+//   - The run manifest is updated with the content hash of every successfully reviewed document and
+//     the few-shot examples shown in its prompts.
 //   - If saving the results fails, an error is logged and returned.
 //
-// 7. **Cleanup**:
-//   - If the Duplication feature was enabled for debugging, the function removes the duplicated input files created earlier.
+// 7. **Completion**:
 //   - Finally, it logs "Done!" to indicate the successful completion of the review.
 //
 // 8. **Error Handling**:
@@ -87,53 +132,593 @@ var mutex sync.Mutex
 // The Review function is the primary entry point for executing the entire review process, based on the user-provided TOML configuration string.
 // It orchestrates the different stages of the review process, including input parsing, prompt generation, extraction, and results handling.
 func Review(tomlConfiguration string) error {
+	_, err := ReviewContext(context.Background(), tomlConfiguration, ReviewOptions{})
+	return err
+}
+
+// ReviewWithProgress runs a review as Review does, reporting its progress to a function: one event
+// per document sent to a model, or resumed from the checkpoint, in every repetition, with the
+// failures, an estimate of the tokens used and of the remaining time.
+//
+// Parameters:
+//   - tomlConfiguration: A string containing the TOML configuration data for the review project.
+//   - report: The function receiving the progress events; nil to disable progress reporting.
+//
+// Returns:
+//   - An error if any step in the review process fails, or nil if the process completes successfully.
+func ReviewWithProgress(tomlConfiguration string, report progress.Func) error {
+	_, err := ReviewContext(context.Background(), tomlConfiguration, ReviewOptions{Progress: report})
+	return err
+}
+
+// ReviewContext runs a review as Review does and returns its outcome. The review stops before the
+// next document once the context is canceled; the documents already reviewed are kept in the
+// checkpoint store, so that running the project again resumes it.
+//
+// Parameters:
+//   - ctx: The context of the review, whose cancellation stops it.
+//   - tomlConfiguration: A string containing the TOML configuration data for the review project.
+//   - options: The options of the run, such as the progress hook.
+//
+// Returns:
+//   - The outcome of the review; also returned, with the failures, when some extractions fail.
+//   - An error if any step in the review process fails, the context is canceled, or extractions fail.
+func ReviewContext(ctx context.Context, tomlConfiguration string, options ReviewOptions) (*ReviewResult, error) {
 	// load project configuration
 	config, err := config.LoadConfig(tomlConfiguration, config.RealEnvReader{})
 	if err != nil {
-		fmt.Println("Error loading project configuration:", err) // here the logging function is not implemented yet
-		return err
+		fmt.Println("Error loading project configuration:", secrets.RedactError(err)) // here the logging function is not implemented yet
+		return nil, err
 	}
+	result := &ReviewResult{ResultsFile: config.Project.Configuration.ResultsFileName + "." + config.Project.Configuration.OutputFormat}
 
 	// setup logging
-	if config.Project.Configuration.LogLevel == "high" {
-		logger.SetupLogging(logger.File, config.Project.Configuration.ResultsFileName)
-	} else if config.Project.Configuration.LogLevel == "medium" {
-		logger.SetupLogging(logger.Stdout, config.Project.Configuration.ResultsFileName)
-	} else {
-		logger.SetupLogging(logger.Silent, config.Project.Configuration.ResultsFileName) // default value
+	setupLogging(config)
+
+	// load the run manifest and, in incremental mode, select new or modified documents
+	manifestPath := manifest.Path(config.Project.Configuration.ResultsFileName)
+	runManifest, err := manifest.Load(manifestPath)
+	if err != nil {
+		return nil, err
 	}
+	hashes, err := inputHashes(config)
+	if err != nil {
+		return nil, err
+	}
+	configHash := manifest.ConfigHash(config)
 
-	// setup other debugging features
-	if config.Project.Configuration.Duplication == "yes" {
-		debug.DuplicateInput(config)
+	var selected map[string]bool
+	var previousResults *results.Previous
+	if config.Project.Configuration.Incremental == "yes" {
+		selected, previousResults, err = selectChangedDocuments(config, runManifest, hashes, configHash)
+		if err != nil {
+			logger.Error("Error selecting documents for incremental review:", err)
+			return nil, err
+		}
+		if selected != nil && len(selected) == 0 {
+			removed, err := hasRemovedDocuments(config, previousResults, hashes)
+			if err != nil {
+				return nil, err
+			}
+			if !removed {
+				logger.Info("No new or modified documents to review.")
+				return result, nil
+			}
+		}
 	}
 
 	// generate prompts
-	jsonString, filenames, err := prompt.PrepareInput(config)
+	input, filenames, examples, err := prompt.BuildSelectedInput(config, selected)
 	if err != nil {
 		logger.Error("Error generating prompts:", err)
-		return err
+		return nil, err
 	}
 	logger.Info("Found", len(filenames), "files")
 
+	// open the checkpoint store to resume previous runs
+	var store *checkpoint.Store
+	if config.Project.Configuration.Resume == "yes" {
+		store, err = checkpoint.Open(checkpoint.Path(config.Project.Configuration.ResultsFileName))
+		if err != nil {
+			logger.Error("Error opening checkpoint store:", err)
+			return nil, err
+		}
+	}
+
 	// run review
-	reviewResults, err := extraction.Extract(jsonString)
+	merger := newChunkMerger(config)
+	checker, err := newAnswerChecker(config)
+	if err != nil {
+		return nil, err
+	}
+	result.Documents = filenames
+	tracker := progress.Start(options.Progress, progress.OperationReview, len(filenames)*len(input.Models)*config.Project.Configuration.Repetitions)
+	defer tracker.Finish()
+	reviewResults, run, failed, err := runExtraction(ctx, input, filenames, store, merger, checker, tracker, 1)
+	if err != nil {
+		logger.Error("Error running review:", err)
+		return nil, err
+	}
 
 	logger.Info("Results:\n%s", reviewResults)
 
+	// repeat the review to measure the stability of the answers
+	repetitions := []results.Repetition{{Results: reviewResults, Run: run}}
+	records := run
+	for repetition := 2; repetition <= config.Project.Configuration.Repetitions; repetition++ {
+		repeatedResults, repeatedRun, repeatedFailed, err := runExtraction(ctx, input, filenames, store, merger, checker, tracker, repetition)
+		if err != nil {
+			logger.Error("Error running repetition %d of the review: %v", repetition, err)
+			return nil, err
+		}
+		repetitions = append(repetitions, results.Repetition{Results: repeatedResults, Run: repeatedRun})
+		records = append(records, repeatedRun...)
+		for filename, count := range repeatedFailed {
+			failed[filename] += count
+		}
+	}
+
 	// save results
+	// in incremental runs the new results are written apart and merged into the previous ones
 	keys := prompt.SortReviewKeysAlphabetically(config)
-	err = results.Save(config, reviewResults, filenames, keys)
+	saveConfig := config
+	if previousResults != nil {
+		saveConfig = results.Staged(config)
+	}
+	err = results.Save(saveConfig, reviewResults, run, keys)
 	if err != nil {
 		logger.Error("Error saving results:", err)
-		return err
+		return nil, err
+	}
+	if err := results.SaveStability(saveConfig, repetitions, keys); err != nil {
+		logger.Error("Error saving the stability report:", err)
+		return nil, err
+	}
+	if previousResults != nil {
+		if err := results.MergePrevious(config, previousResults, hashes); err != nil {
+			logger.Error("Error merging previous results:", err)
+			return nil, err
+		}
+	}
+
+	// record the reviewed documents in the run manifest; failed documents are left out to be retried
+	if runManifest.ConfigHash != configHash {
+		runManifest.Documents = make(map[string]manifest.Document)
+	}
+	runManifest.ConfigHash = configHash
+	runManifest.Responses = records
+	for filename := range runManifest.Documents {
+		if _, ok := hashes[filename]; !ok {
+			delete(runManifest.Documents, filename)
+		}
+	}
+	reviewedAt := time.Now().Format(time.RFC3339)
+	failures := 0
+	for _, filename := range filenames {
+		if failed[filename] > 0 {
+			failures += failed[filename]
+			delete(runManifest.Documents, filename)
+			continue
+		}
+		runManifest.Documents[filename] = manifest.Document{ContentHash: hashes[filename], ReviewedAt: reviewedAt, Examples: examples[filename]}
+	}
+	if err := runManifest.Save(manifestPath); err != nil {
+		return nil, err
+	}
+	result.Answers, err = results.Answers(config, reviewResults, run, keys)
+	if err != nil {
+		return nil, err
 	}
+	result.Failed = failures
 
-	// cleanup eventual debugging temporary files
-	if config.Project.Configuration.Duplication == "yes" {
-		debug.RemoveDuplicateInput(config)
+	if failures > 0 {
+		if store != nil {
+			logger.Info("Run the project again to retry the failed extractions.")
+		}
+		return result, fmt.Errorf("%d of %d extractions failed", failures, len(filenames)*len(input.Models)*config.Project.Configuration.Repetitions)
 	}
 
 	logger.Info("Done!")
-	return nil
+	return result, nil
+}
+
+// EstimateReview estimates the tokens and the cost of a review project without calling any provider.
+// The prompts are generated as in Review and measured for every configured model, and the totals are
+// priced with the bundled price table, updated with the [prices] section of the configuration.
+//
+// Parameters:
+//   - tomlConfiguration: A string containing the TOML configuration data for the review project.
+//
+// Returns:
+//   - The estimate of every configured model, including the documents exceeding their context window.
+//   - An error if the configuration is invalid or the prompts cannot be generated.
+func EstimateReview(tomlConfiguration string) (*cost.Estimate, error) {
+	config, err := config.LoadConfig(tomlConfiguration, config.RealEnvReader{})
+	if err != nil {
+		fmt.Println("Error loading project configuration:", secrets.RedactError(err)) // here the logging function is not implemented yet
+		return nil, err
+	}
+	setupLogging(config)
+
+	estimate, err := cost.Review(config)
+	if err != nil {
+		logger.Error("Error estimating review cost:", err)
+		return nil, err
+	}
+	return estimate, nil
+}
+
+// CheckConfig checks a review configuration without running the review, reporting every problem
+// found with the TOML line of the offending key.
+//
+// Parameters:
+//   - tomlConfiguration: A string containing the TOML configuration data for the review project.
+//
+// Returns:
+//   - The report of the problems found, empty if the configuration is valid.
+func CheckConfig(tomlConfiguration string) *lint.Report {
+	return lint.Check(tomlConfiguration, config.RealEnvReader{})
+}
+
+// ValidateResults compares the results of a review with a gold standard of manuscripts coded by hand
+// and writes the accuracy, confusion and disagreement reports next to the results file.
+//
+// Parameters:
+//   - resultsPath: The results file of the review, in CSV, JSON or JSON Lines format.
+//   - goldPath: The CSV file of the manuscripts coded by hand, with a "File Name" column and the review keys.
+//
+// Returns:
+//   - The comparison of every model with the gold standard.
+//   - An error if the files cannot be read or the reports cannot be written.
+func ValidateResults(resultsPath string, goldPath string) (*gold.Comparison, error) {
+	comparison, err := gold.Compare(resultsPath, goldPath)
+	if err != nil {
+		logger.Error("Error comparing results with the gold standard:", err)
+		return nil, err
+	}
+	paths, err := comparison.Save(gold.ReportBase(resultsPath))
+	if err != nil {
+		return nil, err
+	}
+	logger.Info("Validation reports saved to: %s", strings.Join(paths, ", "))
+	return comparison, nil
+}
+
+// setupLogging configures logging from the log level of the configuration: "high" writes to a file
+// next to the results, "medium" to stdout, and "low" (default) disables logging.
+func setupLogging(config *config.Config) {
+	if config.Project.Configuration.LogLevel == "high" {
+		logger.SetupLogging(logger.File, config.Project.Configuration.ResultsFileName)
+	} else if config.Project.Configuration.LogLevel == "medium" {
+		logger.SetupLogging(logger.Stdout, config.Project.Configuration.ResultsFileName)
+	} else {
+		logger.SetupLogging(logger.Silent, config.Project.Configuration.ResultsFileName) // default value
+	}
+}
+
+// runExtraction sends the prompts of every document to every model, one document and model at a time,
+// and collects the responses into a single alembica output. When a checkpoint store is provided,
+// completed work is looked up before calling the provider and every outcome is recorded as it arrives.
+// The repeated queries of a review are checkpointed apart from the first ones, so that each repetition
+// is sent and resumed on its own.
+//
+// Arguments:
+// - ctx: The context of the review; the extraction stops before the next document once it is canceled.
+// - input: The alembica input built from the configuration.
+// - filenames: The filenames associated with each SequenceID.
+// - store: The checkpoint store, or nil when resuming is disabled.
+// - merger: The merger combining the responses of the chunks of long documents.
+// - checker: The checker validating the answers against their JSON Schema, or nil when disabled.
+// - tracker: The progress tracker, completing one unit per document and model; nil when not reported.
+// - repetition: The repetition of the review, starting at 1.
+//
+// Returns:
+// - A JSON string containing all responses, in the alembica output format.
+// - The run manifest records linking every response, and every failed prompt, to its document, model and prompt.
+// - The number of models whose extraction failed, per filename.
+// - An error if the context is canceled, the checkpoint store cannot be updated or the output cannot be serialized.
+func runExtraction(ctx context.Context, input definitions.Input, filenames []string, store *checkpoint.Store, merger *chunkMerger, checker *answerChecker, tracker *progress.Tracker, repetition int) (string, []manifest.Response, map[string]int, error) {
+	sequences := make(map[string][]definitions.Prompt)
+	parts := make(map[string][]string) // SequenceIDs of the chunks of each document, in order
+	for _, p := range input.Prompts {
+		if _, seen := sequences[p.SequenceID]; !seen {
+			document, _, _ := strings.Cut(p.SequenceID, ".")
+			parts[document] = append(parts[document], p.SequenceID)
+		}
+		sequences[p.SequenceID] = append(sequences[p.SequenceID], p)
+	}
+
+	var output definitions.Output
+	var run []manifest.Response
+	failed := make(map[string]int)
+	if repetition > 1 {
+		tracker.SetStage(fmt.Sprintf("repetition %d", repetition))
+	}
+
+	for _, model := range input.Models {
+		for i, filename := range filenames {
+			if err := ctx.Err(); err != nil {
+				return "", run, failed, err
+			}
+			sequenceID := strconv.Itoa(i + 1)
+			var prompts []definitions.Prompt
+			for _, part := range parts[sequenceID] {
+				prompts = append(prompts, sequences[part]...)
+			}
+			chunked := len(parts[sequenceID]) > 1
+			if chunked {
+				// the merge strategy changes the results of chunked documents
+				prompts = append(prompts, definitions.Prompt{PromptContent: merger.strategy})
+			}
+			hashed := prompts
+			if checker != nil {
+				// the schema retries change the answers kept, but are not sent to the model
+				hashed = append(slices.Clone(prompts), definitions.Prompt{PromptContent: fmt.Sprintf("schema retries %d", checker.retries)})
+			}
+			promptHash := checkpoint.HashRequest(model, hashed)
+			record := manifest.Response{File: filename, Provider: model.Provider, Model: model.Model}
+			if repetition > 1 {
+				// each repetition is checkpointed apart from the others
+				promptHash = checkpoint.HashRequest(model, append(slices.Clone(hashed), definitions.Prompt{PromptContent: fmt.Sprintf("repetition %d", repetition)}))
+				record.Repetition = repetition
+			}
+
+			if store != nil {
+				if entry, ok := store.Lookup(filename, model.Provider, model.Model, promptHash); ok {
+					logger.Info("Skipping %s with %s %s (repetition %d): already completed in checkpoint", filename, model.Provider, model.Model, repetition)
+					record.CompletedAt = entry.Timestamp
+					record.Status = manifest.StatusResumed
+					for _, response := range entry.Responses {
+						response.SequenceID = sequenceID // the document position may differ from the previous run
+						output.Responses = append(output.Responses, response)
+					}
+					run = append(run, runRecords(record, sequenceID, prompts, entry.Responses, entry.Retries, entry.SchemaErrors)...)
+					tracker.Skip(filename)
+					continue
+				}
+			}
+
+			entry := checkpoint.Entry{
+				File:       filename,
+				Provider:   model.Provider,
+				Model:      model.Model,
+				PromptHash: promptHash,
+			}
+
+			var responses []definitions.Response
+			var retries map[int]int
+			var schemaErrors map[int][]string
+			var err error
+			record.StartedAt = time.Now().Format(time.RFC3339)
+			if chunked {
+				responses, retries, schemaErrors, err = extractChunks(input.Metadata, model, sequenceID, parts[sequenceID], sequences, merger, checker)
+			} else {
+				responses, err = extractDocument(input.Metadata, model, prompts)
+				if err == nil {
+					responses, retries, schemaErrors = checker.check(input.Metadata, model, prompts, responses)
+				}
+			}
+			entry.Timestamp = time.Now().Format(time.RFC3339)
+			record.CompletedAt = entry.Timestamp
+			if err != nil {
+				logger.Error("Extraction failed for %s with %s %s (repetition %d): %v", filename, model.Provider, model.Model, repetition, err)
+				entry.Status = checkpoint.StatusFailed
+				entry.Error = err.Error()
+				record.Status = manifest.StatusFailed
+				record.Error = err.Error()
+				failed[filename]++
+			} else {
+				entry.Status = checkpoint.StatusCompleted
+				entry.Responses = responses
+				entry.Retries = retries
+				entry.SchemaErrors = schemaErrors
+				record.Status = manifest.StatusCompleted
+				output.Responses = append(output.Responses, responses...)
+			}
+			run = append(run, runRecords(record, sequenceID, prompts, responses, retries, schemaErrors)...)
+			tracker.Done(filename, err != nil, exchangedTokens(prompts, responses))
+
+			if store != nil {
+				if err := store.Record(entry); err != nil {
+					return "", run, failed, err
+				}
+			}
+		}
+	}
+
+	results, err := json.Marshal(output)
+	if err != nil {
+		return "", run, failed, err
+	}
+	return string(results), run, failed, nil
+}
+
+// runRecords builds the run manifest records of the prompts sent for a document to a model.
+// The prompt hash of each record covers the prompts with its sequence number, including those
+// of every chunk of a long document. A failed extraction is recorded once per sequence number.
+// Each record carries the number of times its answer was asked again and the problems left in it.
+//
+// Arguments:
+// - record: The record shared by the responses, with file, model, timestamps and status.
+// - sequenceID: The SequenceID of the document.
+// - prompts: The prompts sent for the document.
+// - responses: The responses received, empty if the extraction failed.
+// - retries: The number of times each answer was asked again, by sequence number.
+// - schemaErrors: The problems left in each answer by the schema validation, by sequence number.
+//
+// Returns:
+// - One record per response, or per sequence number for failed extractions.
+func runRecords(record manifest.Response, sequenceID string, prompts []definitions.Prompt, responses []definitions.Response, retries map[int]int, schemaErrors map[int][]string) []manifest.Response {
+	sequences := make(map[int][]definitions.Prompt)
+	var numbers []int
+	for _, p := range prompts {
+		if p.SequenceNumber < 1 {
+			continue // the merge strategy of chunked documents is not a prompt
+		}
+		if _, seen := sequences[p.SequenceNumber]; !seen {
+			numbers = append(numbers, p.SequenceNumber)
+		}
+		sequences[p.SequenceNumber] = append(sequences[p.SequenceNumber], p)
+	}
+	sort.Ints(numbers)
+
+	var records []manifest.Response
+	add := func(sequenceNumber int) {
+		r := record
+		r.SequenceID = sequenceID
+		r.SequenceNumber = sequenceNumber
+		r.PromptHash = checkpoint.HashPrompts(sequences[sequenceNumber])
+		r.Retries = retries[sequenceNumber]
+		r.SchemaErrors = schemaErrors[sequenceNumber]
+		records = append(records, r)
+	}
+	if record.Status == manifest.StatusFailed {
+		for _, number := range numbers {
+			add(number)
+		}
+		return records
+	}
+	for _, response := range responses {
+		add(response.SequenceNumber)
+	}
+	return records
+}
+
+// exchangedTokens estimates the tokens of the prompts sent for a document and of the answers received,
+// for progress reports. Re-asked answers and merge calls are not counted. The estimate is taken from
+// the text length, as tokenizing every prompt again would slow down reviews for a progress line.
+func exchangedTokens(prompts []definitions.Prompt, responses []definitions.Response) int {
+	tokens := 0
+	for _, p := range prompts {
+		tokens += prompt.EstimateTokens(p.PromptContent)
+	}
+	for _, response := range responses {
+		for _, answer := range response.ModelResponses {
+			tokens += prompt.EstimateTokens(answer)
+		}
+	}
+	return tokens
+}
+
+// extractDocument runs the prompts of a single document through a single model.
+//
+// Arguments:
+// - metadata: The metadata of the full review input.
+// - model: The model to query.
+// - prompts: The main prompt of the document and its follow-ups, sharing one SequenceID.
+//
+// Returns:
+// - The responses returned by the model.
+// - An error if the call fails or the main prompt received no answer; registered secrets are
+// redacted from its message.
+func extractDocument(metadata definitions.InputMetadata, model definitions.Model, prompts []definitions.Prompt) ([]definitions.Response, error) {
+	input := definitions.Input{
+		Metadata: metadata,
+		Models:   []definitions.Model{model},
+		Prompts:  prompts,
+	}
+	jsonInput, err := json.Marshal(input)
+	if err != nil {
+		return nil, err
+	}
+
+	waitForRateLimit(model, len(prompts))
+
+	result, err := extract(string(jsonInput))
+	if err != nil {
+		// provider errors may quote the request, API key included; the message is logged and recorded
+		return nil, errors.New(secrets.RedactError(err))
+	}
+
+	var output definitions.Output
+	if err := json.Unmarshal([]byte(result), &output); err != nil {
+		return nil, fmt.Errorf("error parsing extraction output: %v", err)
+	}
+
+	for _, response := range output.Responses {
+		if response.SequenceNumber == 1 && len(response.ModelResponses) > 0 && response.ModelResponses[0] != "" {
+			return output.Responses, nil
+		}
+	}
+	return nil, fmt.Errorf("no answer received for the main prompt")
+}
+
+// extractChunks runs the prompts of each chunk of a document through a model and merges the responses.
+// The answers of every chunk are validated, and asked again if needed, before the merge.
+//
+// Arguments:
+// - metadata: The metadata of the full review input.
+// - model: The model to query.
+// - sequenceID: The SequenceID of the document.
+// - parts: The SequenceIDs of the chunks of the document, in order.
+// - sequences: The prompts of every sequence, indexed by SequenceID.
+// - merger: The merger combining the chunk responses.
+// - checker: The checker validating the answers against their JSON Schema, or nil when disabled.
+//
+// Returns:
+// - The merged responses, with the SequenceID of the document.
+// - The number of times the answers were asked again over the chunks, per sequence number.
+// - The problems left in the answers of the chunks, per sequence number.
+// - An error if any chunk fails or the responses cannot be merged.
+func extractChunks(metadata definitions.InputMetadata, model definitions.Model, sequenceID string, parts []string, sequences map[string][]definitions.Prompt, merger *chunkMerger, checker *answerChecker) ([]definitions.Response, map[int]int, map[int][]string, error) {
+	chunks := make([][]definitions.Response, 0, len(parts))
+	var retries map[int]int
+	var schemaErrors map[int][]string
+	for k, part := range parts {
+		responses, err := extractDocument(metadata, model, sequences[part])
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("chunk %d of %d: %v", k+1, len(parts), err)
+		}
+		responses, chunkRetries, chunkErrors := checker.check(metadata, model, sequences[part], responses)
+		for number, count := range chunkRetries {
+			if retries == nil {
+				retries = make(map[int]int)
+			}
+			retries[number] += count
+		}
+		for number, problems := range chunkErrors {
+			if schemaErrors == nil {
+				schemaErrors = make(map[int][]string)
+			}
+			for _, problem := range problems {
+				schemaErrors[number] = append(schemaErrors[number], fmt.Sprintf("chunk %d: %s", k+1, problem))
+			}
+		}
+		chunks = append(chunks, responses)
+	}
+	merged, err := merger.merge(metadata, model, sequenceID, chunks)
+	return merged, retries, schemaErrors, err
+}
+
+// waitForRateLimit delays the next call to a model until sending the given number of requests keeps
+// the requests of the last minute within its RPM limit. alembica enforces limits within a single call,
+// while this keeps the separate per-document calls within the same limit.
+func waitForRateLimit(model definitions.Model, requests int) {
+	if model.RPMLimit <= 0 {
+		return
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	key := model.Provider + "/" + model.Model
+	for {
+		now := time.Now()
+		recent := requestTimestamps[key][:0]
+		for _, timestamp := range requestTimestamps[key] {
+			if now.Sub(timestamp) < time.Minute {
+				recent = append(recent, timestamp)
+			}
+		}
+		requestTimestamps[key] = recent
+
+		if len(recent) == 0 || len(recent)+requests <= model.RPMLimit {
+			break
+		}
+		time.Sleep(time.Minute - now.Sub(recent[0]))
+	}
+
+	for i := 0; i < requests; i++ {
+		requestTimestamps[key] = append(requestTimestamps[key], time.Now())
+	}
 }
@@ -1,10 +1,18 @@
 package logic
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/progress"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
 )
 
 const mockConfigDataTemplate = `
@@ -100,3 +108,433 @@ func TestRunReviewWithTempFiles(t *testing.T) {
 		t.Fatalf("Failed to clean up the output file: %v", err)
 	}
 }
+
+func TestReviewResumesFromCheckpoint(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	if err := os.Mkdir(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input directory: %v", err)
+	}
+	for _, name := range []string{"paper1.txt", "paper2.txt"} {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte("Content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to write input file: %v", err)
+		}
+	}
+	mockConfig := fmt.Sprintf(mockConfigDataTemplate, inputDir, tmpDir) + `
+[review]
+[review.1]
+key = "test"
+values = ["yes", "no"]
+`
+
+	originalExtract := extract
+	defer func() { extract = originalExtract }()
+
+	calls := 0
+	failPaper2 := true
+	extract = mockExtract(&calls, func(prompt string) bool {
+		return failPaper2 && strings.Contains(prompt, "paper2")
+	})
+
+	if err := Review(mockConfig); err == nil {
+		t.Fatalf("Expected first run to report the failed extraction")
+	}
+	if calls != 2 {
+		t.Fatalf("Expected 2 extraction calls in the first run, got %d", calls)
+	}
+
+	calls = 0
+	failPaper2 = false
+	if err := Review(mockConfig); err != nil {
+		t.Fatalf("Expected resumed run to succeed, got: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("Expected only the failed document to be extracted again, got %d calls", calls)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test_results.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	expectedContent := "Provider,Model,File Name,test\n" +
+		"OpenAI,gpt-4o-mini,paper1,yes\n" +
+		"OpenAI,gpt-4o-mini,paper2,yes\n"
+	if string(content) != expectedContent {
+		t.Errorf("Expected resumed output %q, got %q", expectedContent, string(content))
+	}
+
+	runManifest, err := manifest.Load(manifest.Path(filepath.Join(tmpDir, "test_results")))
+	if err != nil {
+		t.Fatalf("Failed to load run manifest: %v", err)
+	}
+	if len(runManifest.Responses) != 2 {
+		t.Fatalf("Expected one manifest record per response, got %+v", runManifest.Responses)
+	}
+	for i, expected := range []manifest.Response{
+		{SequenceID: "1", SequenceNumber: 1, File: "paper1", Status: manifest.StatusResumed},
+		{SequenceID: "2", SequenceNumber: 1, File: "paper2", Status: manifest.StatusCompleted},
+	} {
+		record := runManifest.Responses[i]
+		if record.SequenceID != expected.SequenceID || record.SequenceNumber != expected.SequenceNumber ||
+			record.File != expected.File || record.Status != expected.Status || record.PromptHash == "" || record.CompletedAt == "" {
+			t.Errorf("Unexpected manifest record %d: %+v", i+1, record)
+		}
+	}
+
+	// answers checkpointed without schema validation are not resumed once it is enabled
+	calls = 0
+	validated := strings.Replace(mockConfig, `summary = "no"`, "summary = \"no\"\nschema_retries = 1", 1)
+	if err := Review(validated); err != nil {
+		t.Fatalf("Expected validated run to succeed, got: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("Expected both documents to be extracted again with schema_retries, got %d calls", calls)
+	}
+}
+
+func TestReviewReportsProgress(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	if err := os.Mkdir(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input directory: %v", err)
+	}
+	for _, name := range []string{"paper1.txt", "paper2.txt"} {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte("Content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to write input file: %v", err)
+		}
+	}
+	mockConfig := fmt.Sprintf(mockConfigDataTemplate, inputDir, tmpDir) + `
+[review]
+[review.1]
+key = "test"
+values = ["yes", "no"]
+`
+
+	originalExtract := extract
+	defer func() { extract = originalExtract }()
+	calls := 0
+	failPaper2 := true
+	extract = mockExtract(&calls, func(prompt string) bool {
+		return failPaper2 && strings.Contains(prompt, "paper2")
+	})
+
+	var events []progress.Event
+	record := func(e progress.Event) { events = append(events, e) }
+	if err := ReviewWithProgress(mockConfig, record); err == nil {
+		t.Fatalf("Expected first run to report the failed extraction")
+	}
+	if len(events) != 4 {
+		t.Fatalf("Expected a start, 2 documents and a finish event, got %+v", events)
+	}
+	last := events[len(events)-1]
+	if events[0].Total != 2 || events[0].Done != 0 || !last.Finished || last.Done != 2 || last.Failed != 1 || last.Tokens == 0 {
+		t.Errorf("Unexpected events of the first run %+v", events)
+	}
+
+	events = nil
+	failPaper2 = false
+	if err := ReviewWithProgress(mockConfig, record); err != nil {
+		t.Fatalf("Expected resumed run to succeed, got: %v", err)
+	}
+	if len(events) != 4 || events[1].Item != "paper1" || events[1].Tokens != 0 || events[2].Item != "paper2" || events[3].Failed != 0 {
+		t.Errorf("Expected the resumed document to be skipped, got %+v", events)
+	}
+}
+
+func TestReviewContextReturnsAnswers(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	if err := os.Mkdir(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input directory: %v", err)
+	}
+	for _, name := range []string{"paper1.txt", "paper2.txt"} {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte("Content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to write input file: %v", err)
+		}
+	}
+	mockConfig := fmt.Sprintf(mockConfigDataTemplate, inputDir, tmpDir) + `
+[review]
+[review.1]
+key = "test"
+values = ["yes", "no"]
+`
+
+	originalExtract := extract
+	defer func() { extract = originalExtract }()
+	calls := 0
+	extract = mockExtract(&calls, func(string) bool { return false })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := ReviewContext(ctx, mockConfig, ReviewOptions{}); !errors.Is(err, context.Canceled) || calls != 0 {
+		t.Fatalf("Expected a canceled review without extraction, got %v after %d calls", err, calls)
+	}
+
+	result, err := ReviewContext(context.Background(), mockConfig, ReviewOptions{})
+	if err != nil {
+		t.Fatalf("ReviewContext failed: %v", err)
+	}
+	if result.ResultsFile != filepath.Join(tmpDir, "test_results.csv") || strings.Join(result.Documents, ",") != "paper1,paper2" || result.Failed != 0 {
+		t.Errorf("Unexpected result %+v", result)
+	}
+	if len(result.Answers) != 2 {
+		t.Fatalf("Expected one answer per document, got %+v", result.Answers)
+	}
+	for i, answer := range result.Answers {
+		if answer.File != result.Documents[i] || answer.Provider != "OpenAI" || answer.Model != "gpt-4o-mini" || answer.Values["test"] != "yes" {
+			t.Errorf("Unexpected answer %+v", answer)
+		}
+	}
+}
+
+func TestReviewIncrementalReviewsOnlyChangedDocuments(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	if err := os.Mkdir(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input directory: %v", err)
+	}
+	writeInput := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write input file: %v", err)
+		}
+	}
+	writeInput("paper1.txt", "Content of paper1")
+	writeInput("paper2.txt", "Content of paper2")
+
+	mockConfig := strings.Replace(fmt.Sprintf(mockConfigDataTemplate, inputDir, tmpDir),
+		`summary = "no"`, "summary = \"no\"\nincremental = \"yes\"\nresume = \"no\"", 1) + `
+[review]
+[review.1]
+key = "test"
+values = ["yes", "no"]
+`
+
+	originalExtract := extract
+	defer func() { extract = originalExtract }()
+	calls := 0
+	extract = mockExtract(&calls, func(string) bool { return false })
+
+	if err := Review(mockConfig); err != nil {
+		t.Fatalf("First run failed: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("Expected 2 extraction calls in the first run, got %d", calls)
+	}
+
+	calls = 0
+	if err := Review(mockConfig); err != nil {
+		t.Fatalf("Second run failed: %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("Expected no extraction calls without changes, got %d", calls)
+	}
+
+	calls = 0
+	writeInput("paper2.txt", "Revised content of paper2")
+	writeInput("paper3.txt", "Content of paper3")
+	if err := Review(mockConfig); err != nil {
+		t.Fatalf("Third run failed: %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("Expected only the modified and added documents to be extracted, got %d calls", calls)
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test_results.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	expectedContent := "Provider,Model,File Name,test\n" +
+		"OpenAI,gpt-4o-mini,paper1,yes\n" +
+		"OpenAI,gpt-4o-mini,paper2,yes\n" +
+		"OpenAI,gpt-4o-mini,paper3,yes\n"
+	if string(content) != expectedContent {
+		t.Errorf("Expected merged output %q, got %q", expectedContent, string(content))
+	}
+
+	calls = 0
+	if err := os.Remove(filepath.Join(inputDir, "paper1.txt")); err != nil {
+		t.Fatalf("Failed to remove input file: %v", err)
+	}
+	if err := Review(mockConfig); err != nil {
+		t.Fatalf("Fourth run failed: %v", err)
+	}
+	if calls != 0 {
+		t.Fatalf("Expected no extraction calls after removing a document, got %d", calls)
+	}
+	content, err = os.ReadFile(filepath.Join(tmpDir, "test_results.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	expectedContent = "Provider,Model,File Name,test\n" +
+		"OpenAI,gpt-4o-mini,paper2,yes\n" +
+		"OpenAI,gpt-4o-mini,paper3,yes\n"
+	if string(content) != expectedContent {
+		t.Errorf("Expected the removed document to be pruned, got %q", string(content))
+	}
+	runManifest, err := manifest.Load(manifest.Path(filepath.Join(tmpDir, "test_results")))
+	if err != nil {
+		t.Fatalf("Failed to load the run manifest: %v", err)
+	}
+	if _, ok := runManifest.Documents["paper1"]; ok {
+		t.Errorf("Expected the removed document to be dropped from the run manifest")
+	}
+}
+
+func TestReviewRepetitionsReportStability(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	if err := os.Mkdir(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input directory: %v", err)
+	}
+	for _, name := range []string{"paper1.txt", "paper2.txt"} {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte("Content of "+name), 0644); err != nil {
+			t.Fatalf("Failed to write input file: %v", err)
+		}
+	}
+	mockConfig := strings.Replace(fmt.Sprintf(mockConfigDataTemplate, inputDir, tmpDir),
+		`summary = "no"`, "summary = \"no\"\nrepetitions = 3\nresume = \"yes\"", 1) + `
+[review]
+[review.1]
+key = "test"
+values = ["yes", "no"]
+`
+
+	originalExtract := extract
+	defer func() { extract = originalExtract }()
+	calls := 0
+	extract = mockExtract(&calls, func(string) bool { return false })
+
+	if err := Review(mockConfig); err != nil {
+		t.Fatalf("Review failed: %v", err)
+	}
+	if calls != 6 {
+		t.Fatalf("Expected every document to be sent 3 times, got %d calls", calls)
+	}
+
+	inputs, err := os.ReadDir(inputDir)
+	if err != nil {
+		t.Fatalf("Failed to list input directory: %v", err)
+	}
+	if len(inputs) != 2 {
+		t.Errorf("Expected the input directory to be left untouched, got %d files", len(inputs))
+	}
+
+	content, err := os.ReadFile(filepath.Join(tmpDir, "test_results.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read output file: %v", err)
+	}
+	expectedContent := "Provider,Model,File Name,test\n" +
+		"OpenAI,gpt-4o-mini,paper1,yes\n" +
+		"OpenAI,gpt-4o-mini,paper2,yes\n"
+	if string(content) != expectedContent {
+		t.Errorf("Expected the answers of the first repetition %q, got %q", expectedContent, string(content))
+	}
+
+	content, err = os.ReadFile(filepath.Join(tmpDir, "test_results_stability.csv"))
+	if err != nil {
+		t.Fatalf("Failed to read stability report: %v", err)
+	}
+	if !strings.Contains(string(content), "OpenAI,gpt-4o-mini,paper2,test,3,yes (3),1.00,yes") {
+		t.Errorf("Unexpected stability report %q", string(content))
+	}
+
+	runManifest, err := manifest.Load(manifest.Path(filepath.Join(tmpDir, "test_results")))
+	if err != nil {
+		t.Fatalf("Failed to load run manifest: %v", err)
+	}
+	if len(runManifest.Responses) != 6 || runManifest.Responses[0].Repetition != 0 || runManifest.Responses[5].Repetition != 3 {
+		t.Errorf("Expected the responses of every repetition in the manifest, got %+v", runManifest.Responses)
+	}
+
+	calls = 0
+	if err := Review(mockConfig); err != nil {
+		t.Fatalf("Resumed run failed: %v", err)
+	}
+	if calls != 0 {
+		t.Errorf("Expected every repetition to be resumed from the checkpoint, got %d calls", calls)
+	}
+}
+
+func TestReviewRedactsAPIKeysFromFailures(t *testing.T) {
+	tmpDir := t.TempDir()
+	inputDir := filepath.Join(tmpDir, "input")
+	if err := os.Mkdir(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "paper1.txt"), []byte("Content of paper1"), 0644); err != nil {
+		t.Fatalf("Failed to write input file: %v", err)
+	}
+	mockConfig := strings.Replace(fmt.Sprintf(mockConfigDataTemplate, inputDir, tmpDir),
+		`summary = "no"`, "summary = \"no\"\nresume = \"yes\"", 1) + `
+[review]
+[review.1]
+key = "test"
+values = ["yes", "no"]
+`
+
+	originalExtract := extract
+	defer func() { extract = originalExtract }()
+	extract = func(input string) (string, error) {
+		var parsed definitions.Input
+		if err := json.Unmarshal([]byte(input), &parsed); err != nil {
+			return "", err
+		}
+		return "", fmt.Errorf("incorrect API key provided: %s", parsed.Models[0].APIKey)
+	}
+
+	if err := Review(mockConfig); err == nil {
+		t.Fatalf("Expected the run to report the failed extraction")
+	}
+
+	runManifest, err := manifest.Load(manifest.Path(filepath.Join(tmpDir, "test_results")))
+	if err != nil {
+		t.Fatalf("Failed to load run manifest: %v", err)
+	}
+	if len(runManifest.Responses) != 1 || runManifest.Responses[0].Error != "incorrect API key provided: [REDACTED]" {
+		t.Errorf("Expected the failure to be recorded without the API key, got %+v", runManifest.Responses)
+	}
+
+	files, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatalf("Failed to list output files: %v", err)
+	}
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(tmpDir, file.Name()))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file.Name(), err)
+		}
+		if strings.Contains(string(content), "test-api-key") {
+			t.Errorf("Expected no API key in %s, got %s", file.Name(), content)
+		}
+	}
+}
+
+// mockExtract returns a replacement for extract answering every prompt with {"test": "yes"},
+// counting the calls and failing those whose main prompt matches fail.
+func mockExtract(calls *int, fail func(prompt string) bool) func(string) (string, error) {
+	return func(input string) (string, error) {
+		*calls++
+		var parsed definitions.Input
+		if err := json.Unmarshal([]byte(input), &parsed); err != nil {
+			return "", err
+		}
+		if fail(parsed.Prompts[0].PromptContent) {
+			return "", fmt.Errorf("provider unavailable")
+		}
+		output := definitions.Output{}
+		for _, p := range parsed.Prompts {
+			output.Responses = append(output.Responses, definitions.Response{
+				SequenceID:     p.SequenceID,
+				SequenceNumber: p.SequenceNumber,
+				Provider:       parsed.Models[0].Provider,
+				Model:          parsed.Models[0].Model,
+				ModelResponses: []string{`{"test": "yes"}`},
+			})
+		}
+		data, err := json.Marshal(output)
+		return string(data), err
+	}
+}
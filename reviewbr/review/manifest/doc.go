@@ -0,0 +1,7 @@
+// Package manifest provides the run manifest written next to the results of a review. The manifest
+// records the content hash of every reviewed document, with the few-shot examples shown in its prompts,
+// and a hash of the prompt and model configuration, so that later runs can detect which manuscripts
+// were added or modified since the previous review.
+// It also lists every response of the latest run with the document, model and prompt that produced it,
+// which the results writers use to attribute answers to manuscripts.
+package manifest
@@ -0,0 +1,277 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+const manifestSuffix = "_manifest.json"
+
+// Status of a response in the run manifest.
+const (
+	// StatusCompleted marks a response received from the provider during the run.
+	StatusCompleted = "completed"
+	// StatusResumed marks a response taken from the checkpoint store of a previous run.
+	StatusResumed = "resumed"
+	// StatusFailed marks a prompt whose extraction failed; it has no response.
+	StatusFailed = "failed"
+)
+
+// Manifest describes the documents covered by the results of a review project and the responses
+// of its latest run.
+type Manifest struct {
+	Updated    string              `json:"updated"`
+	ConfigHash string              `json:"config_hash"`
+	Documents  map[string]Document `json:"documents"`
+	Responses  []Response          `json:"responses,omitempty"`
+}
+
+// Document records the state of a reviewed input file and the few-shot examples shown in its prompts.
+type Document struct {
+	ContentHash string   `json:"content_hash"`
+	ReviewedAt  string   `json:"reviewed_at"`
+	Examples    []string `json:"examples,omitempty"`
+}
+
+// Response links a response of the latest run to the document, model and prompt that produced it.
+// SequenceID and SequenceNumber identify the response in the alembica output; the prompt hash
+// identifies the prompt text, so that each answer can be traced back to the prompt that produced it.
+type Response struct {
+	SequenceID     string `json:"sequence_id"`
+	SequenceNumber int    `json:"sequence_number"`
+	File           string `json:"file"`
+	Provider       string `json:"provider"`
+	Model          string `json:"model"`
+	PromptHash     string `json:"prompt_hash"`
+	StartedAt      string `json:"started_at,omitempty"`
+	CompletedAt    string `json:"completed_at"`
+	Status         string `json:"status"`
+	Error          string `json:"error,omitempty"`
+	Repetition     int    `json:"repetition,omitempty"` // set from 2 for the repeated queries of a document
+
+	Retries      int      `json:"retries,omitempty"`       // times the answer was asked again after failing its JSON Schema
+	SchemaErrors []string `json:"schema_errors,omitempty"` // problems left in the answer by the schema validation
+}
+
+// Path returns the location of the manifest associated with a results file name.
+//
+// Arguments:
+// - resultsFileName: The results_file_name from the project configuration, without extension.
+//
+// Returns:
+// - The path of the manifest file, placed next to the results.
+func Path(resultsFileName string) string {
+	return resultsFileName + manifestSuffix
+}
+
+// Load reads the manifest at the given path. A missing file results in an empty manifest.
+//
+// Arguments:
+// - path: The location of the manifest file.
+//
+// Returns:
+// - A pointer to the loaded Manifest.
+// - An error if the file exists but cannot be read or parsed.
+func Load(path string) (*Manifest, error) {
+	manifest := &Manifest{Documents: make(map[string]Document)}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return manifest, nil
+	}
+	if err != nil {
+		logger.Error("Error reading run manifest: %v", err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, manifest); err != nil {
+		logger.Error("Error parsing run manifest: %v", err)
+		return nil, err
+	}
+	if manifest.Documents == nil {
+		manifest.Documents = make(map[string]Document)
+	}
+	return manifest, nil
+}
+
+// Save writes the manifest to the given path, updating its timestamp.
+//
+// Arguments:
+// - path: The location of the manifest file.
+//
+// Returns:
+// - An error if the manifest cannot be serialized or written.
+func (m *Manifest) Save(path string) error {
+	m.Updated = time.Now().Format(time.RFC3339)
+
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		logger.Error("Error marshaling run manifest: %v", err)
+		return err
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		logger.Error("Error writing run manifest: %v", err)
+		return err
+	}
+
+	logger.Info("Run manifest saved to: %s", path)
+	return nil
+}
+
+// HashInputs computes the SHA-256 content hash of every .txt file in the input directory.
+//
+// Arguments:
+// - inputDirectory: The directory containing the manuscripts to review.
+//
+// Returns:
+// - A map from filename (without extension) to hex-encoded content hash.
+// - An error if the directory or one of its files cannot be read.
+func HashInputs(inputDirectory string) (map[string]string, error) {
+	files, err := os.ReadDir(inputDirectory)
+	if err != nil {
+		logger.Error(err)
+		return nil, err
+	}
+
+	hashes := make(map[string]string)
+	for _, file := range files {
+		if filepath.Ext(file.Name()) != ".txt" {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(inputDirectory, file.Name()))
+		if err != nil {
+			logger.Error("Error reading file:", err)
+			return nil, err
+		}
+		hashes[strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))] = HashContent(content)
+	}
+	return hashes, nil
+}
+
+// HashContent computes the SHA-256 hash of the content of a document, as recorded in the manifest.
+//
+// Arguments:
+// - content: The content of the document.
+//
+// Returns:
+// - The hex-encoded content hash.
+func HashContent(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// ConfigHash computes a hash of the configuration elements that determine the answers of a review:
+// the prompt, the review items and groups, the follow-up options and queries, the prompt language and the
+// [internal_prompts] overrides, the repetitions and schema retries, the chunking options when enabled, the
+// content of the metadata file used by prompt templates, the few-shot examples and their options when an
+// examples directory is set, and the configured providers and models with their temperature and endpoint.
+// Results produced under a different hash cannot be merged with new ones.
+//
+// Arguments:
+// - config: A pointer to the application's configuration.
+//
+// Returns:
+// - The hex-encoded hash.
+func ConfigHash(config *config.Config) string {
+	models := make(map[string]string, len(config.Project.LLM))
+	for key, llm := range config.Project.LLM {
+		models[key] = llm.Provider + "/" + llm.Model
+		// the parameters changing the answers, as in the checkpoint key; models without any keep
+		// the hashes of the manifests written before they were hashed
+		if llm.Temperature != 0 || llm.BaseURL != "" || llm.EndpointType != "" || llm.Region != "" || llm.ProjectID != "" || llm.Location != "" || llm.APIVersion != "" {
+			data, _ := json.Marshal([]any{llm.Temperature, llm.BaseURL, llm.EndpointType, llm.Region, llm.ProjectID, llm.Location, llm.APIVersion})
+			models[key] += " " + string(data)
+		}
+	}
+
+	settings := map[string]any{
+		"prompt":            config.Prompt,
+		"review":            config.Review,
+		"models":            models,
+		"cot_justification": config.Project.Configuration.CotJustification,
+		"summary":           config.Project.Configuration.Summary,
+	}
+	if len(config.ReviewGroups) > 0 {
+		settings["review_groups"] = config.ReviewGroups
+	}
+	if len(config.FollowUpQueries) > 0 {
+		settings["follow_ups"] = config.FollowUpQueries
+	}
+	// English prompts without overrides keep the hashes of the manifests written before prompt_language
+	if language := config.Project.Configuration.PromptLanguage; language != "" && language != localization.English {
+		settings["prompt_language"] = language
+	}
+	if repetitions := config.Project.Configuration.Repetitions; repetitions > 1 {
+		settings["repetitions"] = repetitions
+	}
+	if retries := config.Project.Configuration.SchemaRetries; retries > 0 {
+		settings["schema_retries"] = retries
+	}
+	if len(config.InternalPrompts) > 0 {
+		settings["internal_prompts"] = config.InternalPrompts // marshaled with sorted keys
+	}
+	if config.Project.Configuration.Chunking == "yes" {
+		settings["chunking"] = []any{
+			config.Project.Configuration.ChunkTokens,
+			config.Project.Configuration.ChunkOverlap,
+			config.Project.Configuration.MergeStrategy,
+		}
+	}
+
+	if directory := config.Project.Configuration.ExamplesDirectory; directory != "" {
+		examples, err := hashDirectory(directory)
+		if err != nil {
+			logger.Error("Error reading examples directory: %v", err)
+		}
+		settings["examples"] = []any{
+			examples,
+			config.Project.Configuration.ExamplesTokens,
+			config.Project.Configuration.ExamplesSelection,
+			config.Project.Configuration.ExamplesCount,
+		}
+	}
+
+	if path := config.Project.Configuration.MetadataFile; path != "" {
+		metadata, err := os.ReadFile(path)
+		if err != nil {
+			logger.Error("Error reading metadata file: %v", err)
+		}
+		metadataSum := sha256.Sum256(metadata)
+		settings["metadata"] = hex.EncodeToString(metadataSum[:])
+	}
+
+	data, _ := json.Marshal(settings)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashDirectory computes the SHA-256 content hash of every file of a directory, by filename.
+func hashDirectory(directory string) (map[string]string, error) {
+	files, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make(map[string]string, len(files))
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(directory, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		sum := sha256.Sum256(content)
+		hashes[file.Name()] = hex.EncodeToString(sum[:])
+	}
+	return hashes, nil
+}
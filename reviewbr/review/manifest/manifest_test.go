@@ -0,0 +1,133 @@
+package manifest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestSaveAndLoad(t *testing.T) {
+	path := Path(filepath.Join(t.TempDir(), "results"))
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned an error for a missing manifest: %v", err)
+	}
+	if len(loaded.Documents) != 0 {
+		t.Fatalf("Expected an empty manifest, got %d documents", len(loaded.Documents))
+	}
+
+	loaded.ConfigHash = "hash"
+	loaded.Documents["paper1"] = Document{ContentHash: "abc", ReviewedAt: "2026-01-01T00:00:00Z"}
+	if err := loaded.Save(path); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	reloaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if reloaded.ConfigHash != "hash" || reloaded.Documents["paper1"].ContentHash != "abc" {
+		t.Errorf("Unexpected manifest content after reload: %+v", reloaded)
+	}
+}
+
+func TestHashInputs(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"paper1.txt": "same content",
+		"paper2.txt": "same content",
+		"paper3.txt": "other content",
+		"notes.md":   "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	hashes, err := HashInputs(dir)
+	if err != nil {
+		t.Fatalf("HashInputs returned an error: %v", err)
+	}
+	if len(hashes) != 3 {
+		t.Fatalf("Expected 3 hashed documents, got %d", len(hashes))
+	}
+	if hashes["paper1"] != hashes["paper2"] {
+		t.Errorf("Expected identical content to produce identical hashes")
+	}
+	if hashes["paper1"] == hashes["paper3"] {
+		t.Errorf("Expected different content to produce different hashes")
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	cfg := &config.Config{
+		Prompt: config.PromptConfig{Task: "Map the concepts."},
+		Review: map[string]config.ReviewItem{"1": {Key: "test", Values: []string{"yes", "no"}}},
+		Project: config.ProjectConfig{
+			LLM: map[string]config.LLMItem{"1": {Provider: "OpenAI", Model: "gpt-4o-mini", ApiKey: "key1"}},
+		},
+	}
+	original := ConfigHash(cfg)
+
+	cfg.Project.LLM["1"] = config.LLMItem{Provider: "OpenAI", Model: "gpt-4o-mini", ApiKey: "key2"}
+	if ConfigHash(cfg) != original {
+		t.Errorf("Expected API key changes not to affect the configuration hash")
+	}
+
+	cfg.Review["2"] = config.ReviewItem{Key: "scale", Values: []string{"world"}}
+	if ConfigHash(cfg) == original {
+		t.Errorf("Expected a new review item to change the configuration hash")
+	}
+
+	withReviewItem := ConfigHash(cfg)
+	cfg.FollowUpQueries = map[string]config.FollowUpItem{"limitations": {Query: "List the limitations."}}
+	if ConfigHash(cfg) == withReviewItem {
+		t.Errorf("Expected a new follow-up query to change the configuration hash")
+	}
+
+	withFollowUp := ConfigHash(cfg)
+	cfg.Project.Configuration.PromptLanguage = "en"
+	if ConfigHash(cfg) != withFollowUp {
+		t.Errorf("Expected the default prompt language not to change the configuration hash")
+	}
+	cfg.Project.Configuration.PromptLanguage = "es"
+	withLanguage := ConfigHash(cfg)
+	if withLanguage == withFollowUp {
+		t.Errorf("Expected a new prompt language to change the configuration hash")
+	}
+	cfg.InternalPrompts = map[string]string{"summary_query": "Resume el texto."}
+	withOverride := ConfigHash(cfg)
+	if withOverride == withLanguage {
+		t.Errorf("Expected an internal prompt override to change the configuration hash")
+	}
+	cfg.InternalPrompts["summary_query"] = "Resume el texto en una frase."
+	if ConfigHash(cfg) == withOverride {
+		t.Errorf("Expected a changed internal prompt override to change the configuration hash")
+	}
+
+	withoutParameters := ConfigHash(cfg)
+	cfg.Project.LLM["1"] = config.LLMItem{Provider: "OpenAI", Model: "gpt-4o-mini", ApiKey: "key2", Temperature: 0.7}
+	withTemperature := ConfigHash(cfg)
+	if withTemperature == withoutParameters {
+		t.Errorf("Expected a new temperature to change the configuration hash")
+	}
+	cfg.Project.LLM["1"] = config.LLMItem{Provider: "OpenAI", Model: "gpt-4o-mini", ApiKey: "key2", Temperature: 0.7, BaseURL: "http://localhost:11434/v1"}
+	if ConfigHash(cfg) == withTemperature {
+		t.Errorf("Expected a new endpoint to change the configuration hash")
+	}
+
+	withEndpoint := ConfigHash(cfg)
+	cfg.Project.Configuration.SchemaRetries = 2
+	withRetries := ConfigHash(cfg)
+	if withRetries == withEndpoint {
+		t.Errorf("Expected schema retries to change the configuration hash")
+	}
+	cfg.Project.Configuration.Repetitions = 3
+	if ConfigHash(cfg) == withRetries {
+		t.Errorf("Expected repetitions to change the configuration hash")
+	}
+}
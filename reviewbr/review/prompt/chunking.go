@@ -0,0 +1,187 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/pkoukk/tiktoken-go"
+)
+
+// tokenEncoding is the tiktoken encoding used to measure texts. Providers tokenize differently,
+// so counts are an approximation for models not using this encoding.
+const tokenEncoding = "cl100k_base"
+
+var (
+	encodingOnce  sync.Once
+	encoding      *tiktoken.Tiktoken
+	encodingError error
+)
+
+// countTokens returns the number of tokens of a text. It can be replaced in tests.
+var countTokens = func(text string) int {
+	if LoadTokenEncoding() != nil {
+		return EstimateTokens(text)
+	}
+	return len(encoding.EncodeOrdinary(text))
+}
+
+// LoadTokenEncoding loads the cl100k_base encoding used to count tokens. The encoding file is
+// downloaded on first use and cached in the directory named by TIKTOKEN_CACHE_DIR, or in the
+// temporary directory, so machines without network access need the file in that cache.
+//
+// Returns:
+// - An error if the encoding cannot be loaded, in which case token counts are estimated.
+func LoadTokenEncoding() error {
+	encodingOnce.Do(func() {
+		encoding, encodingError = tiktoken.GetEncoding(tokenEncoding)
+		if encodingError != nil {
+			logger.Error("Token encoding not available, estimating tokens from text length: %v", encodingError)
+		}
+	})
+	return encodingError
+}
+
+// CountTokens returns the number of tokens of a text using the cl100k_base encoding.
+// If the encoding cannot be loaded, the count is estimated as one token every four bytes.
+//
+// Arguments:
+// - text: The text to measure.
+//
+// Returns:
+// - The number of tokens of the text.
+func CountTokens(text string) int {
+	return countTokens(text)
+}
+
+// EstimateTokens estimates the number of tokens of a text as one token every four bytes, without
+// loading the encoding, for counts needing no precision such as progress reports.
+//
+// Arguments:
+// - text: The text to measure.
+//
+// Returns:
+// - The estimated number of tokens of the text.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// textUnit is a piece of a document kept together when chunking, with its token count.
+type textUnit struct {
+	text   string
+	tokens int
+}
+
+// splitDocument splits a document into chunks of at most maxTokens tokens. Paragraphs are kept
+// together whenever they fit in a chunk, while longer paragraphs are split between words.
+// Every chunk after the first starts with the last words of the previous chunk, up to overlap tokens.
+// Token counts of joined units are approximated by the sum of the counts of the units.
+//
+// Arguments:
+// - text: The document text.
+// - maxTokens: The maximum number of tokens of a chunk.
+// - overlap: The number of tokens repeated from the end of the previous chunk.
+//
+// Returns:
+// - The chunks of the document, or the whole document if it fits in a single chunk.
+func splitDocument(text string, maxTokens int, overlap int) []string {
+	if maxTokens <= 0 || countTokens(text) <= maxTokens {
+		return []string{text}
+	}
+
+	var units []textUnit
+	for _, paragraph := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n\n") {
+		paragraph = strings.TrimSpace(paragraph)
+		if paragraph == "" {
+			continue
+		}
+		tokens := countTokens(paragraph)
+		if tokens <= maxTokens {
+			units = append(units, textUnit{text: paragraph, tokens: tokens})
+			continue
+		}
+		units = append(units, splitWords(paragraph, maxTokens)...)
+	}
+
+	var chunks []string
+	var current []string
+	currentTokens := 0
+	for _, unit := range units {
+		if currentTokens+unit.tokens > maxTokens && len(current) > 0 {
+			chunk := strings.Join(current, "\n\n")
+			chunks = append(chunks, chunk)
+			current, currentTokens = nil, 0
+			if tail, tokens := tailWords(chunk, min(overlap, maxTokens-unit.tokens)); tail != "" {
+				current = append(current, tail)
+				currentTokens = tokens
+			}
+		}
+		current = append(current, unit.text)
+		currentTokens += unit.tokens
+	}
+	if len(current) > 0 {
+		chunks = append(chunks, strings.Join(current, "\n\n"))
+	}
+	return chunks
+}
+
+// splitWords splits a paragraph longer than maxTokens into units of whole words.
+func splitWords(paragraph string, maxTokens int) []textUnit {
+	var units []textUnit
+	var words []string
+	tokens := 0
+	for _, word := range strings.Fields(paragraph) {
+		wordTokens := countTokens(" " + word)
+		if tokens+wordTokens > maxTokens && len(words) > 0 {
+			units = append(units, textUnit{text: strings.Join(words, " "), tokens: tokens})
+			words, tokens = nil, 0
+		}
+		words = append(words, word)
+		tokens += wordTokens
+	}
+	if len(words) > 0 {
+		units = append(units, textUnit{text: strings.Join(words, " "), tokens: tokens})
+	}
+	return units
+}
+
+// tailWords returns the last words of a text fitting in the given number of tokens, and their token count.
+func tailWords(text string, budget int) (string, int) {
+	if budget <= 0 {
+		return "", 0
+	}
+	words := strings.Fields(text)
+	start, tokens := len(words), 0
+	for start > 0 {
+		wordTokens := countTokens(" " + words[start-1])
+		if tokens+wordTokens > budget {
+			break
+		}
+		tokens += wordTokens
+		start--
+	}
+	return strings.Join(words[start:], " "), tokens
+}
+
+// BuildReducePrompt generates the prompt asking a model to combine the answers given on the chunks
+// of a long document into a single answer, used by the llm_reduce merge strategy.
+//
+// Arguments:
+// - config: A pointer to the application's configuration, providing the persona and the review items.
+// - group: The review group whose answers are combined.
+// - answers: The JSON answers given on each chunk, in document order.
+//
+// Returns:
+// - The reduce prompt.
+func BuildReducePrompt(config *config.Config, group config.ReviewGroup, answers []string) string {
+	prompts := config.LocalizedPrompts()
+	var parts strings.Builder
+	for i, answer := range answers {
+		fmt.Fprintf(&parts, "%s\n%s\n\n", prompts.Format(localization.ReducePart, i+1), answer)
+	}
+	prompt := renderPromptConfig(config.Prompt, nil) // the reduce prompt is not bound to document variables
+	return fmt.Sprintf("%s\n%s\n%s\n\n%s", prompt.Persona, prompts.Text(localization.ReduceQuery), formatExpectedResults(config, prompt.ExpectedResult, group.Items), parts.String())
+}
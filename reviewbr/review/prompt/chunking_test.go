@@ -0,0 +1,132 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+// countWords replaces the tiktoken counter in tests, counting one token per word.
+func countWords(t *testing.T) {
+	original := countTokens
+	countTokens = func(text string) int { return len(strings.Fields(text)) }
+	t.Cleanup(func() { countTokens = original })
+}
+
+func TestSplitDocument(t *testing.T) {
+	countWords(t)
+
+	tests := []struct {
+		name      string
+		text      string
+		maxTokens int
+		overlap   int
+		expected  []string
+	}{
+		{"short document", "one two three", 5, 0, []string{"one two three"}},
+		{"paragraphs", "a b c\n\nd e\n\nf g h", 5, 0, []string{"a b c\n\nd e", "f g h"}},
+		{"overlap", "a b c\n\nd e\n\nf g h", 5, 1, []string{"a b c\n\nd e", "e\n\nf g h"}},
+		{"long paragraph", "a b c d e f g", 3, 0, []string{"a b c", "d e f", "g"}},
+		{"overlap limited by next unit", "a b c\n\nd e f", 3, 2, []string{"a b c", "d e f"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chunks := splitDocument(tt.text, tt.maxTokens, tt.overlap)
+			if len(chunks) != len(tt.expected) {
+				t.Fatalf("Expected %d chunks %q, got %d: %q", len(tt.expected), tt.expected, len(chunks), chunks)
+			}
+			for i := range chunks {
+				if chunks[i] != tt.expected[i] {
+					t.Errorf("Chunk %d: expected %q, got %q", i+1, tt.expected[i], chunks[i])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSelectedInputChunking(t *testing.T) {
+	countWords(t)
+
+	inputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "long.txt"), []byte("a b c\n\nd e f"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(inputDir, "short.txt"), []byte("a b"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{
+				InputDirectory:   inputDir,
+				CotJustification: "yes",
+				Chunking:         "yes",
+				ChunkTokens:      3,
+			},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "test", Values: []string{"yes", "no"}},
+		},
+	}
+
+	input, filenames, _, _ := BuildSelectedInput(cfg, nil)
+	if len(filenames) != 2 || filenames[0] != "long" || filenames[1] != "short" {
+		t.Fatalf("Unexpected filenames: %v", filenames)
+	}
+
+	var sequenceIDs []string
+	for _, p := range input.Prompts {
+		if p.SequenceNumber == 1 {
+			sequenceIDs = append(sequenceIDs, p.SequenceID)
+		}
+	}
+	expected := []string{"1.1", "1.2", "2"}
+	if strings.Join(sequenceIDs, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected main prompt sequences %v, got %v", expected, sequenceIDs)
+	}
+	if len(input.Prompts) != 6 {
+		t.Errorf("Expected a justification query for every chunk, got %d prompts", len(input.Prompts))
+	}
+	if !strings.Contains(input.Prompts[2].PromptContent, "(Part 2 of 2 of the document)\n\nd e f") {
+		t.Errorf("Expected the second chunk in the prompt, got %q", input.Prompts[2].PromptContent)
+	}
+}
+
+func TestChunkLabelsFollowThePromptLanguage(t *testing.T) {
+	countWords(t)
+
+	inputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "long.txt"), []byte("a b c\n\nd e f"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{
+				InputDirectory: inputDir,
+				PromptLanguage: "es",
+				Chunking:       "yes",
+				ChunkTokens:    3,
+			},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "test", Values: []string{"yes", "no"}},
+		},
+		InternalPrompts: map[string]string{"reduce_part": "Respuesta %d:"},
+	}
+
+	input, _, _, err := BuildSelectedInput(cfg, nil)
+	if err != nil {
+		t.Fatalf("BuildSelectedInput returned an error: %v", err)
+	}
+	if len(input.Prompts) != 2 || !strings.Contains(input.Prompts[1].PromptContent, "(Parte 2 de 2 del documento)\n\nd e f") {
+		t.Errorf("Expected the Spanish chunk heading, got %+v", input.Prompts)
+	}
+
+	reduce := BuildReducePrompt(cfg, cfg.Groups()[0], []string{`{"test": "yes"}`, `{"test": ""}`})
+	if !strings.Contains(reduce, "Respuesta 2:\n{\"test\": \"\"}") || strings.Contains(reduce, "Part ") {
+		t.Errorf("Expected the overridden part label in the reduce prompt, got %q", reduce)
+	}
+}
@@ -0,0 +1,193 @@
+package prompt
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+// minExampleTokens is the smallest number of manuscript tokens for which a truncated example is still included.
+const minExampleTokens = 100
+
+// example is an annotated example manuscript, shown to the model with its expected answer.
+type example struct {
+	name   string             // filename without extension
+	text   string             // manuscript text
+	answer string             // expected JSON answer
+	terms  map[string]float64 // term frequencies of the text, used to rank examples by similarity
+}
+
+// loadExamples reads the annotated examples of the directory configured in examples_directory: every
+// .txt manuscript with a .json file of the same name holding its expected answer. Manuscripts without
+// answer, and answers that are not valid JSON, are logged and skipped.
+//
+// Arguments:
+// - cfg: A pointer to the application's configuration, specifying the examples directory.
+//
+// Returns:
+// - The examples, in filename order; nil if no directory is configured.
+// - An error if the directory or one of its files cannot be read.
+func loadExamples(cfg *config.Config) ([]example, error) {
+	directory := cfg.Project.Configuration.ExamplesDirectory
+	if directory == "" {
+		return nil, nil
+	}
+	files, err := os.ReadDir(directory)
+	if err != nil {
+		return nil, err
+	}
+
+	var examples []example
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".txt" {
+			continue
+		}
+		name := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
+		text, err := os.ReadFile(filepath.Join(directory, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		answer, err := os.ReadFile(filepath.Join(directory, name+".json"))
+		if os.IsNotExist(err) {
+			logger.Error("Example %s has no expected answer %s.json, skipping it", file.Name(), name)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(answer) {
+			logger.Error("Expected answer %s.json is not valid JSON, skipping the example", name)
+			continue
+		}
+		examples = append(examples, example{
+			name:   name,
+			text:   strings.TrimSpace(string(text)),
+			answer: strings.TrimSpace(string(answer)),
+			terms:  termFrequencies(string(text)),
+		})
+	}
+	return examples, nil
+}
+
+// selectExamples chooses the examples shown in the prompt of a document. With the similar selection,
+// examples are ranked by the cosine similarity of their terms with the document; otherwise they keep
+// filename order. Examples are then taken up to examples_count and within the examples_tokens budget:
+// the manuscript of the first example exceeding the remaining budget is truncated to fit, and no
+// further example is added. An example named as the document is never shown in its own prompt.
+//
+// Arguments:
+// - cfg: A pointer to the application's configuration, specifying the selection options.
+// - examples: The available examples, as returned by loadExamples.
+// - filename: The filename of the document, without extension.
+// - documentText: The text of the document.
+//
+// Returns:
+// - The selected examples, in the order they are shown.
+func selectExamples(cfg *config.Config, examples []example, filename string, documentText string) []example {
+	if len(examples) == 0 {
+		return nil
+	}
+	candidates := make([]example, 0, len(examples))
+	for _, e := range examples {
+		if e.name != filename {
+			candidates = append(candidates, e)
+		}
+	}
+	if cfg.Project.Configuration.ExamplesSelection == config.ExamplesSimilar {
+		terms := termFrequencies(documentText)
+		scores := make(map[string]float64, len(candidates))
+		for _, e := range candidates {
+			scores[e.name] = cosineSimilarity(terms, e.terms)
+		}
+		sort.SliceStable(candidates, func(i, j int) bool { return scores[candidates[i].name] > scores[candidates[j].name] })
+	}
+
+	budget := cfg.Project.Configuration.ExamplesTokens
+	limit := cfg.Project.Configuration.ExamplesCount
+	var selected []example
+	for _, e := range candidates {
+		if limit > 0 && len(selected) == limit {
+			break
+		}
+		answerTokens := countTokens(e.answer)
+		textTokens := countTokens(e.text)
+		if answerTokens+textTokens <= budget {
+			selected = append(selected, e)
+			budget -= answerTokens + textTokens
+			continue
+		}
+		if budget-answerTokens >= minExampleTokens {
+			e.text = splitWords(e.text, budget-answerTokens)[0].text + " [...]"
+			selected = append(selected, e)
+		}
+		break
+	}
+	return selected
+}
+
+// fewShotPrompt formats the selected examples as pairs of manuscript and expected answer, introduced
+// and labeled in the language of the internal prompts.
+func fewShotPrompt(prompts *localization.Prompts, examples []example) string {
+	if len(examples) == 0 {
+		return ""
+	}
+	var prompt strings.Builder
+	prompt.WriteString(prompts.Text(localization.ExamplesIntro))
+	for i, e := range examples {
+		fmt.Fprintf(&prompt, "\n\n%s\n%s\n\n%s\n%s", prompts.Format(localization.ExampleText, i+1), e.text, prompts.Format(localization.ExampleAnswer, i+1), e.answer)
+	}
+	return prompt.String()
+}
+
+// exampleNames returns the names of the given examples.
+func exampleNames(examples []example) []string {
+	names := make([]string, len(examples))
+	for i, e := range examples {
+		names[i] = e.name
+	}
+	return names
+}
+
+// termFrequencies counts the lowercase words of a text longer than two characters, normalized by their total.
+func termFrequencies(text string) map[string]float64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make(map[string]float64)
+	total := 0.0
+	for _, word := range words {
+		if len([]rune(word)) > 2 {
+			terms[word]++
+			total++
+		}
+	}
+	for word := range terms {
+		terms[word] /= total
+	}
+	return terms
+}
+
+// cosineSimilarity computes the cosine similarity of two term frequency vectors, 0 if either is empty.
+func cosineSimilarity(a map[string]float64, b map[string]float64) float64 {
+	var dot, normA, normB float64
+	for term, weight := range a {
+		dot += weight * b[term]
+		normA += weight * weight
+	}
+	for _, weight := range b {
+		normB += weight * weight
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,134 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestSelectExamples(t *testing.T) {
+	countWords(t)
+
+	newExample := func(name, text string) example {
+		return example{name: name, text: text, answer: `{"hazard": "` + name + `"}`, terms: termFrequencies(text)}
+	}
+	examples := []example{
+		newExample("drought", "rainfall deficit dried the soil and crops during the drought"),
+		newExample("floods", "the river flood raised the discharge of the basin"),
+		newExample("paper1", "the river flood of the paper itself"),
+	}
+	document := "a flood of the river basin with peak discharge"
+
+	tests := []struct {
+		name      string
+		selection string
+		tokens    int
+		count     int
+		expected  []string
+	}{
+		{"all", config.ExamplesAll, 1000, 0, []string{"drought", "floods"}},
+		{"similar", config.ExamplesSimilar, 1000, 1, []string{"floods"}},
+		{"budget", config.ExamplesAll, 15, 0, []string{"drought"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{Project: config.ProjectConfig{Configuration: config.ProjectConfiguration{
+				ExamplesSelection: tt.selection, ExamplesTokens: tt.tokens, ExamplesCount: tt.count,
+			}}}
+			selected := selectExamples(cfg, examples, "paper1", document)
+			if names := exampleNames(selected); !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("Expected examples %v, got %v", tt.expected, names)
+			}
+		})
+	}
+}
+
+func TestSelectExamplesTruncatesToBudget(t *testing.T) {
+	countWords(t)
+
+	long := strings.TrimSpace(strings.Repeat("word ", 300))
+	examples := []example{
+		{name: "long", text: long, answer: `{"key": "value"}`},
+		{name: "short", text: "short text", answer: `{"key": "value"}`},
+	}
+	cfg := &config.Config{Project: config.ProjectConfig{Configuration: config.ProjectConfiguration{
+		ExamplesSelection: config.ExamplesAll, ExamplesTokens: 152,
+	}}}
+
+	selected := selectExamples(cfg, examples, "paper1", "")
+	if len(selected) != 1 {
+		t.Fatalf("Expected only the truncated example, got %v", exampleNames(selected))
+	}
+	if words := len(strings.Fields(selected[0].text)); words != 151 || !strings.HasSuffix(selected[0].text, "[...]") {
+		t.Errorf("Expected the example truncated to 150 words, got %d words", words)
+	}
+}
+
+func TestBuildSelectedInputFewShotExamples(t *testing.T) {
+	dir := t.TempDir()
+	inputDir := filepath.Join(dir, "input")
+	examplesDir := filepath.Join(dir, "examples")
+	files := map[string]string{
+		filepath.Join(inputDir, "paper1.txt"):    "Text of paper1",
+		filepath.Join(examplesDir, "coded.txt"):  "Text of a coded paper",
+		filepath.Join(examplesDir, "coded.json"): `{"test": "yes"}`,
+		filepath.Join(examplesDir, "draft.txt"):  "Text without answer",
+	}
+	for path, content := range files {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	cfg := &config.Config{
+		Prompt: config.PromptConfig{Task: "Task.", ExpectedResult: "Answer:"},
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{
+				InputDirectory:    inputDir,
+				ExamplesDirectory: examplesDir,
+				ExamplesTokens:    config.DefaultExamplesTokens,
+				ExamplesSelection: config.ExamplesAll,
+			},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "test", Values: []string{"yes", "no"}},
+		},
+	}
+
+	input, _, examples, err := BuildSelectedInput(cfg, nil)
+	if err != nil {
+		t.Fatalf("BuildSelectedInput returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(examples, map[string][]string{"paper1": {"coded"}}) {
+		t.Errorf("Expected the coded example recorded for paper1, got %v", examples)
+	}
+	expected := "Example 1 text:\nText of a coded paper\n\nExample 1 answer:\n{\"test\": \"yes\"}"
+	if len(input.Prompts) != 1 || !strings.Contains(input.Prompts[0].PromptContent, expected) {
+		t.Errorf("Expected the few-shot example in the prompt, got %+v", input.Prompts)
+	}
+	if strings.Contains(input.Prompts[0].PromptContent, "Text without answer") {
+		t.Errorf("Expected the example without answer to be skipped")
+	}
+
+	cfg.Project.Configuration.ExamplesDirectory = filepath.Join(dir, "missing")
+	if _, _, _, err := BuildSelectedInput(cfg, nil); err == nil {
+		t.Errorf("Expected an error for a missing examples directory")
+	}
+}
+
+func TestFewShotPromptFollowsThePromptLanguage(t *testing.T) {
+	prompt := fewShotPrompt(localization.New("pt-BR", nil), []example{{name: "coded", text: "Texto", answer: `{"test": "yes"}`}})
+	expected := "Aqui estão exemplos de textos com as respostas esperadas para eles:\n\nTexto do exemplo 1:\nTexto\n\nResposta do exemplo 1:\n{\"test\": \"yes\"}"
+	if prompt != expected {
+		t.Errorf("Expected %q, got %q", expected, prompt)
+	}
+}
@@ -11,62 +11,83 @@ import (
 	"time"
 
 	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/localization"
 	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/records"
+	"github.com/open-and-sustainable/prismaid/secrets"
 
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/logger"
 )
 
-// prompts for specific functionalities
-const justification_query = `For each one of the keys and answers you provided, provide a justification for your answer as a chain of thought. In particular, I want a textual description of the few stages of the chain of thought that lead you to the answer you provided and the sentences in the text you analyzes that support your decision. If the value of a key was 'no' or empty '' because of lack of information on that topic in the text analyzed, explicitly report this reason. Please provide only the information requested, neither introductory nor concluding remarks.
-Format:
-{
-  "justifications": {
-    "<key>": {
-      "reasoning_steps": ["Step 1", "Step 2", "Step 3"],
-      "supporting_sentences": ["Sentence 1", "Sentence 2"]
-    },
-    ...
-  }
+// commonPrompt combines the parts of the prompt preceding the document text: persona, task, expected results,
+// failsafe, definitions, and example, with their templates rendered with the variables of the document.
+// The task and the expected results are those of the first review group, and the few-shot examples
+// of the document, if any, follow the example.
+func commonPrompt(config *config.Config, variables map[string]string, group config.ReviewGroup, fewShot string) string {
+	prompt := renderPromptConfig(config.Prompt, variables)
+	task := renderTemplate("task", group.Task, variables)
+	expected_result := formatExpectedResults(config, prompt.ExpectedResult, group.Items)
+	example := prompt.Example
+	if fewShot != "" {
+		example = strings.TrimSpace(example + "\n" + fewShot)
+	}
+	return fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s",
+		prompt.Persona, task, expected_result,
+		prompt.Failsafe, prompt.Definitions, example)
 }
-`
 
-const summary_query = `Summarize in very few sentences the text provided to you before for your review, provide a JSON object summarizing the reviewed text.
-JSON object format for response:
-{
-  "summary": "Your concise summary here."
-}`
+// groupPrompts returns the prompts of the review groups following the first one, sent in the same
+// sequence after the document: the task of the group, the expected results of its items, the failsafe
+// and the definitions.
+func groupPrompts(config *config.Config, variables map[string]string, groups []config.ReviewGroup) []string {
+	prompt := renderPromptConfig(config.Prompt, variables)
+	prompts := make([]string, 0, len(groups))
+	for _, group := range groups {
+		task := renderTemplate("task", group.Task, variables)
+		prompts = append(prompts, fmt.Sprintf("%s\n%s\n%s\n%s",
+			task, formatExpectedResults(config, prompt.ExpectedResult, group.Items),
+			prompt.Failsafe, prompt.Definitions))
+	}
+	return prompts
+}
 
-// parsePrompts reads the configuration and generates a list of prompts along with their corresponding filenames.
-// The function combines different parts of the prompts (persona, task, expected results, failsafe,
-// definitions, and example) with the content of text files to create a structured list of inputs.
-//
-// It processes all .txt files in the input directory specified in the configuration and generates
-// a prompt for each file by combining the common components with the file's content.
+// documentPrompt appends a document text to the common part of the prompt.
+func documentPrompt(commonPart string, documentText string) string {
+	return fmt.Sprintf("%s \n\n%s", commonPart, documentText)
+}
+
+// loadDocuments reads the .txt files of the input directory or, when screening results are configured,
+// the records included by the screening, named after their identity.
 //
 // Arguments:
-// - config: A pointer to the application's configuration which specifies how prompts should be parsed and organized.
+// - config: A pointer to the application's configuration, specifying the input directory or screening results.
 //
 // Returns:
-// - Two slices of strings:
-//   - The first slice contains the generated prompts.
-//   - The second slice contains the filenames (without extensions) associated with each prompt.
-//   - If there's an error reading a file, both return values may be nil.
-func parsePrompts(config *config.Config) ([]string, []string) {
-	// This slice will store all combined prompts
-	var prompts []string
-	// This slice will store the filenames corresponding to each prompt
+// - The texts of the documents.
+// - The filenames (without extensions) or record identities of the documents.
+// - An error if the screening results, the input directory or one of its files cannot be read.
+func loadDocuments(config *config.Config) ([]string, []string, error) {
+	var texts []string
 	var filenames []string
 
-	// The common part of prompts
-	expected_result := parseExpectedResults(config)
-	common_part := fmt.Sprintf("%s\n%s\n%s\n%s\n%s\n%s",
-		config.Prompt.Persona, config.Prompt.Task, expected_result,
-		config.Prompt.Failsafe, config.Prompt.Definitions, config.Prompt.Example)
+	screening, err := records.FromConfig(config)
+	if err != nil {
+		logger.Error("Error reading screening results:", err)
+		return nil, nil, err
+	}
+	if screening != nil {
+		for _, record := range screening.Records {
+			texts = append(texts, record.Text)
+			filenames = append(filenames, record.ID)
+		}
+		return texts, filenames, nil
+	}
 
 	// Load text files
 	files, err := os.ReadDir(config.Project.Configuration.InputDirectory)
 	if err != nil {
-		logger.Error(err)
+		logger.Error("Error reading input directory:", err)
+		return nil, nil, err
 	}
 
 	for _, file := range files {
@@ -75,13 +96,9 @@ func parsePrompts(config *config.Config) ([]string, []string) {
 			documentText, err := os.ReadFile(filePath)
 			if err != nil {
 				logger.Error("Error reading file:", err)
-				return nil, nil
+				return nil, nil, err
 			}
-
-			// Combine prompt elements
-			prompt := fmt.Sprintf("%s \n\n%s", common_part, documentText)
-			// Append the combined text to the slice
-			prompts = append(prompts, prompt)
+			texts = append(texts, string(documentText))
 
 			// Get the filename without extension
 			fileNameWithoutExt := strings.TrimSuffix(file.Name(), filepath.Ext(file.Name()))
@@ -90,7 +107,7 @@ func parsePrompts(config *config.Config) ([]string, []string) {
 		}
 	}
 
-	return prompts, filenames
+	return texts, filenames, nil
 }
 
 // parseExpectedResults generates the expected result format to be included in prompts.
@@ -108,14 +125,17 @@ func parsePrompts(config *config.Config) ([]string, []string) {
 // This function ensures that the prompt sent to the LLM includes a clear specification
 // of the expected response format, facilitating structured parsing of the responses.
 func parseExpectedResults(config *config.Config) string {
-	expectedResult := config.Prompt.ExpectedResult
-	keys := GetReviewKeysByEntryOrder(config)
+	return formatExpectedResults(config, config.Prompt.ExpectedResult, GetReviewKeysByEntryOrder(config))
+}
 
+// formatExpectedResults combines an expected result text with the JSON representation of the given
+// review items, identified by their TOML entry keys.
+func formatExpectedResults(config *config.Config, expectedResult string, keys []string) string {
 	// Build a map from sorted keys using descriptive keys
-	sortedReviewItems := make(map[string][]string)
+	sortedReviewItems := make(map[string]any)
 	for _, numericKey := range keys {
 		item := config.Review[numericKey]
-		sortedReviewItems[item.Key] = item.Values // Use the descriptive key for the JSON output
+		sortedReviewItems[item.Key] = describeReviewItem(item) // Use the descriptive key for the JSON output
 	}
 
 	// Convert sorted map to JSON
@@ -129,6 +149,46 @@ func parseExpectedResults(config *config.Config) string {
 	return fullSummary
 }
 
+// describeReviewItem returns the representation of the answer expected for a review item in the prompt.
+// Items without an explicit type keep their list of values, while typed items describe the expected format.
+//
+// Arguments:
+//   - item: The review item to describe.
+//
+// Returns:
+//   - The list of allowed values, or a string describing the expected answer.
+func describeReviewItem(item config.ReviewItem) any {
+	if item.Type == "" {
+		return item.Values
+	}
+
+	var bounds string
+	if item.Min != nil && item.Max != nil {
+		bounds = fmt.Sprintf(" between %v and %v", *item.Min, *item.Max)
+	} else if item.Min != nil {
+		bounds = fmt.Sprintf(" greater than or equal to %v", *item.Min)
+	} else if item.Max != nil {
+		bounds = fmt.Sprintf(" less than or equal to %v", *item.Max)
+	}
+
+	switch item.Type {
+	case config.ItemTypeEnum:
+		return item.Values
+	case config.ItemTypeMultiEnum:
+		return fmt.Sprintf("list of one or more of: %s", strings.Join(item.Values, ", "))
+	case config.ItemTypeInteger:
+		return "integer" + bounds
+	case config.ItemTypeFloat:
+		return "number" + bounds
+	case config.ItemTypeDate:
+		return "date as YYYY-MM-DD, YYYY-MM or YYYY"
+	case config.ItemTypeBoolean:
+		return []string{"yes", "no"}
+	default:
+		return "free text"
+	}
+}
+
 // GetReviewKeysByEntryOrder retrieves the keys from the review configuration and sorts them alphabetically.
 // This function ensures that the keys are returned in a consistent alphabetical order, which is useful for
 // processing that relies on a deterministic sequence of entries rather than the potentially variable order
@@ -153,6 +213,23 @@ func GetReviewKeysByEntryOrder(config *config.Config) []string {
 	return keys
 }
 
+// GetLLMKeysByEntryOrder returns the keys of the [project.llm] entries sorted alphabetically,
+// so that models are always queried and reported in a deterministic order.
+//
+// Arguments:
+// - config: A pointer to the application's configuration, which specifies the LLM entries.
+//
+// Returns:
+// - A slice of strings containing the LLM entry keys in sorted order.
+func GetLLMKeysByEntryOrder(config *config.Config) []string {
+	keys := make([]string, 0, len(config.Project.LLM))
+	for key := range config.Project.LLM {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
 // SortReviewKeysAlphabetically retrieves and sorts the descriptive keys (not the TOML entry keys) from the
 // review configuration alphabetically. This sorting approach focuses on the descriptive aspects of the keys
 // rather than their position in the configuration file, making it useful for user interfaces or outputs where
@@ -179,7 +256,9 @@ func SortReviewKeysAlphabetically(config *config.Config) []string {
 // PrepareInput generates a structured JSON input object based on the provided configuration.
 // It processes prompts, adds metadata, configures models, and includes optional justification
 // and summary queries as specified in the configuration. The function ensures all components
-// are properly sequenced and organized for further processing.
+// are properly sequenced and organized for further processing. API keys are replaced with
+// secrets.Redacted, so that the JSON can be saved or shared; review runs query the models with the
+// structure of BuildInput.
 //
 // Arguments:
 //   - config: A pointer to the application's configuration which contains all necessary settings
@@ -191,9 +270,119 @@ func SortReviewKeysAlphabetically(config *config.Config) []string {
 // - A slice of strings containing the filenames associated with each prompt for result correlation.
 // - An error if any issues occur during the JSON preparation process.
 func PrepareInput(config *config.Config) (string, []string, error) {
-	prompts, filenames := parsePrompts(config)
+	jsonSchema, filenames, err := BuildInput(config)
+	if err != nil {
+		return "", nil, err
+	}
+	for i := range jsonSchema.Models {
+		if jsonSchema.Models[i].APIKey != "" {
+			jsonSchema.Models[i].APIKey = secrets.Redacted
+		}
+	}
 
-	logger.Info("Generating input JSON with %d prompts.", len(prompts))
+	// Convert to JSON string
+	jsonData, err := json.MarshalIndent(jsonSchema, "", "  ")
+	if err != nil {
+		logger.Error("Error marshaling JSON: %v", err)
+		return "", nil, err
+	}
+
+	logger.Info("Input JSON successfully generated.")
+
+	return string(jsonData), filenames, nil
+}
+
+// BuildInput assembles the alembica input structure used by PrepareInput without serializing it.
+// Models are added in the order of their TOML entry keys, and every document receives its own
+// SequenceID (the 1-based index of its filename) shared by the main prompt and its follow-ups.
+//
+// Arguments:
+//   - config: A pointer to the application's configuration.
+//
+// Returns:
+// - The populated definitions.Input structure.
+// - A slice of strings containing the filenames associated with each SequenceID.
+// - An error if the variables of the prompts cannot be read.
+func BuildInput(config *config.Config) (definitions.Input, []string, error) {
+	input, filenames, _, err := BuildSelectedInput(config, nil)
+	return input, filenames, err
+}
+
+// BuildSelectedInput works like BuildInput but only includes the documents whose filename
+// (without extension) is in the selected set. A nil set selects every document.
+// When chunking is enabled, documents longer than the configured chunk size are split, and each
+// chunk receives its own sequence, with SequenceID "<document>.<chunk>" (e.g. "3.2"), and follow-ups.
+// When an examples directory is configured, the selected few-shot examples are added to the prompts
+// of each document.
+//
+// Arguments:
+//   - config: A pointer to the application's configuration.
+//   - selected: The set of filenames to include, or nil to include all of them.
+//
+// Returns:
+// - The populated definitions.Input structure.
+// - A slice of strings containing the filenames associated with each SequenceID.
+// - The names of the few-shot examples shown in the prompts of each document, by filename.
+// - An error if the documents, the metadata file or the examples directory cannot be read.
+func BuildSelectedInput(config *config.Config, selected map[string]bool) (definitions.Input, []string, map[string][]string, error) {
+	texts, filenames, err := loadDocuments(config)
+	if err != nil {
+		return definitions.Input{}, nil, nil, err
+	}
+	if selected != nil {
+		var keptTexts, keptFilenames []string
+		for i, filename := range filenames {
+			if selected[filename] {
+				keptTexts = append(keptTexts, texts[i])
+				keptFilenames = append(keptFilenames, filename)
+			}
+		}
+		texts, filenames = keptTexts, keptFilenames
+	}
+
+	metadata, err := loadMetadata(config)
+	if err != nil {
+		logger.Error("Error reading metadata file: %v", err)
+		return definitions.Input{}, nil, nil, fmt.Errorf("error reading metadata file: %w", err)
+	}
+
+	examples, err := loadExamples(config)
+	if err != nil {
+		logger.Error("Error reading examples directory: %v", err)
+		return definitions.Input{}, nil, nil, fmt.Errorf("error reading examples directory: %w", err)
+	}
+	usedExamples := make(map[string][]string)
+
+	// Build the prompts of each document, split in chunks if enabled, and of its other review groups
+	groups := config.Groups()
+	documents := make([][]string, len(texts))
+	followUps := make([][]string, len(texts))
+	variables := make([]map[string]string, len(texts))
+	prompts := 0
+	for i, documentText := range texts {
+		variables[i] = documentVariables(filenames[i], metadata)
+		documentExamples := selectExamples(config, examples, filenames[i], documentText)
+		if len(documentExamples) > 0 {
+			usedExamples[filenames[i]] = exampleNames(documentExamples)
+		}
+		common_part := commonPrompt(config, variables[i], groups[0], fewShotPrompt(config.LocalizedPrompts(), documentExamples))
+		followUps[i] = groupPrompts(config, variables[i], groups[1:])
+		chunks := []string{documentText}
+		if config.Project.Configuration.Chunking == "yes" {
+			chunks = splitDocument(documentText, config.Project.Configuration.ChunkTokens, config.Project.Configuration.ChunkOverlap)
+		}
+		if len(chunks) == 1 {
+			documents[i] = []string{documentPrompt(common_part, chunks[0])}
+		} else {
+			logger.Info("Split %s in %d chunks", filenames[i], len(chunks))
+			for k, chunk := range chunks {
+				documents[i] = append(documents[i], documentPrompt(common_part, fmt.Sprintf("%s\n\n%s", config.LocalizedPrompts().Format(localization.ChunkPart, k+1, len(chunks)), chunk)))
+			}
+		}
+		prompts += len(documents[i])
+	}
+
+	logger.Info("Generating input JSON with %d prompts.", prompts)
 
 	// Populate metadata
 	jsonSchema := definitions.Input{
@@ -205,7 +394,8 @@ func PrepareInput(config *config.Config) (string, []string, error) {
 	}
 
 	// Populate models
-	for _, llm := range config.Project.LLM {
+	for _, llmKey := range GetLLMKeysByEntryOrder(config) {
+		llm := config.Project.LLM[llmKey]
 		jsonSchema.Models = append(jsonSchema.Models, definitions.Model{
 			Provider:     llm.Provider,
 			APIKey:       llm.ApiKey,
@@ -224,34 +414,13 @@ func PrepareInput(config *config.Config) (string, []string, error) {
 	logger.Info("Added %d models to input JSON.", len(jsonSchema.Models))
 
 	// Populate prompts
-	for i, promptText := range prompts {
-		sequenceNumber := 1 // Track sequence numbering dynamically
-
-		// Append the main prompt
-		jsonSchema.Prompts = append(jsonSchema.Prompts, definitions.Prompt{
-			PromptContent:  promptText,
-			SequenceID:     strconv.Itoa(i + 1),
-			SequenceNumber: sequenceNumber,
-		})
-
-		// Add justification query if enabled
-		if config.Project.Configuration.CotJustification == "yes" {
-			sequenceNumber++
-			jsonSchema.Prompts = append(jsonSchema.Prompts, definitions.Prompt{
-				PromptContent:  justification_query,
-				SequenceID:     strconv.Itoa(i + 1),
-				SequenceNumber: sequenceNumber,
-			})
-		}
-
-		// Add summary query if enabled
-		if config.Project.Configuration.Summary == "yes" {
-			sequenceNumber++
-			jsonSchema.Prompts = append(jsonSchema.Prompts, definitions.Prompt{
-				PromptContent:  summary_query,
-				SequenceID:     strconv.Itoa(i + 1),
-				SequenceNumber: sequenceNumber,
-			})
+	for i, documentPrompts := range documents {
+		for k, promptText := range documentPrompts {
+			sequenceID := strconv.Itoa(i + 1)
+			if len(documentPrompts) > 1 {
+				sequenceID = fmt.Sprintf("%d.%d", i+1, k+1) // chunks of a document
+			}
+			jsonSchema.Prompts = append(jsonSchema.Prompts, sequencePrompts(config, sequenceID, promptText, followUps[i], variables[i])...)
 		}
 	}
 
@@ -262,14 +431,45 @@ func PrepareInput(config *config.Config) (string, []string, error) {
 		logger.Info("Generated prompt: %s (SeqID: %s, SeqNum: %d)", prompt.PromptContent, prompt.SequenceID, prompt.SequenceNumber)
 	}
 
-	// Convert to JSON string
-	jsonData, err := json.MarshalIndent(jsonSchema, "", "  ")
-	if err != nil {
-		logger.Error("Error marshaling JSON: %v", err)
-		return "", nil, err
+	return jsonSchema, filenames, usedExamples, nil
+}
+
+// sequencePrompts returns the main prompt of a sequence followed by the prompts of the other review
+// groups and the enabled follow-up queries, in the order of config.FollowUps. The queries of the
+// [follow_ups] table are rendered with the variables of the document.
+func sequencePrompts(config *config.Config, sequenceID string, promptText string, groupPrompts []string, variables map[string]string) []definitions.Prompt {
+	sequenceNumber := 1 // Track sequence numbering dynamically
+
+	// Append the main prompt
+	prompts := []definitions.Prompt{{
+		PromptContent:  promptText,
+		SequenceID:     sequenceID,
+		SequenceNumber: sequenceNumber,
+	}}
+
+	// Append the prompts of the other review groups
+	for _, groupPrompt := range groupPrompts {
+		sequenceNumber++
+		prompts = append(prompts, definitions.Prompt{
+			PromptContent:  groupPrompt,
+			SequenceID:     sequenceID,
+			SequenceNumber: sequenceNumber,
+		})
 	}
 
-	logger.Info("Input JSON successfully generated.")
+	// Append the justification, summary and named follow-up queries
+	for _, followUp := range config.FollowUps() {
+		query := followUp.Query
+		if !followUp.Builtin() {
+			query = renderTemplate(followUp.Name, query, variables)
+		}
+		sequenceNumber++
+		prompts = append(prompts, definitions.Prompt{
+			PromptContent:  query,
+			SequenceID:     sequenceID,
+			SequenceNumber: sequenceNumber,
+		})
+	}
 
-	return string(jsonData), filenames, nil
+	return prompts
 }
@@ -3,12 +3,14 @@ package prompt
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/secrets"
 )
 
-func TestParsePrompts(t *testing.T) {
+func TestBuildSelectedInput(t *testing.T) {
 	// Setup
 	cfg := &config.Config{
 		Prompt: config.PromptConfig{
@@ -37,11 +39,19 @@ func TestParsePrompts(t *testing.T) {
 	file.Close()
 
 	// Execute
-	prompts, filenames := parsePrompts(cfg)
+	input, filenames, _, _ := BuildSelectedInput(cfg, nil)
 
 	// Verify
-	if len(prompts) == 0 || len(filenames) == 0 {
-		t.Errorf("Expected non-empty results, got prompts: %d, filenames: %d", len(prompts), len(filenames))
+	if len(input.Prompts) == 0 || len(filenames) == 0 {
+		t.Errorf("Expected non-empty results, got prompts: %d, filenames: %d", len(input.Prompts), len(filenames))
+	}
+	if len(input.Prompts) > 0 && !strings.Contains(input.Prompts[0].PromptContent, "Test file content") {
+		t.Errorf("Expected the document text in the prompt, got %q", input.Prompts[0].PromptContent)
+	}
+
+	input, filenames, _, _ = BuildSelectedInput(cfg, map[string]bool{"other": true})
+	if len(input.Prompts) != 0 || len(filenames) != 0 {
+		t.Errorf("Expected unselected documents to be left out, got prompts: %d, filenames: %d", len(input.Prompts), len(filenames))
 	}
 }
 
@@ -74,3 +84,133 @@ func TestSortReviewKeysAlphabetically(t *testing.T) {
 		t.Errorf("Expected %v, got %v", expected, result)
 	}
 }
+
+func TestParseExpectedResultsTypedItems(t *testing.T) {
+	maxYear := 2025.0
+	cfg := &config.Config{
+		Prompt: config.PromptConfig{ExpectedResult: "Output JSON:"},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "scale", Values: []string{"world", "continent"}},
+			"2": {Key: "year", Type: config.ItemTypeInteger, Max: &maxYear},
+			"3": {Key: "methods", Type: config.ItemTypeMultiEnum, Values: []string{"survey", "experiment"}},
+		},
+	}
+
+	expected := `Output JSON: {"methods":"list of one or more of: survey, experiment","scale":["world","continent"],"year":"integer less than or equal to 2025"}`
+	if result := parseExpectedResults(cfg); result != expected {
+		t.Errorf("Expected %s, got %s", expected, result)
+	}
+}
+
+func TestBuildInputReviewGroups(t *testing.T) {
+	inputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "paper1.txt"), []byte("Text of paper1"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cfg := &config.Config{
+		Prompt: config.PromptConfig{Persona: "You are an expert.", Task: "Main task.", ExpectedResult: "Answer:"},
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{InputDirectory: inputDir, Summary: "yes"},
+		},
+		ReviewGroups: map[string]config.ReviewGroupItem{
+			"outcomes": {Task: "Outcomes of {{.Filename}}."},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "design", Values: []string{"cohort", "trial"}},
+			"2": {Key: "mortality", Values: []string{"yes", "no"}, Group: "outcomes"},
+		},
+	}
+
+	input, _, _ := BuildInput(cfg)
+	if len(input.Prompts) != 3 {
+		t.Fatalf("Expected the main, group and summary prompts, got %+v", input.Prompts)
+	}
+	main, group := input.Prompts[0].PromptContent, input.Prompts[1].PromptContent
+	if !strings.Contains(main, "Main task.") || !strings.Contains(main, `"design"`) || strings.Contains(main, "mortality") {
+		t.Errorf("Expected the main prompt to ask only the ungrouped items, got %q", main)
+	}
+	if !strings.Contains(main, "Text of paper1") {
+		t.Errorf("Expected the document in the main prompt, got %q", main)
+	}
+	if !strings.HasPrefix(group, "Outcomes of paper1.") || !strings.Contains(group, `"mortality"`) || strings.Contains(group, `"design"`) || strings.Contains(group, "Text of paper1") {
+		t.Errorf("Expected the group prompt to ask only its items, got %q", group)
+	}
+	for i, p := range input.Prompts {
+		if p.SequenceID != "1" || p.SequenceNumber != i+1 {
+			t.Errorf("Expected prompt %d in sequence 1 with number %d, got %s.%d", i+1, i+1, p.SequenceID, p.SequenceNumber)
+		}
+	}
+}
+
+func TestSequencePromptsLocalizedFollowUps(t *testing.T) {
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{CotJustification: "yes", Summary: "yes", PromptLanguage: "es"},
+		},
+		InternalPrompts: map[string]string{"summary_query": "Resume el texto."},
+	}
+
+	prompts := sequencePrompts(cfg, "1", "Main prompt.", nil, nil)
+	if len(prompts) != 3 {
+		t.Fatalf("Expected the main, justification and summary prompts, got %+v", prompts)
+	}
+	if !strings.HasPrefix(prompts[1].PromptContent, "Para cada una de las claves") {
+		t.Errorf("Expected the Spanish justification query, got %q", prompts[1].PromptContent)
+	}
+	if prompts[2].PromptContent != "Resume el texto." {
+		t.Errorf("Expected the overridden summary query, got %q", prompts[2].PromptContent)
+	}
+}
+
+func TestSequencePromptsNamedFollowUps(t *testing.T) {
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{Summary: "yes"},
+		},
+		FollowUpQueries: map[string]config.FollowUpItem{
+			"limitations": {Query: "List the limitations of {{.Filename}}."},
+			"funding":     {Query: "Extract the funding sources."},
+		},
+	}
+
+	prompts := sequencePrompts(cfg, "2", "Main prompt.", []string{"Group prompt."}, map[string]string{"Filename": "paper2"})
+	expected := []string{"Main prompt.", "Group prompt.", cfg.LocalizedPrompts().Text("summary_query"), "Extract the funding sources.", "List the limitations of paper2."}
+	if len(prompts) != len(expected) {
+		t.Fatalf("Expected %d prompts, got %+v", len(expected), prompts)
+	}
+	for i, p := range prompts {
+		if p.PromptContent != expected[i] || p.SequenceID != "2" || p.SequenceNumber != i+1 {
+			t.Errorf("Expected prompt %d to be %q in sequence 2, got %q in %s.%d", i+1, expected[i], p.PromptContent, p.SequenceID, p.SequenceNumber)
+		}
+	}
+}
+
+func TestPrepareInputRedactsAPIKeys(t *testing.T) {
+	inputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "paper1.txt"), []byte("Text of paper1"), 0644); err != nil {
+		t.Fatalf("Failed to write input: %v", err)
+	}
+
+	cfg := &config.Config{
+		Prompt: config.PromptConfig{Task: "Main task."},
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{InputDirectory: inputDir},
+			LLM:           map[string]config.LLMItem{"1": {Provider: "OpenAI", Model: "gpt-4o-mini", ApiKey: "sk-prepared-key"}},
+		},
+		Review: map[string]config.ReviewItem{"1": {Key: "design", Values: []string{"cohort", "trial"}}},
+	}
+
+	jsonInput, _, err := PrepareInput(cfg)
+	if err != nil {
+		t.Fatalf("PrepareInput returned an unexpected error: %v", err)
+	}
+	if strings.Contains(jsonInput, "sk-prepared-key") || !strings.Contains(jsonInput, secrets.Redacted) {
+		t.Errorf("Expected the API key to be redacted from the input JSON, got %s", jsonInput)
+	}
+
+	input, _, _ := BuildInput(cfg)
+	if input.Models[0].APIKey != "sk-prepared-key" {
+		t.Errorf("Expected BuildInput to keep the API key for the run, got %q", input.Models[0].APIKey)
+	}
+}
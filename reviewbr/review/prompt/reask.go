@@ -0,0 +1,29 @@
+package prompt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/open-and-sustainable/prismaid/localization"
+)
+
+// BuildReaskPrompt generates the prompt asking a model to answer again a review prompt whose answer
+// failed the validation against the JSON Schema of its review items.
+//
+// Arguments:
+// - prompts: The internal prompts of the review, giving the re-ask query and the labels of its parts.
+// - original: The review prompt that was answered.
+// - answer: The answer of the model.
+// - problems: The problems found in the answer by the validation.
+// - schema: The JSON Schema the answer must satisfy, as JSON text.
+//
+// Returns:
+// - The prompt asking for a corrected answer.
+func BuildReaskPrompt(prompts *localization.Prompts, original string, answer string, problems []string, schema string) string {
+	var list strings.Builder
+	for _, problem := range problems {
+		fmt.Fprintf(&list, "- %s\n", problem)
+	}
+	return fmt.Sprintf("%s\n\n%s\n%s\n\n%s\n\n%s\n%s\n%s\n%s", original, prompts.Text(localization.ReaskAnswer), answer,
+		prompts.Text(localization.ReaskQuery), prompts.Text(localization.ReaskProblems), list.String(), prompts.Text(localization.ReaskSchema), schema)
+}
@@ -0,0 +1,24 @@
+package prompt
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/prismaid/localization"
+)
+
+func TestBuildReaskPrompt(t *testing.T) {
+	prompt := BuildReaskPrompt(localization.New("es", nil), "Original prompt.", `{"test": "maybe"}`, []string{"test: not an allowed value"}, `{"type": "object"}`)
+	for _, fragment := range []string{
+		"Original prompt.\n\nTu respuesta anterior:\n{\"test\": \"maybe\"}",
+		"Problemas:\n- test: not an allowed value\n",
+		"JSON Schema:\n{\"type\": \"object\"}",
+	} {
+		if !strings.Contains(prompt, fragment) {
+			t.Errorf("Expected %q in the re-ask prompt, got %q", fragment, prompt)
+		}
+	}
+	if strings.Contains(prompt, "Your previous answer") {
+		t.Errorf("Expected no English label in the Spanish re-ask prompt, got %q", prompt)
+	}
+}
@@ -0,0 +1,190 @@
+package prompt
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/records"
+)
+
+// metadataFileColumn is the column of the sidecar metadata file holding the document filename.
+const metadataFileColumn = "File Name"
+
+// documentVariables returns the template variables of a document: its filename, as .Filename, and the
+// columns of its row in the sidecar metadata file, named after the column headers (e.g. .Title, .Year, .DOI).
+//
+// Arguments:
+// - filename: The document filename, without extension.
+// - metadata: The metadata of every document, indexed by filename, as returned by loadMetadata.
+//
+// Returns:
+// - The variables available to the prompt templates of the document.
+func documentVariables(filename string, metadata map[string]map[string]string) map[string]string {
+	variables := map[string]string{"Filename": filename}
+	for name, value := range metadata[filename] {
+		variables[name] = value
+	}
+	return variables
+}
+
+// loadMetadata reads the sidecar metadata file configured in metadata_file. The file is a CSV with a
+// "File Name" column, matched with the document filenames with or without extension, and one column
+// per variable. Headers are turned into template names by removing spaces and punctuation and
+// capitalizing each word, so "title" becomes .Title and "publication year" becomes .PublicationYear.
+// When reviewing screening results, the metadata columns of the records are variables as well, named
+// in the same way; the sidecar file takes precedence over them.
+//
+// Arguments:
+// - config: A pointer to the application's configuration, specifying the metadata file.
+//
+// Returns:
+// - The variables of every document listed in the file, indexed by filename; nil if no file is configured.
+// - An error if the file cannot be read or has no "File Name" column.
+func loadMetadata(config *config.Config) (map[string]map[string]string, error) {
+	metadata, err := screeningMetadata(config)
+	if err != nil {
+		return nil, err
+	}
+	path := config.Project.Configuration.MetadataFile
+	if path == "" {
+		return metadata, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return metadata, nil
+	}
+
+	fileColumn := -1
+	names := make([]string, len(records[0]))
+	for i, header := range records[0] {
+		header = strings.TrimSpace(strings.TrimPrefix(header, "\ufeff"))
+		if header == metadataFileColumn {
+			fileColumn = i
+		}
+		names[i] = templateName(header)
+	}
+	if fileColumn < 0 {
+		return nil, fmt.Errorf("%s has no %q column", path, metadataFileColumn)
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]map[string]string)
+	}
+	for _, record := range records[1:] {
+		if fileColumn >= len(record) {
+			continue
+		}
+		filename := strings.TrimSpace(record[fileColumn])
+		filename = strings.TrimSuffix(filename, filepath.Ext(filename))
+		variables := metadata[filename]
+		if variables == nil {
+			variables = make(map[string]string)
+			metadata[filename] = variables
+		}
+		for i, value := range record {
+			if i != fileColumn && names[i] != "" {
+				variables[names[i]] = strings.TrimSpace(value)
+			}
+		}
+	}
+	return metadata, nil
+}
+
+// screeningMetadata returns the metadata columns of the records included in the configured screening
+// results as template variables, indexed by record identity; nil if no screening results are configured.
+func screeningMetadata(config *config.Config) (map[string]map[string]string, error) {
+	screening, err := records.FromConfig(config)
+	if err != nil || screening == nil {
+		return nil, err
+	}
+	metadata := make(map[string]map[string]string, len(screening.Records))
+	for _, record := range screening.Records {
+		variables := make(map[string]string, len(record.Metadata))
+		for column, value := range record.Metadata {
+			if name := templateName(column); name != "" {
+				variables[name] = strings.TrimSpace(value)
+			}
+		}
+		metadata[record.ID] = variables
+	}
+	return metadata, nil
+}
+
+// templateName turns a column header into a template variable name, e.g. "publication year" into "PublicationYear".
+func templateName(header string) string {
+	var name strings.Builder
+	upper := true
+	for _, r := range header {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		name.WriteRune(r)
+	}
+	return name.String()
+}
+
+// renderPromptConfig executes the templates of the prompt fields with the variables of a document.
+// Fields without template actions are returned unchanged; variables missing for the document are
+// rendered as empty strings.
+//
+// Arguments:
+// - prompt: The prompt configuration, whose fields may contain text/template actions.
+// - variables: The variables of the document, as returned by documentVariables; nil for none.
+//
+// Returns:
+// - The prompt configuration with every field rendered.
+func renderPromptConfig(prompt config.PromptConfig, variables map[string]string) config.PromptConfig {
+	return config.PromptConfig{
+		Persona:        renderTemplate("persona", prompt.Persona, variables),
+		Task:           renderTemplate("task", prompt.Task, variables),
+		ExpectedResult: renderTemplate("expected_result", prompt.ExpectedResult, variables),
+		Failsafe:       renderTemplate("failsafe", prompt.Failsafe, variables),
+		Definitions:    renderTemplate("definitions", prompt.Definitions, variables),
+		Example:        renderTemplate("example", prompt.Example, variables),
+	}
+}
+
+// renderTemplate executes a single prompt field as a template. Templates are checked when the
+// configuration is loaded, so execution errors are only logged and the field is kept as written.
+func renderTemplate(name string, text string, variables map[string]string) string {
+	if !strings.Contains(text, "{{") {
+		return text
+	}
+	if variables == nil {
+		variables = map[string]string{}
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(text)
+	if err != nil {
+		logger.Error("Error parsing %s template: %v", name, err)
+		return text
+	}
+	var rendered strings.Builder
+	if err := tmpl.Execute(&rendered, variables); err != nil {
+		logger.Error("Error rendering %s template: %v", name, err)
+		return text
+	}
+	return rendered.String()
+}
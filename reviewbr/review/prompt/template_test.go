@@ -0,0 +1,143 @@
+package prompt
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestTemplateName(t *testing.T) {
+	tests := map[string]string{
+		"title":            "Title",
+		"DOI":              "DOI",
+		"publication year": "PublicationYear",
+		"journal-name":     "JournalName",
+		" ":                "",
+	}
+	for header, expected := range tests {
+		if name := templateName(header); name != expected {
+			t.Errorf("templateName(%q) = %q, want %q", header, name, expected)
+		}
+	}
+}
+
+func TestBuildInputRendersTemplates(t *testing.T) {
+	dir := t.TempDir()
+	inputDir := filepath.Join(dir, "input")
+	if err := os.Mkdir(inputDir, 0755); err != nil {
+		t.Fatalf("Failed to create input directory: %v", err)
+	}
+	for _, name := range []string{"paper1.txt", "paper2.txt"} {
+		if err := os.WriteFile(filepath.Join(inputDir, name), []byte("Text of "+name), 0644); err != nil {
+			t.Fatalf("Failed to write input: %v", err)
+		}
+	}
+	metadataFile := filepath.Join(dir, "metadata.csv")
+	metadata := "File Name,title,year,journal\npaper1.pdf,Floods in Europe,2021,Nature Climate Change\n"
+	if err := os.WriteFile(metadataFile, []byte(metadata), 0644); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+
+	cfg := &config.Config{
+		Prompt: config.PromptConfig{
+			Persona:        "You are an expert.",
+			Task:           "This paper ({{.Filename}}), published in {{.Year}} in {{.Journal}}, is titled '{{.Title}}'.",
+			ExpectedResult: "{{if .Year}}Answer for the {{.Year}} study{{else}}Answer{{end}}:",
+		},
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{InputDirectory: inputDir, MetadataFile: metadataFile},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "test", Values: []string{"yes", "no"}},
+		},
+	}
+
+	input, filenames, err := BuildInput(cfg)
+	if err != nil {
+		t.Fatalf("BuildInput returned an error: %v", err)
+	}
+	if len(input.Prompts) != 2 || strings.Join(filenames, ",") != "paper1,paper2" {
+		t.Fatalf("Unexpected input: %v, %+v", filenames, input.Prompts)
+	}
+
+	expected := []string{
+		"This paper (paper1), published in 2021 in Nature Climate Change, is titled 'Floods in Europe'.\nAnswer for the 2021 study: ",
+		"This paper (paper2), published in  in , is titled ''.\nAnswer: ",
+	}
+	for i, fragment := range expected {
+		if !strings.Contains(input.Prompts[i].PromptContent, fragment) {
+			t.Errorf("Expected %q in prompt %d, got %q", fragment, i+1, input.Prompts[i].PromptContent)
+		}
+	}
+
+	cfg.Project.Configuration.MetadataFile = filepath.Join(dir, "missing.csv")
+	if _, _, err := BuildInput(cfg); err == nil {
+		t.Errorf("Expected an error for a missing metadata file")
+	}
+	if err := os.WriteFile(metadataFile, []byte("title,year\nFloods in Europe,2021\n"), 0644); err != nil {
+		t.Fatalf("Failed to write metadata: %v", err)
+	}
+	cfg.Project.Configuration.MetadataFile = metadataFile
+	if _, _, err := BuildInput(cfg); err == nil {
+		t.Errorf("Expected an error for a metadata file without a File Name column")
+	}
+}
+
+func TestBuildInputFromScreeningResults(t *testing.T) {
+	dir := t.TempDir()
+	results := "doi,title,abstract,include,exclusion_reason\n" +
+		"10.1/a,Floods in Europe,Text of the first paper.,true,\n" +
+		"10.1/b,An essay,Text of the second paper.,false,article type\n" +
+		"10.1/c,Droughts in Africa,Text of the third paper.,true,\n"
+	screeningResults := filepath.Join(dir, "screening.csv")
+	if err := os.WriteFile(screeningResults, []byte(results), 0644); err != nil {
+		t.Fatalf("Failed to write screening results: %v", err)
+	}
+
+	cfg := &config.Config{
+		Prompt: config.PromptConfig{Task: "This paper ({{.Filename}}) is titled '{{.Title}}'."},
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{
+				ScreeningResults:          screeningResults,
+				ScreeningTextColumn:       "abstract",
+				ScreeningIdentifierColumn: "doi",
+			},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "test", Values: []string{"yes", "no"}},
+		},
+	}
+
+	input, filenames, err := BuildInput(cfg)
+	if err != nil {
+		t.Fatalf("BuildInput returned an error: %v", err)
+	}
+	if len(input.Prompts) != 2 || strings.Join(filenames, ",") != "10.1/a,10.1/c" {
+		t.Fatalf("Expected the included records only, got %v", filenames)
+	}
+	expected := []string{
+		"This paper (10.1/a) is titled 'Floods in Europe'.",
+		"This paper (10.1/c) is titled 'Droughts in Africa'.",
+	}
+	for i, fragment := range expected {
+		if !strings.Contains(input.Prompts[i].PromptContent, fragment) || strings.Contains(input.Prompts[i].PromptContent, "second paper") {
+			t.Errorf("Expected %q in prompt %d, got %q", fragment, i+1, input.Prompts[i].PromptContent)
+		}
+	}
+	if !strings.HasSuffix(input.Prompts[1].PromptContent, "Text of the third paper.") {
+		t.Errorf("Expected the text of the record in the prompt, got %q", input.Prompts[1].PromptContent)
+	}
+
+	cfg.Project.Configuration.ScreeningResults = filepath.Join(dir, "missing.csv")
+	if _, _, err := BuildInput(cfg); err == nil {
+		t.Errorf("Expected an error for missing screening results")
+	}
+	cfg.Project.Configuration.ScreeningResults = ""
+	cfg.Project.Configuration.InputDirectory = filepath.Join(dir, "missing")
+	if _, _, err := BuildInput(cfg); err == nil {
+		t.Errorf("Expected an error for a missing input directory")
+	}
+}
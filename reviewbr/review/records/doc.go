@@ -0,0 +1,5 @@
+// Package records reads the results of a screening run, in its JSON or CSV output format, as the input
+// of a review. Only the records included by the screening are returned, each with its text, read from
+// the file the text column points to when it holds a path, the identity under which it is reviewed,
+// and the other columns of the original data, which are carried through to the review results.
+package records
@@ -0,0 +1,248 @@
+package records
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+// Columns added by the screening to the original data in its CSV output.
+const (
+	includeColumn   = "include"
+	exclusionColumn = "exclusion_reason"
+	tagPrefix       = "tag_"
+)
+
+// Record is a manuscript included by the screening.
+type Record struct {
+	ID       string            // identity of the record in the review: the screening ID, or the value of the identifier column
+	Text     string            // text of the manuscript, read from the file named in the text column if it holds a path
+	Metadata map[string]string // the other columns of the original data, by column name
+}
+
+// Screening holds the records included in the results of a screening run.
+type Screening struct {
+	Columns []string // metadata columns, in file order for CSV and in alphabetical order for JSON
+	Records []Record // included records, in file order
+}
+
+// screened is a record of the screening results, before selection.
+type screened struct {
+	id      string
+	data    map[string]string
+	include bool
+}
+
+// FromConfig reads the screening results configured in screening_results.
+//
+// Arguments:
+// - cfg: A pointer to the application's configuration, specifying the screening results and their columns.
+//
+// Returns:
+// - The included records; nil if no screening results are configured.
+// - An error if the results cannot be read, as returned by Load.
+func FromConfig(cfg *config.Config) (*Screening, error) {
+	configuration := cfg.Project.Configuration
+	if configuration.ScreeningResults == "" {
+		return nil, nil
+	}
+	return Load(configuration.ScreeningResults, configuration.ScreeningTextColumn, configuration.ScreeningIdentifierColumn)
+}
+
+// Load reads the records included in screening results, written by the screening tool in JSON (.json)
+// or CSV (.csv) format. Columns are matched case-insensitively, as in the screening. The text column
+// holds the text of the manuscript or the path to a file containing it. Records are identified by
+// their screening ID, i.e. their row number in the screening input, or by the value of the identifier
+// column when given, which must be unique. The text and identifier columns are not part of the metadata.
+//
+// Arguments:
+// - path: The screening results file.
+// - textColumn: The column holding the text of the manuscripts, or the path to their files.
+// - identifierColumn: The column identifying the records; empty to use the screening ID.
+//
+// Returns:
+// - The included records and the names of their metadata columns.
+// - An error if the file cannot be read, a column is missing, or two records have the same identity.
+func Load(path string, textColumn string, identifierColumn string) (*Screening, error) {
+	var columns []string
+	var rows []screened
+	var err error
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".json":
+		columns, rows, err = readJSON(path)
+	case ".csv":
+		columns, rows, err = readCSV(path)
+	default:
+		return nil, fmt.Errorf("unsupported screening results format: %s", ext)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	textColumn, ok := findColumn(columns, textColumn)
+	if !ok {
+		return nil, fmt.Errorf("text column '%s' not found in %s", textColumn, path)
+	}
+	if identifierColumn != "" {
+		if identifierColumn, ok = findColumn(columns, identifierColumn); !ok {
+			return nil, fmt.Errorf("identifier column '%s' not found in %s", identifierColumn, path)
+		}
+	}
+
+	screening := &Screening{}
+	for _, column := range columns {
+		if column != textColumn && column != identifierColumn {
+			screening.Columns = append(screening.Columns, column)
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, row := range rows {
+		if !row.include {
+			continue
+		}
+		id := row.id
+		if identifierColumn != "" {
+			id = strings.TrimSpace(row.data[identifierColumn])
+			if id == "" {
+				return nil, fmt.Errorf("record %s has no %s", row.id, identifierColumn)
+			}
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("duplicate record identity '%s' in %s", id, path)
+		}
+		seen[id] = true
+
+		text, err := recordText(row.data[textColumn])
+		if err != nil {
+			return nil, fmt.Errorf("record %s: %v", id, err)
+		}
+		metadata := make(map[string]string, len(screening.Columns))
+		for _, column := range screening.Columns {
+			metadata[column] = row.data[column]
+		}
+		screening.Records = append(screening.Records, Record{ID: id, Text: text, Metadata: metadata})
+	}
+	return screening, nil
+}
+
+// readJSON reads the records of screening results in JSON format. The columns are those of the
+// original data of every record, sorted.
+func readJSON(path string) ([]string, []screened, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	var result struct {
+		Records []struct {
+			ID           string            `json:"id"`
+			OriginalData map[string]string `json:"original_data"`
+			Include      bool              `json:"include"`
+		} `json:"records"`
+	}
+	if err := json.Unmarshal(content, &result); err != nil {
+		return nil, nil, fmt.Errorf("cannot parse %s: %v", path, err)
+	}
+
+	var columns []string
+	rows := make([]screened, 0, len(result.Records))
+	for _, record := range result.Records {
+		for column := range record.OriginalData {
+			if !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+		rows = append(rows, screened{id: record.ID, data: record.OriginalData, include: record.Include})
+	}
+	sort.Strings(columns)
+	return columns, rows, nil
+}
+
+// readCSV reads the records of screening results in CSV format. The columns are those of the original
+// data, without the tag and status columns added by the screening; records are numbered from 1 in row
+// order, as the screening does.
+func readCSV(path string) ([]string, []screened, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer file.Close()
+
+	reader := csv.NewReader(file)
+	reader.FieldsPerRecord = -1
+	lines, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot parse %s: %v", path, err)
+	}
+	if len(lines) == 0 {
+		return nil, nil, fmt.Errorf("%s is empty", path)
+	}
+
+	header := lines[0]
+	if len(header) > 0 {
+		header[0] = strings.TrimPrefix(header[0], "\ufeff")
+	}
+	include := slices.Index(header, includeColumn)
+	if include < 0 {
+		return nil, nil, fmt.Errorf("%s has no %q column, it is not a screening result", path, includeColumn)
+	}
+	var columns []string
+	for _, column := range header {
+		if column != includeColumn && column != exclusionColumn && !strings.HasPrefix(column, tagPrefix) {
+			columns = append(columns, column)
+		}
+	}
+
+	rows := make([]screened, 0, len(lines)-1)
+	for i, line := range lines[1:] {
+		row := screened{id: strconv.Itoa(i + 1), data: make(map[string]string, len(columns))}
+		for k, value := range line {
+			if k < len(header) && slices.Contains(columns, header[k]) {
+				row.data[header[k]] = value
+			}
+		}
+		if include < len(line) {
+			row.include, err = strconv.ParseBool(strings.TrimSpace(line[include]))
+			if err != nil {
+				return nil, nil, fmt.Errorf("record %s: invalid %s value %q", row.id, includeColumn, line[include])
+			}
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, nil
+}
+
+// findColumn returns the column matching a name case-insensitively.
+func findColumn(columns []string, name string) (string, bool) {
+	for _, column := range columns {
+		if strings.EqualFold(column, name) {
+			return column, true
+		}
+	}
+	return name, false
+}
+
+// recordText returns the text of a record: the content of the file named in the text column if it
+// exists, otherwise the value of the column itself.
+func recordText(value string) (string, error) {
+	path := strings.TrimSpace(value)
+	if path == "" {
+		return value, nil
+	}
+	if info, err := os.Stat(path); err != nil || info.IsDir() {
+		return value, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
@@ -0,0 +1,108 @@
+package records
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadJSON(t *testing.T) {
+	dir := t.TempDir()
+	textPath := filepath.Join(dir, "paper2.txt")
+	if err := os.WriteFile(textPath, []byte("Full text of the second paper."), 0644); err != nil {
+		t.Fatalf("Failed to write manuscript: %v", err)
+	}
+	results := `{
+  "total_records": 3,
+  "records": [
+    {"id": "1", "original_data": {"title": "Floods", "abstract": "Text of the first paper.", "year": "2021"}, "tags": {}, "include": true},
+    {"id": "2", "original_data": {"title": "Droughts", "abstract": "` + textPath + `", "year": "2022"}, "tags": {}, "include": true},
+    {"id": "3", "original_data": {"title": "Duplicate", "abstract": "Text of the first paper.", "year": "2021"}, "tags": {}, "exclusion_reason": "duplicate", "include": false}
+  ]
+}`
+	path := filepath.Join(dir, "screening.json")
+	if err := os.WriteFile(path, []byte(results), 0644); err != nil {
+		t.Fatalf("Failed to write screening results: %v", err)
+	}
+
+	screening, err := Load(path, "Abstract", "")
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(screening.Columns, []string{"title", "year"}) {
+		t.Errorf("Expected the title and year columns, got %v", screening.Columns)
+	}
+	expected := []Record{
+		{ID: "1", Text: "Text of the first paper.", Metadata: map[string]string{"title": "Floods", "year": "2021"}},
+		{ID: "2", Text: "Full text of the second paper.", Metadata: map[string]string{"title": "Droughts", "year": "2022"}},
+	}
+	if !reflect.DeepEqual(screening.Records, expected) {
+		t.Errorf("Expected records %+v, got %+v", expected, screening.Records)
+	}
+}
+
+func TestLoadCSV(t *testing.T) {
+	dir := t.TempDir()
+	results := "doi,title,abstract,tag_language,include,exclusion_reason\n" +
+		"10.1/a,Floods,Text of the first paper.,en,true,\n" +
+		"10.1/b,Essay,Text of the second paper.,en,false,article type\n" +
+		"10.1/c,Droughts,Text of the third paper.,es,true,\n"
+	path := filepath.Join(dir, "screening.csv")
+	if err := os.WriteFile(path, []byte(results), 0644); err != nil {
+		t.Fatalf("Failed to write screening results: %v", err)
+	}
+
+	screening, err := Load(path, "abstract", "")
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(screening.Columns, []string{"doi", "title"}) {
+		t.Errorf("Expected the original columns without tags and status, got %v", screening.Columns)
+	}
+	if len(screening.Records) != 2 || screening.Records[0].ID != "1" || screening.Records[1].ID != "3" {
+		t.Fatalf("Expected records 1 and 3, got %+v", screening.Records)
+	}
+
+	screening, err = Load(path, "abstract", "DOI")
+	if err != nil {
+		t.Fatalf("Load returned an error: %v", err)
+	}
+	if screening.Records[1].ID != "10.1/c" || !reflect.DeepEqual(screening.Columns, []string{"title"}) {
+		t.Errorf("Expected records identified by DOI, got %v and %+v", screening.Columns, screening.Records)
+	}
+}
+
+func TestLoadErrors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+		return path
+	}
+	duplicates := write("duplicates.csv", "doi,abstract,include\n10.1/a,First,true\n10.1/a,Second,true\n")
+	input := write("input.csv", "doi,abstract\n10.1/a,First\n")
+	other := write("screening.txt", "")
+
+	tests := []struct {
+		path       string
+		textColumn string
+		idColumn   string
+		expected   string
+	}{
+		{duplicates, "abstract", "doi", "duplicate record identity '10.1/a'"},
+		{duplicates, "text", "", "text column 'text' not found"},
+		{duplicates, "abstract", "pmid", "identifier column 'pmid' not found"},
+		{input, "abstract", "", "it is not a screening result"},
+		{other, "abstract", "", "unsupported screening results format"},
+	}
+	for _, test := range tests {
+		_, err := Load(test.path, test.textColumn, test.idColumn)
+		if err == nil || !strings.Contains(err.Error(), test.expected) {
+			t.Errorf("Expected an error containing %q for %s, got %v", test.expected, filepath.Base(test.path), err)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package results
+
+import (
+	"encoding/json"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
+)
+
+// Answer is the review of a document by a model, as written to the results file.
+type Answer struct {
+	File          string            `json:"file"` // document, without extension
+	Provider      string            `json:"provider"`
+	Model         string            `json:"model"`
+	Values        map[string]string `json:"values"`                  // validated answer of every review key, empty if missing or invalid
+	Justification string            `json:"justification,omitempty"` // answer to the justification query, if enabled
+	Summary       string            `json:"summary,omitempty"`       // text of the summary, if enabled
+	FollowUps     map[string]string `json:"follow_ups,omitempty"`    // text of the answers to the [follow_ups] queries, by name
+}
+
+// Answers converts the model responses into the answers of every document and model, validated as
+// in the results file. With review groups, the answers of the groups are merged into one Answer.
+//
+// Arguments:
+// - cfg: The application configuration containing the review items and follow-up settings.
+// - results: JSON string containing all model responses.
+// - run: The responses of the run, as recorded in the run manifest.
+// - keys: The review keys.
+//
+// Returns:
+// - The answers, in the order of the responses; responses that are not JSON objects are left out.
+// - An error if the results cannot be parsed.
+func Answers(cfg *config.Config, results string, run []manifest.Response, keys []string) ([]Answer, error) {
+	results, run, err := mergeGroups(len(cfg.Groups()), results, run)
+	if err != nil {
+		return nil, err
+	}
+	var parsedResults definitions.Output
+	if err := json.Unmarshal([]byte(results), &parsedResults); err != nil {
+		logger.Error("Error parsing results JSON: %v", err)
+		return nil, err
+	}
+	attribution := newAttribution(run)
+	validator := newAnswerValidator(cfg)
+
+	// follow-up answers are matched to the main answer of the same sequence and model
+	type followUpKey struct{ sequenceID, provider, model string }
+	justificationSequence, summarySequence := followUpSequences(cfg)
+	names := followUpNames(cfg)
+	justifications := make(map[followUpKey]string)
+	summaries := make(map[followUpKey]string)
+	texts := make(map[followUpKey]map[string]string)
+	for _, response := range parsedResults.Responses {
+		if len(response.ModelResponses) == 0 {
+			continue
+		}
+		id := followUpKey{response.SequenceID, response.Provider, response.Model}
+		switch response.SequenceNumber {
+		case justificationSequence:
+			justifications[id] = response.ModelResponses[0]
+		case summarySequence:
+			summaries[id] = followUpText(config.FollowUpSummary, response.ModelResponses[0])
+		default:
+			if name, ok := names[response.SequenceNumber]; ok {
+				if texts[id] == nil {
+					texts[id] = make(map[string]string)
+				}
+				texts[id][name] = followUpText(name, response.ModelResponses[0])
+			}
+		}
+	}
+
+	var answers []Answer
+	for _, response := range parsedResults.Responses {
+		if response.SequenceNumber != 1 || len(response.ModelResponses) == 0 {
+			continue
+		}
+		filename, ok := attribution.file(response)
+		if !ok {
+			continue
+		}
+		row, ok := answerRow(response.ModelResponses[0], filename, response.Provider, response.Model, keys, validator)
+		if !ok {
+			continue
+		}
+		values := make(map[string]string, len(keys))
+		for i, key := range keys {
+			values[key] = row[i+3]
+		}
+		id := followUpKey{response.SequenceID, response.Provider, response.Model}
+		answers = append(answers, Answer{
+			File:          filename,
+			Provider:      response.Provider,
+			Model:         response.Model,
+			Values:        values,
+			Justification: justifications[id],
+			Summary:       summaries[id],
+			FollowUps:     texts[id],
+		})
+	}
+	return answers, nil
+}
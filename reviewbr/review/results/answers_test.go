@@ -0,0 +1,50 @@
+package results
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestAnswersMatchFollowUps(t *testing.T) {
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{CotJustification: "yes", Summary: "yes"},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "design", Values: []string{"cohort", "trial"}},
+		},
+	}
+
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"design": "cohort"}`}},
+			{SequenceID: "1", SequenceNumber: 2, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{"Because of the follow-up."}},
+			{SequenceID: "1", SequenceNumber: 3, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"summary": "A cohort."}`}},
+			{SequenceID: "2", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"design": "survey"}`}},
+			{SequenceID: "3", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{"not json"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	run := testRun(t, string(output), []string{"paper1", "paper2", "paper3"})
+
+	answers, err := Answers(cfg, string(output), run, []string{"design"})
+	if err != nil {
+		t.Fatalf("Answers returned an error: %v", err)
+	}
+	if len(answers) != 2 {
+		t.Fatalf("Expected the answers of the two JSON responses, got %+v", answers)
+	}
+	first := answers[0]
+	if first.File != "paper1" || first.Values["design"] != "cohort" || first.Justification != "Because of the follow-up." || first.Summary != "A cohort." {
+		t.Errorf("Unexpected first answer %+v", first)
+	}
+	second := answers[1]
+	if second.File != "paper2" || second.Values["design"] != "" || second.Justification != "" {
+		t.Errorf("Expected an invalid value left empty and no follow-ups, got %+v", second)
+	}
+}
@@ -0,0 +1,125 @@
+package results
+
+import (
+	"encoding/json"
+	"strconv"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
+	"github.com/open-and-sustainable/prismaid/review/records"
+)
+
+// attribution maps the responses of a review to the documents they answer, as recorded in the run manifest.
+type attribution struct {
+	files     map[string]string // document of each response, by response key
+	documents []string          // documents with at least one response, in run order
+
+	columns  []string                     // metadata columns of the reviewed screening records, if any
+	metadata map[string]map[string]string // metadata of the reviewed screening records, by record identity
+
+	retries map[string]int // times each answer was asked again, by response key; nil without schema_retries
+}
+
+// newAttribution indexes the responses recorded in the run manifest. Failed prompts have no response
+// and are left out.
+//
+// Arguments:
+// - run: The responses of the run, as recorded in the run manifest.
+//
+// Returns:
+// - A pointer to the attribution.
+func newAttribution(run []manifest.Response) *attribution {
+	a := &attribution{files: make(map[string]string, len(run))}
+	seen := make(map[string]bool)
+	for _, record := range run {
+		if record.Status == manifest.StatusFailed {
+			continue
+		}
+		a.files[responseKey(record.SequenceID, record.SequenceNumber, record.Provider, record.Model)] = record.File
+		if !seen[record.File] {
+			seen[record.File] = true
+			a.documents = append(a.documents, record.File)
+		}
+	}
+	return a
+}
+
+// file returns the document a response answers. Responses missing from the run manifest are
+// logged and reported as not attributed.
+//
+// Arguments:
+// - response: A response of the alembica output.
+//
+// Returns:
+// - The filename of the document, without extension.
+// - True if the response is recorded in the run manifest.
+func (a *attribution) file(response definitions.Response) (string, bool) {
+	filename, ok := a.files[responseKey(response.SequenceID, response.SequenceNumber, response.Provider, response.Model)]
+	if !ok {
+		logger.Error("Response %s.%d of %s %s is not recorded in the run manifest", response.SequenceID, response.SequenceNumber, response.Provider, response.Model)
+	}
+	return filename, ok
+}
+
+// addRecords attaches the metadata of the reviewed screening records, which the writers carry through
+// to the results next to the file name.
+//
+// Arguments:
+// - screening: The records included by the screening; nil when the input directory is reviewed.
+func (a *attribution) addRecords(screening *records.Screening) {
+	if screening == nil {
+		return
+	}
+	a.columns = screening.Columns
+	a.metadata = make(map[string]map[string]string, len(screening.Records))
+	for _, record := range screening.Records {
+		a.metadata[record.ID] = record.Metadata
+	}
+}
+
+// record returns the values of the metadata columns of a document, in column order; empty unless
+// screening records are reviewed.
+func (a *attribution) record(filename string) []string {
+	values := make([]string, len(a.columns))
+	for i, column := range a.columns {
+		values[i] = a.metadata[filename][column]
+	}
+	return values
+}
+
+// addRetries attaches the times each answer was asked again after failing its JSON Schema, which the
+// writers add to the results in a Retries column or a "retries" field.
+//
+// Arguments:
+// - run: The responses of the run, as recorded in the run manifest.
+func (a *attribution) addRetries(run []manifest.Response) {
+	a.retries = make(map[string]int, len(run))
+	for _, record := range run {
+		if record.Status != manifest.StatusFailed {
+			a.retries[responseKey(record.SequenceID, record.SequenceNumber, record.Provider, record.Model)] = record.Retries
+		}
+	}
+}
+
+// retryColumns returns the Retries column of the tabular outputs, written after the metadata columns;
+// empty unless retries are attached.
+func (a *attribution) retryColumns() []string {
+	if a.retries == nil {
+		return nil
+	}
+	return []string{"Retries"}
+}
+
+// retryValues returns the value of the Retries column for a response; empty unless retries are attached.
+func (a *attribution) retryValues(response definitions.Response) []string {
+	if a.retries == nil {
+		return nil
+	}
+	return []string{strconv.Itoa(a.retries[responseKey(response.SequenceID, response.SequenceNumber, response.Provider, response.Model)])}
+}
+
+func responseKey(sequenceID string, sequenceNumber int, provider, model string) string {
+	data, _ := json.Marshal([]any{sequenceID, sequenceNumber, provider, model})
+	return string(data)
+}
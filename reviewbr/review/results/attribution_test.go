@@ -0,0 +1,252 @@
+package results
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
+)
+
+// testRun records every response of an output in a run manifest, attributing it to the
+// filename at the position of its SequenceID.
+func testRun(t *testing.T, output string, filenames []string) []manifest.Response {
+	t.Helper()
+	var parsed definitions.Output
+	if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+		t.Fatalf("Failed to parse output: %v", err)
+	}
+	var run []manifest.Response
+	for _, response := range parsed.Responses {
+		index, err := strconv.Atoi(response.SequenceID)
+		if err != nil || index < 1 || index > len(filenames) {
+			t.Fatalf("No filename for sequence %s", response.SequenceID)
+		}
+		run = append(run, manifest.Response{
+			SequenceID:     response.SequenceID,
+			SequenceNumber: response.SequenceNumber,
+			File:           filenames[index-1],
+			Provider:       response.Provider,
+			Model:          response.Model,
+			Status:         manifest.StatusCompleted,
+		})
+	}
+	return run
+}
+
+func TestSaveAttributesResponsesThroughRun(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "json", Summary: "yes"},
+		},
+	}
+
+	// two documents with a summary each: attributing by response position would mislabel the rows
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"key": "a"}`}},
+			{SequenceID: "1", SequenceNumber: 2, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"summary": "A."}`}},
+			{SequenceID: "2", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"key": "b"}`}},
+			{SequenceID: "2", SequenceNumber: 2, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"summary": "B."}`}},
+			{SequenceID: "3", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"key": "c"}`}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	run := []manifest.Response{
+		{SequenceID: "1", SequenceNumber: 1, File: "paper1", Provider: "OpenAI", Model: "gpt-4o-mini", Status: manifest.StatusCompleted},
+		{SequenceID: "1", SequenceNumber: 2, File: "paper1", Provider: "OpenAI", Model: "gpt-4o-mini", Status: manifest.StatusCompleted},
+		{SequenceID: "2", SequenceNumber: 1, File: "paper2", Provider: "OpenAI", Model: "gpt-4o-mini", Status: manifest.StatusResumed},
+		{SequenceID: "2", SequenceNumber: 2, File: "paper2", Provider: "OpenAI", Model: "gpt-4o-mini", Status: manifest.StatusResumed},
+		{SequenceID: "3", SequenceNumber: 1, File: "paper3", Provider: "OpenAI", Model: "gpt-4o-mini", Status: manifest.StatusFailed},
+	}
+
+	if err := Save(cfg, string(output), run, []string{"key"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	content, err := os.ReadFile(resultsFileName + ".json")
+	if err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+	var objects []map[string]any
+	if err := json.Unmarshal(content, &objects); err != nil {
+		t.Fatalf("Failed to parse results: %v", err)
+	}
+	expected := []string{"paper1", "paper1", "paper2", "paper2"}
+	if len(objects) != len(expected) {
+		t.Fatalf("Expected %d attributed responses, got %d: %v", len(expected), len(objects), objects)
+	}
+	for i, object := range objects {
+		if object["filename"] != expected[i] {
+			t.Errorf("Response %d: expected filename %s, got %v", i+1, expected[i], object["filename"])
+		}
+	}
+	if objects[2]["key"] != "b" || objects[3]["summary"] != "B." {
+		t.Errorf("Expected the answers of paper2 to be attributed to it, got %v and %v", objects[2], objects[3])
+	}
+}
+
+func TestSaveCarriesScreeningMetadata(t *testing.T) {
+	dir := t.TempDir()
+	screeningResults := filepath.Join(dir, "screening.csv")
+	records := "doi,title,year,abstract,include,exclusion_reason\n" +
+		"10.1/a,Floods,2021,We followed 200 patients.,true,\n" +
+		"10.1/b,Essay,2020,An opinion.,false,article type\n"
+	if err := os.WriteFile(screeningResults, []byte(records), 0644); err != nil {
+		t.Fatalf("Failed to write screening results: %v", err)
+	}
+
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{
+				ResultsFileName:           filepath.Join(dir, "results"),
+				OutputFormat:              "csv",
+				CotJustification:          "yes",
+				ScreeningResults:          screeningResults,
+				ScreeningTextColumn:       "abstract",
+				ScreeningIdentifierColumn: "doi",
+			},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "design", Values: []string{"cohort", "trial"}},
+		},
+	}
+	justification := `{"justifications": {"design": {"reasoning_steps": ["Patients were followed"], "supporting_sentences": ["We followed 200 patients."]}}}`
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"design": "cohort"}`}},
+			{SequenceID: "1", SequenceNumber: 2, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{justification}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	run := testRun(t, string(output), []string{"10.1/a"})
+
+	if err := Save(cfg, string(output), run, []string{"design"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	rows := readCSVFile(t, cfg.Project.Configuration.ResultsFileName+".csv")
+	expected := [][]string{
+		{"Provider", "Model", "File Name", "title", "year", "design"},
+		{"OpenAI", "gpt-4o-mini", "10.1/a", "Floods", "2021", "cohort"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows %q, got %q", expected, rows)
+	}
+	grounding := readCSVFile(t, cfg.Project.Configuration.ResultsFileName+groundingSuffix)
+	if len(grounding) != 2 || grounding[1][4] != "yes" {
+		t.Errorf("Expected the justification grounded in the text of the record, got %q", grounding)
+	}
+
+	cfg.Project.Configuration.OutputFormat = "json"
+	if err := Save(cfg, string(output), run, []string{"design"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	content, err := os.ReadFile(cfg.Project.Configuration.ResultsFileName + ".json")
+	if err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+	var objects []map[string]any
+	if err := json.Unmarshal(content, &objects); err != nil {
+		t.Fatalf("Failed to parse results: %v", err)
+	}
+	metadata := map[string]any{"title": "Floods", "year": "2021"}
+	if len(objects) != 2 || !reflect.DeepEqual(objects[0]["metadata"], metadata) {
+		t.Errorf("Expected the record metadata in the JSON objects, got %v", objects)
+	}
+}
+
+func TestSaveReportsRetries(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "csv", SchemaRetries: 2},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "design", Values: []string{"cohort", "trial"}},
+		},
+	}
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"design": "cohort"}`}},
+			{SequenceID: "2", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"design": "trial"}`}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	run := testRun(t, string(output), []string{"paper1", "paper2"})
+	run[1].Retries = 2
+
+	if err := Save(cfg, string(output), run, []string{"design"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	rows := readCSVFile(t, resultsFileName+".csv")
+	expected := [][]string{
+		{"Provider", "Model", "File Name", "Retries", "design"},
+		{"OpenAI", "gpt-4o-mini", "paper1", "0", "cohort"},
+		{"OpenAI", "gpt-4o-mini", "paper2", "2", "trial"},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected rows %q, got %q", expected, rows)
+	}
+
+	attribution := newAttribution(run)
+	attribution.addRetries(run)
+	sheets, err := xlsxSheets(cfg, string(output), attribution, []string{"design"}, newAnswerValidator(cfg))
+	if err != nil {
+		t.Fatalf("xlsxSheets returned an error: %v", err)
+	}
+	if !reflect.DeepEqual(sheets[0].rows, expected) {
+		t.Errorf("Expected xlsx rows %q, got %q", expected, sheets[0].rows)
+	}
+
+	cfg.Project.Configuration.OutputLayout = "long"
+	if err := Save(cfg, string(output), run, []string{"design"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	rows = readCSVFile(t, resultsFileName+".csv")
+	if len(rows) != 3 || rows[0][1] != "Retries" || rows[2][1] != "2" {
+		t.Errorf("Expected the retries after the file name in the long layout, got %q", rows)
+	}
+
+	cfg.Project.Configuration.OutputFormat = "json"
+	if err := Save(cfg, string(output), run, []string{"design"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	content, err := os.ReadFile(resultsFileName + ".json")
+	if err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+	var objects []map[string]any
+	if err := json.Unmarshal(content, &objects); err != nil {
+		t.Fatalf("Failed to parse results: %v", err)
+	}
+	if len(objects) != 2 || objects[0]["retries"] != 0.0 || objects[1]["retries"] != 2.0 {
+		t.Errorf("Expected the retries in the JSON objects, got %v", objects)
+	}
+}
+
+func readCSVFile(t *testing.T, path string) [][]string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("Failed to open %s: %v", path, err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", path, err)
+	}
+	return rows
+}
@@ -0,0 +1,209 @@
+package results
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+const consensusSuffix = "_consensus.csv"
+
+// Agreement levels reported in the consensus output.
+const (
+	consensusUnanimous = "unanimous" // every model gave the same answer
+	consensusMajority  = "majority"  // the answer holds more than half of the total weight
+	consensusSplit     = "split"     // the answer holds the largest weight, but not more than half
+	consensusTie       = "tie"       // two or more answers hold the same largest weight
+)
+
+// consensusVote is the answer of a single model, weighted by the model weight.
+type consensusVote struct {
+	value  string
+	weight float64
+}
+
+// saveConsensus writes the ensemble consensus of a multi-model project to a CSV file.
+// Rows of the primary responses are grouped by filename and, for each review key, the answer
+// with the largest total model weight is selected. Each answer is followed by a column
+// reporting the agreement level among models; on ties the tied answers are listed separated by " | ".
+// Nothing is written when fewer than two models are configured.
+//
+// Arguments:
+// - cfg: The application configuration, providing the models and their weights.
+// - resultsString: JSON string containing all model responses.
+// - attribution: The documents answered by the responses, from the run manifest.
+// - keys: The review keys, in column order.
+// - validator: The answer validator; invalid answers do not take part in the vote.
+//
+// Returns:
+// - An error if the results cannot be parsed or the file cannot be written.
+func saveConsensus(cfg *config.Config, resultsString string, attribution *attribution, keys []string, validator *answerValidator) error {
+	if len(cfg.Project.LLM) < 2 {
+		return nil
+	}
+
+	var parsedResults definitions.Output
+	if err := json.Unmarshal([]byte(resultsString), &parsedResults); err != nil {
+		logger.Error("Error parsing results JSON: %v", err)
+		return err
+	}
+
+	weights := modelWeights(cfg)
+	votes := make(map[string]map[string][]consensusVote)
+	models := make(map[string]int)
+	for _, response := range parsedResults.Responses {
+		if response.SequenceNumber != 1 || len(response.ModelResponses) == 0 {
+			continue
+		}
+		filename, ok := attribution.file(response)
+		if !ok {
+			continue
+		}
+
+		var data map[string]any
+		if err := json.Unmarshal([]byte(cleanJSON(response.ModelResponses[0])), &data); err != nil {
+			logger.Error("Error parsing JSON:", err)
+			continue
+		}
+
+		weight := modelWeight(weights, response.Provider, response.Model)
+		if votes[filename] == nil {
+			votes[filename] = make(map[string][]consensusVote)
+		}
+		models[filename]++
+		for _, key := range keys {
+			value := ""
+			if answer, exists := data[key]; exists {
+				normalized, err := validator.normalize(key, answer)
+				if err != nil {
+					continue
+				}
+				value = normalized
+			}
+			votes[filename][key] = append(votes[filename][key], consensusVote{value: value, weight: weight})
+		}
+	}
+
+	filePath := cfg.Project.Configuration.ResultsFileName + consensusSuffix
+	outputFile, err := os.Create(filePath)
+	if err != nil {
+		logger.Error("Error creating consensus file: %v", err)
+		return err
+	}
+	defer outputFile.Close()
+
+	writer := csv.NewWriter(outputFile)
+	header := []string{"File Name", "Models"}
+	for _, key := range keys {
+		header = append(header, key, key+" consensus")
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, filename := range attribution.documents {
+		fileVotes, ok := votes[filename]
+		if !ok {
+			continue
+		}
+		row := []string{filename, strconv.Itoa(models[filename])}
+		for _, key := range keys {
+			value, agreement := tallyVotes(fileVotes[key])
+			row = append(row, value, agreement)
+		}
+		if err := writer.Write(row); err != nil {
+			logger.Error("Error writing consensus row: %v", err)
+			return err
+		}
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.Error("Error flushing consensus file: %v", err)
+		return err
+	}
+	logger.Info("Consensus results successfully saved to: %s", filePath)
+	return nil
+}
+
+// tallyVotes selects the answer with the largest total weight and reports the agreement level.
+//
+// Arguments:
+// - votes: The weighted answers of the models for a single key and document.
+//
+// Returns:
+// - The winning answer, or the tied answers separated by " | ".
+// - The agreement level, or an empty string if there are no votes.
+func tallyVotes(votes []consensusVote) (string, string) {
+	if len(votes) == 0 {
+		return "", ""
+	}
+
+	totals := make(map[string]float64)
+	total := 0.0
+	for _, vote := range votes {
+		totals[vote.value] += vote.weight
+		total += vote.weight
+	}
+
+	candidates := make([]consensusVote, 0, len(totals))
+	for value, weight := range totals {
+		candidates = append(candidates, consensusVote{value: value, weight: weight})
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].weight != candidates[j].weight {
+			return candidates[i].weight > candidates[j].weight
+		}
+		return candidates[i].value < candidates[j].value
+	})
+
+	top := candidates[0]
+	if len(candidates) == 1 {
+		return top.value, consensusUnanimous
+	}
+
+	var tied []string
+	for _, candidate := range candidates {
+		if math.Abs(candidate.weight-top.weight) < 1e-9 {
+			tied = append(tied, candidate.value)
+		}
+	}
+	if len(tied) > 1 {
+		return strings.Join(tied, " | "), consensusTie
+	}
+	if top.weight*2 > total {
+		return top.value, consensusMajority
+	}
+	return top.value, consensusSplit
+}
+
+// modelWeights indexes the configured model weights by provider and model name.
+func modelWeights(cfg *config.Config) map[string]float64 {
+	weights := make(map[string]float64, len(cfg.Project.LLM))
+	for _, llm := range cfg.Project.LLM {
+		weights[llm.Provider+"/"+llm.Model] = llm.Weight
+	}
+	return weights
+}
+
+// modelWeight returns the weight of a model, falling back to the provider default model;
+// unknown models and models without a weight count as 1. LoadConfig rejects weights set to
+// zero or less, so a zero weight is always an unset one.
+func modelWeight(weights map[string]float64, provider string, model string) float64 {
+	weight, ok := weights[provider+"/"+model]
+	if !ok {
+		weight = weights[provider+"/"]
+	}
+	if weight == 0 {
+		return 1
+	}
+	return weight
+}
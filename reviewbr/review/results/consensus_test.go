@@ -0,0 +1,120 @@
+package results
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestTallyVotes(t *testing.T) {
+	tests := []struct {
+		name          string
+		votes         []consensusVote
+		wantValue     string
+		wantAgreement string
+	}{
+		{"no votes", nil, "", ""},
+		{"unanimous", []consensusVote{{"yes", 1}, {"yes", 1}}, "yes", consensusUnanimous},
+		{"majority", []consensusVote{{"yes", 1}, {"yes", 1}, {"no", 1}}, "yes", consensusMajority},
+		{"split", []consensusVote{{"a", 1}, {"a", 1}, {"b", 1}, {"c", 1}}, "a", consensusSplit},
+		{"tie", []consensusVote{{"yes", 1}, {"no", 1}}, "no | yes", consensusTie},
+		{"weighted", []consensusVote{{"yes", 2}, {"no", 1}}, "yes", consensusMajority},
+		{"weights break equal counts", []consensusVote{{"yes", 1.5}, {"no", 1}, {"no", 0.5}}, "no | yes", consensusTie},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, agreement := tallyVotes(tt.votes)
+			if value != tt.wantValue || agreement != tt.wantAgreement {
+				t.Errorf("tallyVotes() = (%q, %q), want (%q, %q)", value, agreement, tt.wantValue, tt.wantAgreement)
+			}
+		})
+	}
+}
+
+func TestSaveWritesConsensus(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "csv"},
+			LLM: map[string]config.LLMItem{
+				"1": {Provider: "OpenAI", Model: "gpt-4o-mini", Weight: 2},
+				"2": {Provider: "Anthropic", Model: "claude-3-5-haiku"},
+				"3": {Provider: "GoogleAI", Model: "gemini-1.5-flash"},
+			},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "scale", Values: []string{"world", "continent"}},
+		},
+	}
+
+	var responses []definitions.Response
+	answers := map[string][]string{
+		"OpenAI/gpt-4o-mini":         {"World", "world"},
+		"Anthropic/claude-3-5-haiku": {"continent", "continent"},
+		"GoogleAI/gemini-1.5-flash":  {"continent", "world"},
+	}
+	for _, llm := range []config.LLMItem{cfg.Project.LLM["1"], cfg.Project.LLM["2"], cfg.Project.LLM["3"]} {
+		for i, answer := range answers[llm.Provider+"/"+llm.Model] {
+			responses = append(responses, definitions.Response{
+				SequenceID:     []string{"1", "2"}[i],
+				SequenceNumber: 1,
+				Provider:       llm.Provider,
+				Model:          llm.Model,
+				ModelResponses: []string{`{"scale": "` + answer + `"}`},
+			})
+		}
+	}
+	output, err := json.Marshal(definitions.Output{Responses: responses})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+
+	if err := Save(cfg, string(output), testRun(t, string(output), []string{"paper1", "paper2"}), []string{"scale"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	content, err := os.ReadFile(resultsFileName + "_consensus.csv")
+	if err != nil {
+		t.Fatalf("Failed to read consensus: %v", err)
+	}
+	expected := "File Name,Models,scale,scale consensus\n" +
+		"paper1,3,continent | world,tie\n" +
+		"paper2,3,world,majority\n"
+	if string(content) != expected {
+		t.Errorf("Expected consensus %q, got %q", expected, string(content))
+	}
+}
+
+func TestSaveSkipsConsensusForSingleModel(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "csv"},
+			LLM:           map[string]config.LLMItem{"1": {Provider: "OpenAI", Model: "gpt-4o-mini"}},
+		},
+	}
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{{
+			SequenceID:     "1",
+			SequenceNumber: 1,
+			Provider:       "OpenAI",
+			Model:          "gpt-4o-mini",
+			ModelResponses: []string{`{"key": "value"}`},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+
+	if err := Save(cfg, string(output), testRun(t, string(output), []string{"paper1"}), []string{"key"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+	if _, err := os.Stat(resultsFileName + "_consensus.csv"); !os.IsNotExist(err) {
+		t.Errorf("Expected no consensus file for a single model, got %v", err)
+	}
+}
@@ -3,10 +3,10 @@ package results
 import (
 	"encoding/csv"
 	"encoding/json"
-	"fmt"
 	"os"
+	"slices"
 
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/logger"
 )
 
 // createCSVWriter initializes and returns a CSV writer for the specified output file.
@@ -33,11 +33,44 @@ func createCSVWriter(outputFile *os.File, keys []string) *csv.Writer {
 // Arguments:
 // - response: JSON string containing key-value pairs corresponding to the CSV header.
 // - filename: The name of the file being processed (written as the third column).
+// - record: The values of the metadata columns of the screening record and of the Retries column, written after the file name; nil for none.
 // - provider: The name of the LLM provider (first column).
 // - model: The model name used (second column).
 // - writer: A pointer to a csv.Writer to which the data will be written.
 // - keys: A slice of strings representing the column headers.
-func writeCSVData(response string, filename string, provider string, model string, writer *csv.Writer, keys []string) {
+// - validator: The answer validator; invalid answers are left empty and recorded for the validation report.
+func writeCSVData(response string, filename string, record []string, provider string, model string, writer *csv.Writer, keys []string, validator *answerValidator) {
+	row, ok := answerRow(response, filename, provider, model, keys, validator)
+	if !ok {
+		return
+	}
+	row = slices.Insert(row, 3, record...)
+
+	// Write row to CSV
+	if err := writer.Write(row); err != nil {
+		logger.Error("Error writing to CSV:", err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.Error("Error flushing CSV:", err)
+	}
+}
+
+// answerRow converts a main response into a row of the tabular outputs: provider, model, file name,
+// and the validated answer of each key, empty if missing or invalid.
+//
+// Arguments:
+// - response: JSON string containing key-value pairs corresponding to the keys.
+// - filename: The name of the file being processed.
+// - provider: The name of the LLM provider.
+// - model: The model name used.
+// - keys: A slice of strings representing the answer columns.
+// - validator: The answer validator; invalid answers are left empty and recorded for the validation report.
+//
+// Returns:
+// - The row.
+// - False if the response is not a JSON object.
+func answerRow(response string, filename string, provider string, model string, keys []string, validator *answerValidator) ([]string, bool) {
 	// Clean the response
 	response = cleanJSON(response)
 
@@ -47,10 +80,10 @@ func writeCSVData(response string, filename string, provider string, model strin
 	if err != nil {
 		logger.Error("Error parsing JSON:", err)
 		logger.Error("Raw response:", response) // Debug output
-		return
+		return nil, false
 	}
 
-	// Prepare CSV row
+	// Prepare row
 	row := make([]string, len(keys)+3)
 	row[0] = provider
 	row[1] = model
@@ -59,18 +92,10 @@ func writeCSVData(response string, filename string, provider string, model strin
 	// Map values to the correct columns
 	for i, key := range keys {
 		if val, exists := data[key]; exists {
-			row[i+3] = fmt.Sprintf("%v", val)
+			row[i+3], _ = validator.check(filename, provider, model, key, val)
 		} else {
 			row[i+3] = "" // Empty field if key is missing
 		}
 	}
-
-	// Write row to CSV
-	if err := writer.Write(row); err != nil {
-		logger.Error("Error writing to CSV:", err)
-	}
-	writer.Flush()
-	if err := writer.Error(); err != nil {
-		logger.Error("Error flushing CSV:", err)
-	}
+	return row, true
 }
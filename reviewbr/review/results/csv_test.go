@@ -51,7 +51,7 @@ func TestWriteCSVData(t *testing.T) {
     model := "TestModel"
 
     // Write data to CSV
-    writeCSVData(response, fileNameWithoutExt, provider, model, writer, keys)
+    writeCSVData(response, fileNameWithoutExt, nil, provider, model, writer, keys, nil)
     writer.Flush()
 
     // Reopen the file to check contents
@@ -1,4 +1,4 @@
-// Package results provides functionalities for writing output data in different formats, such as CSV and JSON.
+// Package results provides functionalities for writing output data in different formats, such as CSV, JSON, JSON Lines and XLSX.
 // The package includes utilities to create writers, write data to files, and manage structured outputs for 
 // efficient data storage and retrieval.
 package results
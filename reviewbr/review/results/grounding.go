@@ -0,0 +1,414 @@
+package results
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/records"
+)
+
+const groundingSuffix = "_grounding.csv"
+
+// groundingThreshold is the minimum match score of a supporting sentence found in the manuscript.
+const groundingThreshold = 0.8
+
+// groundingCandidates is the number of candidate positions compared for each supporting sentence.
+const groundingCandidates = 5
+
+// sentenceMatch is the best match of a supporting sentence in the source manuscript.
+// Start and End are character offsets in the manuscript text, -1 if no match was found.
+type sentenceMatch struct {
+	Sentence string  `json:"sentence"`
+	Score    float64 `json:"score"`
+	Start    int     `json:"start"`
+	End      int     `json:"end"`
+}
+
+// keyGrounding holds the matches of the supporting sentences cited for a review key.
+// A key is grounded when all of its supporting sentences are found in the manuscript.
+type keyGrounding struct {
+	Grounded  bool            `json:"grounded"`
+	Sentences []sentenceMatch `json:"sentences"`
+}
+
+// justificationGrounding holds the grounding of a justification response.
+type justificationGrounding struct {
+	sequenceID string
+	filename   string
+	provider   string
+	model      string
+	keys       map[string]keyGrounding
+}
+
+// groundingChecker verifies the supporting sentences of the justifications against the source manuscripts.
+type groundingChecker struct {
+	inputDirectory string
+	texts          map[string]string // texts of the reviewed screening records, by record identity
+	sources        map[string]*sourceIndex
+	results        []justificationGrounding
+}
+
+// checkGrounding fuzzy-matches every supporting sentence cited in the justifications against the
+// source .txt file of its manuscript, or the text of its screening record, recording the match score
+// and character offsets.
+//
+// Arguments:
+// - cfg: The application configuration, providing the input directory.
+// - resultsString: JSON string containing all model responses.
+// - attribution: The documents answered by the responses, from the run manifest.
+// - screening: The reviewed screening records; nil when the input directory is reviewed.
+//
+// Returns:
+// - The grounding checker holding the results, in response order.
+// - An error if the results cannot be parsed.
+func checkGrounding(cfg *config.Config, resultsString string, attribution *attribution, screening *records.Screening) (*groundingChecker, error) {
+	var parsedResults definitions.Output
+	if err := json.Unmarshal([]byte(resultsString), &parsedResults); err != nil {
+		logger.Error("Error parsing results JSON: %v", err)
+		return nil, err
+	}
+
+	checker := &groundingChecker{
+		inputDirectory: cfg.Project.Configuration.InputDirectory,
+		sources:        make(map[string]*sourceIndex),
+	}
+	if screening != nil {
+		checker.texts = make(map[string]string, len(screening.Records))
+		for _, record := range screening.Records {
+			checker.texts[record.ID] = record.Text
+		}
+	}
+	for _, response := range parsedResults.Responses {
+		// the justification is always the first follow-up query
+		if response.SequenceNumber != 2 || len(response.ModelResponses) == 0 {
+			continue
+		}
+		filename, ok := attribution.file(response)
+		if !ok {
+			continue
+		}
+
+		sentences, err := supportingSentences(response.ModelResponses[0])
+		if err != nil {
+			logger.Error("Error parsing justification of %s: %v", filename, err)
+			continue
+		}
+		source, err := checker.source(filename)
+		if err != nil {
+			logger.Error("Error reading source of %s for grounding: %v", filename, err)
+			continue
+		}
+
+		keys := make(map[string]keyGrounding, len(sentences))
+		for key, keySentences := range sentences {
+			grounding := keyGrounding{Grounded: true, Sentences: []sentenceMatch{}}
+			for _, sentence := range keySentences {
+				match := source.match(sentence)
+				if match.Score < groundingThreshold {
+					grounding.Grounded = false
+				}
+				grounding.Sentences = append(grounding.Sentences, match)
+			}
+			if !grounding.Grounded {
+				logger.Info("Justification of '%s' for %s by %s %s cites text not found in the manuscript", key, filename, response.Provider, response.Model)
+			}
+			keys[key] = grounding
+		}
+		checker.results = append(checker.results, justificationGrounding{
+			sequenceID: response.SequenceID,
+			filename:   filename,
+			provider:   response.Provider,
+			model:      response.Model,
+			keys:       keys,
+		})
+	}
+	return checker, nil
+}
+
+// lookup returns the grounding of the justification with the given sequence ID, provider and model.
+// A nil checker has no results.
+func (c *groundingChecker) lookup(sequenceID, provider, model string) (map[string]keyGrounding, bool) {
+	if c == nil {
+		return nil, false
+	}
+	for _, result := range c.results {
+		if result.sequenceID == sequenceID && result.provider == provider && result.model == model {
+			return result.keys, true
+		}
+	}
+	return nil, false
+}
+
+// source returns the index of the source manuscript of a filename, reading it on first use.
+func (c *groundingChecker) source(filename string) (*sourceIndex, error) {
+	if source, ok := c.sources[filename]; ok {
+		return source, nil
+	}
+	if c.texts != nil {
+		text, ok := c.texts[filename]
+		if !ok {
+			return nil, fmt.Errorf("no screening record %s", filename)
+		}
+		source := newSourceIndex(text)
+		c.sources[filename] = source
+		return source, nil
+	}
+	text, err := os.ReadFile(filepath.Join(c.inputDirectory, filename+".txt"))
+	if err != nil {
+		return nil, err
+	}
+	source := newSourceIndex(string(text))
+	c.sources[filename] = source
+	return source, nil
+}
+
+// writeReport writes the grounding report next to the results, with one row per supporting sentence.
+//
+// Arguments:
+// - resultsFileName: Base name for result files (without extension).
+//
+// Returns:
+// - An error if the report cannot be written.
+func (c *groundingChecker) writeReport(resultsFileName string) error {
+	if c == nil {
+		return nil
+	}
+
+	reportPath := resultsFileName + groundingSuffix
+	reportFile, err := os.Create(reportPath)
+	if err != nil {
+		logger.Error("Error creating grounding report: %v", err)
+		return err
+	}
+	defer reportFile.Close()
+
+	writer := csv.NewWriter(reportFile)
+	writer.Write([]string{"Provider", "Model", "File Name", "Key", "Key Grounded", "Sentence", "Score", "Start", "End"})
+	flagged := 0
+	for _, result := range c.results {
+		keys := make([]string, 0, len(result.keys))
+		for key := range result.keys {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			grounding := result.keys[key]
+			grounded := "yes"
+			if !grounding.Grounded {
+				grounded = "no"
+				flagged++
+			}
+			for _, match := range grounding.Sentences {
+				writer.Write([]string{
+					result.provider, result.model, result.filename, key, grounded, match.Sentence,
+					strconv.FormatFloat(match.Score, 'f', 2, 64), strconv.Itoa(match.Start), strconv.Itoa(match.End),
+				})
+			}
+		}
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.Error("Error writing grounding report: %v", err)
+		return err
+	}
+
+	logger.Info("Grounding report saved to %s: %d justifications cite text not found in the manuscripts", reportPath, flagged)
+	return nil
+}
+
+// keyJustification is the chain of thought given for the answer of a review key.
+type keyJustification struct {
+	steps     []string
+	sentences []string
+}
+
+// parseJustification extracts the reasoning steps and supporting sentences of each key from a
+// justification answer. Both may be given as a list or as a single text.
+func parseJustification(answer string) (map[string]keyJustification, error) {
+	var justification struct {
+		Justifications map[string]struct {
+			ReasoningSteps      any `json:"reasoning_steps"`
+			SupportingSentences any `json:"supporting_sentences"`
+		} `json:"justifications"`
+	}
+	if err := json.Unmarshal([]byte(cleanJSON(answer)), &justification); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]keyJustification, len(justification.Justifications))
+	for key, value := range justification.Justifications {
+		keys[key] = keyJustification{
+			steps:     textList(value.ReasoningSteps),
+			sentences: textList(value.SupportingSentences),
+		}
+	}
+	return keys, nil
+}
+
+// supportingSentences extracts the supporting sentences of each key from a justification answer.
+func supportingSentences(answer string) (map[string][]string, error) {
+	keys, err := parseJustification(answer)
+	if err != nil {
+		return nil, err
+	}
+
+	sentences := make(map[string][]string, len(keys))
+	for key, justification := range keys {
+		if len(justification.sentences) > 0 {
+			sentences[key] = justification.sentences
+		}
+	}
+	return sentences, nil
+}
+
+// textList returns the non-empty texts of a JSON value given as a single string or as a list.
+func textList(value any) []string {
+	var texts []string
+	switch typed := value.(type) {
+	case string:
+		if strings.TrimSpace(typed) != "" {
+			texts = []string{typed}
+		}
+	case []any:
+		for _, element := range typed {
+			if text := strings.TrimSpace(fmt.Sprintf("%v", element)); text != "" {
+				texts = append(texts, text)
+			}
+		}
+	}
+	return texts
+}
+
+// sourceWord is a normalized word of a manuscript with its character offsets.
+type sourceWord struct {
+	word  string
+	start int
+	end   int
+}
+
+// sourceIndex indexes the words of a manuscript by their n-grams, to locate cited sentences.
+type sourceIndex struct {
+	words  []sourceWord
+	ngrams [4]map[string][]int // positions of the n-grams of length 1 to 3
+}
+
+// newSourceIndex tokenizes a manuscript and indexes its word n-grams.
+func newSourceIndex(text string) *sourceIndex {
+	index := &sourceIndex{words: normalizeWords(text)}
+	for n := 1; n <= 3; n++ {
+		index.ngrams[n] = make(map[string][]int)
+		for i := 0; i+n <= len(index.words); i++ {
+			key := joinWords(index.words[i : i+n])
+			index.ngrams[n][key] = append(index.ngrams[n][key], i)
+		}
+	}
+	return index
+}
+
+// match finds the passage of the manuscript most similar to a sentence. Candidate positions are
+// the alignments sharing most word n-grams with the sentence, and each candidate is scored by the
+// word-level edit distance between the sentence and the passage of the same length.
+func (index *sourceIndex) match(sentence string) sentenceMatch {
+	result := sentenceMatch{Sentence: sentence, Start: -1, End: -1}
+	words := normalizeWords(sentence)
+	if len(words) == 0 || len(index.words) == 0 {
+		return result
+	}
+
+	n := min(3, len(words))
+	votes := make(map[int]int)
+	for i := 0; i+n <= len(words); i++ {
+		for _, position := range index.ngrams[n][joinWords(words[i:i+n])] {
+			votes[max(0, position-i)]++
+		}
+	}
+	candidates := make([]int, 0, len(votes))
+	for start := range votes {
+		candidates = append(candidates, start)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if votes[candidates[i]] != votes[candidates[j]] {
+			return votes[candidates[i]] > votes[candidates[j]]
+		}
+		return candidates[i] < candidates[j]
+	})
+	if len(candidates) > groundingCandidates {
+		candidates = candidates[:groundingCandidates]
+	}
+
+	for _, start := range candidates {
+		end := min(len(index.words), start+len(words))
+		passage := index.words[start:end]
+		score := 1 - float64(wordDistance(words, passage))/float64(max(len(words), len(passage)))
+		if score > result.Score {
+			result.Score = score
+			result.Start = passage[0].start
+			result.End = passage[len(passage)-1].end
+		}
+	}
+	return result
+}
+
+// normalizeWords splits a text into lowercase words of letters and digits, with their character offsets.
+func normalizeWords(text string) []sourceWord {
+	var words []sourceWord
+	var current []rune
+	start := 0
+	position := 0
+	for _, r := range text {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			if len(current) == 0 {
+				start = position
+			}
+			current = append(current, unicode.ToLower(r))
+		} else if len(current) > 0 {
+			words = append(words, sourceWord{word: string(current), start: start, end: position})
+			current = current[:0]
+		}
+		position++
+	}
+	if len(current) > 0 {
+		words = append(words, sourceWord{word: string(current), start: start, end: position})
+	}
+	return words
+}
+
+// joinWords joins the normalized form of a sequence of words.
+func joinWords(words []sourceWord) string {
+	parts := make([]string, len(words))
+	for i, word := range words {
+		parts[i] = word.word
+	}
+	return strings.Join(parts, " ")
+}
+
+// wordDistance computes the edit distance between two word sequences.
+func wordDistance(a []sourceWord, b []sourceWord) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1].word == b[j-1].word {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
@@ -0,0 +1,116 @@
+package results
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+const groundingSource = "Floods are frequent in the region.\n\nThe multidimensional representation of the joint distributions of relevant hydrological climate impacts is based on the concept of statistical copulas [43]. Future scenarios use hazard and damage data."
+
+func TestSourceIndexMatch(t *testing.T) {
+	index := newSourceIndex(groundingSource)
+
+	tests := []struct {
+		name      string
+		sentence  string
+		grounded  bool
+		wantStart int
+	}{
+		{"exact", "The multidimensional representation of the joint distributions of relevant hydrological climate impacts is based on the concept of statistical copulas [43].", true, 36},
+		{"case and punctuation", "the multidimensional representation of the joint distributions, of relevant hydrological climate impacts, is based on the concept of statistical copulas", true, 36},
+		{"minor paraphrase", "The multidimensional representation of joint distributions of relevant hydrological climate impacts is based on statistical copulas.", true, 36},
+		{"short sentence", "Floods are frequent", true, 0},
+		{"invented", "The authors calibrate a neural network on satellite observations of soil moisture.", false, -1},
+		{"empty", "...", false, -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match := index.match(tt.sentence)
+			if (match.Score >= groundingThreshold) != tt.grounded {
+				t.Errorf("Expected grounded=%v, got score %.2f", tt.grounded, match.Score)
+			}
+			if tt.wantStart >= 0 && match.Start != tt.wantStart {
+				t.Errorf("Expected match to start at %d, got %d (%q)", tt.wantStart, match.Start, groundingSource[match.Start:match.End])
+			}
+		})
+	}
+
+	match := index.match("Floods are frequent in the region.")
+	if groundingSource[match.Start:match.End] != "Floods are frequent in the region" {
+		t.Errorf("Unexpected offsets %d-%d", match.Start, match.End)
+	}
+}
+
+func TestSaveWritesGroundingReport(t *testing.T) {
+	inputDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(inputDir, "paper1.txt"), []byte(groundingSource), 0644); err != nil {
+		t.Fatalf("Failed to write source: %v", err)
+	}
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{
+				InputDirectory:   inputDir,
+				ResultsFileName:  resultsFileName,
+				OutputFormat:     "json",
+				CotJustification: "yes",
+			},
+		},
+	}
+
+	justification := `{"justifications": {
+		"copulas": {"reasoning_steps": ["a"], "supporting_sentences": ["The multidimensional representation of the joint distributions of relevant hydrological climate impacts is based on the concept of statistical copulas [43]."]},
+		"forecasting": {"reasoning_steps": ["b"], "supporting_sentences": ["Future scenarios use hazard and damage data.", "Forecasts are validated against the 2010 flood."]}
+	}}`
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"copulas": "yes", "forecasting": "yes"}`}},
+			{SequenceID: "1", SequenceNumber: 2, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{justification}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+
+	if err := Save(cfg, string(output), testRun(t, string(output), []string{"paper1"}), []string{"copulas", "forecasting"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	report, err := os.ReadFile(resultsFileName + "_grounding.csv")
+	if err != nil {
+		t.Fatalf("Expected a grounding report: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(report)), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("Expected a header and 3 sentence rows, got:\n%s", report)
+	}
+	if !strings.HasPrefix(lines[1], "OpenAI,gpt-4o-mini,paper1,copulas,yes,") || !strings.HasSuffix(lines[1], ",1.00,36,190") {
+		t.Errorf("Unexpected row for a grounded sentence: %s", lines[1])
+	}
+	if !strings.HasPrefix(lines[3], "OpenAI,gpt-4o-mini,paper1,forecasting,no,Forecasts are validated") {
+		t.Errorf("Expected the key citing invented text to be flagged: %s", lines[3])
+	}
+
+	var objects []map[string]any
+	content, err := os.ReadFile(resultsFileName + ".json")
+	if err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+	if err := json.Unmarshal(content, &objects); err != nil {
+		t.Fatalf("Failed to parse results: %v", err)
+	}
+	grounding, ok := objects[1]["grounding"].(map[string]any)
+	if !ok {
+		t.Fatalf("Expected grounding in the justification object, got %v", objects[1])
+	}
+	if forecasting := grounding["forecasting"].(map[string]any); forecasting["grounded"] != false {
+		t.Errorf("Expected forecasting to be flagged in the JSON output, got %v", forecasting)
+	}
+}
@@ -0,0 +1,112 @@
+package results
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
+)
+
+// mergeGroups combines the answers of the review groups of each document into a single response, so
+// that the writers find one main answer per document and model as without groups. The answers of the
+// groups are the first sequence numbers of a sequence; they are merged into sequence number 1, and the
+// follow-up responses are renumbered to follow it. The run records are remapped the same way.
+//
+// Arguments:
+// - groups: The number of review groups, each answered by a prompt of the sequence.
+// - results: JSON string containing all model responses.
+// - run: The responses of the run, as recorded in the run manifest.
+//
+// Returns:
+// - The results with the group answers merged.
+// - The run records matching the merged results.
+// - An error if the results cannot be parsed.
+func mergeGroups(groups int, results string, run []manifest.Response) (string, []manifest.Response, error) {
+	if groups <= 1 {
+		return results, run, nil
+	}
+
+	var output definitions.Output
+	if err := json.Unmarshal([]byte(results), &output); err != nil {
+		logger.Error("Error parsing JSON for group merging:", err)
+		return "", nil, err
+	}
+
+	merged := make(map[string]map[string]any)
+	position := make(map[string]int)
+	var responses []definitions.Response
+	for _, response := range output.Responses {
+		if response.SequenceNumber > groups {
+			response.SequenceNumber -= groups - 1
+			responses = append(responses, response)
+			continue
+		}
+		key := responseKey(response.SequenceID, 1, response.Provider, response.Model)
+		if _, seen := position[key]; !seen {
+			position[key] = len(responses)
+			merged[key] = make(map[string]any)
+			responses = append(responses, definitions.Response{
+				SequenceID:     response.SequenceID,
+				SequenceNumber: 1,
+				Provider:       response.Provider,
+				Model:          response.Model,
+			})
+		}
+		if len(response.ModelResponses) == 0 {
+			continue
+		}
+		var data map[string]any
+		if err := json.Unmarshal([]byte(cleanJSON(strings.TrimSpace(response.ModelResponses[0]))), &data); err != nil {
+			logger.Error("Error parsing answer %s.%d of %s %s: %v", response.SequenceID, response.SequenceNumber, response.Provider, response.Model, err)
+			continue
+		}
+		for field, value := range data {
+			merged[key][field] = value
+		}
+	}
+	for key, index := range position {
+		data, err := json.Marshal(merged[key])
+		if err != nil {
+			return "", nil, err
+		}
+		responses[index].ModelResponses = []string{string(data)}
+	}
+	output.Responses = responses
+
+	data, err := json.Marshal(output)
+	if err != nil {
+		return "", nil, err
+	}
+	return string(data), mergeGroupRecords(groups, run), nil
+}
+
+// mergeGroupRecords remaps the run records of the group answers to sequence number 1, keeping a single
+// record per sequence and model, completed if any group was answered, with the retries of all groups,
+// and renumbers the follow-up records.
+func mergeGroupRecords(groups int, run []manifest.Response) []manifest.Response {
+	records := make([]manifest.Response, 0, len(run))
+	index := make(map[string]int)
+	for _, record := range run {
+		if record.SequenceNumber > groups {
+			record.SequenceNumber -= groups - 1
+			records = append(records, record)
+			continue
+		}
+		record.SequenceNumber = 1
+		key := responseKey(record.SequenceID, 1, record.Provider, record.Model)
+		i, seen := index[key]
+		if !seen {
+			index[key] = len(records)
+			records = append(records, record)
+		} else {
+			retries := records[i].Retries + record.Retries
+			if records[i].Status == manifest.StatusFailed && record.Status != manifest.StatusFailed {
+				records[i] = record
+			}
+			records[i].Retries = retries
+		}
+	}
+	return records
+}
@@ -0,0 +1,80 @@
+package results
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
+)
+
+func TestSaveMergesReviewGroups(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "json", Summary: "yes"},
+		},
+		ReviewGroups: map[string]config.ReviewGroupItem{"outcomes": {Task: "Outcomes."}},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "design", Values: []string{"cohort", "trial"}},
+			"2": {Key: "mortality", Values: []string{"yes", "no"}, Group: "outcomes"},
+		},
+	}
+
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"design": "cohort"}`}},
+			{SequenceID: "1", SequenceNumber: 2, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{"```json\n{\"mortality\": \"yes\"}\n```"}},
+			{SequenceID: "1", SequenceNumber: 3, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"summary": "A cohort."}`}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	run := testRun(t, string(output), []string{"paper1"})
+
+	if err := Save(cfg, string(output), run, []string{"design", "mortality"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	content, err := os.ReadFile(resultsFileName + ".json")
+	if err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+	var objects []map[string]any
+	if err := json.Unmarshal(content, &objects); err != nil {
+		t.Fatalf("Failed to parse results: %v", err)
+	}
+	if len(objects) != 2 {
+		t.Fatalf("Expected the merged answer and the summary, got %v", objects)
+	}
+	if objects[0]["design"] != "cohort" || objects[0]["mortality"] != "yes" || objects[0]["filename"] != "paper1" {
+		t.Errorf("Expected the group answers in one object, got %v", objects[0])
+	}
+	if objects[1]["summary"] != "A cohort." {
+		t.Errorf("Expected the summary after the merged answer, got %v", objects[1])
+	}
+}
+
+func TestMergeGroupRecords(t *testing.T) {
+	run := []manifest.Response{
+		{SequenceID: "1", SequenceNumber: 1, File: "paper1", Status: manifest.StatusFailed},
+		{SequenceID: "1", SequenceNumber: 2, File: "paper1", Status: manifest.StatusCompleted},
+		{SequenceID: "1", SequenceNumber: 3, File: "paper1", Status: manifest.StatusCompleted},
+		{SequenceID: "1", SequenceNumber: 4, File: "paper1", Status: manifest.StatusCompleted},
+	}
+
+	records := mergeGroupRecords(3, run)
+	if len(records) != 2 {
+		t.Fatalf("Expected one record for the groups and one for the follow-up, got %+v", records)
+	}
+	if records[0].SequenceNumber != 1 || records[0].Status != manifest.StatusCompleted {
+		t.Errorf("Expected a completed record for the merged groups, got %+v", records[0])
+	}
+	if records[1].SequenceNumber != 2 {
+		t.Errorf("Expected the follow-up renumbered to 2, got %d", records[1].SequenceNumber)
+	}
+}
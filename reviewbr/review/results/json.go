@@ -5,7 +5,7 @@ import (
 	"os"
 	"strings"
 
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/logger"
 )
 
 // startJSONArray begins a new JSON array in the specified output file.
@@ -0,0 +1,156 @@
+package results
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+// longDelimiter separates the reasoning steps and the supporting sentences within a cell of the long layout.
+const longDelimiter = " | "
+
+// longLayout reports whether the tabular outputs are written in the long layout.
+func longLayout(cfg *config.Config) bool {
+	return cfg.Project.Configuration.OutputLayout == config.LayoutLong
+}
+
+// longRows converts the model responses into the rows of the long (tidy) layout: one row per file,
+// model and review key with its validated answer, the file name followed by the metadata columns of
+// the screening record, if any, and the retries of the answer with schema_retries. When enabled, the reasoning steps and supporting
+// sentences of the justification of the key, the summary of the file, and the answer of the file to
+// each query of the [follow_ups] table, under its name, are added in further columns.
+//
+// Arguments:
+// - cfg: The application configuration containing justification and summary settings.
+// - resultsString: JSON string containing all model responses.
+// - attribution: The documents answered by the responses, from the run manifest.
+// - keys: The review keys, in row order.
+// - validator: The answer validator; invalid answers are left empty and recorded for the validation report.
+//
+// Returns:
+// - The rows, starting with the header.
+// - An error if the results cannot be parsed.
+func longRows(cfg *config.Config, resultsString string, attribution *attribution, keys []string, validator *answerValidator) ([][]string, error) {
+	var parsedResults definitions.Output
+	if err := json.Unmarshal([]byte(resultsString), &parsedResults); err != nil {
+		logger.Error("Error parsing results JSON: %v", err)
+		return nil, err
+	}
+
+	justificationSequence, summarySequence := followUpSequences(cfg)
+	header := append(slices.Concat([]string{"File Name"}, attribution.columns, attribution.retryColumns()), "Provider", "Model", "Key", "Value")
+	if justificationSequence > 0 {
+		header = append(header, "Reasoning Steps", "Supporting Sentences")
+	}
+	if summarySequence > 0 {
+		header = append(header, "Summary")
+	}
+	named := namedFollowUps(cfg)
+	header = append(header, named...)
+	names := followUpNames(cfg)
+
+	// follow-up answers are matched to the main answer of the same sequence and model
+	type followUpKey struct{ sequenceID, provider, model string }
+	justifications := make(map[followUpKey]map[string]keyJustification)
+	summaries := make(map[followUpKey]string)
+	texts := make(map[followUpKey]map[string]string) // answers to the [follow_ups] queries, by name
+	for _, response := range parsedResults.Responses {
+		if len(response.ModelResponses) == 0 {
+			continue
+		}
+		id := followUpKey{response.SequenceID, response.Provider, response.Model}
+		switch response.SequenceNumber {
+		case justificationSequence:
+			justification, err := parseJustification(response.ModelResponses[0])
+			if err != nil {
+				logger.Error("Error parsing justification of sequence %s: %v", response.SequenceID, err)
+				continue
+			}
+			justifications[id] = justification
+		case summarySequence:
+			summaries[id] = followUpText(config.FollowUpSummary, response.ModelResponses[0])
+		default:
+			if name, ok := names[response.SequenceNumber]; ok {
+				if texts[id] == nil {
+					texts[id] = make(map[string]string)
+				}
+				texts[id][name] = followUpText(name, response.ModelResponses[0])
+			}
+		}
+	}
+
+	rows := [][]string{header}
+	for _, response := range parsedResults.Responses {
+		if response.SequenceNumber != 1 || len(response.ModelResponses) == 0 {
+			continue
+		}
+		filename, ok := attribution.file(response)
+		if !ok {
+			continue
+		}
+		answers, ok := answerRow(response.ModelResponses[0], filename, response.Provider, response.Model, keys, validator)
+		if !ok {
+			continue
+		}
+
+		id := followUpKey{response.SequenceID, response.Provider, response.Model}
+		record := append(attribution.record(filename), attribution.retryValues(response)...)
+		for i, key := range keys {
+			row := append(append([]string{filename}, record...), response.Provider, response.Model, key, answers[i+3])
+			if justificationSequence > 0 {
+				justification := justifications[id][key]
+				row = append(row, strings.Join(justification.steps, longDelimiter), strings.Join(justification.sentences, longDelimiter))
+			}
+			if summarySequence > 0 {
+				row = append(row, summaries[id])
+			}
+			for _, name := range named {
+				row = append(row, texts[id][name])
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+// saveLongCSV creates a CSV file with the model responses in the long layout, which holds the
+// follow-up answers in place of the separate text files.
+//
+// Arguments:
+// - cfg: The application configuration containing justification and summary settings.
+// - filePath: The output file path for the CSV.
+// - resultsString: JSON string containing all model responses.
+// - attribution: The documents answered by the responses, from the run manifest.
+// - keys: The review keys, in row order.
+// - validator: The answer validator; invalid answers are left empty and recorded for the validation report.
+//
+// Returns:
+// - An error if the results cannot be parsed or the file cannot be written.
+func saveLongCSV(cfg *config.Config, filePath string, resultsString string, attribution *attribution, keys []string, validator *answerValidator) error {
+	rows, err := longRows(cfg, resultsString, attribution, keys, validator)
+	if err != nil {
+		return err
+	}
+
+	outputFile, err := os.Create(filePath)
+	if err != nil {
+		logger.Error("Error creating CSV file: %v", err)
+		return err
+	}
+	defer outputFile.Close()
+
+	writer := csv.NewWriter(outputFile)
+	if err := writer.WriteAll(rows); err != nil {
+		logger.Error("Error writing CSV file: %v", err)
+		return err
+	}
+
+	logger.Info("Long-format results successfully saved to: %s", filePath)
+	return nil
+}
@@ -0,0 +1,76 @@
+package results
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestSaveLongCSV(t *testing.T) {
+	dir := t.TempDir()
+	resultsFileName := filepath.Join(dir, "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{
+				InputDirectory:   dir,
+				ResultsFileName:  resultsFileName,
+				OutputFormat:     "csv",
+				OutputLayout:     config.LayoutLong,
+				CotJustification: "yes",
+				Summary:          "yes",
+			},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "design", Values: []string{"cohort", "trial"}},
+			"2": {Key: "country", Values: []string{""}},
+		},
+	}
+
+	justification := `{"justifications": {"design": {"reasoning_steps": ["Patients were followed", "No randomization"], "supporting_sentences": ["We followed 200 patients."]}}}`
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"design": "cohort", "country": "Italy"}`}},
+			{SequenceID: "1", SequenceNumber: 2, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{justification}},
+			{SequenceID: "1", SequenceNumber: 3, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"summary": "A cohort study."}`}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "paper1.txt"), []byte("We followed 200 patients."), 0644); err != nil {
+		t.Fatalf("Failed to write manuscript: %v", err)
+	}
+
+	if err := Save(cfg, string(output), testRun(t, string(output), []string{"paper1"}), []string{"design", "country"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	file, err := os.Open(resultsFileName + ".csv")
+	if err != nil {
+		t.Fatalf("Failed to open results: %v", err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse results: %v", err)
+	}
+
+	expected := [][]string{
+		{"File Name", "Provider", "Model", "Key", "Value", "Reasoning Steps", "Supporting Sentences", "Summary"},
+		{"paper1", "OpenAI", "gpt-4o-mini", "design", "cohort", "Patients were followed | No randomization", "We followed 200 patients.", "A cohort study."},
+		{"paper1", "OpenAI", "gpt-4o-mini", "country", "Italy", "", "", "A cohort study."},
+	}
+	if !reflect.DeepEqual(rows, expected) {
+		t.Errorf("Expected long rows %q, got %q", expected, rows)
+	}
+
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*_justification.txt")); len(matches) != 0 {
+		t.Errorf("Expected no justification text files in the long layout, got %v", matches)
+	}
+}
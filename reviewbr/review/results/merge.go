@@ -0,0 +1,499 @@
+package results
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+// mergedReports lists the suffixes of the CSV reports merged by file name in incremental runs.
+var mergedReports = []string{consensusSuffix, groundingSuffix, validationSuffix, stabilitySuffix}
+
+// stagingSuffix is appended to the results file name of an incremental run until its results are
+// merged with the previous ones.
+const stagingSuffix = "_new"
+
+// Previous holds the files written by a previous run of the project.
+type Previous struct {
+	Output  []byte            // The content of the output file
+	Reports map[string][]byte // The content of the existing CSV reports, by file suffix
+}
+
+// LoadPrevious reads the files written by a previous run of the project, so that they can be
+// merged with the results of an incremental run.
+//
+// Parameters:
+//   - config: Application configuration containing output settings
+//
+// Returns:
+//   - *Previous: The content of the previous files, or nil if there is no previous output file
+//   - error: nil if successful, otherwise an error describing what failed
+func LoadPrevious(config *config.Config) (*Previous, error) {
+	filePath := config.Project.Configuration.ResultsFileName + "." + config.Project.Configuration.OutputFormat
+	output, err := readOptionalFile(filePath)
+	if err != nil || output == nil {
+		return nil, err
+	}
+	previous := &Previous{Output: output, Reports: make(map[string][]byte)}
+	for _, suffix := range mergedReports {
+		report, err := readOptionalFile(config.Project.Configuration.ResultsFileName + suffix)
+		if err != nil {
+			return nil, err
+		}
+		if report != nil {
+			previous.Reports[suffix] = report
+		}
+	}
+	return previous, nil
+}
+
+// PreviousFilenames lists the filenames having at least one row in a previous output.
+//
+// Parameters:
+//   - config: Application configuration containing output settings
+//   - previous: The files written by the previous run
+//
+// Returns:
+//   - map[string]bool: The set of filenames found in the previous output
+//   - error: nil if successful, otherwise an error describing what failed
+func PreviousFilenames(config *config.Config, previous *Previous) (map[string]bool, error) {
+	if previous == nil || len(previous.Output) == 0 {
+		return make(map[string]bool), nil
+	}
+	return outputFilenames(config.Project.Configuration.OutputFormat, previous.Output)
+}
+
+// Staged returns a copy of the configuration writing the results of an incremental run next to
+// the previous ones, under a separate name, so that the previous results are left intact until
+// MergePrevious has merged the new results into them.
+//
+// Parameters:
+//   - config: Application configuration containing output settings
+//
+// Returns:
+//   - *config.Config: The configuration to pass to Save and SaveStability
+func Staged(config *config.Config) *config.Config {
+	staged := *config
+	staged.Project.Configuration.ResultsFileName = config.Project.Configuration.ResultsFileName + stagingSuffix
+	return &staged
+}
+
+// MergePrevious combines the output written by Save with the configuration returned by Staged with
+// the rows of a previous output. Rows of the previous output belonging to documents with a new
+// response are replaced by the new ones, rows of documents no longer among the inputs are removed,
+// while rows of all other documents, including documents whose extraction failed in this run, are
+// kept. The consensus, grounding, validation and stability
+// reports are merged in the same way, and the stability summary is computed again from the merged
+// stability report. Every merged file is written to a temporary file renamed into place, and the
+// staged files are removed once merged.
+//
+// Parameters:
+//   - config: Application configuration containing output settings
+//   - previous: The files written by the previous run
+//   - inputs: The content hashes of the current input documents, by filename
+//
+// Returns:
+//   - error: nil if successful, otherwise an error describing what failed
+func MergePrevious(config *config.Config, previous *Previous, inputs map[string]string) error {
+	if previous == nil {
+		return nil
+	}
+
+	resultsFileName := config.Project.Configuration.ResultsFileName
+	stagedFileName := resultsFileName + stagingSuffix
+	outputFormat := config.Project.Configuration.OutputFormat
+	filePath := resultsFileName + "." + outputFormat
+	stagedPath := stagedFileName + "." + outputFormat
+	current, err := os.ReadFile(stagedPath)
+	if err != nil {
+		logger.Error("Error reading new results: %v", err)
+		return err
+	}
+	replaced, err := outputFilenames(outputFormat, current)
+	if err != nil {
+		return err
+	}
+	dropped := func(filename string) bool {
+		_, input := inputs[filename]
+		return replaced[filename] || !input
+	}
+
+	switch outputFormat {
+	case "csv":
+		err = mergeCSV(filePath, previous.Output, current, dropped)
+	case "json":
+		err = mergeJSON(filePath, previous.Output, current, dropped)
+	case "jsonl":
+		err = mergeJSONL(filePath, previous.Output, current, dropped)
+	default:
+		return fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+	if err != nil {
+		return err
+	}
+	if err := removeFile(stagedPath); err != nil {
+		return err
+	}
+
+	for _, suffix := range mergedReports {
+		reportPath := resultsFileName + suffix
+		report, err := readOptionalFile(stagedFileName + suffix)
+		if err != nil {
+			return err
+		}
+		header, rows, err := mergeCSVRows(previous.Reports[suffix], report, dropped)
+		if err != nil {
+			return err
+		}
+		if suffix == stabilitySuffix {
+			if err := mergeStabilitySummary(resultsFileName+stabilitySummarySuffix, header, rows); err != nil {
+				return err
+			}
+			if err := removeFile(stagedFileName + stabilitySummarySuffix); err != nil {
+				return err
+			}
+		}
+		if len(rows) == 0 {
+			// no document left in the report, e.g. the only invalid answers were replaced
+			if err := removeFile(reportPath); err != nil {
+				return err
+			}
+		} else if err := writeCSV(reportPath, header, rows); err != nil {
+			return err
+		}
+		if err := removeFile(stagedFileName + suffix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputFilenames lists the filenames having at least one row in an output.
+func outputFilenames(outputFormat string, output []byte) (map[string]bool, error) {
+	filenames := make(map[string]bool)
+	switch outputFormat {
+	case "csv":
+		header, rows, err := readCSVRows(output)
+		if err != nil {
+			return nil, err
+		}
+		column := columnIndex(header, "File Name")
+		for _, row := range rows {
+			if column >= 0 && column < len(row) {
+				filenames[row[column]] = true
+			}
+		}
+	case "json", "jsonl":
+		read := readJSONObjects
+		if outputFormat == "jsonl" {
+			read = readJSONLines
+		}
+		objects, err := read(output)
+		if err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			if filename, ok := object["filename"].(string); ok {
+				filenames[filename] = true
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported output format: %s", outputFormat)
+	}
+	return filenames, nil
+}
+
+// mergeCSV rewrites the CSV file with the kept previous rows followed by the new rows.
+func mergeCSV(filePath string, previous []byte, current []byte, dropped func(string) bool) error {
+	header, merged, err := mergeCSVRows(previous, current, dropped)
+	if err != nil {
+		return err
+	}
+	return writeCSV(filePath, header, merged)
+}
+
+// mergeCSVRows keeps the previous rows of the documents not dropped, followed by the new rows.
+// Either content may be empty; the header is taken from the new content when there is one.
+func mergeCSVRows(previous []byte, current []byte, dropped func(string) bool) ([]string, [][]string, error) {
+	previousHeader, previousRows, err := readCSVRows(previous)
+	if err != nil {
+		return nil, nil, err
+	}
+	header, rows, err := readCSVRows(current)
+	if err != nil {
+		return nil, nil, err
+	}
+	if header == nil {
+		header = previousHeader
+	} else if previousHeader != nil && !slices.Equal(previousHeader, header) {
+		return nil, nil, fmt.Errorf("cannot merge results: previous CSV header %v differs from %v", previousHeader, header)
+	}
+
+	column := columnIndex(header, "File Name")
+	var merged [][]string
+	for _, row := range previousRows {
+		if column >= 0 && column < len(row) && dropped(row[column]) {
+			continue
+		}
+		merged = append(merged, row)
+	}
+	merged = append(merged, rows...)
+	return header, merged, nil
+}
+
+// mergeStabilitySummary writes the stability summary of the merged rows of a stability report, or
+// removes it if there are none.
+func mergeStabilitySummary(filePath string, header []string, rows [][]string) error {
+	if len(rows) == 0 {
+		return removeFile(filePath)
+	}
+	columns := make(map[string]int)
+	for _, name := range []string{"Provider", "Model", "Key", "Agreement"} {
+		columns[name] = columnIndex(header, name)
+		if columns[name] < 0 {
+			return fmt.Errorf("cannot merge stability report: missing column %s", name)
+		}
+	}
+
+	var entries []stabilityEntry
+	var keys []string
+	for _, row := range rows {
+		if len(row) != len(header) {
+			continue
+		}
+		agreement, err := strconv.ParseFloat(row[columns["Agreement"]], 64)
+		if err != nil {
+			logger.Error("Error parsing stability agreement: %v", err)
+			return err
+		}
+		key := row[columns["Key"]]
+		if !slices.Contains(keys, key) {
+			keys = append(keys, key)
+		}
+		entries = append(entries, stabilityEntry{row[columns["Provider"]], row[columns["Model"]], key, agreement})
+	}
+	summary := stabilitySummary(entries, keys)
+	return writeCSV(filePath, summary[0], summary[1:])
+}
+
+// writeCSV writes the header and rows of a CSV file.
+func writeCSV(filePath string, header []string, rows [][]string) error {
+	err := writeAtomically(filePath, func(outputFile *os.File) error {
+		writer := csv.NewWriter(outputFile)
+		if err := writer.Write(header); err != nil {
+			return err
+		}
+		if err := writer.WriteAll(rows); err != nil {
+			logger.Error("Error writing merged CSV: %v", err)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	logger.Info("Merged CSV successfully saved to: %s", filePath)
+	return nil
+}
+
+// mergeJSON rewrites the JSON file with the kept previous objects followed by the new ones.
+func mergeJSON(filePath string, previous []byte, current []byte, dropped func(string) bool) error {
+	previousObjects, err := readJSONObjects(previous)
+	if err != nil {
+		return err
+	}
+	objects, err := readJSONObjects(current)
+	if err != nil {
+		return err
+	}
+
+	var merged []map[string]any
+	for _, object := range previousObjects {
+		if filename, ok := object["filename"].(string); ok && dropped(filename) {
+			continue
+		}
+		merged = append(merged, object)
+	}
+	merged = append(merged, objects...)
+
+	err = writeAtomically(filePath, func(outputFile *os.File) error {
+		if err := startJSONArray(outputFile); err != nil {
+			return err
+		}
+		for i, object := range merged {
+			if i > 0 {
+				if err := writeCommaInJSONArray(outputFile); err != nil {
+					return err
+				}
+			}
+			data, err := json.MarshalIndent(object, "", "    ")
+			if err != nil {
+				logger.Error("Error marshaling merged JSON:", err)
+				return err
+			}
+			if _, err := outputFile.Write(data); err != nil {
+				logger.Error("Error writing JSON to file:", err)
+				return err
+			}
+		}
+		return closeJSONArray(outputFile)
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Merged %d new objects with %d previous objects in: %s", len(objects), len(merged)-len(objects), filePath)
+	return nil
+}
+
+// mergeJSONL rewrites the JSON Lines file with the kept previous objects followed by the new ones.
+func mergeJSONL(filePath string, previous []byte, current []byte, dropped func(string) bool) error {
+	previousObjects, err := readJSONLines(previous)
+	if err != nil {
+		return err
+	}
+	objects, err := readJSONLines(current)
+	if err != nil {
+		return err
+	}
+
+	kept := 0
+	err = writeAtomically(filePath, func(outputFile *os.File) error {
+		encoder := json.NewEncoder(outputFile)
+		for _, object := range previousObjects {
+			if filename, ok := object["filename"].(string); ok && dropped(filename) {
+				continue
+			}
+			if err := encoder.Encode(object); err != nil {
+				logger.Error("Error writing JSON Lines to file: %v", err)
+				return err
+			}
+			kept++
+		}
+		for _, object := range objects {
+			if err := encoder.Encode(object); err != nil {
+				logger.Error("Error writing JSON Lines to file: %v", err)
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("Merged %d new objects with %d previous objects in: %s", len(objects), kept, filePath)
+	return nil
+}
+
+// writeAtomically writes a file through a temporary file in the same directory, renamed into place
+// once complete, so that an interrupted write leaves the existing file intact.
+func writeAtomically(filePath string, write func(*os.File) error) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(filePath), filepath.Base(filePath)+".*.tmp")
+	if err != nil {
+		logger.Error("Error creating temporary file: %v", err)
+		return err
+	}
+	defer os.Remove(tempFile.Name())
+
+	if err := write(tempFile); err != nil {
+		tempFile.Close()
+		return err
+	}
+	if err := tempFile.Chmod(0644); err != nil {
+		tempFile.Close()
+		logger.Error("Error setting file permissions: %v", err)
+		return err
+	}
+	if err := tempFile.Close(); err != nil {
+		logger.Error("Error closing temporary file: %v", err)
+		return err
+	}
+	if err := os.Rename(tempFile.Name(), filePath); err != nil {
+		logger.Error("Error replacing %s: %v", filePath, err)
+		return err
+	}
+	return nil
+}
+
+// removeFile removes a file, if it exists.
+func removeFile(filePath string) error {
+	if err := os.Remove(filePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Error("Error removing %s: %v", filePath, err)
+		return err
+	}
+	return nil
+}
+
+// readOptionalFile reads a file, returning nil content if it does not exist.
+func readOptionalFile(filePath string) ([]byte, error) {
+	data, err := os.ReadFile(filePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		logger.Error("Error reading previous results: %v", err)
+		return nil, err
+	}
+	return data, nil
+}
+
+func readCSVRows(data []byte) ([]string, [][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		logger.Error("Error parsing CSV results: %v", err)
+		return nil, nil, err
+	}
+	if len(records) == 0 {
+		return nil, nil, nil
+	}
+	return records[0], records[1:], nil
+}
+
+func readJSONObjects(data []byte) ([]map[string]any, error) {
+	var objects []map[string]any
+	if len(bytes.TrimSpace(data)) == 0 {
+		return objects, nil
+	}
+	if err := json.Unmarshal(data, &objects); err != nil {
+		logger.Error("Error parsing JSON results: %v", err)
+		return nil, err
+	}
+	return objects, nil
+}
+
+func readJSONLines(data []byte) ([]map[string]any, error) {
+	var objects []map[string]any
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var object map[string]any
+		if err := json.Unmarshal(line, &object); err != nil {
+			logger.Error("Error parsing JSON Lines results: %v", err)
+			return nil, err
+		}
+		objects = append(objects, object)
+	}
+	return objects, nil
+}
+
+func columnIndex(header []string, name string) int {
+	for i, column := range header {
+		if column == name {
+			return i
+		}
+	}
+	return -1
+}
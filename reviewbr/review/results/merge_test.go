@@ -0,0 +1,236 @@
+package results
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestMergePreviousCSV(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "csv"},
+		},
+	}
+
+	previous := &Previous{
+		Output: []byte("Provider,Model,File Name,key\n" +
+			"OpenAI,gpt-4o-mini,paper1,old\n" +
+			"OpenAI,gpt-4o-mini,paper2,kept\n"),
+		Reports: map[string][]byte{
+			"_consensus.csv": []byte("File Name,Models,key,key consensus\n" +
+				"paper1,2,old,unanimous\n" +
+				"paper2,2,kept,majority\n"),
+		},
+	}
+	current := "Provider,Model,File Name,key\n" +
+		"OpenAI,gpt-4o-mini,paper1,new\n" +
+		"OpenAI,gpt-4o-mini,paper3,added\n"
+	if err := os.WriteFile(resultsFileName+"_new.csv", []byte(current), 0644); err != nil {
+		t.Fatalf("Failed to write current results: %v", err)
+	}
+	currentConsensus := "File Name,Models,key,key consensus\n" +
+		"paper1,2,new,tie\n"
+	if err := os.WriteFile(resultsFileName+"_new_consensus.csv", []byte(currentConsensus), 0644); err != nil {
+		t.Fatalf("Failed to write current consensus: %v", err)
+	}
+
+	filenames, err := PreviousFilenames(cfg, previous)
+	if err != nil {
+		t.Fatalf("PreviousFilenames returned an error: %v", err)
+	}
+	if !filenames["paper1"] || !filenames["paper2"] || filenames["paper3"] {
+		t.Errorf("Unexpected previous filenames: %v", filenames)
+	}
+
+	if err := MergePrevious(cfg, previous, map[string]string{"paper1": "a", "paper2": "b", "paper3": "c"}); err != nil {
+		t.Fatalf("MergePrevious returned an error: %v", err)
+	}
+
+	content, err := os.ReadFile(resultsFileName + ".csv")
+	if err != nil {
+		t.Fatalf("Failed to read merged results: %v", err)
+	}
+	expected := "Provider,Model,File Name,key\n" +
+		"OpenAI,gpt-4o-mini,paper2,kept\n" +
+		"OpenAI,gpt-4o-mini,paper1,new\n" +
+		"OpenAI,gpt-4o-mini,paper3,added\n"
+	if string(content) != expected {
+		t.Errorf("Expected merged CSV %q, got %q", expected, string(content))
+	}
+
+	content, err = os.ReadFile(resultsFileName + "_consensus.csv")
+	if err != nil {
+		t.Fatalf("Failed to read merged consensus: %v", err)
+	}
+	expected = "File Name,Models,key,key consensus\n" +
+		"paper2,2,kept,majority\n" +
+		"paper1,2,new,tie\n"
+	if string(content) != expected {
+		t.Errorf("Expected merged consensus %q, got %q", expected, string(content))
+	}
+}
+
+func TestMergePreviousCSVHeaderMismatch(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "csv"},
+		},
+	}
+	if err := os.WriteFile(resultsFileName+"_new.csv", []byte("Provider,Model,File Name,other\n"), 0644); err != nil {
+		t.Fatalf("Failed to write current results: %v", err)
+	}
+
+	err := MergePrevious(cfg, &Previous{Output: []byte("Provider,Model,File Name,key\n")}, nil)
+	if err == nil {
+		t.Errorf("Expected an error when the CSV headers differ")
+	}
+}
+
+func TestMergePreviousJSON(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "json"},
+		},
+	}
+
+	previous := &Previous{Output: []byte(`[{"filename": "paper1", "key": "old"}, {"filename": "paper2", "key": "kept"}]`)}
+	current := `[{"filename": "paper1", "key": "new"}]`
+	if err := os.WriteFile(resultsFileName+"_new.json", []byte(current), 0644); err != nil {
+		t.Fatalf("Failed to write current results: %v", err)
+	}
+
+	if err := MergePrevious(cfg, previous, map[string]string{"paper1": "a", "paper2": "b"}); err != nil {
+		t.Fatalf("MergePrevious returned an error: %v", err)
+	}
+
+	content, err := os.ReadFile(resultsFileName + ".json")
+	if err != nil {
+		t.Fatalf("Failed to read merged results: %v", err)
+	}
+	merged := string(content)
+	if strings.Contains(merged, `"old"`) {
+		t.Errorf("Expected the replaced object to be removed, got %s", merged)
+	}
+	if !strings.Contains(merged, `"kept"`) || !strings.Contains(merged, `"new"`) {
+		t.Errorf("Expected kept and new objects in merged JSON, got %s", merged)
+	}
+	if strings.Index(merged, `"kept"`) > strings.Index(merged, `"new"`) {
+		t.Errorf("Expected previous objects before new ones, got %s", merged)
+	}
+}
+
+func TestMergePreviousJSONL(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "jsonl"},
+		},
+	}
+
+	// paper9 was removed from the inputs since the previous run
+	previous := &Previous{Output: []byte("{\"filename\":\"paper1\",\"key\":\"old\"}\n{\"filename\":\"paper2\",\"key\":\"kept\"}\n{\"filename\":\"paper9\",\"key\":\"removed\"}\n")}
+	filenames, err := PreviousFilenames(cfg, previous)
+	if err != nil || !filenames["paper1"] || !filenames["paper2"] || !filenames["paper9"] {
+		t.Fatalf("Expected all previous filenames, got %v (%v)", filenames, err)
+	}
+	if err := os.WriteFile(resultsFileName+"_new.jsonl", []byte("{\"filename\":\"paper1\",\"key\":\"new\"}\n"), 0644); err != nil {
+		t.Fatalf("Failed to write current results: %v", err)
+	}
+
+	if err := MergePrevious(cfg, previous, map[string]string{"paper1": "a", "paper2": "b"}); err != nil {
+		t.Fatalf("MergePrevious returned an error: %v", err)
+	}
+
+	content, err := os.ReadFile(resultsFileName + ".jsonl")
+	if err != nil {
+		t.Fatalf("Failed to read merged results: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(content)), "\n")
+	if len(lines) != 2 || !strings.Contains(lines[0], `"kept"`) || !strings.Contains(lines[1], `"new"`) {
+		t.Errorf("Expected the kept object followed by the new one, without the removed input, got %q", lines)
+	}
+}
+
+func TestMergePreviousReports(t *testing.T) {
+	dir := t.TempDir()
+	resultsFileName := filepath.Join(dir, "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "csv"},
+		},
+	}
+
+	// paper1 is reviewed again, the extraction of paper2 failed and paper3 was not reviewed
+	previous := &Previous{
+		Output: []byte("Provider,Model,File Name,key\n" +
+			"OpenAI,gpt-4o-mini,paper1,old\n" +
+			"OpenAI,gpt-4o-mini,paper2,failed\n" +
+			"OpenAI,gpt-4o-mini,paper3,kept\n"),
+		Reports: map[string][]byte{
+			"_validation.csv": []byte("Provider,Model,File Name,Key,Answer,Problem\n" +
+				"OpenAI,gpt-4o-mini,paper1,key,maybe,not an allowed value\n" +
+				"OpenAI,gpt-4o-mini,paper2,key,perhaps,not an allowed value\n"),
+			"_stability.csv": []byte("Provider,Model,File Name,Key,Repetitions,Answers,Agreement,Stable\n" +
+				"OpenAI,gpt-4o-mini,paper1,key,2,old (1) | other (1),0.50,no\n" +
+				"OpenAI,gpt-4o-mini,paper3,key,2,kept (2),1.00,yes\n"),
+		},
+	}
+	files := map[string]string{
+		"_new.csv": "Provider,Model,File Name,key\n" +
+			"OpenAI,gpt-4o-mini,paper1,new\n",
+		"_new_stability.csv": "Provider,Model,File Name,Key,Repetitions,Answers,Agreement,Stable\n" +
+			"OpenAI,gpt-4o-mini,paper1,key,2,new (2),1.00,yes\n",
+		"_new_stability_summary.csv": "Provider,Model,Key,Documents,Mean Agreement,Stable Documents\n" +
+			"OpenAI,gpt-4o-mini,key,1,1.00,1\n",
+	}
+	for suffix, content := range files {
+		if err := os.WriteFile(resultsFileName+suffix, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", suffix, err)
+		}
+	}
+
+	if err := MergePrevious(cfg, previous, map[string]string{"paper1": "a", "paper2": "b", "paper3": "c"}); err != nil {
+		t.Fatalf("MergePrevious returned an error: %v", err)
+	}
+
+	expected := map[string]string{
+		".csv": "Provider,Model,File Name,key\n" +
+			"OpenAI,gpt-4o-mini,paper2,failed\n" +
+			"OpenAI,gpt-4o-mini,paper3,kept\n" +
+			"OpenAI,gpt-4o-mini,paper1,new\n",
+		"_validation.csv": "Provider,Model,File Name,Key,Answer,Problem\n" +
+			"OpenAI,gpt-4o-mini,paper2,key,perhaps,not an allowed value\n",
+		"_stability.csv": "Provider,Model,File Name,Key,Repetitions,Answers,Agreement,Stable\n" +
+			"OpenAI,gpt-4o-mini,paper3,key,2,kept (2),1.00,yes\n" +
+			"OpenAI,gpt-4o-mini,paper1,key,2,new (2),1.00,yes\n",
+		"_stability_summary.csv": "Provider,Model,Key,Documents,Mean Agreement,Stable Documents\n" +
+			"OpenAI,gpt-4o-mini,key,2,1.00,2\n" +
+			"OpenAI,gpt-4o-mini,(all keys),2,1.00,2\n",
+	}
+	for suffix, want := range expected {
+		content, err := os.ReadFile(resultsFileName + suffix)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", suffix, err)
+		}
+		if string(content) != want {
+			t.Errorf("Expected merged %s %q, got %q", suffix, want, string(content))
+		}
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to list the results directory: %v", err)
+	}
+	for _, entry := range entries {
+		if strings.Contains(entry.Name(), "_new") || strings.HasSuffix(entry.Name(), ".tmp") {
+			t.Errorf("Expected staged and temporary files to be removed, found %s", entry.Name())
+		}
+	}
+}
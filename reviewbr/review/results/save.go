@@ -5,45 +5,108 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"sort"
-	"strconv"
+	"slices"
 	"strings"
 
 	"github.com/open-and-sustainable/alembica/definitions"
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/logger"
 	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
+	"github.com/open-and-sustainable/prismaid/review/records"
 )
 
 // Save writes processed model response data to a file in the configured format.
-// It determines the appropriate output format based on the configuration (JSON or CSV)
-// and dispatches to the corresponding save function. When CSV format is selected,
-// it also extracts and saves justifications and summaries to separate text files.
+// It determines the appropriate output format based on the configuration (JSON, JSON Lines,
+// CSV or XLSX) and dispatches to the corresponding save function. When CSV format is selected,
+// it also saves the answers to the follow-up queries (justifications, summaries and the queries of
+// the [follow_ups] table) to separate text files named after the follow-up; the XLSX workbook holds
+// them in separate sheets. In the long layout, the CSV and XLSX outputs have one row per file, model
+// and review key, with the follow-up answers in further columns.
+// Every answer is validated against the type of its review item; invalid or out-of-vocabulary
+// answers are left empty in the results and listed in a separate validation report.
+// When more than one model is configured, the weighted consensus of the models is also
+// written to a separate CSV file. When justifications are enabled, their supporting sentences
+// are matched against the source manuscripts and the outcome is written to a grounding report.
+// Every writer attributes responses to documents through the run manifest; responses that are
+// not recorded in it are left out. With review groups, the answers of the groups of each document
+// are first merged into a single response, so that each document still has one row per model.
+// When screening results are reviewed, the metadata columns of the records follow the file name in
+// the tabular outputs and are added under "metadata" to the JSON objects. With schema_retries, the
+// times each answer was asked again follow them in a Retries column, or under "retries".
 //
 // Parameters:
 //   - config: Application configuration containing output settings
 //   - results: JSON string containing all model responses
-//   - filenames: List of input filenames that were processed
+//   - run: The responses of the run, as recorded in the run manifest
 //   - keys: List of column headers to include in CSV output
 //
 // Returns:
 //   - error: nil if successful, otherwise an error describing what failed
-func Save(config *config.Config, results string, filenames []string, keys []string) error {
+func Save(config *config.Config, results string, run []manifest.Response, keys []string) error {
 	resultsFileName := config.Project.Configuration.ResultsFileName
 	outputFormat := config.Project.Configuration.OutputFormat
 	outputFilePath := resultsFileName + "." + outputFormat
 
-	// Save justifications & summaries ONLY if CSV format
-	if outputFormat == "csv" {
-		saveJustificationsAndSummaries(config, resultsFileName, results, filenames)
+	results, run, err := mergeGroups(len(config.Groups()), results, run)
+	if err != nil {
+		return err
+	}
+	attribution := newAttribution(run)
+	screening, err := records.FromConfig(config)
+	if err != nil {
+		logger.Error("Error reading screening results: %v", err)
+		return err
+	}
+	attribution.addRecords(screening)
+	if config.Project.Configuration.SchemaRetries > 0 {
+		attribution.addRetries(run)
+	}
+
+	// Save the follow-up answers as text files ONLY if CSV format in the wide layout;
+	// XLSX and the long layout hold them with the answers
+	if outputFormat == "csv" && !longLayout(config) {
+		saveFollowUps(config, resultsFileName, results, attribution)
 	}
+	followUps := followUpNames(config)
 
-	if outputFormat == "json" {
-		return saveJSON(outputFilePath, results, filenames)
-	} else if outputFormat == "csv" {
-		return saveCSV(outputFilePath, results, filenames, keys)
-	} else {
+	validator := newAnswerValidator(config)
+	var grounding *groundingChecker
+	if config.Project.Configuration.CotJustification == "yes" {
+		grounding, err = checkGrounding(config, results, attribution, screening)
+		if err != nil {
+			return err
+		}
+	}
+
+	switch outputFormat {
+	case "json":
+		err = saveJSON(outputFilePath, results, attribution, followUps, validator, grounding)
+	case "jsonl":
+		err = saveJSONL(outputFilePath, results, attribution, followUps, validator, grounding)
+	case "csv":
+		if longLayout(config) {
+			err = saveLongCSV(config, outputFilePath, results, attribution, keys, validator)
+		} else {
+			err = saveCSV(outputFilePath, results, attribution, keys, validator)
+		}
+	case "xlsx":
+		err = saveXLSX(config, outputFilePath, results, attribution, keys, validator)
+	default:
 		return fmt.Errorf("unsupported output format: %s", outputFormat)
 	}
+	if err != nil {
+		return err
+	}
+
+	if err := saveConsensus(config, results, attribution, keys, validator); err != nil {
+		return err
+	}
+
+	if err := grounding.writeReport(resultsFileName); err != nil {
+		return err
+	}
+
+	return validator.writeReport(resultsFileName)
 }
 
 // saveJSON creates and populates a JSON file with processed model responses.
@@ -54,11 +117,14 @@ func Save(config *config.Config, results string, filenames []string, keys []stri
 // Parameters:
 //   - filePath: The output file path for the JSON
 //   - resultsString: JSON string containing all model responses
-//   - filenames: List of input filenames that were processed
+//   - attribution: The documents answered by the responses, from the run manifest
+//   - followUps: The names of the follow-up responses, by sequence number
+//   - validator: The answer validator; invalid answers are set to an empty string
+//   - grounding: The grounding of the justifications, added to them under "grounding"; nil if not checked
 //
 // Returns:
 //   - error: nil if successful, otherwise an error describing what failed
-func saveJSON(filePath string, resultsString string, filenames []string) error {
+func saveJSON(filePath string, resultsString string, attribution *attribution, followUps map[int]string, validator *answerValidator, grounding *groundingChecker) error {
 	outputFile, err := os.Create(filePath)
 	if err != nil {
 		logger.Error("Error creating JSON file:")
@@ -79,36 +145,26 @@ func saveJSON(filePath string, resultsString string, filenames []string) error {
 	}
 
 	// Debugging: Check response count
-	fmt.Println("Total JSON responses:", len(parsedResults.Responses))
+	logger.Info("Total JSON responses: %d", len(parsedResults.Responses))
 
 	// Write each response separately with provider & model metadata
+	written := 0
 	for i, response := range parsedResults.Responses {
-		filenameIndex := i % len(filenames) // Prevent index out of range
-		fmt.Println("Processing response", i+1, "/", len(parsedResults.Responses), "Filename:", filenames[filenameIndex])
-
-		modifiedResponse := map[string]interface{}{
-			"provider": response.Provider,
-			"model":    response.Model,
-			"filename": filenames[filenameIndex],
-		}
-
-		// Merge model response into the modified response map
-		var responseData map[string]interface{}
-		if err := json.Unmarshal([]byte(response.ModelResponses[0]), &responseData); err == nil {
-			for key, value := range responseData {
-				modifiedResponse[key] = value
-			}
+		filename, ok := attribution.file(response)
+		if !ok || len(response.ModelResponses) == 0 {
+			continue
 		}
+		logger.Info("Processing response %d / %d, Filename: %s", i+1, len(parsedResults.Responses), filename)
 
 		// Convert to JSON string and write it
-		modifiedJSON, err := json.MarshalIndent(modifiedResponse, "", "    ")
+		modifiedJSON, err := json.MarshalIndent(responseObject(response, filename, attribution, followUps[response.SequenceNumber], validator, grounding), "", "    ")
 		if err != nil {
 			logger.Error("Error marshaling modified JSON:", err)
 			return err
 		}
 
 		// Ensure newline between JSON objects
-		if i > 0 {
+		if written > 0 {
 			if err := writeCommaInJSONArray(outputFile); err != nil {
 				return err
 			}
@@ -119,6 +175,7 @@ func saveJSON(filePath string, resultsString string, filenames []string) error {
 			logger.Error("Error writing JSON to file:", err)
 			return err
 		}
+		written++
 	}
 
 	// Close JSON array
@@ -130,6 +187,89 @@ func saveJSON(filePath string, resultsString string, filenames []string) error {
 	return nil
 }
 
+// responseObject builds the object written for a response in the JSON and JSON Lines outputs: the
+// provider, model and filename, the metadata of the screening record and the retries of the main
+// answer if any, merged with the fields of the model response. Invalid main answers are set to an empty
+// string, and justifications receive the grounding of their supporting sentences. The answers to the
+// queries of the [follow_ups] table are added as text under the name of their follow-up.
+func responseObject(response definitions.Response, filename string, attribution *attribution, followUp string, validator *answerValidator, grounding *groundingChecker) map[string]interface{} {
+	object := map[string]interface{}{
+		"provider": response.Provider,
+		"model":    response.Model,
+		"filename": filename,
+	}
+	if metadata := attribution.metadata[filename]; metadata != nil {
+		object["metadata"] = metadata
+	}
+	if retries, ok := attribution.retries[responseKey(response.SequenceID, response.SequenceNumber, response.Provider, response.Model)]; ok && response.SequenceNumber == 1 {
+		object["retries"] = retries
+	}
+	if followUp != "" && !(config.FollowUp{Name: followUp}).Builtin() {
+		object[followUp] = followUpText(followUp, response.ModelResponses[0])
+		return object
+	}
+
+	// Merge model response into the object
+	var responseData map[string]interface{}
+	if err := json.Unmarshal([]byte(response.ModelResponses[0]), &responseData); err == nil {
+		for key, value := range responseData {
+			if response.SequenceNumber == 1 {
+				if _, valid := validator.check(filename, response.Provider, response.Model, key, value); !valid {
+					value = ""
+				}
+			}
+			object[key] = value
+		}
+	}
+	if keys, ok := grounding.lookup(response.SequenceID, response.Provider, response.Model); ok && followUp == config.FollowUpJustification {
+		object["grounding"] = keys
+	}
+	return object
+}
+
+// saveJSONL creates a JSON Lines file with processed model responses, streaming one compact object
+// per response and per line, with the same content as the objects of the JSON output.
+//
+// Parameters:
+//   - filePath: The output file path for the JSON Lines
+//   - resultsString: JSON string containing all model responses
+//   - attribution: The documents answered by the responses, from the run manifest
+//   - followUps: The names of the follow-up responses, by sequence number
+//   - validator: The answer validator; invalid answers are set to an empty string
+//   - grounding: The grounding of the justifications, added to them under "grounding"; nil if not checked
+//
+// Returns:
+//   - error: nil if successful, otherwise an error describing what failed
+func saveJSONL(filePath string, resultsString string, attribution *attribution, followUps map[int]string, validator *answerValidator, grounding *groundingChecker) error {
+	var parsedResults definitions.Output
+	if err := json.Unmarshal([]byte(resultsString), &parsedResults); err != nil {
+		logger.Error("Error parsing JSON for structured output:", err)
+		return err
+	}
+
+	outputFile, err := os.Create(filePath)
+	if err != nil {
+		logger.Error("Error creating JSON Lines file: %v", err)
+		return err
+	}
+	defer outputFile.Close()
+
+	encoder := json.NewEncoder(outputFile)
+	for _, response := range parsedResults.Responses {
+		filename, ok := attribution.file(response)
+		if !ok || len(response.ModelResponses) == 0 {
+			continue
+		}
+		if err := encoder.Encode(responseObject(response, filename, attribution, followUps[response.SequenceNumber], validator, grounding)); err != nil {
+			logger.Error("Error writing JSON Lines to file: %v", err)
+			return err
+		}
+	}
+
+	logger.Info("JSON Lines results successfully saved to: %s", filePath)
+	return nil
+}
+
 // saveCSV creates and populates a CSV file with processed model responses.
 // It converts the JSON model responses into a tabular format with columns specified by keys.
 // Only primary responses (SequenceNumber = 1) are included in the CSV; justifications and summaries are skipped.
@@ -137,12 +277,13 @@ func saveJSON(filePath string, resultsString string, filenames []string) error {
 // Parameters:
 //   - filePath: The output file path for the CSV
 //   - resultsString: JSON string containing all model responses
-//   - filenames: List of input filenames that were processed
+//   - attribution: The documents answered by the responses, from the run manifest
 //   - keys: List of column headers to include in the CSV
+//   - validator: The answer validator; invalid answers are left empty
 //
 // Returns:
 //   - error: nil if successful, otherwise an error describing what failed
-func saveCSV(filePath string, resultsString string, filenames []string, keys []string) error {
+func saveCSV(filePath string, resultsString string, attribution *attribution, keys []string, validator *answerValidator) error {
 	outputFile, err := os.Create(filePath)
 	if err != nil {
 		logger.Error("Error creating CSV file: %v", err)
@@ -150,7 +291,7 @@ func saveCSV(filePath string, resultsString string, filenames []string, keys []s
 	}
 	defer outputFile.Close()
 
-	writer := createCSVWriter(outputFile, keys)
+	writer := createCSVWriter(outputFile, slices.Concat(attribution.columns, attribution.retryColumns(), keys))
 	defer writer.Flush()
 
 	// Parse JSON results
@@ -161,19 +302,22 @@ func saveCSV(filePath string, resultsString string, filenames []string, keys []s
 	}
 
 	// Process responses
-	for i, response := range parsedResults.Responses {
+	for _, response := range parsedResults.Responses {
 		// Skip justifications & summaries (SequenceNumber > 1)
 		if response.SequenceNumber > 1 {
 			logger.Info("Skipping justification/summary in CSV (SeqNum: %d)", response.SequenceNumber)
 			continue
 		}
 
-		// Ensure correct filename mapping
-		filenameIndex := i % len(filenames) // Prevent index out of range
+		// Map the response to its document through the run manifest
+		filename, ok := attribution.file(response)
+		if !ok {
+			continue
+		}
 
 		// Write the main response data
 		for _, modelResponse := range response.ModelResponses {
-			writeCSVData(modelResponse, filenames[filenameIndex], response.Provider, response.Model, writer, keys)
+			writeCSVData(modelResponse, filename, append(attribution.record(filename), attribution.retryValues(response)...), response.Provider, response.Model, writer, keys, validator)
 		}
 	}
 
@@ -181,6 +325,67 @@ func saveCSV(filePath string, resultsString string, filenames []string, keys []s
 	return nil
 }
 
+// followUpNames returns the names of the follow-up responses by sequence number: the follow-ups of
+// config.FollowUps follow the main answer in their order, from sequence number 2.
+//
+// Parameters:
+//   - cfg: The application configuration containing the follow-up settings
+//
+// Returns:
+//   - map[int]string: The name of every follow-up, by sequence number
+func followUpNames(cfg *config.Config) map[int]string {
+	names := make(map[int]string)
+	for i, followUp := range cfg.FollowUps() {
+		names[i+2] = followUp.Name
+	}
+	return names
+}
+
+// namedFollowUps returns the names of the follow-ups of the [follow_ups] table, in the order they are asked.
+func namedFollowUps(cfg *config.Config) []string {
+	var names []string
+	for _, followUp := range cfg.FollowUps() {
+		if !followUp.Builtin() {
+			names = append(names, followUp.Name)
+		}
+	}
+	return names
+}
+
+// followUpSequences returns the sequence numbers of the justification and summary responses.
+//
+// Parameters:
+//   - cfg: The application configuration containing the follow-up settings
+//
+// Returns:
+//   - int: The sequence number of the justification, 0 if not enabled
+//   - int: The sequence number of the summary, 0 if not enabled
+func followUpSequences(cfg *config.Config) (int, int) {
+	justification, summary := 0, 0
+	for sequenceNumber, name := range followUpNames(cfg) {
+		switch name {
+		case config.FollowUpJustification:
+			justification = sequenceNumber
+		case config.FollowUpSummary:
+			summary = sequenceNumber
+		}
+	}
+	return justification, summary
+}
+
+// followUpText returns the text of a follow-up answer: the field named after the follow-up if the
+// answer is a JSON object with such a text field, as asked by the summary query, otherwise the
+// whole answer.
+func followUpText(name string, answer string) string {
+	var object map[string]any
+	if err := json.Unmarshal([]byte(cleanJSON(answer)), &object); err == nil {
+		if text, ok := object[name].(string); ok && text != "" {
+			return text
+		}
+	}
+	return strings.TrimSpace(answer)
+}
+
 // GetDirectoryPath extracts the directory component from a file path.
 // It returns an empty string if the directory is the current directory (".").
 //
@@ -199,23 +404,22 @@ func GetDirectoryPath(resultsFileName string) string {
 	return dir
 }
 
-// saveJustificationsAndSummaries extracts and saves justification and summary content from model responses
-// to separate text files. It only processes these files if the respective configuration options are enabled.
+// saveFollowUps saves the answer of every follow-up query to a separate text file, named after the
+// document, the model and the follow-up, e.g. "paper_OpenAI_gpt-4o-mini_justification.txt" or
+// "paper_OpenAI_gpt-4o-mini_limitations.txt".
 //
 // Parameters:
-//   - config: The application configuration containing justification and summary settings
+//   - cfg: The application configuration containing the follow-up settings
 //   - resultsFileName: Base name for result files (without extension)
 //   - resultsString: JSON string containing all model responses
-//   - filenames: List of input filenames that were processed
+//   - attribution: The documents answered by the responses, from the run manifest
 //
 // Returns:
 //   - error: nil if successful, otherwise an error describing what failed
-func saveJustificationsAndSummaries(config *config.Config, resultsFileName string, resultsString string, filenames []string) error {
-	justificationEnabled := config.Project.Configuration.CotJustification == "yes"
-	summaryEnabled := config.Project.Configuration.Summary == "yes"
-
-	if !justificationEnabled && !summaryEnabled {
-		logger.Info("Skipping justification and summary saving as they are not enabled.")
+func saveFollowUps(cfg *config.Config, resultsFileName string, resultsString string, attribution *attribution) error {
+	names := followUpNames(cfg)
+	if len(names) == 0 {
+		logger.Info("Skipping follow-up saving as no follow-up is enabled.")
 		return nil
 	}
 
@@ -226,79 +430,28 @@ func saveJustificationsAndSummaries(config *config.Config, resultsFileName strin
 		return err
 	}
 
-	// Ensure we have filenames
-	if len(filenames) == 0 {
-		return fmt.Errorf("no filenames provided")
+	// Ensure we have documents
+	if len(attribution.documents) == 0 {
+		return fmt.Errorf("no documents recorded in the run manifest")
 	}
 
-	// Group responses by sequenceId AND provider AND model
-	sequenceResponses := make(map[string][]definitions.Response)
 	for _, response := range parsedResults.Responses {
-		// Create a composite key that includes all three fields
-		compositeKey := fmt.Sprintf("%s_%s_%s", response.SequenceID, response.Provider, response.Model)
-		sequenceResponses[compositeKey] = append(sequenceResponses[compositeKey], response)
-	}
-
-	// Process grouped responses
-	for compositeKey, responses := range sequenceResponses {
-		if len(responses) < 2 {
-			continue // Not enough responses to contain a justification or summary
-		}
-
-		// Sort responses by SequenceNumber
-		sort.SliceStable(responses, func(i, j int) bool {
-			return responses[i].SequenceNumber < responses[j].SequenceNumber
-		})
-
-		// Extract sequenceId from the composite key (assuming format "seqId_provider_model")
-		parts := strings.Split(compositeKey, "_")
-		seqID := parts[0]
-
-		// Identify filename mapping
-		seqIndex, err := strconv.Atoi(seqID)
-		if err != nil || seqIndex < 1 || seqIndex > len(filenames) {
-			logger.Error("Invalid sequence ID mapping for file: %s", seqID)
+		name, ok := names[response.SequenceNumber]
+		if !ok || len(response.ModelResponses) == 0 {
 			continue
 		}
-
-		originalFilename := filenames[seqIndex-1]
-		provider := responses[0].Provider
-		model := responses[0].Model
-		baseFilename := fmt.Sprintf("%s/%s_%s_%s", GetDirectoryPath(resultsFileName), originalFilename, provider, model)
-
-		// Identify and save Justification (if enabled)
-		if justificationEnabled {
-			for _, response := range responses {
-				if response.SequenceNumber == 2 && len(response.ModelResponses) > 0 {
-					justificationFilePath := baseFilename + "_justification.txt"
-					justificationContent := response.ModelResponses[0] // Correctly extract model output
-					if err := os.WriteFile(justificationFilePath, []byte(justificationContent), 0644); err != nil {
-						logger.Error("Error writing justification file: %v", err)
-						return err
-					}
-					logger.Info("Saved justification to: %s", justificationFilePath)
-					break
-				}
-			}
+		originalFilename, ok := attribution.file(response)
+		if !ok {
+			continue
 		}
-
-		// Identify and save Summary (if enabled)
-		if summaryEnabled {
-			for _, response := range responses {
-				if response.SequenceNumber == 3 && len(response.ModelResponses) > 0 {
-					summaryFilePath := baseFilename + "_summary.txt"
-					summaryContent := response.ModelResponses[0] // Correctly extract model output
-					if err := os.WriteFile(summaryFilePath, []byte(summaryContent), 0644); err != nil {
-						logger.Error("Error writing summary file: %v", err)
-						return err
-					}
-					logger.Info("Saved summary to: %s", summaryFilePath)
-					break
-				}
-			}
+		filePath := fmt.Sprintf("%s/%s_%s_%s_%s.txt", GetDirectoryPath(resultsFileName), originalFilename, response.Provider, response.Model, name)
+		if err := os.WriteFile(filePath, []byte(response.ModelResponses[0]), 0644); err != nil {
+			logger.Error("Error writing %s file: %v", name, err)
+			return err
 		}
+		logger.Info("Saved %s to: %s", name, filePath)
 	}
 
-	logger.Info("Justifications and Summaries saved successfully")
+	logger.Info("Follow-up answers saved successfully")
 	return nil
 }
@@ -1,7 +1,15 @@
 package results
 
 import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
 )
 
 // TestGetDirectoryPath tests the directory path extraction logic
@@ -48,3 +56,145 @@ func TestGetDirectoryPath(t *testing.T) {
 		})
 	}
 }
+
+func TestSaveNamedFollowUps(t *testing.T) {
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"design": "cohort"}`}},
+			{SequenceID: "1", SequenceNumber: 2, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"summary": "A cohort study."}`}},
+			{SequenceID: "1", SequenceNumber: 3, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{"National research council."}},
+			{SequenceID: "1", SequenceNumber: 4, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"limitations": "Small sample."}`}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	run := testRun(t, string(output), []string{"paper1"})
+	newConfig := func(resultsFileName, format, layout string) *config.Config {
+		return &config.Config{
+			Project: config.ProjectConfig{
+				Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: format, OutputLayout: layout, Summary: "yes"},
+			},
+			Review: map[string]config.ReviewItem{"1": {Key: "design", Values: []string{"cohort", "trial"}}},
+			FollowUpQueries: map[string]config.FollowUpItem{
+				"limitations": {Query: "List the limitations."},
+				"funding":     {Query: "Extract the funding sources."},
+			},
+		}
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		resultsFileName := filepath.Join(t.TempDir(), "results")
+		if err := Save(newConfig(resultsFileName, "csv", config.LayoutWide), string(output), run, []string{"design"}); err != nil {
+			t.Fatalf("Save returned an error: %v", err)
+		}
+		expected := map[string]string{
+			"summary":     `{"summary": "A cohort study."}`,
+			"funding":     "National research council.",
+			"limitations": `{"limitations": "Small sample."}`,
+		}
+		for name, text := range expected {
+			content, err := os.ReadFile(filepath.Join(filepath.Dir(resultsFileName), "paper1_OpenAI_gpt-4o-mini_"+name+".txt"))
+			if err != nil || string(content) != text {
+				t.Errorf("Expected the %s file to hold %q, got %q, %v", name, text, content, err)
+			}
+		}
+	})
+
+	t.Run("json", func(t *testing.T) {
+		resultsFileName := filepath.Join(t.TempDir(), "results")
+		if err := Save(newConfig(resultsFileName, "json", config.LayoutWide), string(output), run, []string{"design"}); err != nil {
+			t.Fatalf("Save returned an error: %v", err)
+		}
+		content, err := os.ReadFile(resultsFileName + ".json")
+		if err != nil {
+			t.Fatalf("Failed to read results: %v", err)
+		}
+		var objects []map[string]any
+		if err := json.Unmarshal(content, &objects); err != nil {
+			t.Fatalf("Failed to parse results: %v", err)
+		}
+		if len(objects) != 4 || objects[1]["summary"] != "A cohort study." || objects[2]["funding"] != "National research council." || objects[3]["limitations"] != "Small sample." {
+			t.Errorf("Expected the follow-up answers under their names, got %v", objects)
+		}
+	})
+
+	t.Run("json without answer", func(t *testing.T) {
+		var parsed definitions.Output
+		if err := json.Unmarshal(output, &parsed); err != nil {
+			t.Fatalf("Failed to parse output: %v", err)
+		}
+		parsed.Responses[2].ModelResponses = nil
+		unanswered, err := json.Marshal(parsed)
+		if err != nil {
+			t.Fatalf("Failed to marshal output: %v", err)
+		}
+
+		resultsFileName := filepath.Join(t.TempDir(), "results")
+		if err := Save(newConfig(resultsFileName, "json", config.LayoutWide), string(unanswered), run, []string{"design"}); err != nil {
+			t.Fatalf("Save returned an error: %v", err)
+		}
+		content, err := os.ReadFile(resultsFileName + ".json")
+		if err != nil {
+			t.Fatalf("Failed to read results: %v", err)
+		}
+		var objects []map[string]any
+		if err := json.Unmarshal(content, &objects); err != nil {
+			t.Fatalf("Failed to parse results: %v", err)
+		}
+		if len(objects) != 3 || objects[2]["limitations"] != "Small sample." {
+			t.Errorf("Expected the response without answer to be left out, got %v", objects)
+		}
+	})
+
+	t.Run("long", func(t *testing.T) {
+		resultsFileName := filepath.Join(t.TempDir(), "results")
+		if err := Save(newConfig(resultsFileName, "csv", config.LayoutLong), string(output), run, []string{"design"}); err != nil {
+			t.Fatalf("Save returned an error: %v", err)
+		}
+		file, err := os.Open(resultsFileName + ".csv")
+		if err != nil {
+			t.Fatalf("Failed to open results: %v", err)
+		}
+		defer file.Close()
+		rows, err := csv.NewReader(file).ReadAll()
+		if err != nil {
+			t.Fatalf("Failed to parse results: %v", err)
+		}
+		expected := [][]string{
+			{"File Name", "Provider", "Model", "Key", "Value", "Summary", "funding", "limitations"},
+			{"paper1", "OpenAI", "gpt-4o-mini", "design", "cohort", "A cohort study.", "National research council.", "Small sample."},
+		}
+		if len(rows) != len(expected) || strings.Join(rows[0], ",") != strings.Join(expected[0], ",") || strings.Join(rows[1], ",") != strings.Join(expected[1], ",") {
+			t.Errorf("Expected rows %v, got %v", expected, rows)
+		}
+	})
+
+	t.Run("xlsx", func(t *testing.T) {
+		cfg := newConfig("", "xlsx", config.LayoutWide)
+		sheets, err := xlsxSheets(cfg, string(output), newAttribution(run), []string{"design"}, newAnswerValidator(cfg))
+		if err != nil {
+			t.Fatalf("xlsxSheets returned an error: %v", err)
+		}
+		var names []string
+		for _, sheet := range sheets {
+			names = append(names, sheet.name)
+		}
+		if strings.Join(names, ",") != "Answers,Summaries,funding,limitations" {
+			t.Fatalf("Expected a sheet per follow-up, got %v", names)
+		}
+		if row := sheets[3].rows[1]; row[2] != "paper1" || row[3] != `{"limitations": "Small sample."}` {
+			t.Errorf("Unexpected limitations row %v", row)
+		}
+	})
+
+	t.Run("answers", func(t *testing.T) {
+		answers, err := Answers(newConfig("", "csv", config.LayoutWide), string(output), run, []string{"design"})
+		if err != nil {
+			t.Fatalf("Answers returned an error: %v", err)
+		}
+		if len(answers) != 1 || answers[0].Summary != "A cohort study." || answers[0].FollowUps["funding"] != "National research council." || answers[0].FollowUps["limitations"] != "Small sample." {
+			t.Errorf("Expected the follow-up answers by name, got %+v", answers)
+		}
+	})
+}
@@ -0,0 +1,261 @@
+package results
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/open-and-sustainable/prismaid/review/manifest"
+)
+
+const (
+	stabilitySuffix        = "_stability.csv"
+	stabilitySummarySuffix = "_stability_summary.csv"
+)
+
+// stabilityAllKeys names the summary rows aggregating every review key of a model.
+const stabilityAllKeys = "(all keys)"
+
+// Repetition holds the responses of one repetition of a review, in which every document was sent
+// once more to every model.
+type Repetition struct {
+	Results string              // JSON string of the responses, in the alembica output format
+	Run     []manifest.Response // run manifest records of the responses
+}
+
+// stabilityKey identifies the answers of a model on a document.
+type stabilityKey struct {
+	provider string
+	model    string
+	filename string
+}
+
+// stabilityEntry holds the agreement of the repetitions of a model on a key of a document.
+type stabilityEntry struct {
+	provider  string
+	model     string
+	key       string
+	agreement float64
+}
+
+// stabilityTotal accumulates the stability of the answers to a key over the documents.
+type stabilityTotal struct {
+	documents int
+	agreement float64
+	stable    int
+}
+
+// SaveStability compares the answers given to every review key in the repetitions of a review and
+// writes two reports next to the results. "_stability.csv" has one row per model, document and key,
+// with the distinct answers and their counts, the agreement, i.e. the share of repetitions giving
+// the most frequent answer, and whether all repetitions agree. "_stability_summary.csv" has, per
+// model, the mean agreement and the number of stable documents of every key, and of all keys.
+// Invalid answers are left out, as in the consensus. Nothing is written for a single repetition.
+//
+// Arguments:
+// - cfg: The application configuration, providing the review items and groups.
+// - repetitions: The responses of every repetition, the first review included.
+// - keys: The review keys, in row order.
+//
+// Returns:
+// - An error if the results cannot be parsed or the reports cannot be written.
+func SaveStability(cfg *config.Config, repetitions []Repetition, keys []string) error {
+	if len(repetitions) < 2 {
+		return nil
+	}
+
+	validator := newAnswerValidator(cfg)
+	answers := make(map[stabilityKey]map[string][]string)
+	var order []stabilityKey
+	for _, repetition := range repetitions {
+		results, run, err := mergeGroups(len(cfg.Groups()), repetition.Results, repetition.Run)
+		if err != nil {
+			return err
+		}
+		attribution := newAttribution(run)
+
+		var parsedResults definitions.Output
+		if err := json.Unmarshal([]byte(results), &parsedResults); err != nil {
+			logger.Error("Error parsing results JSON: %v", err)
+			return err
+		}
+		for _, response := range parsedResults.Responses {
+			if response.SequenceNumber != 1 || len(response.ModelResponses) == 0 {
+				continue
+			}
+			filename, ok := attribution.file(response)
+			if !ok {
+				continue
+			}
+
+			var data map[string]any
+			if err := json.Unmarshal([]byte(cleanJSON(response.ModelResponses[0])), &data); err != nil {
+				logger.Error("Error parsing JSON:", err)
+				continue
+			}
+
+			id := stabilityKey{response.Provider, response.Model, filename}
+			if answers[id] == nil {
+				answers[id] = make(map[string][]string)
+				order = append(order, id)
+			}
+			for _, key := range keys {
+				value := ""
+				if answer, exists := data[key]; exists {
+					normalized, err := validator.normalize(key, answer)
+					if err != nil {
+						continue
+					}
+					value = normalized
+				}
+				answers[id][key] = append(answers[id][key], value)
+			}
+		}
+	}
+
+	rows := [][]string{{"Provider", "Model", "File Name", "Key", "Repetitions", "Answers", "Agreement", "Stable"}}
+	var entries []stabilityEntry
+	for _, id := range order {
+		for _, key := range keys {
+			values := answers[id][key]
+			if len(values) == 0 {
+				continue
+			}
+			tally, agreement := answerStability(values)
+			stable := "no"
+			if agreement == 1 {
+				stable = "yes"
+			}
+			rows = append(rows, []string{
+				id.provider, id.model, id.filename, key, strconv.Itoa(len(values)), tally,
+				strconv.FormatFloat(agreement, 'f', 2, 64), stable,
+			})
+			entries = append(entries, stabilityEntry{id.provider, id.model, key, agreement})
+		}
+	}
+
+	summary := stabilitySummary(entries, keys)
+	for _, row := range summary[1:] {
+		if row[2] == stabilityAllKeys {
+			logger.Info("Mean agreement of %s %s over %d repetitions: %s", row[0], row[1], len(repetitions), row[4])
+		}
+	}
+
+	resultsFileName := cfg.Project.Configuration.ResultsFileName
+	if err := writeStabilityReport(resultsFileName+stabilitySuffix, rows); err != nil {
+		return err
+	}
+	return writeStabilityReport(resultsFileName+stabilitySummarySuffix, summary)
+}
+
+// stabilitySummary aggregates the agreement of every model on its documents, per review key and
+// over all keys.
+//
+// Arguments:
+// - entries: The agreement of a model on a key of a document, one entry per row of the stability report.
+// - keys: The review keys, in row order.
+//
+// Returns:
+// - The rows of the summary report, header included, with the models in order of first entry.
+func stabilitySummary(entries []stabilityEntry, keys []string) [][]string {
+	type summaryKey struct{ provider, model, key string }
+	totals := make(map[summaryKey]*stabilityTotal)
+	var models []summaryKey
+	add := func(id summaryKey, agreement float64) {
+		total, ok := totals[id]
+		if !ok {
+			total = &stabilityTotal{}
+			totals[id] = total
+		}
+		total.documents++
+		total.agreement += agreement
+		if agreement == 1 {
+			total.stable++
+		}
+	}
+
+	for _, entry := range entries {
+		model := summaryKey{entry.provider, entry.model, stabilityAllKeys}
+		if _, ok := totals[model]; !ok {
+			models = append(models, model)
+		}
+		add(summaryKey{entry.provider, entry.model, entry.key}, entry.agreement)
+		add(model, entry.agreement)
+	}
+
+	summary := [][]string{{"Provider", "Model", "Key", "Documents", "Mean Agreement", "Stable Documents"}}
+	for _, model := range models {
+		for _, key := range append(slices.Clone(keys), stabilityAllKeys) {
+			total, ok := totals[summaryKey{model.provider, model.model, key}]
+			if !ok {
+				continue
+			}
+			mean := total.agreement / float64(total.documents)
+			summary = append(summary, []string{
+				model.provider, model.model, key, strconv.Itoa(total.documents),
+				strconv.FormatFloat(mean, 'f', 2, 64), strconv.Itoa(total.stable),
+			})
+		}
+	}
+	return summary
+}
+
+// answerStability tallies the answers given to a key across the repetitions of a document.
+//
+// Arguments:
+// - values: The answers of the repetitions; an empty answer is shown as a pair of single quotes.
+//
+// Returns:
+// - The distinct answers with their counts, most frequent first, separated by " | ".
+// - The share of repetitions giving the most frequent answer.
+func answerStability(values []string) (string, float64) {
+	counts := make(map[string]int)
+	for _, value := range values {
+		counts[value]++
+	}
+	distinct := make([]string, 0, len(counts))
+	for value := range counts {
+		distinct = append(distinct, value)
+	}
+	sort.Slice(distinct, func(i, j int) bool {
+		if counts[distinct[i]] != counts[distinct[j]] {
+			return counts[distinct[i]] > counts[distinct[j]]
+		}
+		return distinct[i] < distinct[j]
+	})
+
+	tally := make([]string, len(distinct))
+	for i, value := range distinct {
+		if value == "" {
+			value = "''"
+		}
+		tally[i] = fmt.Sprintf("%s (%d)", value, counts[distinct[i]])
+	}
+	return strings.Join(tally, " | "), float64(counts[distinct[0]]) / float64(len(values))
+}
+
+// writeStabilityReport writes the rows of a stability report to a CSV file.
+func writeStabilityReport(path string, rows [][]string) error {
+	reportFile, err := os.Create(path)
+	if err != nil {
+		logger.Error("Error creating stability report: %v", err)
+		return err
+	}
+	defer reportFile.Close()
+
+	writer := csv.NewWriter(reportFile)
+	if err := writer.WriteAll(rows); err != nil {
+		logger.Error("Error writing stability report: %v", err)
+		return err
+	}
+	logger.Info("Stability report successfully saved to: %s", path)
+	return nil
+}
@@ -0,0 +1,111 @@
+package results
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestAnswerStability(t *testing.T) {
+	tests := []struct {
+		values        []string
+		wantTally     string
+		wantAgreement float64
+	}{
+		{[]string{"yes", "yes", "yes"}, "yes (3)", 1},
+		{[]string{"no", "yes", "yes"}, "yes (2) | no (1)", 2.0 / 3},
+		{[]string{"", "b", "a", "b"}, "b (2) | '' (1) | a (1)", 0.5},
+	}
+	for _, tt := range tests {
+		tally, agreement := answerStability(tt.values)
+		if tally != tt.wantTally || agreement != tt.wantAgreement {
+			t.Errorf("answerStability(%v) = (%q, %v), want (%q, %v)", tt.values, tally, agreement, tt.wantTally, tt.wantAgreement)
+		}
+	}
+}
+
+func TestSaveStability(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "design", Values: []string{"cohort", "trial"}},
+			"2": {Key: "scale", Values: []string{"world", "continent"}},
+		},
+	}
+
+	// answers of paper1 and paper2 in each of three repetitions
+	answers := [][2]string{
+		{`{"design": "cohort", "scale": "world"}`, `{"design": "trial", "scale": "world"}`},
+		{`{"design": "Cohort", "scale": "world"}`, `{"design": "cohort", "scale": "city"}`},
+		{`{"design": "cohort", "scale": "world"}`, `{"design": "trial", "scale": "world"}`},
+	}
+	var repetitions []Repetition
+	for _, repetition := range answers {
+		var responses []definitions.Response
+		for i, answer := range repetition {
+			responses = append(responses, definitions.Response{
+				SequenceID:     []string{"1", "2"}[i],
+				SequenceNumber: 1,
+				Provider:       "OpenAI",
+				Model:          "gpt-4o-mini",
+				ModelResponses: []string{answer},
+			})
+		}
+		output, err := json.Marshal(definitions.Output{Responses: responses})
+		if err != nil {
+			t.Fatalf("Failed to marshal output: %v", err)
+		}
+		repetitions = append(repetitions, Repetition{Results: string(output), Run: testRun(t, string(output), []string{"paper1", "paper2"})})
+	}
+
+	if err := SaveStability(cfg, repetitions, []string{"design", "scale"}); err != nil {
+		t.Fatalf("SaveStability returned an error: %v", err)
+	}
+
+	content, err := os.ReadFile(resultsFileName + "_stability.csv")
+	if err != nil {
+		t.Fatalf("Failed to read stability report: %v", err)
+	}
+	expected := "Provider,Model,File Name,Key,Repetitions,Answers,Agreement,Stable\n" +
+		"OpenAI,gpt-4o-mini,paper1,design,3,cohort (3),1.00,yes\n" +
+		"OpenAI,gpt-4o-mini,paper1,scale,3,world (3),1.00,yes\n" +
+		"OpenAI,gpt-4o-mini,paper2,design,3,trial (2) | cohort (1),0.67,no\n" +
+		"OpenAI,gpt-4o-mini,paper2,scale,2,world (2),1.00,yes\n"
+	if string(content) != expected {
+		t.Errorf("Expected stability report %q, got %q", expected, string(content))
+	}
+
+	content, err = os.ReadFile(resultsFileName + "_stability_summary.csv")
+	if err != nil {
+		t.Fatalf("Failed to read stability summary: %v", err)
+	}
+	expected = "Provider,Model,Key,Documents,Mean Agreement,Stable Documents\n" +
+		"OpenAI,gpt-4o-mini,design,2,0.83,1\n" +
+		"OpenAI,gpt-4o-mini,scale,2,1.00,2\n" +
+		"OpenAI,gpt-4o-mini,(all keys),4,0.92,3\n"
+	if string(content) != expected {
+		t.Errorf("Expected stability summary %q, got %q", expected, string(content))
+	}
+}
+
+func TestSaveStabilitySkipsSingleRepetition(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName},
+		},
+	}
+	if err := SaveStability(cfg, []Repetition{{Results: `{"responses": []}`}}, []string{"key"}); err != nil {
+		t.Fatalf("SaveStability returned an error: %v", err)
+	}
+	if _, err := os.Stat(resultsFileName + "_stability.csv"); !os.IsNotExist(err) {
+		t.Errorf("Expected no stability report for a single repetition, got %v", err)
+	}
+}
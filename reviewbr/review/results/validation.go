@@ -0,0 +1,260 @@
+package results
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+const validationSuffix = "_validation.csv"
+
+// validationIssue describes an answer rejected by the answer validator.
+type validationIssue struct {
+	filename string
+	provider string
+	model    string
+	key      string
+	answer   string
+	problem  string
+}
+
+// answerValidator checks model answers against the typed review items of the configuration
+// and collects the rejected answers for the validation report.
+type answerValidator struct {
+	items  map[string]config.ReviewItem
+	issues []validationIssue
+}
+
+// newAnswerValidator creates a validator for the review items of the configuration, indexed by their descriptive key.
+func newAnswerValidator(cfg *config.Config) *answerValidator {
+	items := make(map[string]config.ReviewItem, len(cfg.Review))
+	for _, item := range cfg.Review {
+		items[item.Key] = item
+	}
+	return &answerValidator{items: items}
+}
+
+// check validates the answer given for a key and returns its normalized textual form.
+// Invalid answers are recorded as issues and reported as not valid, so that they are left out of the results.
+// A nil validator, or a key not defined in the review items, accepts any answer.
+//
+// Arguments:
+// - filename, provider, model: The origin of the answer, used in the validation report.
+// - key: The descriptive review key.
+// - value: The answer as decoded from the model JSON response.
+//
+// Returns:
+// - The normalized answer, or an empty string if the answer is invalid.
+// - true if the answer is valid.
+func (v *answerValidator) check(filename, provider, model, key string, value any) (string, bool) {
+	normalized, err := v.normalize(key, value)
+	if err != nil {
+		v.issues = append(v.issues, validationIssue{
+			filename: filename,
+			provider: provider,
+			model:    model,
+			key:      key,
+			answer:   fmt.Sprintf("%v", value),
+			problem:  err.Error(),
+		})
+		return "", false
+	}
+	return normalized, true
+}
+
+// normalize validates the answer given for a key without recording issues.
+// A nil validator, or a key not defined in the review items, accepts any answer.
+func (v *answerValidator) normalize(key string, value any) (string, error) {
+	if v == nil {
+		return fmt.Sprintf("%v", value), nil
+	}
+	item, ok := v.items[key]
+	if !ok {
+		return fmt.Sprintf("%v", value), nil
+	}
+	return validateAnswer(item, value)
+}
+
+// writeReport writes the rejected answers to the validation report next to the results file.
+// When no answer was rejected, any report left by a previous run is removed.
+//
+// Arguments:
+// - resultsFileName: Base name for result files (without extension).
+//
+// Returns:
+// - An error if the report cannot be written.
+func (v *answerValidator) writeReport(resultsFileName string) error {
+	reportPath := resultsFileName + validationSuffix
+	if len(v.issues) == 0 {
+		if err := os.Remove(reportPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			logger.Error("Error removing previous validation report: %v", err)
+		}
+		return nil
+	}
+
+	reportFile, err := os.Create(reportPath)
+	if err != nil {
+		logger.Error("Error creating validation report: %v", err)
+		return err
+	}
+	defer reportFile.Close()
+
+	writer := csv.NewWriter(reportFile)
+	writer.Write([]string{"Provider", "Model", "File Name", "Key", "Answer", "Problem"})
+	for _, issue := range v.issues {
+		writer.Write([]string{issue.provider, issue.model, issue.filename, issue.key, issue.answer, issue.problem})
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		logger.Error("Error writing validation report: %v", err)
+		return err
+	}
+
+	logger.Info("%d invalid answers written to validation report: %s", len(v.issues), reportPath)
+	return nil
+}
+
+// validateAnswer checks an answer against the type of its review item. Empty answers are always
+// valid, as the failsafe prompt asks models to leave unknown values empty.
+//
+// Arguments:
+// - item: The review item the answer refers to.
+// - value: The answer as decoded from the model JSON response.
+//
+// Returns:
+// - The normalized answer.
+// - An error describing why the answer is invalid.
+func validateAnswer(item config.ReviewItem, value any) (string, error) {
+	if value == nil {
+		return "", nil
+	}
+	if text, ok := value.(string); ok && strings.TrimSpace(text) == "" {
+		return "", nil
+	}
+
+	switch item.ResolvedType() {
+	case config.ItemTypeEnum:
+		return matchValue(item.Values, value)
+	case config.ItemTypeMultiEnum:
+		var elements []any
+		switch typed := value.(type) {
+		case []any:
+			elements = typed
+		case string:
+			if _, err := matchValue(item.Values, typed); err == nil {
+				elements = []any{typed}
+			} else {
+				for _, part := range strings.FieldsFunc(typed, func(r rune) bool { return r == ',' || r == ';' }) {
+					elements = append(elements, part)
+				}
+			}
+		default:
+			elements = []any{value}
+		}
+		var matched []string
+		for _, element := range elements {
+			normalized, err := matchValue(item.Values, element)
+			if err != nil {
+				return "", err
+			}
+			if normalized != "" {
+				matched = append(matched, normalized)
+			}
+		}
+		return strings.Join(matched, "; "), nil
+	case config.ItemTypeInteger:
+		number, err := parseNumber(value)
+		if err != nil {
+			return "", err
+		}
+		if number != math.Trunc(number) {
+			return "", fmt.Errorf("%v is not an integer", number)
+		}
+		if err := checkRange(item, number); err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(int64(number), 10), nil
+	case config.ItemTypeFloat:
+		number, err := parseNumber(value)
+		if err != nil {
+			return "", err
+		}
+		if err := checkRange(item, number); err != nil {
+			return "", err
+		}
+		return strconv.FormatFloat(number, 'f', -1, 64), nil
+	case config.ItemTypeDate:
+		text := strings.TrimSpace(fmt.Sprintf("%v", value))
+		for _, layout := range []string{"2006-01-02", "2006-01", "2006"} {
+			if _, err := time.Parse(layout, text); err == nil {
+				return text, nil
+			}
+		}
+		return "", fmt.Errorf("'%s' is not a date as YYYY-MM-DD, YYYY-MM or YYYY", text)
+	case config.ItemTypeBoolean:
+		if boolean, ok := value.(bool); ok {
+			if boolean {
+				return "yes", nil
+			}
+			return "no", nil
+		}
+		switch strings.ToLower(strings.TrimSpace(fmt.Sprintf("%v", value))) {
+		case "yes", "true":
+			return "yes", nil
+		case "no", "false":
+			return "no", nil
+		}
+		return "", fmt.Errorf("'%v' is not yes or no", value)
+	default:
+		return fmt.Sprintf("%v", value), nil
+	}
+}
+
+// matchValue finds the allowed value matching an answer, ignoring case and surrounding whitespace.
+func matchValue(values []string, value any) (string, error) {
+	text := strings.TrimSpace(fmt.Sprintf("%v", value))
+	if text == "" {
+		return "", nil
+	}
+	for _, allowed := range values {
+		if strings.EqualFold(text, strings.TrimSpace(allowed)) {
+			return allowed, nil
+		}
+	}
+	return "", fmt.Errorf("'%s' is not one of the allowed values", text)
+}
+
+// parseNumber reads a numeric answer given either as a JSON number or as a string.
+func parseNumber(value any) (float64, error) {
+	switch typed := value.(type) {
+	case float64:
+		return typed, nil
+	case string:
+		number, err := strconv.ParseFloat(strings.TrimSpace(typed), 64)
+		if err != nil {
+			return 0, fmt.Errorf("'%s' is not a number", typed)
+		}
+		return number, nil
+	default:
+		return 0, fmt.Errorf("'%v' is not a number", value)
+	}
+}
+
+// checkRange verifies that a numeric answer lies within the bounds of its review item.
+func checkRange(item config.ReviewItem, number float64) error {
+	if item.Min != nil && number < *item.Min {
+		return fmt.Errorf("%v is below the minimum %v", number, *item.Min)
+	}
+	if item.Max != nil && number > *item.Max {
+		return fmt.Errorf("%v is above the maximum %v", number, *item.Max)
+	}
+	return nil
+}
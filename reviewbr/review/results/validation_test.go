@@ -0,0 +1,102 @@
+package results
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestValidateAnswer(t *testing.T) {
+	minSize, maxSize := 1.0, 5000.0
+
+	testCases := []struct {
+		name     string
+		item     config.ReviewItem
+		value    any
+		expected string
+		valid    bool
+	}{
+		{"Empty answer", config.ReviewItem{Type: config.ItemTypeInteger}, "", "", true},
+		{"Untyped enum match ignoring case", config.ReviewItem{Values: []string{"yes", "no"}}, "Yes", "yes", true},
+		{"Untyped enum out of vocabulary", config.ReviewItem{Values: []string{"yes", "no"}}, "maybe", "", false},
+		{"Untyped free text", config.ReviewItem{Values: []string{""}}, "anything", "anything", true},
+		{"Multi enum from list", config.ReviewItem{Type: config.ItemTypeMultiEnum, Values: []string{"world", "continent", "river basin"}}, []any{"world", "River Basin"}, "world; river basin", true},
+		{"Multi enum from string", config.ReviewItem{Type: config.ItemTypeMultiEnum, Values: []string{"world", "continent"}}, "world, continent", "world; continent", true},
+		{"Multi enum with unknown value", config.ReviewItem{Type: config.ItemTypeMultiEnum, Values: []string{"world"}}, []any{"world", "city"}, "", false},
+		{"Integer in range", config.ReviewItem{Type: config.ItemTypeInteger, Min: &minSize, Max: &maxSize}, float64(250), "250", true},
+		{"Integer from string", config.ReviewItem{Type: config.ItemTypeInteger}, "42", "42", true},
+		{"Integer with decimals", config.ReviewItem{Type: config.ItemTypeInteger}, 4.5, "", false},
+		{"Integer above maximum", config.ReviewItem{Type: config.ItemTypeInteger, Min: &minSize, Max: &maxSize}, float64(10000), "", false},
+		{"Float", config.ReviewItem{Type: config.ItemTypeFloat}, 4.3, "4.3", true},
+		{"Float not a number", config.ReviewItem{Type: config.ItemTypeFloat}, "about four", "", false},
+		{"Date full", config.ReviewItem{Type: config.ItemTypeDate}, "2021-06-30", "2021-06-30", true},
+		{"Date year as number", config.ReviewItem{Type: config.ItemTypeDate}, float64(2019), "2019", true},
+		{"Date invalid", config.ReviewItem{Type: config.ItemTypeDate}, "June 2021", "", false},
+		{"Boolean true", config.ReviewItem{Type: config.ItemTypeBoolean}, true, "yes", true},
+		{"Boolean string", config.ReviewItem{Type: config.ItemTypeBoolean}, "False", "no", true},
+		{"Boolean invalid", config.ReviewItem{Type: config.ItemTypeBoolean}, "partially", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := validateAnswer(tc.item, tc.value)
+			if (err == nil) != tc.valid {
+				t.Fatalf("validateAnswer(%v) error = %v, expected valid = %v", tc.value, err, tc.valid)
+			}
+			if result != tc.expected {
+				t.Errorf("validateAnswer(%v) = %q, expected %q", tc.value, result, tc.expected)
+			}
+		})
+	}
+}
+
+func TestSaveWritesValidationReport(t *testing.T) {
+	resultsFileName := filepath.Join(t.TempDir(), "results")
+	cfg := &config.Config{
+		Project: config.ProjectConfig{
+			Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: "csv"},
+		},
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "sample size", Type: config.ItemTypeInteger},
+			"2": {Key: "scale", Values: []string{"world", "continent"}},
+		},
+	}
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{{
+			SequenceID:     "1",
+			SequenceNumber: 1,
+			Provider:       "OpenAI",
+			Model:          "gpt-4o-mini",
+			ModelResponses: []string{`{"sample size": "120", "scale": "city"}`},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+
+	if err := Save(cfg, string(output), testRun(t, string(output), []string{"paper1"}), []string{"sample size", "scale"}); err != nil {
+		t.Fatalf("Save returned an error: %v", err)
+	}
+
+	content, err := os.ReadFile(resultsFileName + ".csv")
+	if err != nil {
+		t.Fatalf("Failed to read results: %v", err)
+	}
+	expected := "Provider,Model,File Name,sample size,scale\nOpenAI,gpt-4o-mini,paper1,120,\n"
+	if string(content) != expected {
+		t.Errorf("Expected results %q, got %q", expected, string(content))
+	}
+
+	report, err := os.ReadFile(resultsFileName + "_validation.csv")
+	if err != nil {
+		t.Fatalf("Expected a validation report: %v", err)
+	}
+	if !strings.Contains(string(report), "OpenAI,gpt-4o-mini,paper1,scale,city,") {
+		t.Errorf("Expected the out-of-vocabulary answer in the report, got %q", string(report))
+	}
+}
@@ -0,0 +1,214 @@
+package results
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+// xlsxMaxCell is the maximum number of characters of a spreadsheet cell; longer texts are truncated.
+const xlsxMaxCell = 32767
+
+// xlsxSheet is a worksheet of an XLSX workbook, with its rows of text cells.
+type xlsxSheet struct {
+	name string
+	rows [][]string
+}
+
+// saveXLSX creates an XLSX workbook with processed model responses. The "Answers" sheet has the same
+// columns as the CSV output; when enabled, the "Justifications" and "Summaries" sheets, and a sheet
+// named after each query of the [follow_ups] table, hold the follow-up answers, one row per file and
+// model. In the long layout, the workbook has the single sheet of the long CSV output instead.
+//
+// Parameters:
+//   - config: The application configuration containing layout, justification and summary settings
+//   - filePath: The output file path for the workbook
+//   - resultsString: JSON string containing all model responses
+//   - attribution: The documents answered by the responses, from the run manifest
+//   - keys: List of answer columns
+//   - validator: The answer validator; invalid answers are left empty
+//
+// Returns:
+//   - error: nil if successful, otherwise an error describing what failed
+func saveXLSX(config *config.Config, filePath string, resultsString string, attribution *attribution, keys []string, validator *answerValidator) error {
+	sheets, err := xlsxSheets(config, resultsString, attribution, keys, validator)
+	if err != nil {
+		return err
+	}
+
+	outputFile, err := os.Create(filePath)
+	if err != nil {
+		logger.Error("Error creating XLSX file: %v", err)
+		return err
+	}
+	defer outputFile.Close()
+
+	if err := writeXLSX(outputFile, sheets); err != nil {
+		logger.Error("Error writing XLSX file: %v", err)
+		return err
+	}
+
+	logger.Info("XLSX results successfully saved to: %s", filePath)
+	return nil
+}
+
+// xlsxSheets builds the sheets of the XLSX workbook. In the long layout, a single "Results" sheet
+// has the same rows as the long CSV output.
+//
+// Parameters:
+//   - cfg: The application configuration containing layout, justification and summary settings
+//   - resultsString: JSON string containing all model responses
+//   - attribution: The documents answered by the responses, from the run manifest
+//   - keys: List of answer columns
+//   - validator: The answer validator; invalid answers are left empty
+//
+// Returns:
+//   - []xlsxSheet: The sheets, in order
+//   - error: nil if successful, otherwise an error describing what failed
+func xlsxSheets(cfg *config.Config, resultsString string, attribution *attribution, keys []string, validator *answerValidator) ([]xlsxSheet, error) {
+	if longLayout(cfg) {
+		rows, err := longRows(cfg, resultsString, attribution, keys, validator)
+		if err != nil {
+			return nil, err
+		}
+		return []xlsxSheet{{name: "Results", rows: rows}}, nil
+	}
+
+	var parsedResults definitions.Output
+	if err := json.Unmarshal([]byte(resultsString), &parsedResults); err != nil {
+		logger.Error("Error parsing results JSON: %v", err)
+		return nil, err
+	}
+
+	header := slices.Concat([]string{"Provider", "Model", "File Name"}, attribution.columns, attribution.retryColumns(), keys)
+	sheets := []xlsxSheet{{name: "Answers", rows: [][]string{header}}}
+
+	// one sheet per follow-up, in the order they are asked
+	followUpSheets := make(map[int]int) // index of the sheet of every follow-up, by sequence number
+	for i, followUp := range cfg.FollowUps() {
+		name, column := followUp.Name, followUp.Name
+		switch followUp.Name {
+		case config.FollowUpJustification:
+			name, column = "Justifications", "Justification"
+		case config.FollowUpSummary:
+			name, column = "Summaries", "Summary"
+		}
+		followUpSheets[i+2] = len(sheets)
+		sheets = append(sheets, xlsxSheet{name: name, rows: [][]string{{"Provider", "Model", "File Name", column}}})
+	}
+
+	for _, response := range parsedResults.Responses {
+		filename, ok := attribution.file(response)
+		if !ok || len(response.ModelResponses) == 0 {
+			continue
+		}
+		if response.SequenceNumber == 1 {
+			if row, ok := answerRow(response.ModelResponses[0], filename, response.Provider, response.Model, keys, validator); ok {
+				sheets[0].rows = append(sheets[0].rows, slices.Insert(row, 3, append(attribution.record(filename), attribution.retryValues(response)...)...))
+			}
+		} else if index, ok := followUpSheets[response.SequenceNumber]; ok {
+			sheets[index].rows = append(sheets[index].rows, []string{response.Provider, response.Model, filename, response.ModelResponses[0]})
+		}
+	}
+	return sheets, nil
+}
+
+// writeXLSX writes a minimal Office Open XML workbook with the given sheets, storing every cell as an
+// inline string.
+//
+// Arguments:
+// - w: The writer receiving the workbook.
+// - sheets: The worksheets, in order.
+//
+// Returns:
+// - An error if writing fails.
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	archive := zip.NewWriter(w)
+
+	var contentTypes, workbook, relationships strings.Builder
+	contentTypes.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	workbook.WriteString(xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	relationships.WriteString(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i, sheet := range sheets {
+		id := strconv.Itoa(i + 1)
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%s.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, id)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%s" r:id="rId%s"/>`, escapeXML(sheet.name), id, id)
+		fmt.Fprintf(&relationships, `<Relationship Id="rId%s" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%s.xml"/>`, id, id)
+	}
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets></workbook>`)
+	relationships.WriteString(`</Relationships>`)
+
+	parts := [][2]string{
+		{"[Content_Types].xml", contentTypes.String()},
+		{"_rels/.rels", xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+			`</Relationships>`},
+		{"xl/workbook.xml", workbook.String()},
+		{"xl/_rels/workbook.xml.rels", relationships.String()},
+	}
+	for i, sheet := range sheets {
+		parts = append(parts, [2]string{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), worksheetXML(sheet.rows)})
+	}
+
+	// parts are written as name and content pairs
+	for _, part := range parts {
+		file, err := archive.Create(part[0])
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(file, part[1]); err != nil {
+			return err
+		}
+	}
+	return archive.Close()
+}
+
+// worksheetXML renders the rows of a sheet as SpreadsheetML.
+func worksheetXML(rows [][]string) string {
+	var sheet strings.Builder
+	sheet.WriteString(xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+	for i, row := range rows {
+		fmt.Fprintf(&sheet, `<row r="%d">`, i+1)
+		for j, value := range row {
+			if runes := []rune(value); len(runes) > xlsxMaxCell {
+				value = string(runes[:xlsxMaxCell])
+			}
+			fmt.Fprintf(&sheet, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, columnName(j), i+1, escapeXML(value))
+		}
+		sheet.WriteString(`</row>`)
+	}
+	sheet.WriteString(`</sheetData></worksheet>`)
+	return sheet.String()
+}
+
+// columnName returns the spreadsheet name of a zero-based column index: A, B, ..., Z, AA, AB, ...
+func columnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// escapeXML escapes a text for XML character data, replacing characters not allowed in XML.
+func escapeXML(text string) string {
+	var escaped strings.Builder
+	xml.EscapeText(&escaped, []byte(text))
+	return escaped.String()
+}
@@ -0,0 +1,104 @@
+package results
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/alembica/definitions"
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func TestColumnName(t *testing.T) {
+	tests := map[int]string{0: "A", 25: "Z", 26: "AA", 27: "AB", 701: "ZZ", 702: "AAA"}
+	for index, expected := range tests {
+		if name := columnName(index); name != expected {
+			t.Errorf("columnName(%d) = %s, expected %s", index, name, expected)
+		}
+	}
+}
+
+func TestSaveXLSXAndJSONL(t *testing.T) {
+	output, err := json.Marshal(definitions.Output{
+		Responses: []definitions.Response{
+			{SequenceID: "1", SequenceNumber: 1, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{`{"scale": "world", "notes": "a < b & c"}`}},
+			{SequenceID: "1", SequenceNumber: 2, Provider: "OpenAI", Model: "gpt-4o-mini", ModelResponses: []string{"A global summary."}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal output: %v", err)
+	}
+	run := testRun(t, string(output), []string{"paper1"})
+	newConfig := func(resultsFileName, format string) *config.Config {
+		return &config.Config{
+			Project: config.ProjectConfig{
+				Configuration: config.ProjectConfiguration{ResultsFileName: resultsFileName, OutputFormat: format, Summary: "yes"},
+			},
+			Review: map[string]config.ReviewItem{
+				"1": {Key: "scale", Values: []string{"world", "river basin"}},
+				"2": {Key: "notes", Values: []string{""}},
+			},
+		}
+	}
+
+	t.Run("xlsx", func(t *testing.T) {
+		resultsFileName := filepath.Join(t.TempDir(), "results")
+		if err := Save(newConfig(resultsFileName, "xlsx"), string(output), run, []string{"scale", "notes"}); err != nil {
+			t.Fatalf("Save returned an error: %v", err)
+		}
+		content, err := os.ReadFile(resultsFileName + ".xlsx")
+		if err != nil {
+			t.Fatalf("Failed to read results: %v", err)
+		}
+		archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+		if err != nil {
+			t.Fatalf("Expected a zip archive: %v", err)
+		}
+		parts := map[string]string{}
+		for _, file := range archive.File {
+			reader, err := file.Open()
+			if err != nil {
+				t.Fatalf("Failed to open %s: %v", file.Name, err)
+			}
+			data, _ := io.ReadAll(reader)
+			reader.Close()
+			parts[file.Name] = string(data)
+		}
+
+		if workbook := parts["xl/workbook.xml"]; !strings.Contains(workbook, `name="Answers"`) || !strings.Contains(workbook, `name="Summaries"`) || strings.Contains(workbook, `name="Justifications"`) {
+			t.Errorf("Expected the Answers and Summaries sheets, got %s", workbook)
+		}
+		answers := parts["xl/worksheets/sheet1.xml"]
+		for _, cell := range []string{">File Name<", ">scale<", ">paper1<", ">world<", ">a &lt; b &amp; c<"} {
+			if !strings.Contains(answers, cell) {
+				t.Errorf("Expected %s in the Answers sheet, got %s", cell, answers)
+			}
+		}
+		if summaries := parts["xl/worksheets/sheet2.xml"]; !strings.Contains(summaries, ">A global summary.<") {
+			t.Errorf("Expected the summary in the Summaries sheet, got %s", summaries)
+		}
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		resultsFileName := filepath.Join(t.TempDir(), "results")
+		if err := Save(newConfig(resultsFileName, "jsonl"), string(output), run, []string{"scale", "notes"}); err != nil {
+			t.Fatalf("Save returned an error: %v", err)
+		}
+		content, err := os.ReadFile(resultsFileName + ".jsonl")
+		if err != nil {
+			t.Fatalf("Failed to read results: %v", err)
+		}
+		objects, err := readJSONLines(content)
+		if err != nil {
+			t.Fatalf("Failed to parse JSON Lines: %v", err)
+		}
+		if len(objects) != 2 || objects[0]["scale"] != "world" || objects[0]["filename"] != "paper1" {
+			t.Errorf("Expected one object per response, got %v", objects)
+		}
+	})
+}
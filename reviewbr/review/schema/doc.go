@@ -0,0 +1,4 @@
+// Package schema generates the JSON Schema of the answers expected from the models, from the typed
+// review items of each review group, and validates model answers against it. The validation errors
+// are worded to be sent back to the model when its answer is asked again.
+package schema
@@ -0,0 +1,261 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/open-and-sustainable/prismaid/review/config"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// Draft is the JSON Schema version of the generated schemas.
+const Draft = "http://json-schema.org/draft-07/schema#"
+
+// Patterns of the answers given as strings for numeric and date items.
+const (
+	integerPattern = `^\s*-?[0-9]+\s*$`
+	numberPattern  = `^\s*-?[0-9]+(\.[0-9]+)?\s*$`
+	datePattern    = `^[0-9]{4}(-[0-9]{2}(-[0-9]{2})?)?$`
+)
+
+// Generate returns the JSON Schema of the answer to the prompt of a review group: an object with one
+// required property per review item of the group, named after its key, and no other property. Every
+// property accepts an empty answer, as the failsafe prompt asks models to leave unknown values empty.
+//
+// Arguments:
+// - cfg: The application configuration, providing the review items.
+// - group: The review group whose answer is described.
+//
+// Returns:
+// - The JSON Schema, ready to be marshaled.
+func Generate(cfg *config.Config, group config.ReviewGroup) map[string]any {
+	properties := make(map[string]any, len(group.Items))
+	required := make([]string, 0, len(group.Items))
+	for _, entry := range group.Items {
+		item := cfg.Review[entry]
+		properties[item.Key] = itemSchema(item)
+		required = append(required, item.Key)
+	}
+	sort.Strings(required)
+	return map[string]any{
+		"$schema":              Draft,
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// itemSchema returns the schema of the answer to a review item, according to its type. Numbers may
+// also be given as strings, booleans as yes or no, and the values of multiple choice items as a list
+// or a single value.
+func itemSchema(item config.ReviewItem) map[string]any {
+	var schemas []any
+	switch item.ResolvedType() {
+	case config.ItemTypeEnum:
+		schemas = []any{map[string]any{"enum": allowedValues(item)}}
+	case config.ItemTypeMultiEnum:
+		values := allowedValues(item)
+		schemas = []any{
+			map[string]any{"type": "array", "items": map[string]any{"enum": values}},
+			map[string]any{"enum": values},
+		}
+	case config.ItemTypeInteger:
+		schemas = []any{withBounds(item, map[string]any{"type": "integer"}), map[string]any{"type": "string", "pattern": integerPattern}}
+	case config.ItemTypeFloat:
+		schemas = []any{withBounds(item, map[string]any{"type": "number"}), map[string]any{"type": "string", "pattern": numberPattern}}
+	case config.ItemTypeDate:
+		schemas = []any{map[string]any{"type": "string", "pattern": datePattern}}
+	case config.ItemTypeBoolean:
+		schemas = []any{map[string]any{"type": "boolean"}, map[string]any{"enum": []any{"yes", "no"}}}
+	default:
+		schemas = []any{map[string]any{"type": []any{"string", "number", "boolean"}}}
+	}
+	schemas = append(schemas, map[string]any{"type": "null"}, map[string]any{"const": ""})
+	return map[string]any{"anyOf": schemas}
+}
+
+// allowedValues returns the non-empty values of an item, which the empty answer completes.
+func allowedValues(item config.ReviewItem) []any {
+	values := make([]any, 0, len(item.Values))
+	for _, value := range item.Values {
+		if value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// withBounds adds the range of a numeric item to its schema.
+func withBounds(item config.ReviewItem, schema map[string]any) map[string]any {
+	if item.Min != nil {
+		schema["minimum"] = *item.Min
+	}
+	if item.Max != nil {
+		schema["maximum"] = *item.Max
+	}
+	return schema
+}
+
+// Validator checks the answers to the prompt of a review group against its JSON Schema.
+type Validator struct {
+	schema   *gojsonschema.Schema
+	document string                       // indented JSON text of the schema
+	items    map[string]config.ReviewItem // review items of the group, by key
+}
+
+// NewValidator compiles the JSON Schema of a review group.
+//
+// Arguments:
+// - cfg: The application configuration, providing the review items.
+// - group: The review group whose answers are validated.
+//
+// Returns:
+// - The validator.
+// - An error if the schema cannot be compiled.
+func NewValidator(cfg *config.Config, group config.ReviewGroup) (*Validator, error) {
+	document, err := json.MarshalIndent(Generate(cfg, group), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	compiled, err := gojsonschema.NewSchema(gojsonschema.NewBytesLoader(document))
+	if err != nil {
+		return nil, fmt.Errorf("invalid JSON Schema of the review items: %v", err)
+	}
+	items := make(map[string]config.ReviewItem, len(group.Items))
+	for _, entry := range group.Items {
+		item := cfg.Review[entry]
+		items[item.Key] = item
+	}
+	return &Validator{schema: compiled, document: string(document), items: items}, nil
+}
+
+// Schema returns the JSON Schema of the validator as indented JSON text.
+func (v *Validator) Schema() string {
+	return v.document
+}
+
+// Validate checks a model answer against the schema. Code fences around the JSON object are ignored,
+// and the values of enum, multiple choice and boolean items are matched ignoring case and surrounding
+// whitespace, as they are when the results are written, so that such answers are not re-asked.
+//
+// Arguments:
+// - answer: The answer of the model.
+//
+// Returns:
+// - The problems found, sorted; empty if the answer is valid.
+func (v *Validator) Validate(answer string) []string {
+	answer = strings.TrimSpace(answer)
+	answer = strings.TrimPrefix(answer, "```json")
+	answer = strings.TrimPrefix(answer, "```")
+	answer = strings.TrimSuffix(answer, "```")
+
+	var data any
+	if err := json.Unmarshal([]byte(answer), &data); err != nil {
+		return []string{fmt.Sprintf("the answer is not a valid JSON object: %v", err)}
+	}
+	v.normalize(data)
+	result, err := v.schema.Validate(gojsonschema.NewGoLoader(data))
+	if err != nil {
+		return []string{fmt.Sprintf("the answer cannot be validated: %v", err)}
+	}
+
+	// the generic anyOf error of a property is left out when the error of its best match explains it
+	explained := make(map[string]bool)
+	for _, resultError := range result.Errors() {
+		if resultError.Type() != "number_any_of" {
+			explained[resultError.Field()] = true
+		}
+	}
+	problems := make([]string, 0, len(result.Errors()))
+	for _, resultError := range result.Errors() {
+		if resultError.Type() == "number_any_of" && explained[resultError.Field()] {
+			continue
+		}
+		problems = append(problems, resultError.String())
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// normalize replaces, in a decoded answer, the values of enum and multiple choice items with the
+// allowed values they match ignoring case, splitting multiple choice values listed in a string, and
+// the yes, no, true and false answers of boolean items with their lowercase form.
+func (v *Validator) normalize(data any) {
+	object, ok := data.(map[string]any)
+	if !ok {
+		return
+	}
+	for key, value := range object {
+		item, ok := v.items[key]
+		if !ok {
+			continue
+		}
+		switch item.ResolvedType() {
+		case config.ItemTypeEnum:
+			object[key] = canonicalValue(item.Values, value)
+		case config.ItemTypeMultiEnum:
+			switch typed := value.(type) {
+			case []any:
+				for i, element := range typed {
+					typed[i] = canonicalValue(item.Values, element)
+				}
+			case string:
+				object[key] = canonicalValues(item.Values, typed)
+			}
+		case config.ItemTypeBoolean:
+			if text, ok := value.(string); ok {
+				switch lower := strings.ToLower(strings.TrimSpace(text)); lower {
+				case "yes", "no":
+					object[key] = lower
+				case "true", "false":
+					object[key] = lower == "true"
+				}
+			}
+		}
+	}
+}
+
+// canonicalValue returns the allowed value matching a string answer, or the answer itself if it
+// matches none.
+func canonicalValue(values []string, value any) any {
+	if text, ok := value.(string); ok {
+		if allowed, ok := matchAllowed(values, text); ok {
+			return allowed
+		}
+	}
+	return value
+}
+
+// canonicalValues returns the allowed value matching a multiple choice answer given as a string, or
+// the allowed values matching all of its parts separated by commas or semicolons, or the answer itself.
+func canonicalValues(values []string, text string) any {
+	if allowed, ok := matchAllowed(values, text); ok {
+		return allowed
+	}
+	parts := strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == ';' })
+	if len(parts) < 2 {
+		return text
+	}
+	matched := make([]any, 0, len(parts))
+	for _, part := range parts {
+		allowed, ok := matchAllowed(values, part)
+		if !ok {
+			return text
+		}
+		matched = append(matched, allowed)
+	}
+	return matched
+}
+
+// matchAllowed finds the non-empty allowed value matching a text, ignoring case and surrounding whitespace.
+func matchAllowed(values []string, text string) (string, bool) {
+	for _, allowed := range values {
+		if allowed != "" && strings.EqualFold(strings.TrimSpace(text), strings.TrimSpace(allowed)) {
+			return allowed, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,85 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/open-and-sustainable/prismaid/review/config"
+)
+
+func testConfig() *config.Config {
+	minimum, maximum := 1.0, 10000.0
+	return &config.Config{
+		Review: map[string]config.ReviewItem{
+			"1": {Key: "design", Values: []string{"cohort", "trial", ""}},
+			"2": {Key: "sample size", Type: config.ItemTypeInteger, Min: &minimum, Max: &maximum},
+			"3": {Key: "countries", Type: config.ItemTypeMultiEnum, Values: []string{"Italy", "Spain"}},
+			"4": {Key: "published", Type: config.ItemTypeDate},
+			"5": {Key: "funded", Type: config.ItemTypeBoolean},
+			"6": {Key: "notes", Values: []string{""}},
+		},
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	cfg := testConfig()
+	generated := Generate(cfg, cfg.Groups()[0])
+
+	if !reflect.DeepEqual(generated["required"], []string{"countries", "design", "funded", "notes", "published", "sample size"}) {
+		t.Errorf("Expected every key to be required, got %v", generated["required"])
+	}
+	if generated["additionalProperties"] != false {
+		t.Errorf("Expected no additional properties, got %v", generated["additionalProperties"])
+	}
+	design, err := json.Marshal(generated["properties"].(map[string]any)["design"])
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	if string(design) != `{"anyOf":[{"enum":["cohort","trial"]},{"type":"null"},{"const":""}]}` {
+		t.Errorf("Unexpected schema of the enum item: %s", design)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := testConfig()
+	validator, err := NewValidator(cfg, cfg.Groups()[0])
+	if err != nil {
+		t.Fatalf("NewValidator returned an error: %v", err)
+	}
+	if !strings.Contains(validator.Schema(), `"additionalProperties": false`) {
+		t.Errorf("Expected the indented schema, got %s", validator.Schema())
+	}
+
+	valid := []string{
+		`{"design": "cohort", "sample size": 200, "countries": ["Italy", "Spain"], "published": "2021-05", "funded": "yes", "notes": "multicentre"}`,
+		"```json\n" + `{"design": "", "sample size": "42", "countries": "Spain", "published": "", "funded": true, "notes": ""}` + "\n```",
+		`{"design": " Cohort", "sample size": 10, "countries": ["ITALY", "spain"], "published": "", "funded": "Yes", "notes": ""}`,
+		`{"design": "TRIAL", "sample size": 10, "countries": "italy; Spain", "published": "", "funded": "False", "notes": ""}`,
+	}
+	for _, answer := range valid {
+		if problems := validator.Validate(answer); len(problems) != 0 {
+			t.Errorf("Expected %s to be valid, got %v", answer, problems)
+		}
+	}
+
+	tests := []struct {
+		answer   string
+		problems []string
+	}{
+		{`{"design": "cohort"`, []string{"not a valid JSON object"}},
+		{`{"design": "case study", "sample size": 0, "countries": ["France"], "published": "May 2021", "funded": "yes", "notes": "", "extra": 1}`,
+			[]string{"countries", "design", "extra", "published", "sample size"}},
+		{`{"design": "cohort"}`, []string{"countries is required", "funded is required", "notes is required", "published is required", "sample size is required"}},
+	}
+	for _, test := range tests {
+		problems := validator.Validate(test.answer)
+		joined := strings.Join(problems, "\n")
+		for _, expected := range test.problems {
+			if !strings.Contains(joined, expected) {
+				t.Errorf("Expected a problem about %q for %s, got %v", expected, test.answer, problems)
+			}
+		}
+	}
+}
@@ -7,7 +7,8 @@ import (
 
 	"github.com/open-and-sustainable/alembica/definitions"
 	"github.com/open-and-sustainable/alembica/extraction"
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/logger"
 )
 
 // ArticleType represents the classification of an article
@@ -78,8 +79,9 @@ func ClassifyArticleType(text string, llmConfigs []any) (string, error) {
 	return string(jsonData), nil
 }
 
-// BatchClassifyArticleTypesWithAI processes multiple manuscripts in a single AI call
-func BatchClassifyArticleTypesWithAI(manuscriptsData []map[string]string, llmConfigs []any) map[string]*ArticleClassification {
+// BatchClassifyArticleTypesWithAI processes multiple manuscripts in a single AI call, with the
+// article type prompt of localized (English if nil)
+func BatchClassifyArticleTypesWithAI(manuscriptsData []map[string]string, llmConfigs []any, localized *localization.Prompts) map[string]*ArticleClassification {
 	results := make(map[string]*ArticleClassification)
 
 	// Initialize all results with Unknown
@@ -142,55 +144,8 @@ func BatchClassifyArticleTypesWithAI(manuscriptsData []map[string]string, llmCon
 		}
 
 		// Create the prompt (same as in classifyWithAIComprehensive)
-		prompt := fmt.Sprintf(`You are a scientific manuscript classification expert. Analyze this manuscript and provide a comprehensive type classification.
-
-CONTEXT:
-A manuscript can have MULTIPLE overlapping classifications. For example:
-- A paper can be both "research_article" AND "empirical_study" AND "sample_study"
-- A review can be both "review" AND "systematic_review"
-- A methods paper can be both "research_article" AND "methods_paper"
-
-CLASSIFICATION DIMENSIONS:
-
-1. TRADITIONAL PUBLICATION TYPES (what editors would call it):
-   - research_article: Original research with methods, results, and conclusions
-   - review: Literature review without systematic methodology (narrative review, scoping review)
-   - systematic_review: Following structured review protocol (PRISMA, etc.)
-   - meta_analysis: Statistical synthesis of multiple studies
-   - editorial: Opinion piece by editors
-   - letter: Brief correspondence to editors
-   - case_report: Single patient/case/instance report
-   - commentary: Comments on published work
-   - perspective: Author viewpoints and opinions
-
-2. METHODOLOGICAL TYPES (how research is conducted):
-   - empirical_study: Based on observation/experimentation with data collection
-   - theoretical_paper: Conceptual work without empirical data
-   - methods_paper: Presenting new methods, techniques, or protocols
-
-3. STUDY SCOPE (for empirical studies):
-   - single_case_study: In-depth analysis of ONE case/patient/organization (n=1)
-   - sample_study: Multiple subjects (cohort studies, surveys, cross-sectional, etc.)
-
-IMPORTANT RULES:
-- A paper can have types from ALL three dimensions
-- Single case ≠ case report (case report is a publication type, single case is a scope)
-- If empirical, MUST be either single_case_study OR sample_study
-- Default to "research_article" for traditional type if unclear
-- Be specific - don't just say "unknown"
-
-MANUSCRIPT:
-Title: %s
-Abstract: %s
-
-Provide classification as JSON:
-{
-  "primary_type": "most_specific_type",
-  "all_types": ["type1", "type2", ...],
-  "methodological_types": ["empirical_study" | "theoretical_paper" | "methods_paper"],
-  "scope_types": ["single_case_study" | "sample_study"] (only if empirical),
-  "type_scores": {"type1": 0.95, "type2": 0.80, ...}
-}`, title, abstract)
+		dataStr := fmt.Sprintf("Title: %s\nAbstract: %s", title, abstract)
+		prompt := localized.Render(localization.ArticleTypePrompt, localization.Data{Manuscript: dataStr})
 
 		prompts = append(prompts, definitions.Prompt{
 			PromptContent:  prompt,
@@ -294,8 +249,9 @@ Provide classification as JSON:
 	return results
 }
 
-// ClassifyArticleTypeWithAI uses AI to classify article type
-func ClassifyArticleTypeWithAI(manuscriptData map[string]string, useAI bool, llmConfigs []any) (*ArticleClassification, error) {
+// ClassifyArticleTypeWithAI uses AI to classify article type, with the article type prompt of localized
+// (English if nil)
+func ClassifyArticleTypeWithAI(manuscriptData map[string]string, useAI bool, llmConfigs []any, localized *localization.Prompts) (*ArticleClassification, error) {
 	// Extract relevant fields
 	title := ""
 	abstract := ""
@@ -323,7 +279,7 @@ func ClassifyArticleTypeWithAI(manuscriptData map[string]string, useAI bool, llm
 	}
 
 	// Use AI-based classification
-	return classifyWithAIComprehensive(title, abstract, llmConfigs)
+	return classifyWithAIComprehensive(title, abstract, llmConfigs, localized)
 }
 
 // ClassifyArticleTypes returns multiple applicable article types
@@ -1139,7 +1095,7 @@ func parseArticleType(typeStr string) ArticleType {
 }
 
 // classifyWithAIComprehensive performs AI-based article type classification
-func classifyWithAIComprehensive(title, abstract string, llmConfigs []any) (*ArticleClassification, error) {
+func classifyWithAIComprehensive(title, abstract string, llmConfigs []any, localized *localization.Prompts) (*ArticleClassification, error) {
 	// Prepare AI model configurations
 	var models []definitions.Model
 	for _, llmConfig := range llmConfigs {
@@ -1173,54 +1129,7 @@ func classifyWithAIComprehensive(title, abstract string, llmConfigs []any) (*Art
 	dataStr := fmt.Sprintf("Title: %s\nAbstract: %s", title, abstract)
 
 	// Create the comprehensive prompt
-	prompt := fmt.Sprintf(`You are an expert in scientific literature classification. Analyze the following manuscript and classify it into ALL applicable categories.
-
-IMPORTANT: A manuscript can belong to MULTIPLE overlapping categories. For example:
-- A paper can be both "research_article" AND "empirical_study" AND "sample_study"
-- A paper can be both "systematic_review" AND "meta_analysis"
-- A paper can be both "methods_paper" AND "empirical_study"
-
-MANUSCRIPT DATA:
-%s
-
-CLASSIFICATION CATEGORIES:
-
-1. TRADITIONAL PUBLICATION TYPES (select all that apply):
-- research_article: Original research with methods and results
-- review: Literature review or narrative review
-- systematic_review: Following structured protocols (e.g., PRISMA)
-- meta_analysis: Statistical synthesis of multiple studies
-- editorial: Opinion piece by editors
-- letter: Correspondence to editors
-- case_report: Report of individual case(s)
-- commentary: Comment on published work
-- perspective: Author viewpoint/opinion
-
-2. METHODOLOGICAL TYPES (select all that apply):
-- empirical_study: Based on observation/experimentation with data collection
-- theoretical_paper: Conceptual work without empirical data
-- methods_paper: Presenting new methods/techniques/protocols
-
-3. STUDY SCOPE (for empirical studies, select if applicable):
-- single_case_study: In-depth analysis of single case/patient/organization (n=1)
-- sample_study: Multiple participants/subjects (cohort, cross-sectional, survey, etc.)
-
-RESPONSE FORMAT:
-Provide a JSON object with:
-{
-  "primary_type": "most_specific_type",
-  "all_types": ["type1", "type2", "type3"],
-  "methodological_types": ["empirical_study", "theoretical_paper", or "methods_paper"],
-  "scope_types": ["single_case_study" or "sample_study"] if applicable
-}
-
-Example response for a research article with empirical data from multiple participants:
-{
-  "primary_type": "research_article",
-  "all_types": ["research_article", "empirical_study", "sample_study"],
-  "methodological_types": ["empirical_study"],
-  "scope_types": ["sample_study"]
-}`, dataStr)
+	prompt := localized.Render(localization.ArticleTypeSinglePrompt, localization.Data{Manuscript: dataStr})
 
 	// Prepare the input for alembica
 	input := definitions.Input{
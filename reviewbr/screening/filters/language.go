@@ -8,7 +8,8 @@ import (
 
 	"github.com/open-and-sustainable/alembica/definitions"
 	"github.com/open-and-sustainable/alembica/extraction"
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/logger"
 )
 
 // DetectLanguage performs rule-based language detection
@@ -54,8 +55,9 @@ type LanguageDetectionConfig struct {
 	LLMConfigs []any // LLM configurations for AI-based detection
 }
 
-// BatchDetectLanguagesWithAI processes multiple manuscripts in a single AI call
-func BatchDetectLanguagesWithAI(manuscriptsData []map[string]string, llmConfigs []any) map[string]string {
+// BatchDetectLanguagesWithAI processes multiple manuscripts in a single AI call, with the
+// language prompt of localized (English if nil)
+func BatchDetectLanguagesWithAI(manuscriptsData []map[string]string, llmConfigs []any, localized *localization.Prompts) map[string]string {
 	results := make(map[string]string)
 
 	// Initialize all results as "unknown"
@@ -121,26 +123,7 @@ func BatchDetectLanguagesWithAI(manuscriptsData []map[string]string, llmConfigs
 		dataStr := buildLanguageDetectionData(title, abstract, journal)
 
 		// Create the prompt
-		prompt := fmt.Sprintf(`You are a language detection expert analyzing scientific manuscripts. You need to identify the primary language of a manuscript based on the provided fields.
-
-CONTEXT:
-- You are analyzing: title, abstract, and journal information
-- IMPORTANT: Many scientific databases translate abstracts to English while keeping the original title
-- The title language is often more reliable than abstract language
-- Journal names may indicate regional publications (e.g., "Revista Española", "Deutsche Zeitschrift")
-
-SPECIAL CONSIDERATIONS:
-- If title is in one language but abstract is in English, prioritize the title language
-- Look for language-specific characters (é, ñ, ü, ø, etc.)
-- Consider scientific Latin terms as part of the surrounding language context
-- Mixed language content: identify the dominant/primary language
-
-MANUSCRIPT DATA:
-%s
-
-TASK: Identify the primary language of this manuscript.
-Respond with ONLY a JSON object with the ISO 639-1 language code: {"language": "en"} or {"language": "es"} or {"language": "fr"} etc.
-Common codes: en (English), es (Spanish), fr (French), de (German), it (Italian), pt (Portuguese), ru (Russian), zh (Chinese), ja (Japanese), ar (Arabic)`, dataStr)
+		prompt := localized.Render(localization.LanguagePrompt, localization.Data{Manuscript: dataStr})
 
 		prompts = append(prompts, definitions.Prompt{
 			PromptContent:  prompt,
@@ -9,7 +9,8 @@ import (
 
 	"github.com/open-and-sustainable/alembica/definitions"
 	"github.com/open-and-sustainable/alembica/extraction"
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/logger"
 )
 
 // TopicRelevanceConfig represents configuration for topic relevance filtering
@@ -101,8 +102,9 @@ func CalculateTopicRelevance(manuscriptData map[string]string, topics []string,
 	}, nil
 }
 
-// BatchCalculateTopicRelevanceWithAI processes multiple manuscripts in a single AI call
-func BatchCalculateTopicRelevanceWithAI(manuscriptsData []map[string]string, topics []string, minScore float64, llmConfigs []any) map[string]*TopicRelevanceScore {
+// BatchCalculateTopicRelevanceWithAI processes multiple manuscripts in a single AI call, with the
+// topic relevance prompt of localized (English if nil)
+func BatchCalculateTopicRelevanceWithAI(manuscriptsData []map[string]string, topics []string, minScore float64, llmConfigs []any, localized *localization.Prompts) map[string]*TopicRelevanceScore {
 	results := make(map[string]*TopicRelevanceScore)
 
 	// Initialize all results with zero scores
@@ -152,7 +154,7 @@ func BatchCalculateTopicRelevanceWithAI(manuscriptsData []map[string]string, top
 	// Build all prompts
 	var prompts []definitions.Prompt
 	validIndices := []int{}
-	topicsStr := strings.Join(topics, "\n- ")
+	topicsStr := "- " + strings.Join(topics, "\n- ")
 
 	for idx, manuscriptData := range manuscriptsData {
 		// Build the data string for AI analysis
@@ -164,37 +166,7 @@ func BatchCalculateTopicRelevanceWithAI(manuscriptsData []map[string]string, top
 		}
 
 		// Create the prompt
-		prompt := fmt.Sprintf(`You are an expert in academic manuscript screening. Your task is to evaluate whether a manuscript is relevant to specific research topics.
-
-TOPICS OF INTEREST:
-- %s
-
-MANUSCRIPT DATA:
-%s
-
-TASK: Evaluate the relevance of this manuscript to the specified topics.
-
-Analyze the manuscript considering:
-1. Direct keyword matches with the topics
-2. Conceptual alignment with the research areas
-3. Field/domain relevance
-4. Methodological relevance
-5. Research questions and objectives alignment
-
-Respond with a JSON object containing:
-{
-  "overall_score": 0.75,  // Score from 0.0 to 1.0
-  "component_scores": {
-    "keyword_match": 0.8,
-    "concept_match": 0.7,
-    "field_relevance": 0.75
-  },
-  "matched_keywords": ["keyword1", "keyword2"],
-  "matched_concepts": ["concept1", "concept2"],
-  "confidence": 0.85,  // Confidence in the assessment (0-1)
-  "is_relevant": true,  // Boolean decision
-  "reasoning": "Brief explanation of the relevance assessment"
-}`, topicsStr, dataStr)
+		prompt := localized.Render(localization.TopicPrompt, localization.Data{Manuscript: dataStr, Topics: topicsStr})
 
 		prompts = append(prompts, definitions.Prompt{
 			PromptContent:  prompt,
@@ -265,8 +237,9 @@ Respond with a JSON object containing:
 	return results
 }
 
-// CalculateTopicRelevanceWithAI uses AI to calculate topic relevance
-func CalculateTopicRelevanceWithAI(manuscriptData map[string]string, topics []string, llmConfigs []any) (*TopicRelevanceScore, error) {
+// CalculateTopicRelevanceWithAI uses AI to calculate topic relevance, with the topic relevance prompt of
+// localized (English if nil)
+func CalculateTopicRelevanceWithAI(manuscriptData map[string]string, topics []string, llmConfigs []any, localized *localization.Prompts) (*TopicRelevanceScore, error) {
 	if len(topics) == 0 {
 		return nil, fmt.Errorf("no topics provided for relevance calculation")
 	}
@@ -304,40 +277,10 @@ func CalculateTopicRelevanceWithAI(manuscriptData map[string]string, topics []st
 
 	// Build the data string for AI analysis
 	dataStr := buildTopicRelevanceData(manuscriptData)
-	topicsStr := strings.Join(topics, "\n- ")
+	topicsStr := "- " + strings.Join(topics, "\n- ")
 
 	// Create the prompt
-	prompt := fmt.Sprintf(`You are an expert in academic manuscript screening. Your task is to evaluate whether a manuscript is relevant to specific research topics.
-
-TOPICS OF INTEREST:
-- %s
-
-MANUSCRIPT DATA:
-%s
-
-TASK: Evaluate the relevance of this manuscript to the specified topics.
-
-Analyze the manuscript considering:
-1. Direct keyword matches with the topics
-2. Conceptual alignment with the research areas
-3. Field/domain relevance
-4. Methodological relevance
-5. Research questions and objectives alignment
-
-Respond with a JSON object containing:
-{
-  "overall_score": 0.75,  // Score from 0.0 to 1.0
-  "component_scores": {
-    "keyword_match": 0.8,
-    "concept_match": 0.7,
-    "field_relevance": 0.75
-  },
-  "matched_keywords": ["keyword1", "keyword2"],
-  "matched_concepts": ["concept1", "concept2"],
-  "confidence": 0.85,  // Confidence in the assessment (0-1)
-  "is_relevant": true,  // Boolean decision
-  "reasoning": "Brief explanation of the relevance assessment"
-}`, topicsStr, dataStr)
+	prompt := localized.Render(localization.TopicPrompt, localization.Data{Manuscript: dataStr, Topics: topicsStr})
 
 	// Prepare the input for alembica
 	input := definitions.Input{
@@ -708,8 +651,9 @@ func removeDuplicates(items []string) []string {
 	return result
 }
 
-// BatchCalculateTopicRelevance processes multiple manuscripts for topic relevance
-func BatchCalculateTopicRelevance(manuscripts []map[string]string, config TopicRelevanceConfig, llmConfigs []any) ([]TopicRelevanceScore, error) {
+// BatchCalculateTopicRelevance processes multiple manuscripts for topic relevance, with the topic
+// relevance prompt of localized (English if nil) when AI is used
+func BatchCalculateTopicRelevance(manuscripts []map[string]string, config TopicRelevanceConfig, llmConfigs []any, localized *localization.Prompts) ([]TopicRelevanceScore, error) {
 	var scores []TopicRelevanceScore
 
 	for _, manuscript := range manuscripts {
@@ -717,7 +661,7 @@ func BatchCalculateTopicRelevance(manuscripts []map[string]string, config TopicR
 		var err error
 
 		if config.UseAI && len(llmConfigs) > 0 {
-			score, err = CalculateTopicRelevanceWithAI(manuscript, config.Topics, llmConfigs)
+			score, err = CalculateTopicRelevanceWithAI(manuscript, config.Topics, llmConfigs, localized)
 		} else {
 			score, err = CalculateTopicRelevance(manuscript, config.Topics, config.ScoreWeights)
 		}
@@ -393,7 +393,7 @@ func TestBatchCalculateTopicRelevance(t *testing.T) {
 		},
 	}
 
-	scores, err := BatchCalculateTopicRelevance(manuscripts, config, nil)
+	scores, err := BatchCalculateTopicRelevance(manuscripts, config, nil, nil)
 	if err != nil {
 		t.Fatalf("BatchCalculateTopicRelevance() error = %v", err)
 	}
@@ -2,6 +2,7 @@ package logic
 
 import (
 	"bufio"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -12,14 +13,19 @@ import (
 	"time"
 
 	"github.com/BurntSushi/toml"
-	"github.com/open-and-sustainable/alembica/utils/logger"
+	"github.com/open-and-sustainable/prismaid/localization"
+	"github.com/open-and-sustainable/prismaid/logger"
+	"github.com/open-and-sustainable/prismaid/progress"
 	"github.com/open-and-sustainable/prismaid/screening/filters"
+	"github.com/open-and-sustainable/prismaid/secrets"
+	"github.com/open-and-sustainable/prismaid/tomlinclude"
 )
 
 // ScreeningConfig represents the TOML configuration for screening
 type ScreeningConfig struct {
-	Project ProjectConfig `toml:"project"`
-	Filters FiltersConfig `toml:"filters"`
+	Project         ProjectConfig     `toml:"project"`
+	Filters         FiltersConfig     `toml:"filters"`
+	InternalPrompts map[string]string `toml:"internal_prompts"` // Replacements of the prompts of the AI filters, by name
 }
 
 // ProjectConfig contains basic project information
@@ -33,6 +39,7 @@ type ProjectConfig struct {
 	IdentifierColumn string `toml:"identifier_column"` // Column for unique identifiers
 	OutputFormat     string `toml:"output_format"`     // csv or json
 	LogLevel         string `toml:"log_level"`         // low, medium, high
+	PromptLanguage   string `toml:"prompt_language"`   // Language of the prompts of the AI filters: en, pt-BR or es
 }
 
 // FiltersConfig contains settings for each screening filter
@@ -97,7 +104,7 @@ type TopicRelevanceScoreWeights struct {
 // LLMConfig for AI model configuration (reused from main project)
 type LLMConfig struct {
 	Provider    string  `toml:"provider"`
-	APIKey      string  `toml:"api_key"`
+	APIKey      string  `toml:"api_key"` // The key, or a reference to it: "env:NAME" or "file:/path"
 	Model       string  `toml:"model"`
 	Temperature float64 `toml:"temperature"`
 	TPMLimit    int     `toml:"tpm_limit"`
@@ -117,25 +124,58 @@ type ManuscriptRecord struct {
 
 // ScreeningResult contains the complete screening results
 type ScreeningResult struct {
-	TotalRecords    int                `json:"total_records"`
-	IncludedRecords int                `json:"included_records"`
-	ExcludedRecords int                `json:"excluded_records"`
-	Records         []ManuscriptRecord `json:"records"`
-	Statistics      map[string]int     `json:"statistics"`
-	LLMConfigs      []LLMConfig        `json:"-"` // Pass LLM configs through for filters
+	TotalRecords    int                   `json:"total_records"`
+	IncludedRecords int                   `json:"included_records"`
+	ExcludedRecords int                   `json:"excluded_records"`
+	Records         []ManuscriptRecord    `json:"records"`
+	Statistics      map[string]int        `json:"statistics"`
+	LLMConfigs      []LLMConfig           `json:"-"` // Pass LLM configs through for filters
+	Prompts         *localization.Prompts `json:"-"` // Pass internal prompts through for filters
+}
+
+// ScreenOptions controls a screening run with ScreenContext
+type ScreenOptions struct {
+	Progress progress.Func // optional, receives an event every time a filter completes
 }
 
 // Screen performs the main screening process
 func Screen(tomlConfiguration string) error {
+	_, err := ScreenContext(context.Background(), tomlConfiguration, ScreenOptions{})
+	return err
+}
+
+// ScreenWithProgress performs the screening process as Screen does, reporting its progress to a
+// function. Every enabled filter checks every record, so the units of work are the records times
+// the enabled filters, and an event is reported when a filter completes.
+func ScreenWithProgress(tomlConfiguration string, report progress.Func) error {
+	_, err := ScreenContext(context.Background(), tomlConfiguration, ScreenOptions{Progress: report})
+	return err
+}
+
+// ScreenContext performs the screening process as Screen does and returns the screened records.
+// The screening stops before the next filter, or during the wait between AI-assisted filters, once
+// the context is canceled; no results are saved then.
+func ScreenContext(ctx context.Context, tomlConfiguration string, options ScreenOptions) (*ScreeningResult, error) {
+	// Merge the base files the configuration extends
+	tomlConfiguration, err := tomlinclude.Resolve(tomlConfiguration)
+	if err != nil {
+		return nil, fmt.Errorf("error resolving TOML configuration: %v", err)
+	}
+
 	// Parse TOML configuration
 	var config ScreeningConfig
 	if _, err := toml.Decode(tomlConfiguration, &config); err != nil {
-		return fmt.Errorf("error parsing TOML configuration: %v", err)
+		return nil, fmt.Errorf("error parsing TOML configuration: %v", err)
+	}
+
+	// Replace the secret references given as API keys with the keys
+	if err := resolveAPIKeys(config.Filters.LLM); err != nil {
+		return nil, fmt.Errorf("configuration validation error: %v", err)
 	}
 
 	// Validate configuration
 	if err := validateConfig(&config); err != nil {
-		return fmt.Errorf("configuration validation error: %v", err)
+		return nil, fmt.Errorf("configuration validation error: %v", err)
 	}
 
 	// Setup logger based on configuration
@@ -150,7 +190,7 @@ func Screen(tomlConfiguration string) error {
 	// Load input data
 	manuscripts, err := loadInputData(config.Project.InputFile, config.Project.TextColumn, config.Project.IdentifierColumn)
 	if err != nil {
-		return fmt.Errorf("error loading input data: %v", err)
+		return nil, fmt.Errorf("error loading input data: %v", err)
 	}
 
 	// Initialize screening result
@@ -159,21 +199,31 @@ func Screen(tomlConfiguration string) error {
 		Records:      manuscripts,
 		Statistics:   make(map[string]int),
 		LLMConfigs:   config.Filters.LLM,
+		Prompts:      localization.New(config.Project.PromptLanguage, config.InternalPrompts),
 	}
 
 	// Apply filters
 	previousFilterUsedAI := false
+	enabled := 0
+	for _, filter := range []bool{config.Filters.Deduplication.Enabled, config.Filters.Language.Enabled, config.Filters.ArticleType.Enabled, config.Filters.TopicRelevance.Enabled} {
+		if filter {
+			enabled++
+		}
+	}
+	tracker := progress.Start(options.Progress, progress.OperationScreening, enabled*len(manuscripts))
+	defer tracker.Finish()
 
 	if config.Filters.Deduplication.Enabled {
 		// Check if we need to wait before this AI filter
-		if previousFilterUsedAI && config.Filters.Deduplication.UseAI && len(config.Filters.LLM) > 0 {
-			logger.Info("Waiting 30 seconds before next AI-assisted filter...")
-			time.Sleep(30 * time.Second)
+		if err := waitForFilter(ctx, previousFilterUsedAI && config.Filters.Deduplication.UseAI && len(config.Filters.LLM) > 0); err != nil {
+			return nil, err
 		}
 
+		tracker.SetStage("deduplication")
 		if err := applyDeduplicationFilter(result, config.Filters.Deduplication); err != nil {
-			return fmt.Errorf("deduplication filter error: %v", err)
+			return nil, fmt.Errorf("deduplication filter error: %v", err)
 		}
+		tracker.Advance("deduplication", len(result.Records))
 
 		// Update flag for next filter
 		previousFilterUsedAI = config.Filters.Deduplication.UseAI && len(config.Filters.LLM) > 0
@@ -181,14 +231,15 @@ func Screen(tomlConfiguration string) error {
 
 	if config.Filters.Language.Enabled {
 		// Check if we need to wait before this AI filter
-		if previousFilterUsedAI && config.Filters.Language.UseAI && len(config.Filters.LLM) > 0 {
-			logger.Info("Waiting 30 seconds before next AI-assisted filter...")
-			time.Sleep(30 * time.Second)
+		if err := waitForFilter(ctx, previousFilterUsedAI && config.Filters.Language.UseAI && len(config.Filters.LLM) > 0); err != nil {
+			return nil, err
 		}
 
+		tracker.SetStage("language")
 		if err := applyLanguageFilter(result, config.Filters.Language, config.Filters.LLM); err != nil {
-			return fmt.Errorf("language filter error: %v", err)
+			return nil, fmt.Errorf("language filter error: %v", err)
 		}
+		tracker.Advance("language", len(result.Records))
 
 		// Update flag for next filter
 		previousFilterUsedAI = config.Filters.Language.UseAI && len(config.Filters.LLM) > 0
@@ -196,14 +247,15 @@ func Screen(tomlConfiguration string) error {
 
 	if config.Filters.ArticleType.Enabled {
 		// Check if we need to wait before this AI filter
-		if previousFilterUsedAI && config.Filters.ArticleType.UseAI && len(config.Filters.LLM) > 0 {
-			logger.Info("Waiting 30 seconds before next AI-assisted filter...")
-			time.Sleep(30 * time.Second)
+		if err := waitForFilter(ctx, previousFilterUsedAI && config.Filters.ArticleType.UseAI && len(config.Filters.LLM) > 0); err != nil {
+			return nil, err
 		}
 
+		tracker.SetStage("article type")
 		if err := applyArticleTypeFilter(result, config.Filters.ArticleType, config.Filters.LLM); err != nil {
-			return fmt.Errorf("article type filter error: %v", err)
+			return nil, fmt.Errorf("article type filter error: %v", err)
 		}
+		tracker.Advance("article type", len(result.Records))
 
 		// Update flag for next filter
 		previousFilterUsedAI = config.Filters.ArticleType.UseAI && len(config.Filters.LLM) > 0
@@ -211,14 +263,15 @@ func Screen(tomlConfiguration string) error {
 
 	if config.Filters.TopicRelevance.Enabled {
 		// Check if we need to wait before this AI filter
-		if previousFilterUsedAI && config.Filters.TopicRelevance.UseAI && len(config.Filters.LLM) > 0 {
-			logger.Info("Waiting 30 seconds before next AI-assisted filter...")
-			time.Sleep(30 * time.Second)
+		if err := waitForFilter(ctx, previousFilterUsedAI && config.Filters.TopicRelevance.UseAI && len(config.Filters.LLM) > 0); err != nil {
+			return nil, err
 		}
 
+		tracker.SetStage("topic relevance")
 		if err := applyTopicRelevanceFilter(result, config.Filters.TopicRelevance, config.Filters.LLM); err != nil {
-			return fmt.Errorf("topic relevance filter error: %v", err)
+			return nil, fmt.Errorf("topic relevance filter error: %v", err)
 		}
+		tracker.Advance("topic relevance", len(result.Records))
 	}
 
 	// Calculate final statistics
@@ -226,13 +279,31 @@ func Screen(tomlConfiguration string) error {
 
 	// Save results
 	if err := saveResults(result, config.Project.OutputFile, config.Project.OutputFormat); err != nil {
-		return fmt.Errorf("error saving results: %v", err)
+		return nil, fmt.Errorf("error saving results: %v", err)
 	}
 
 	// Log summary
 	logSummary(result, config.Project.LogLevel)
 
-	return nil
+	return result, nil
+}
+
+// waitForFilter checks that the screening is not canceled before a filter and, when the previous
+// filter used AI too, waits 30 seconds before an AI-assisted filter to respect provider rate limits.
+func waitForFilter(ctx context.Context, wait bool) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if !wait {
+		return nil
+	}
+	logger.Info("Waiting 30 seconds before next AI-assisted filter...")
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(30 * time.Second):
+		return nil
+	}
 }
 
 // loadInputData loads manuscripts from CSV or TXT file
@@ -490,7 +561,7 @@ func applyLanguageFilter(result *ScreeningResult, config LanguageConfig, llmConf
 
 			// Batch process all manuscripts
 			logger.Info("Processing %d manuscripts for language detection with AI", len(manuscriptsToProcess))
-			languages := filters.BatchDetectLanguagesWithAI(manuscriptsToProcess, llmInterfaces)
+			languages := filters.BatchDetectLanguagesWithAI(manuscriptsToProcess, llmInterfaces, result.Prompts)
 
 			// Apply results
 			for idx, recordIdx := range recordIndices {
@@ -615,7 +686,7 @@ func applyArticleTypeFilter(result *ScreeningResult, config ArticleTypeConfig, l
 
 			// Batch process all manuscripts
 			logger.Info("Processing %d manuscripts for article type classification with AI", len(manuscriptsToProcess))
-			classifications := filters.BatchClassifyArticleTypesWithAI(manuscriptsToProcess, llmInterfaces)
+			classifications := filters.BatchClassifyArticleTypesWithAI(manuscriptsToProcess, llmInterfaces, result.Prompts)
 
 			// Apply results
 			for idx, recordIdx := range recordIndices {
@@ -751,7 +822,7 @@ func applyTopicRelevanceFilter(result *ScreeningResult, config TopicRelevanceCon
 
 			// Batch process all manuscripts
 			logger.Info("Processing %d manuscripts for topic relevance with AI", len(manuscriptsToProcess))
-			relevanceScores := filters.BatchCalculateTopicRelevanceWithAI(manuscriptsToProcess, config.Topics, config.MinScore, llmInterfaces)
+			relevanceScores := filters.BatchCalculateTopicRelevanceWithAI(manuscriptsToProcess, config.Topics, config.MinScore, llmInterfaces, result.Prompts)
 
 			// Apply results
 			for idx, recordIdx := range recordIndices {
@@ -1003,6 +1074,36 @@ func validateConfig(config *ScreeningConfig) error {
 		return fmt.Errorf("at least one filter must be enabled")
 	}
 
+	if err := localization.Validate(config.Project.PromptLanguage, config.InternalPrompts, localization.ScreeningPrompts); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// resolveAPIKeys replaces the "env:NAME" and "file:/path" references given as API keys with the
+// keys they refer to, and registers the keys for redaction. Variables are read from the environment
+// and then from the .env file of the working directory.
+func resolveAPIKeys(llms []LLMConfig) error {
+	dotEnv, err := secrets.ReadDotEnv(secrets.DotEnvFile)
+	if err != nil {
+		return fmt.Errorf("error loading %s: %v", secrets.DotEnvFile, err)
+	}
+	getenv := func(name string) string {
+		if value := os.Getenv(name); value != "" {
+			return value
+		}
+		return dotEnv[name]
+	}
+
+	for i := range llms {
+		apiKey, err := secrets.Resolve(llms[i].APIKey, getenv)
+		if err != nil {
+			return fmt.Errorf("api_key of LLM %d: %v", i+1, err)
+		}
+		llms[i].APIKey = apiKey
+		secrets.Register(apiKey)
+	}
 	return nil
 }
 
@@ -710,6 +710,34 @@ func TestConfigValidation(t *testing.T) {
 	}
 }
 
+// TestConfigValidationPrompts tests the validation of the prompt language and internal prompts
+func TestConfigValidationPrompts(t *testing.T) {
+	config := &ScreeningConfig{
+		Project: ProjectConfig{
+			InputFile:      "input.csv",
+			OutputFile:     "output.csv",
+			TextColumn:     "abstract",
+			PromptLanguage: "pt-BR",
+		},
+		Filters:         FiltersConfig{Language: LanguageConfig{Enabled: true}},
+		InternalPrompts: map[string]string{"language": "Qual é o idioma?\n{{.Manuscript}}"},
+	}
+	if err := validateConfig(config); err != nil {
+		t.Errorf("Valid prompt configuration should not error: %v", err)
+	}
+
+	config.Project.PromptLanguage = "it"
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "prompt_language") {
+		t.Error("Should error on an unsupported prompt_language")
+	}
+
+	config.Project.PromptLanguage = "es"
+	config.InternalPrompts = map[string]string{"summary_query": "Resume."}
+	if err := validateConfig(config); err == nil || !strings.Contains(err.Error(), "summary_query") {
+		t.Error("Should error on a review prompt in a screening configuration")
+	}
+}
+
 // TestSingleCharacterDifferenceDuplication tests duplicate detection with single character differences
 func TestSingleCharacterDifferenceDuplication(t *testing.T) {
 	records := []ManuscriptRecord{
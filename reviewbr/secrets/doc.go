@@ -0,0 +1,6 @@
+// Package secrets keeps API keys out of configuration files and out of everything prismAId writes.
+// An api_key can be given as a reference, "env:NAME" to read an environment variable or
+// "file:/path" to read a file, instead of the key itself, and variables can be loaded from a .env
+// file. Resolved keys are registered with the package, so that they can be redacted from log
+// messages, error messages, saved inputs and the audit artifacts of a run.
+package secrets
@@ -0,0 +1,61 @@
+package secrets
+
+import (
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces the secrets removed from a text.
+const Redacted = "[REDACTED]"
+
+// minSecretLength is the length below which values are not registered, so that short values, such
+// as placeholders used in tests, do not redact ordinary words.
+const minSecretLength = 6
+
+var (
+	mutex      sync.RWMutex
+	registered []string // longest first, so that a secret containing another is redacted whole
+)
+
+// Register records secrets to be removed by Redact. Empty, short and already registered values are
+// ignored.
+//
+// Arguments:
+// - values: The secrets, such as resolved API keys.
+func Register(values ...string) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	for _, value := range values {
+		if len(value) < minSecretLength || slices.Contains(registered, value) {
+			continue
+		}
+		registered = append(registered, value)
+	}
+	slices.SortFunc(registered, func(a, b string) int { return len(b) - len(a) })
+}
+
+// Redact replaces every registered secret found in a text with Redacted.
+//
+// Arguments:
+// - text: A log or error message, or any text about to be written.
+//
+// Returns:
+// - The text without secrets.
+func Redact(text string) string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	for _, secret := range registered {
+		text = strings.ReplaceAll(text, secret, Redacted)
+	}
+	return text
+}
+
+// RedactError returns the message of an error without registered secrets, or an empty string for
+// a nil error.
+func RedactError(err error) string {
+	if err == nil {
+		return ""
+	}
+	return Redact(err.Error())
+}
@@ -0,0 +1,128 @@
+package secrets
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// Prefixes of the secret references accepted in place of an API key.
+const (
+	EnvPrefix  = "env:"  // "env:NAME" reads the environment variable NAME
+	FilePrefix = "file:" // "file:/path" reads the file at /path
+)
+
+// DotEnvFile is the file of environment variables loaded from the working directory.
+const DotEnvFile = ".env"
+
+// IsReference reports whether a value is a secret reference rather than a secret.
+func IsReference(value string) bool {
+	return strings.HasPrefix(value, EnvPrefix) || strings.HasPrefix(value, FilePrefix)
+}
+
+// Resolve returns the secret a value refers to. "env:NAME" is read from the environment variable
+// NAME and "file:/path" from the file at the path, without surrounding whitespace; any other value
+// is the secret itself. Errors name the variable or file, never the secret.
+//
+// Arguments:
+// - value: The secret or a reference to it.
+// - getenv: The function reading environment variables.
+//
+// Returns:
+// - The secret.
+// - An error if the variable is not set or the file cannot be read or is empty.
+func Resolve(value string, getenv func(string) string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, EnvPrefix):
+		name := strings.TrimSpace(strings.TrimPrefix(value, EnvPrefix))
+		secret := getenv(name)
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return secret, nil
+	case strings.HasPrefix(value, FilePrefix):
+		path := strings.TrimSpace(strings.TrimPrefix(value, FilePrefix))
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("cannot read secret file: %v", err)
+		}
+		secret := strings.TrimSpace(string(data))
+		if secret == "" {
+			return "", fmt.Errorf("secret file %s is empty", path)
+		}
+		return secret, nil
+	}
+	return value, nil
+}
+
+// ReadDotEnv reads a file of environment variables, one NAME=value per line. Blank lines and
+// lines starting with # are skipped, an "export " prefix is allowed, and values can be quoted.
+// Unquoted values end at a " #" comment.
+//
+// Arguments:
+// - path: The location of the file.
+//
+// Returns:
+// - The variables of the file; none if the file does not exist.
+// - An error if the file cannot be read or a line is not a variable assignment.
+func ReadDotEnv(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	variables := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			// the line is not shown, it may hold a secret
+			return nil, fmt.Errorf("%s:%d: expected NAME=value", path, line)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		} else if comment := strings.Index(value, " #"); comment >= 0 {
+			value = strings.TrimSpace(value[:comment])
+		}
+		variables[name] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return variables, nil
+}
+
+// apiKeyLine matches the api_key assignments of a TOML configuration.
+var apiKeyLine = regexp.MustCompile(`(?m)^(\s*"?api_key"?\s*=\s*)("[^"\n]*"|'[^'\n]*')`)
+
+// RedactConfiguration replaces the API keys written in a TOML configuration with Redacted, so that
+// the configuration can be shown or shared. Secret references are kept, since they hold no secret.
+//
+// Arguments:
+// - tomlConfiguration: The TOML configuration text.
+//
+// Returns:
+// - The configuration without its API keys.
+func RedactConfiguration(tomlConfiguration string) string {
+	return apiKeyLine.ReplaceAllStringFunc(tomlConfiguration, func(assignment string) string {
+		match := apiKeyLine.FindStringSubmatch(assignment)
+		value := match[2][1 : len(match[2])-1]
+		if value == "" || IsReference(value) {
+			return assignment
+		}
+		return match[1] + `"` + Redacted + `"`
+	})
+}
@@ -0,0 +1,125 @@
+package secrets
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	keyFile := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(keyFile, []byte("sk-from-file\n"), 0600); err != nil {
+		t.Fatalf("Failed to write key file: %v", err)
+	}
+	getenv := func(name string) string {
+		return map[string]string{"OPENAI_KEY": "sk-from-env"}[name]
+	}
+
+	for value, expected := range map[string]string{
+		"sk-literal":      "sk-literal",
+		"env:OPENAI_KEY":  "sk-from-env",
+		"file:" + keyFile: "sk-from-file",
+		"":                "",
+	} {
+		secret, err := Resolve(value, getenv)
+		if err != nil || secret != expected {
+			t.Errorf("Resolve(%q) = %q, %v; expected %q", value, secret, err, expected)
+		}
+	}
+
+	for _, value := range []string{"env:MISSING_KEY", "file:" + filepath.Join(dir, "missing.txt")} {
+		if _, err := Resolve(value, getenv); err == nil {
+			t.Errorf("Expected an error resolving %q", value)
+		}
+	}
+}
+
+func TestReadDotEnv(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	content := `# API keys
+OPENAI_API_KEY=sk-plain
+export ANTHROPIC_API_KEY="sk-quoted # not a comment"
+CO_API_KEY = sk-commented # a comment
+
+`
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("Failed to write .env file: %v", err)
+	}
+
+	variables, err := ReadDotEnv(path)
+	if err != nil {
+		t.Fatalf("ReadDotEnv returned an unexpected error: %v", err)
+	}
+	expected := map[string]string{
+		"OPENAI_API_KEY":    "sk-plain",
+		"ANTHROPIC_API_KEY": "sk-quoted # not a comment",
+		"CO_API_KEY":        "sk-commented",
+	}
+	if len(variables) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, variables)
+	}
+	for name, value := range expected {
+		if variables[name] != value {
+			t.Errorf("Expected %s=%q, got %q", name, value, variables[name])
+		}
+	}
+
+	if variables, err := ReadDotEnv(filepath.Join(t.TempDir(), ".env")); err != nil || variables != nil {
+		t.Errorf("Expected no variables and no error for a missing file, got %v, %v", variables, err)
+	}
+
+	if err := os.WriteFile(path, []byte("sk-no-name\n"), 0600); err != nil {
+		t.Fatalf("Failed to write .env file: %v", err)
+	}
+	_, err = ReadDotEnv(path)
+	if err == nil || strings.Contains(err.Error(), "sk-no-name") {
+		t.Errorf("Expected an error not showing the line, got %v", err)
+	}
+}
+
+func TestRedact(t *testing.T) {
+	Register("sk-secret-1234", "sk-secret-12345678", "", "short")
+
+	message := "request with key sk-secret-12345678 failed, retrying with sk-secret-1234; short answer"
+	expected := "request with key [REDACTED] failed, retrying with [REDACTED]; short answer"
+	if redacted := Redact(message); redacted != expected {
+		t.Errorf("Expected %q, got %q", expected, redacted)
+	}
+	if RedactError(nil) != "" {
+		t.Errorf("Expected an empty message for a nil error")
+	}
+}
+
+func TestRedactConfiguration(t *testing.T) {
+	configuration := `[project.llm.1]
+provider = "OpenAI"
+api_key = "sk-written-key"
+
+[project.llm.2]
+provider = "Anthropic"
+api_key = "env:ANTHROPIC_API_KEY"
+
+[project.llm.3]
+provider = "Cohere"
+  api_key = 'sk-single-quoted'
+api_key_note = "kept"
+`
+	expected := `[project.llm.1]
+provider = "OpenAI"
+api_key = "[REDACTED]"
+
+[project.llm.2]
+provider = "Anthropic"
+api_key = "env:ANTHROPIC_API_KEY"
+
+[project.llm.3]
+provider = "Cohere"
+  api_key = "[REDACTED]"
+api_key_note = "kept"
+`
+	if redacted := RedactConfiguration(configuration); redacted != expected {
+		t.Errorf("Expected:\n%s\ngot:\n%s", expected, redacted)
+	}
+}
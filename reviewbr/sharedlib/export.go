@@ -2,6 +2,16 @@ package main
 
 /*
 #include <stdlib.h>
+
+// prismaid_progress_callback receives every progress event as a JSON string, which is freed once
+// the callback returns. The callback is only called from the thread that called the exported
+// function, before that function returns, and never concurrently, so it may call back into the
+// Python or R interpreter. The operation waits for the callback to return before going on.
+typedef void (*prismaid_progress_callback)(char*);
+
+static inline void call_progress_callback(prismaid_progress_callback callback, char* event) {
+	callback(event);
+}
 */
 import "C"
 
@@ -22,10 +32,43 @@ func handlePanic() *C.char {
 	return nil
 }
 
+// withProgress runs an operation reporting its progress to a C callback, with every event as a JSON
+// string. The operation runs in its own goroutine and sends its events over a channel, so that the
+// callback is only ever called, one event at a time, from the thread that called the exported
+// function, as the Python and R interpreters require. A NULL callback disables reporting.
+func withProgress(callback C.prismaid_progress_callback, run func(prismaid.ProgressFunc) error) error {
+	if callback == nil {
+		return run(nil)
+	}
+
+	events := make(chan string)
+	var err error
+	go func() {
+		defer close(events)
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("%v", r)
+			}
+		}()
+		err = run(func(event prismaid.ProgressEvent) {
+			events <- event.JSON()
+		})
+	}()
+
+	for event := range events {
+		cEvent := C.CString(event)
+		C.call_progress_callback(callback, cEvent)
+		C.free(unsafe.Pointer(cEvent))
+	}
+	return err
+}
+
 // common logic as an helper function
-func runReview(input *C.char) error {
+func runReview(input *C.char, callback C.prismaid_progress_callback) error {
 	goInput := C.GoString(input)
-	return prismaid.Review(goInput)
+	return withProgress(callback, func(progress prismaid.ProgressFunc) error {
+		return prismaid.ReviewWithProgress(goInput, progress)
+	})
 }
 
 func runDownloadZoteroPDFs(username, apiKey, collectionName, parentDir *C.char) error {
@@ -36,30 +79,37 @@ func runDownloadZoteroPDFs(username, apiKey, collectionName, parentDir *C.char)
 	return prismaid.DownloadZoteroPDFs(goUsername, goApiKey, goCollectionName, goParentDir)
 }
 
-func runDownloadURLList(path *C.char) error {
+func runDownloadURLList(path *C.char, callback C.prismaid_progress_callback) error {
 	goPath := C.GoString(path)
-	return prismaid.DownloadURLList(goPath)
+	return withProgress(callback, func(progress prismaid.ProgressFunc) error {
+		return prismaid.DownloadURLListWithProgress(goPath, progress)
+	})
 }
 
-func runConvert(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly *C.char) error {
+func runConvert(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly *C.char, callback C.prismaid_progress_callback) error {
 	goInputDir := C.GoString(inputDir)
 	goSelectedFormats := C.GoString(selectedFormats)
 	goTikaAddress := C.GoString(tikaAddress)
 	goSingleFile := C.GoString(singleFile)
 	goOcrOnly := strings.TrimSpace(strings.ToLower(C.GoString(ocrOnly)))
 	ocrOnlyEnabled := goOcrOnly == "1" || goOcrOnly == "true" || goOcrOnly == "yes"
-	return prismaid.Convert(goInputDir, goSelectedFormats, prismaid.ConvertOptions{
-		TikaServer: goTikaAddress,
-		PDF: prismaid.PDFOptions{
-			SingleFile: goSingleFile,
-			OCROnly:    ocrOnlyEnabled,
-		},
+	return withProgress(callback, func(progress prismaid.ProgressFunc) error {
+		return prismaid.Convert(goInputDir, goSelectedFormats, prismaid.ConvertOptions{
+			TikaServer: goTikaAddress,
+			PDF: prismaid.PDFOptions{
+				SingleFile: goSingleFile,
+				OCROnly:    ocrOnlyEnabled,
+			},
+			Progress: progress,
+		})
 	})
 }
 
-func runScreening(input *C.char) error {
+func runScreening(input *C.char, callback C.prismaid_progress_callback) error {
 	goInput := C.GoString(input)
-	return prismaid.Screening(goInput)
+	return withProgress(callback, func(progress prismaid.ProgressFunc) error {
+		return prismaid.ScreeningWithProgress(goInput, progress)
+	})
 }
 
 // Python-specific function
@@ -67,7 +117,7 @@ func runScreening(input *C.char) error {
 //export RunReviewPython
 func RunReviewPython(input *C.char) *C.char {
 	defer handlePanic()
-	if err := runReview(input); err != nil {
+	if err := runReview(input, nil); err != nil {
 		return C.CString(err.Error())
 	}
 	return nil
@@ -85,7 +135,7 @@ func DownloadZoteroPDFsPython(username, apiKey, collectionName, parentDir *C.cha
 //export DownloadURLListPython
 func DownloadURLListPython(path *C.char) *C.char {
 	defer handlePanic()
-	if err := runDownloadURLList(path); err != nil {
+	if err := runDownloadURLList(path, nil); err != nil {
 		return C.CString(err.Error())
 	}
 	return nil
@@ -94,7 +144,7 @@ func DownloadURLListPython(path *C.char) *C.char {
 //export ConvertPython
 func ConvertPython(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly *C.char) *C.char {
 	defer handlePanic()
-	if err := runConvert(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly); err != nil {
+	if err := runConvert(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly, nil); err != nil {
 		return C.CString(err.Error())
 	}
 	return nil
@@ -103,7 +153,45 @@ func ConvertPython(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly *
 //export ScreeningPython
 func ScreeningPython(input *C.char) *C.char {
 	defer handlePanic()
-	if err := runScreening(input); err != nil {
+	if err := runScreening(input, nil); err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+// Python-specific functions with a progress callback, called with every progress event as JSON
+//
+//export RunReviewWithProgressPython
+func RunReviewWithProgressPython(input *C.char, callback C.prismaid_progress_callback) *C.char {
+	defer handlePanic()
+	if err := runReview(input, callback); err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+//export DownloadURLListWithProgressPython
+func DownloadURLListWithProgressPython(path *C.char, callback C.prismaid_progress_callback) *C.char {
+	defer handlePanic()
+	if err := runDownloadURLList(path, callback); err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+//export ConvertWithProgressPython
+func ConvertWithProgressPython(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly *C.char, callback C.prismaid_progress_callback) *C.char {
+	defer handlePanic()
+	if err := runConvert(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly, callback); err != nil {
+		return C.CString(err.Error())
+	}
+	return nil
+}
+
+//export ScreeningWithProgressPython
+func ScreeningWithProgressPython(input *C.char, callback C.prismaid_progress_callback) *C.char {
+	defer handlePanic()
+	if err := runScreening(input, callback); err != nil {
 		return C.CString(err.Error())
 	}
 	return nil
@@ -114,7 +202,7 @@ func ScreeningPython(input *C.char) *C.char {
 //export RunReviewR
 func RunReviewR(input *C.char) *C.char {
 	defer handlePanic()
-	if err := runReview(input); err != nil {
+	if err := runReview(input, nil); err != nil {
 		return C.CString(err.Error())
 	}
 	return C.CString("Review completed successfully")
@@ -132,7 +220,7 @@ func DownloadZoteroPDFsR(username, apiKey, collectionName, parentDir *C.char) *C
 //export DownloadURLListR
 func DownloadURLListR(path *C.char) *C.char {
 	defer handlePanic()
-	if err := runDownloadURLList(path); err != nil {
+	if err := runDownloadURLList(path, nil); err != nil {
 		return C.CString(err.Error())
 	}
 	return C.CString("URL list download completed")
@@ -141,7 +229,7 @@ func DownloadURLListR(path *C.char) *C.char {
 //export ConvertR
 func ConvertR(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly *C.char) *C.char {
 	defer handlePanic()
-	if err := runConvert(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly); err != nil {
+	if err := runConvert(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly, nil); err != nil {
 		return C.CString(err.Error())
 	}
 	return C.CString("Conversion completed successfully")
@@ -150,7 +238,45 @@ func ConvertR(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly *C.cha
 //export ScreeningR
 func ScreeningR(input *C.char) *C.char {
 	defer handlePanic()
-	if err := runScreening(input); err != nil {
+	if err := runScreening(input, nil); err != nil {
+		return C.CString(err.Error())
+	}
+	return C.CString("Screening completed successfully")
+}
+
+// R-specific functions with a progress callback, called with every progress event as JSON
+//
+//export RunReviewWithProgressR
+func RunReviewWithProgressR(input *C.char, callback C.prismaid_progress_callback) *C.char {
+	defer handlePanic()
+	if err := runReview(input, callback); err != nil {
+		return C.CString(err.Error())
+	}
+	return C.CString("Review completed successfully")
+}
+
+//export DownloadURLListWithProgressR
+func DownloadURLListWithProgressR(path *C.char, callback C.prismaid_progress_callback) *C.char {
+	defer handlePanic()
+	if err := runDownloadURLList(path, callback); err != nil {
+		return C.CString(err.Error())
+	}
+	return C.CString("URL list download completed")
+}
+
+//export ConvertWithProgressR
+func ConvertWithProgressR(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly *C.char, callback C.prismaid_progress_callback) *C.char {
+	defer handlePanic()
+	if err := runConvert(inputDir, selectedFormats, tikaAddress, singleFile, ocrOnly, callback); err != nil {
+		return C.CString(err.Error())
+	}
+	return C.CString("Conversion completed successfully")
+}
+
+//export ScreeningWithProgressR
+func ScreeningWithProgressR(input *C.char, callback C.prismaid_progress_callback) *C.char {
+	defer handlePanic()
+	if err := runScreening(input, callback); err != nil {
 		return C.CString(err.Error())
 	}
 	return C.CString("Screening completed successfully")
@@ -0,0 +1,6 @@
+// Package tomlinclude implements the inheritance of TOML configuration files. A review or screening
+// configuration can name, in its top-level "extends" key, one or more base files holding shared
+// settings, such as LLM profiles, the persona or the failsafe. The tables of the base files are
+// merged key by key with those of the project file, whose values take precedence, so that a project
+// only states what differs from its base. The merged configuration can be printed for audit.
+package tomlinclude
@@ -0,0 +1,140 @@
+package tomlinclude
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ExtendsKey is the top-level key listing the base files of a configuration.
+const ExtendsKey = "extends"
+
+// Resolve merges a TOML configuration with the base files named in its "extends" key, given as a
+// path or a list of paths. Base files may extend other files in turn. Tables are merged key by key,
+// while values and arrays are replaced: later base files override earlier ones, and the configuration
+// overrides all of its bases. Relative paths in the configuration are resolved from the working
+// directory, as its other paths, while relative paths in a base file are resolved from the directory
+// of that base file, so that shared files can extend each other wherever they are used from.
+//
+// Arguments:
+// - tomlConfiguration: The TOML configuration text.
+//
+// Returns:
+// - The merged configuration as TOML, without the "extends" key; the configuration itself, unchanged,
+// if it extends no file.
+// - An error if a base file cannot be read or parsed, or if files extend each other in a cycle.
+func Resolve(tomlConfiguration string) (string, error) {
+	var data map[string]any
+	if _, err := toml.Decode(tomlConfiguration, &data); err != nil {
+		return "", err
+	}
+	if _, ok := data[ExtendsKey]; !ok {
+		return tomlConfiguration, nil
+	}
+
+	merged, err := resolve(data, "", map[string]bool{})
+	if err != nil {
+		return "", err
+	}
+
+	var buffer bytes.Buffer
+	if err := toml.NewEncoder(&buffer).Encode(merged); err != nil {
+		return "", err
+	}
+	return buffer.String(), nil
+}
+
+// resolve merges decoded configuration data over its base files.
+//
+// Arguments:
+// - data: The decoded configuration.
+// - dir: The directory of the file holding the configuration, against which relative base paths are
+// resolved; empty for the working directory.
+// - visiting: The absolute paths of the files being resolved, to detect cycles.
+//
+// Returns:
+// - The merged data, without the "extends" key.
+// - An error if a base file cannot be resolved.
+func resolve(data map[string]any, dir string, visiting map[string]bool) (map[string]any, error) {
+	paths, err := extendedPaths(data[ExtendsKey])
+	if err != nil {
+		return nil, err
+	}
+	delete(data, ExtendsKey)
+
+	merged := map[string]any{}
+	for _, path := range paths {
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		absolute, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if visiting[absolute] {
+			return nil, fmt.Errorf("configuration file %s extends itself", path)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading base configuration %s: %v", path, err)
+		}
+		var base map[string]any
+		if _, err := toml.Decode(string(content), &base); err != nil {
+			return nil, fmt.Errorf("error parsing base configuration %s: %v", path, err)
+		}
+
+		visiting[absolute] = true
+		base, err = resolve(base, filepath.Dir(path), visiting)
+		delete(visiting, absolute)
+		if err != nil {
+			return nil, err
+		}
+		merged = merge(merged, base)
+	}
+	return merge(merged, data), nil
+}
+
+// extendedPaths returns the base files named by the value of the "extends" key.
+func extendedPaths(value any) ([]string, error) {
+	switch typed := value.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return []string{typed}, nil
+	case []any:
+		paths := make([]string, 0, len(typed))
+		for _, element := range typed {
+			path, ok := element.(string)
+			if !ok {
+				return nil, fmt.Errorf("'%s' must list file paths, found %v", ExtendsKey, element)
+			}
+			paths = append(paths, path)
+		}
+		return paths, nil
+	default:
+		return nil, fmt.Errorf("'%s' must be a file path or a list of file paths, found %v", ExtendsKey, value)
+	}
+}
+
+// merge returns the tables of base updated with those of override: nested tables are merged
+// recursively, and any other value of override replaces the value of base.
+func merge(base, override map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(override))
+	for key, value := range base {
+		merged[key] = value
+	}
+	for key, value := range override {
+		baseTable, baseIsTable := merged[key].(map[string]any)
+		overrideTable, overrideIsTable := value.(map[string]any)
+		if baseIsTable && overrideIsTable {
+			merged[key] = merge(baseTable, overrideTable)
+		} else {
+			merged[key] = value
+		}
+	}
+	return merged
+}
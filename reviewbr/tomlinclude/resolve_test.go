@@ -0,0 +1,114 @@
+package tomlinclude
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write %s: %v", path, err)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	dir := t.TempDir()
+	models := filepath.Join(dir, "models.toml")
+	base := filepath.Join(dir, "base.toml")
+	writeFile(t, models, `
+[project.llm.1]
+provider = "OpenAI"
+model = "gpt-4o-mini"
+temperature = 0.01
+`)
+	writeFile(t, base, `extends = "`+models+`"
+[prompt]
+persona = "You are an experienced scientist."
+failsafe = "Respond with an empty '' value."
+`)
+
+	resolved, err := Resolve(`extends = ["` + base + `"]
+[project.llm.1]
+temperature = 0.5
+
+[prompt]
+task = "Map the concepts."
+`)
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %v", err)
+	}
+
+	var config struct {
+		Extends string `toml:"extends"`
+		Project struct {
+			LLM map[string]struct {
+				Provider    string  `toml:"provider"`
+				Model       string  `toml:"model"`
+				Temperature float64 `toml:"temperature"`
+			} `toml:"llm"`
+		} `toml:"project"`
+		Prompt map[string]string `toml:"prompt"`
+	}
+	if _, err := toml.Decode(resolved, &config); err != nil {
+		t.Fatalf("Failed to decode the resolved configuration: %v\n%s", err, resolved)
+	}
+	llm := config.Project.LLM["1"]
+	if llm.Provider != "OpenAI" || llm.Model != "gpt-4o-mini" || llm.Temperature != 0.5 {
+		t.Errorf("Expected the inherited model with the overridden temperature, got %+v", llm)
+	}
+	if config.Prompt["persona"] == "" || config.Prompt["failsafe"] == "" || config.Prompt["task"] != "Map the concepts." {
+		t.Errorf("Expected the inherited and own prompt fields, got %v", config.Prompt)
+	}
+	if config.Extends != "" {
+		t.Errorf("Expected the extends key to be removed, got %q", config.Extends)
+	}
+}
+
+func TestResolveRelativeToBaseFile(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "shared")
+	if err := os.Mkdir(shared, 0755); err != nil {
+		t.Fatalf("Failed to create %s: %v", shared, err)
+	}
+	writeFile(t, filepath.Join(shared, "models.toml"), "[project.llm.1]\nprovider = \"OpenAI\"\n")
+	writeFile(t, filepath.Join(shared, "base.toml"), "extends = \"models.toml\"\n[prompt]\npersona = \"You are a scientist.\"\n")
+
+	resolved, err := Resolve(`extends = "` + filepath.Join(shared, "base.toml") + `"`)
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %v", err)
+	}
+	if !strings.Contains(resolved, "OpenAI") || !strings.Contains(resolved, "You are a scientist.") {
+		t.Errorf("Expected the base file to extend models.toml from its own directory, got:\n%s", resolved)
+	}
+}
+
+func TestResolveWithoutExtends(t *testing.T) {
+	content := "[prompt]\ntask = \"Task.\"\n"
+	resolved, err := Resolve(content)
+	if err != nil || resolved != content {
+		t.Errorf("Expected the configuration unchanged, got %q (%v)", resolved, err)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.toml")
+	second := filepath.Join(dir, "second.toml")
+	writeFile(t, first, `extends = "`+second+`"`)
+	writeFile(t, second, `extends = "`+first+`"`)
+
+	if _, err := Resolve(`extends = "` + first + `"`); err == nil || !strings.Contains(err.Error(), "extends itself") {
+		t.Errorf("Expected a cycle error, got %v", err)
+	}
+	if _, err := Resolve(`extends = "` + filepath.Join(dir, "missing.toml") + `"`); err == nil {
+		t.Errorf("Expected an error for a missing base file")
+	}
+	if _, err := Resolve(`extends = 1`); err == nil {
+		t.Errorf("Expected an error for an invalid extends value")
+	}
+}